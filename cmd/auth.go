@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"bitbucket.org/cover42/eiscli/internal/bitbucket"
@@ -10,6 +11,18 @@ import (
 	"github.com/spf13/cobra"
 )
 
+// authStatusOutput describes the authentication state for --output json/yaml
+type authStatusOutput struct {
+	Method     string     `json:"method" yaml:"method"` // oauth or basic
+	Workspace  string     `json:"workspace" yaml:"workspace"`
+	TokenFound bool       `json:"token_found" yaml:"token_found"`
+	Expired    bool       `json:"expired" yaml:"expired"`
+	TokenType  string     `json:"token_type,omitempty" yaml:"token_type,omitempty"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty" yaml:"expires_at,omitempty"`
+	Scopes     []string   `json:"scopes,omitempty" yaml:"scopes,omitempty"`
+	TokenFile  string     `json:"token_file,omitempty" yaml:"token_file,omitempty"`
+}
+
 var authCmd = &cobra.Command{
 	Use:   "auth",
 	Short: "Manage authentication with Bitbucket",
@@ -57,7 +70,9 @@ var authStatusCmd = &cobra.Command{
 Displays:
 - Authentication method (OAuth or Basic Auth)
 - Token expiration time (if using OAuth)
-- Granted scopes (if using OAuth)`,
+- Granted scopes (if using OAuth)
+
+Use --output json or --output yaml for machine-readable output.`,
 	RunE: runAuthStatus,
 }
 
@@ -145,6 +160,10 @@ func runAuthStatus(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to load configuration: %w", err)
 	}
 
+	if isMachineOutput() {
+		return printStructured(collectAuthStatus(cfg))
+	}
+
 	green := color.New(color.FgGreen, color.Bold)
 	yellow := color.New(color.FgYellow, color.Bold)
 	cyan := color.New(color.FgCyan, color.Bold)
@@ -207,6 +226,36 @@ func runAuthStatus(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// collectAuthStatus gathers the authentication state without printing anything
+func collectAuthStatus(cfg *config.Config) *authStatusOutput {
+	status := &authStatusOutput{
+		Method:    "basic",
+		Workspace: cfg.Bitbucket.Workspace,
+	}
+
+	if !cfg.Bitbucket.UseOAuth {
+		return status
+	}
+
+	status.Method = "oauth"
+	tokenStore := &bitbucket.TokenStore{}
+	if err := tokenStore.Load(); err != nil {
+		return status
+	}
+
+	status.TokenFound = true
+	status.Expired = tokenStore.IsExpired()
+	status.TokenType = tokenStore.TokenType
+	expiresAt := tokenStore.ExpiresAt
+	status.ExpiresAt = &expiresAt
+	if tokenStore.Scopes != "" {
+		status.Scopes = strings.Fields(tokenStore.Scopes)
+	}
+	status.TokenFile, _ = config.GetTokenFilePath()
+
+	return status
+}
+
 func runAuthRefresh(cmd *cobra.Command, args []string) error {
 	cfg, err := config.Load()
 	if err != nil {
@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"bitbucket.org/cover42/eiscli/internal/bitbucket"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage the local cache of Bitbucket responses",
+	Long: `Manage the local cache of Bitbucket responses.
+
+Repository and project listings of the workspace are cached in ~/.eiscli/cache
+for cache.ttl (default 10m) and revalidated with Bitbucket afterwards. Commands
+that create or change repositories or projects drop the affected listings.
+
+Use --no-cache on any command to fetch fresh listings.`,
+}
+
+var cacheClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Remove all cached responses",
+	Long: `Remove all cached responses from ~/.eiscli/cache.
+
+The next command fetches fresh listings from Bitbucket.`,
+	Args: cobra.NoArgs,
+	RunE: runCacheClear,
+}
+
+func init() {
+	rootCmd.AddCommand(cacheCmd)
+	cacheCmd.AddCommand(cacheClearCmd)
+}
+
+func runCacheClear(cmd *cobra.Command, args []string) error {
+	if err := bitbucket.ClearCache(); err != nil {
+		return err
+	}
+
+	green := color.New(color.FgGreen, color.Bold)
+	green.Println("✓ Cache cleared")
+
+	return nil
+}
@@ -1,8 +1,15 @@
 package cmd
 
 import (
+	"bufio"
 	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
 
+	"bitbucket.org/cover42/eiscli/internal/bitbucket"
+	"bitbucket.org/cover42/eiscli/internal/config"
 	"bitbucket.org/cover42/eiscli/internal/git"
 )
 
@@ -16,7 +23,7 @@ func getServiceName(args []string) string {
 	// Auto-detect service name from git repository
 	detectedSlug, err := git.DetectRepositorySlug()
 	if err != nil {
-		fmt.Println("Error: No service name provided and could not auto-detect from git repository")
+		errorf("Error: No service name provided and could not auto-detect from git repository\n")
 		fmt.Printf("  %v\n", err)
 		fmt.Println("\nUsage:")
 		fmt.Println("  1. Run this command from within a git repository, or")
@@ -24,6 +31,143 @@ func getServiceName(args []string) string {
 		return ""
 	}
 
-	fmt.Printf("Auto-detected service from git repository: %s\n", detectedSlug)
+	infof("Auto-detected service from git repository: %s\n", detectedSlug)
 	return detectedSlug
 }
+
+// parseServiceAndNumberArgs splits "[service-name] [number]" arguments, where
+// the number is a pull request ID or build number and may start with "#". A
+// single numeric argument is taken as the number. The returned service args can
+// be passed to getServiceName; the number is 0 when omitted. name describes the
+// number in errors.
+func parseServiceAndNumberArgs(args []string, name string) ([]string, int, error) {
+	switch len(args) {
+	case 0:
+		return nil, 0, nil
+	case 1:
+		if number, err := strconv.Atoi(strings.TrimPrefix(args[0], "#")); err == nil {
+			return nil, number, nil
+		}
+		return args, 0, nil
+	default:
+		number, err := strconv.Atoi(strings.TrimPrefix(args[1], "#"))
+		if err != nil || number <= 0 {
+			return nil, 0, fmt.Errorf("invalid %s: %s", name, args[1])
+		}
+		return args[:1], number, nil
+	}
+}
+
+// loadConfig loads and validates the configuration. Errors are printed.
+func loadConfig() (*config.Config, bool) {
+	cfg, err := config.Load()
+	if err != nil {
+		errorf("Error loading configuration: %v\n", err)
+		errorf("\nPlease set the following environment variables:\n" +
+			"  EISCLI_BITBUCKET_USERNAME\n" +
+			"  EISCLI_BITBUCKET_APP_PASSWORD\n" +
+			"  EISCLI_BITBUCKET_WORKSPACE\n")
+		return nil, false
+	}
+
+	if err := cfg.Validate(); err != nil {
+		errorf("Configuration error: %v\n", err)
+		return nil, false
+	}
+
+	return cfg, true
+}
+
+// newBitbucketClient loads the configuration and creates a Bitbucket client. Errors are printed.
+func newBitbucketClient() (*bitbucket.Client, bool) {
+	cfg, ok := loadConfig()
+	if !ok {
+		return nil, false
+	}
+
+	client, err := bitbucket.NewClient(cfg)
+	if err != nil {
+		errorf("Error creating Bitbucket client: %v\n", err)
+		return nil, false
+	}
+
+	return client, true
+}
+
+// parseSince parses a relative duration such as "30m", "12h", "7d" or "2w", or a
+// date in YYYY-MM-DD format, and returns the corresponding point in time
+func parseSince(value string, now time.Time) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return t, nil
+	}
+
+	if len(value) < 2 {
+		return time.Time{}, fmt.Errorf("invalid --since value %q (use e.g. 12h, 7d, 2w or 2024-01-31)", value)
+	}
+
+	amount, err := strconv.Atoi(value[:len(value)-1])
+	if err != nil || amount <= 0 {
+		return time.Time{}, fmt.Errorf("invalid --since value %q (use e.g. 12h, 7d, 2w or 2024-01-31)", value)
+	}
+
+	switch value[len(value)-1] {
+	case 'm':
+		return now.Add(-time.Duration(amount) * time.Minute), nil
+	case 'h':
+		return now.Add(-time.Duration(amount) * time.Hour), nil
+	case 'd':
+		return now.AddDate(0, 0, -amount), nil
+	case 'w':
+		return now.AddDate(0, 0, -7*amount), nil
+	default:
+		return time.Time{}, fmt.Errorf("invalid --since value %q (use e.g. 12h, 7d, 2w or 2024-01-31)", value)
+	}
+}
+
+// stdin buffers os.Stdin for prompts. Prompts that follow each other share
+// it, so that answers piped in at once are not lost in a discarded buffer.
+var stdin struct {
+	file   *os.File
+	reader *bufio.Reader
+}
+
+// stdinReader returns the buffered reader of os.Stdin
+func stdinReader() *bufio.Reader {
+	if stdin.file != os.Stdin {
+		stdin.file = os.Stdin
+		stdin.reader = bufio.NewReader(os.Stdin)
+	}
+	return stdin.reader
+}
+
+// promptYesNo asks a yes/no question on stdin and returns true if the answer is yes.
+// An empty answer returns defaultYes.
+func promptYesNo(question string, defaultYes bool) (bool, error) {
+	if defaultYes {
+		fmt.Printf("%s [Y/n]: ", question)
+	} else {
+		fmt.Printf("%s [y/N]: ", question)
+	}
+
+	response, err := stdinReader().ReadString('\n')
+	if err != nil {
+		return false, fmt.Errorf("failed to read input: %w", err)
+	}
+
+	response = strings.TrimSpace(strings.ToLower(response))
+	if response == "" {
+		return defaultYes, nil
+	}
+	return response == "y" || response == "yes", nil
+}
+
+// truncateValue shortens value to maxLen characters, ending it with "..." when
+// it is cut. It counts runes so that multi-byte characters are not split.
+func truncateValue(value string, maxLen int) string {
+	runes := []rune(value)
+	if len(runes) <= maxLen {
+		return value
+	}
+	return string(runes[:maxLen-3]) + "..."
+}
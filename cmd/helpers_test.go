@@ -0,0 +1,23 @@
+package cmd
+
+import "testing"
+
+func TestTruncateValue(t *testing.T) {
+	tests := []struct {
+		value  string
+		maxLen int
+		want   string
+	}{
+		{"EIS-1 Add retries", 50, "EIS-1 Add retries"},
+		{"EIS-1 Add retries to the pipeline client", 20, "EIS-1 Add retries..."},
+		{"Übersetzung für Änderungen", 26, "Übersetzung für Änderungen"},
+		{"Übersetzung für Änderungen", 20, "Übersetzung für Ä..."},
+		{"日本語のタイトルを短くする", 8, "日本語のタ..."},
+	}
+
+	for _, tt := range tests {
+		if got := truncateValue(tt.value, tt.maxLen); got != tt.want {
+			t.Errorf("truncateValue(%q, %d) = %q, want %q", tt.value, tt.maxLen, got, tt.want)
+		}
+	}
+}
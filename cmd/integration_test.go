@@ -0,0 +1,754 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"bitbucket.org/cover42/eiscli/internal/bitbucket"
+	"bitbucket.org/cover42/eiscli/internal/bitbucket/bitbuckettest"
+	"bitbucket.org/cover42/eiscli/internal/config"
+	gogit "github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+func TestMain(m *testing.M) {
+	os.Exit(runWithTestHome(m))
+}
+
+func runWithTestHome(m *testing.M) int {
+	// Keep the tests away from the user's configuration and tokens
+	home, err := os.MkdirTemp("", "eiscli-test-home")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer os.RemoveAll(home)
+
+	// The API URL is passed with --api-url by runEiscli
+	for key, value := range map[string]string{
+		"HOME":                          home,
+		"EISCLI_BITBUCKET_WORKSPACE":    "workspace",
+		"EISCLI_BITBUCKET_USE_OAUTH":    "false",
+		"EISCLI_BITBUCKET_USERNAME":     "tester",
+		"EISCLI_BITBUCKET_APP_PASSWORD": "secret",
+		"EISCLI_BITBUCKET_MAX_RETRIES":  "0",
+	} {
+		os.Setenv(key, value)
+	}
+
+	return m.Run()
+}
+
+// newFakeBitbucket starts a seeded Bitbucket stand-in for one test and points
+// the configuration at it, so that tests do not see each other's changes
+func newFakeBitbucket(t *testing.T) *bitbuckettest.Server {
+	t.Helper()
+
+	fake := bitbuckettest.New("workspace")
+	t.Cleanup(fake.Close)
+	seedFakeBitbucket(fake)
+
+	// The configuration is cached per process; load it again for this server
+	t.Setenv("EISCLI_BITBUCKET_WEB_URL", fake.WebURL())
+	config.Reset()
+	t.Cleanup(config.Reset)
+
+	return fake
+}
+
+// fakeReviewer is a workspace member besides the current user
+var fakeReviewer = bitbuckettest.User{UUID: "{00000000-0000-4000-8000-000000000002}", Username: "reviewer", DisplayName: "Re Viewer"}
+
+// addPullRequestOfReviewer adds an open pull request of fakeReviewer to
+// documentservice and returns its ID
+func addPullRequestOfReviewer(t *testing.T, fake *bitbuckettest.Server, title string) string {
+	t.Helper()
+
+	pr := fake.AddPullRequest("documentservice", bitbuckettest.PullRequest{
+		Title:        title,
+		SourceBranch: "feature/" + strings.ReplaceAll(strings.ToLower(title), " ", "-"),
+		Author:       &fakeReviewer,
+	})
+	return strconv.Itoa(pr.ID)
+}
+
+// pullRequestByTitle returns the pull request of a repository with the given title
+func pullRequestByTitle(t *testing.T, fake *bitbuckettest.Server, repoSlug, title string) bitbuckettest.PullRequest {
+	t.Helper()
+
+	for _, pr := range fake.PullRequests(repoSlug) {
+		if pr.Title == title {
+			return pr
+		}
+	}
+	t.Fatalf("no pull request %q in %s", title, repoSlug)
+	return bitbuckettest.PullRequest{}
+}
+
+func seedFakeBitbucket(fake *bitbuckettest.Server) {
+	now := time.Now().UTC()
+	completedOn := now.Add(-time.Hour)
+
+	fake.AddRepository(bitbuckettest.Repository{Slug: "policyservice", Language: "go", ProjectKey: "EIS"})
+	fake.AddRepository(bitbuckettest.Repository{Slug: "documentservice", Language: "go", ProjectKey: "EIS"})
+
+	fake.AddPipeline("policyservice", bitbuckettest.Pipeline{
+		Branch:      "main",
+		Result:      "SUCCESSFUL",
+		CreatedOn:   now.Add(-2 * time.Hour),
+		CompletedOn: &completedOn,
+	})
+	fake.AddPipeline("policyservice", bitbuckettest.Pipeline{
+		Branch:      "feature/EIS-1",
+		Commit:      "e151c0ffee",
+		Result:      "FAILED",
+		CreatedOn:   now.Add(-90 * time.Minute),
+		CompletedOn: &completedOn,
+	})
+
+	fake.AddVariable("policyservice", bitbuckettest.Variable{Key: "LOG_LEVEL", Value: "debug"})
+	fake.AddVariable("policyservice", bitbuckettest.Variable{Key: "DB_PASSWORD", Value: "hunter2", Secured: true})
+	fake.AddDeploymentVariable("policyservice", "Test", bitbuckettest.Variable{Key: "API_HOST", Value: "api.test"})
+
+	reviewer := &fakeReviewer
+	fake.AddPullRequest("policyservice", bitbuckettest.PullRequest{
+		Title:        "EIS-1 Open change",
+		SourceBranch: "feature/EIS-1",
+		SourceCommit: "e151c0ffee",
+		Reviewers:    []*bitbuckettest.User{reviewer},
+		ReviewStates: map[string]string{reviewer.UUID: "approved"},
+		Files: []*bitbuckettest.FileChange{
+			{Path: "main.go", Status: "modified", LinesAdded: 10, LinesRemoved: 2},
+			{Path: "retry.go", Status: "added", LinesAdded: 40},
+		},
+		Diff:         prDiff,
+		CommentCount: 3,
+		TaskCount:    1,
+	})
+	fake.AddPullRequest("policyservice", bitbuckettest.PullRequest{Title: "EIS-2 Merged change", SourceBranch: "feature/EIS-2", State: "MERGED"})
+
+	// Pull requests of another author
+	fake.AddMember(*reviewer)
+
+	fake.AddPullRequest("documentservice", bitbuckettest.PullRequest{
+		Title:        "EIS-4 Abandoned change",
+		SourceBranch: "feature/EIS-4",
+		Author:       reviewer,
+		Comments: []*bitbuckettest.Comment{
+			{Body: "Why is this abandoned?"},
+			{Body: "This leaks the connection", Path: "store.go", Line: 30},
+			{Body: "Superseded by EIS-5", ParentID: 1, Author: reviewer},
+		},
+		Tasks: []*bitbuckettest.Task{{CommentID: 2, Body: "Close the connection"}},
+	})
+	tester := &bitbuckettest.User{UUID: "{00000000-0000-4000-8000-000000000001}", Username: "tester", DisplayName: "Test User"}
+	fake.AddPullRequest("documentservice", bitbuckettest.PullRequest{
+		Title:        "EIS-5 Cache documents",
+		SourceBranch: "feature/EIS-5",
+		Author:       reviewer,
+		Reviewers:    []*bitbuckettest.User{tester},
+	})
+}
+
+// prDiff is the diff of the open pull request of policyservice
+const prDiff = `diff --git a/main.go b/main.go
+index 1111111..2222222 100644
+--- a/main.go
++++ b/main.go
+@@ -1,3 +1,3 @@
+ package main
+-// TODO retries
++// Retries are in retry.go
+diff --git a/retry.go b/retry.go
+new file mode 100644
+index 0000000..3333333
+--- /dev/null
++++ b/retry.go
+@@ -0,0 +1 @@
++package main
+`
+
+// runEiscli runs the CLI against the fake Bitbucket and returns what it wrote to stdout
+func runEiscli(t *testing.T, fake *bitbuckettest.Server, args ...string) string {
+	t.Helper()
+
+	resetFlags(rootCmd)
+	rootCmd.SetArgs(append(args, "--api-url", fake.APIURL()))
+
+	stdout := os.Stdout
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Stdout = w
+
+	output := make(chan string)
+	go func() {
+		var buf bytes.Buffer
+		_, _ = io.Copy(&buf, r)
+		output <- buf.String()
+	}()
+
+	err = rootCmd.ExecuteContext(context.Background())
+
+	os.Stdout = stdout
+	_ = w.Close()
+	out := <-output
+
+	if err != nil {
+		t.Fatalf("eiscli %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+	return out
+}
+
+// resetFlags restores the defaults of all flags, which keep their values
+// between executions of the command tree
+func resetFlags(cmd *cobra.Command) {
+	reset := func(flag *pflag.Flag) {
+		if slice, ok := flag.Value.(pflag.SliceValue); ok {
+			_ = slice.Replace(nil)
+		} else {
+			_ = flag.Value.Set(flag.DefValue)
+		}
+		flag.Changed = false
+	}
+	cmd.Flags().VisitAll(reset)
+	cmd.PersistentFlags().VisitAll(reset)
+
+	for _, child := range cmd.Commands() {
+		resetFlags(child)
+	}
+}
+
+// decodeOutput decodes the JSON output of a command
+func decodeOutput(t *testing.T, out string, v interface{}) {
+	t.Helper()
+
+	if err := json.Unmarshal([]byte(out), v); err != nil {
+		t.Fatalf("output is not valid JSON: %v\n%s", err, out)
+	}
+}
+
+func TestSvcListAgainstFakeBitbucket(t *testing.T) {
+	fake := newFakeBitbucket(t)
+	var repos []*bitbucket.Repository
+	decodeOutput(t, runEiscli(t, fake, "svc", "list", "-o", "json"), &repos)
+
+	if len(repos) != 2 {
+		t.Fatalf("got %d repositories, want 2", len(repos))
+	}
+	if repos[0].Slug != "policyservice" || repos[0].FullName != "workspace/policyservice" || repos[0].ProjectKey != "EIS" {
+		t.Errorf("first repository = %+v", repos[0])
+	}
+}
+
+func TestPipelinesListAgainstFakeBitbucket(t *testing.T) {
+	fake := newFakeBitbucket(t)
+	var pipelines []*bitbucket.Pipeline
+	decodeOutput(t, runEiscli(t, fake, "pipelines", "policyservice", "--state", "failed", "-o", "json"), &pipelines)
+
+	if len(pipelines) != 1 {
+		t.Fatalf("got %d pipelines, want 1", len(pipelines))
+	}
+	if pipelines[0].Target.RefName != "feature/EIS-1" {
+		t.Errorf("branch = %q, want feature/EIS-1", pipelines[0].Target.RefName)
+	}
+	wantURL := fmt.Sprintf("%s/workspace/policyservice/pipelines/results/%d", fake.WebURL(), pipelines[0].BuildNumber)
+	if pipelines[0].WebURL != wantURL {
+		t.Errorf("WebURL = %q, want %q", pipelines[0].WebURL, wantURL)
+	}
+}
+
+func TestPipelinesRunAgainstFakeBitbucket(t *testing.T) {
+	fake := newFakeBitbucket(t)
+	before := len(fake.Pipelines("documentservice"))
+
+	out := runEiscli(t, fake, "pipelines", "run", "documentservice", "--branch", "main",
+		"--custom", "deploy-to-test", "--var", "VERSION=1.2.3")
+
+	pipelines := fake.Pipelines("documentservice")
+	if len(pipelines) != before+1 {
+		t.Fatalf("got %d pipelines, want %d", len(pipelines), before+1)
+	}
+	triggered := pipelines[len(pipelines)-1]
+	if triggered.Branch != "main" || triggered.Custom != "deploy-to-test" || triggered.Variables["VERSION"] != "1.2.3" {
+		t.Errorf("triggered pipeline = %+v", triggered)
+	}
+	if !strings.Contains(out, fmt.Sprintf("Pipeline #%d started", triggered.BuildNumber)) {
+		t.Errorf("output does not report the started pipeline #%d:\n%s", triggered.BuildNumber, out)
+	}
+}
+
+func TestVarsAgainstFakeBitbucket(t *testing.T) {
+	fake := newFakeBitbucket(t)
+	var listings []*variableListing
+	decodeOutput(t, runEiscli(t, fake, "vars", "policyservice", "-o", "json"), &listings)
+
+	if len(listings) != 2 {
+		t.Fatalf("got %d listings, want repository and deployment", len(listings))
+	}
+
+	repoVars := listings[0].Variables
+	if listings[0].Scope != "repository" || len(repoVars) != 2 {
+		t.Fatalf("repository listing = %+v", listings[0])
+	}
+	if repoVars[1].Key != "DB_PASSWORD" || !repoVars[1].Secured || repoVars[1].Value != "" {
+		t.Errorf("secured variable = %+v, want its value hidden", repoVars[1])
+	}
+
+	if listings[1].Environment == nil || listings[1].Environment.Name != "Test" {
+		t.Fatalf("deployment listing = %+v", listings[1])
+	}
+	if vars := listings[1].Variables; len(vars) != 1 || vars[0].Key != "API_HOST" {
+		t.Errorf("deployment variables = %+v", vars)
+	}
+}
+
+func TestPRListAgainstFakeBitbucket(t *testing.T) {
+	fake := newFakeBitbucket(t)
+	var prs []*bitbucket.PullRequest
+	decodeOutput(t, runEiscli(t, fake, "pr", "list", "policyservice", "-o", "json"), &prs)
+
+	if len(prs) != 1 || prs[0].Title != "EIS-1 Open change" {
+		t.Fatalf("pull requests = %+v, want only the open one", prs)
+	}
+	if !strings.HasPrefix(prs[0].WebURL, fake.WebURL()) {
+		t.Errorf("WebURL = %q, want a link to the fake server", prs[0].WebURL)
+	}
+
+	decodeOutput(t, runEiscli(t, fake, "pr", "list", "policyservice", "--state", "MERGED", "-o", "json"), &prs)
+	if len(prs) != 1 || prs[0].State != "MERGED" {
+		t.Errorf("merged pull requests = %+v", prs)
+	}
+}
+
+func TestPRViewAgainstFakeBitbucket(t *testing.T) {
+	fake := newFakeBitbucket(t)
+	id := strconv.Itoa(pullRequestByTitle(t, fake, "policyservice", "EIS-1 Open change").ID)
+
+	var view pullRequestViewOutput
+	decodeOutput(t, runEiscli(t, fake, "pr", "view", "policyservice", id, "-o", "json"), &view)
+
+	pr := view.PullRequest
+	if pr == nil || pr.Title != "EIS-1 Open change" || pr.CommentCount != 3 || pr.TaskCount != 1 {
+		t.Fatalf("pull request = %+v", pr)
+	}
+	if len(pr.Participants) != 1 || pr.Participants[0].Name != "Re Viewer" || !pr.Participants[0].Approved {
+		t.Errorf("participants = %+v, want the approving reviewer", pr.Participants)
+	}
+	if len(view.Files) != 2 || view.Files[1].Path != "retry.go" || view.Files[1].Status != "added" {
+		t.Errorf("files = %+v", view.Files)
+	}
+	if len(view.Commits) != 1 || view.Commits[0].Message != "EIS-1 Open change" || view.Commits[0].Author != "Test User" {
+		t.Errorf("commits = %+v", view.Commits)
+	}
+	if len(view.Builds) != 1 || view.Builds[0].State != "FAILED" {
+		t.Errorf("builds = %+v, want the failed pipeline of the source commit", view.Builds)
+	}
+	if len(view.Errors) != 0 {
+		t.Errorf("errors = %v", view.Errors)
+	}
+}
+
+func TestPRReviewAgainstFakeBitbucket(t *testing.T) {
+	fake := newFakeBitbucket(t)
+	id := addPullRequestOfReviewer(t, fake, "EIS-3 Ready change")
+
+	if out := runEiscli(t, fake, "pr", "approve", "documentservice", id); !strings.Contains(out, "Approved pull request #"+id) {
+		t.Errorf("pr approve output = %q", out)
+	}
+	if out := runEiscli(t, fake, "pr", "request-changes", "documentservice", id); !strings.Contains(out, "Requested changes on pull request #"+id) {
+		t.Errorf("pr request-changes output = %q", out)
+	}
+	if out := runEiscli(t, fake, "pr", "unapprove", "documentservice", id); !strings.Contains(out, "Withdrew approval of pull request #"+id) {
+		t.Errorf("pr unapprove output = %q", out)
+	}
+
+	var declined bitbucket.PullRequest
+	decodeOutput(t, runEiscli(t, fake, "pr", "decline", "documentservice", id, "--yes", "-o", "json"), &declined)
+	if strconv.Itoa(declined.ID) != id || declined.State != "DECLINED" {
+		t.Errorf("declined pull request = %+v", declined)
+	}
+}
+
+func TestPRMergeAgainstFakeBitbucket(t *testing.T) {
+	fake := newFakeBitbucket(t)
+	// The build of the open pull request of policyservice failed
+	failing := strconv.Itoa(pullRequestByTitle(t, fake, "policyservice", "EIS-1 Open change").ID)
+	out := runEiscli(t, fake, "pr", "merge", "policyservice", failing)
+	if !strings.Contains(out, "failing builds") {
+		t.Errorf("pr merge output = %q, want the merge refused", out)
+	}
+	if state := pullRequestByTitle(t, fake, "policyservice", "EIS-1 Open change").State; state != "OPEN" {
+		t.Errorf("state after refused merge = %s", state)
+	}
+
+	id := addPullRequestOfReviewer(t, fake, "EIS-6 Merged change")
+	out = runEiscli(t, fake, "pr", "merge", "documentservice", id, "--strategy", "rebase")
+	if !strings.Contains(out, "invalid merge strategy") {
+		t.Errorf("pr merge output = %q, want the strategy rejected", out)
+	}
+
+	var merged bitbucket.PullRequest
+	decodeOutput(t, runEiscli(t, fake, "pr", "merge", "documentservice", id, "--strategy", "squash", "--close-source-branch", "-o", "json"), &merged)
+	if strconv.Itoa(merged.ID) != id || merged.State != "MERGED" {
+		t.Errorf("merged pull request = %+v", merged)
+	}
+	for _, pr := range fake.PullRequests("documentservice") {
+		if strconv.Itoa(pr.ID) == id && (pr.MergeStrategy != "squash" || !pr.CloseSourceBranch) {
+			t.Errorf("merge options sent = %+v", pr)
+		}
+	}
+}
+
+func TestPRDiffAgainstFakeBitbucket(t *testing.T) {
+	fake := newFakeBitbucket(t)
+	id := strconv.Itoa(pullRequestByTitle(t, fake, "policyservice", "EIS-1 Open change").ID)
+
+	if out := runEiscli(t, fake, "pr", "diff", "policyservice", id); out != prDiff {
+		t.Errorf("pr diff output = %q, want the whole diff", out)
+	}
+
+	out := runEiscli(t, fake, "pr", "diff", "policyservice", id, "retry.go")
+	if !strings.HasPrefix(out, "diff --git a/retry.go b/retry.go") || strings.Contains(out, "main.go") {
+		t.Errorf("pr diff output limited to retry.go = %q", out)
+	}
+
+	if out := runEiscli(t, fake, "pr", "diff", "policyservice", id, "docs/"); !strings.Contains(out, "No changes.") {
+		t.Errorf("pr diff output for an unchanged path = %q", out)
+	}
+}
+
+func TestPRCommentsAgainstFakeBitbucket(t *testing.T) {
+	fake := newFakeBitbucket(t)
+	pr := pullRequestByTitle(t, fake, "documentservice", "EIS-4 Abandoned change")
+	id := strconv.Itoa(pr.ID)
+	if len(pr.Comments) != 3 || len(pr.Tasks) != 1 {
+		t.Fatalf("seeded pull request has %d comments and %d tasks, want 3 and 1", len(pr.Comments), len(pr.Tasks))
+	}
+	inlineID := strconv.Itoa(pr.Comments[1].ID)
+	taskID := strconv.Itoa(pr.Tasks[0].ID)
+
+	// lastCommentID returns the ID of the comment added last
+	lastCommentID := func() int {
+		comments := pullRequestByTitle(t, fake, "documentservice", pr.Title).Comments
+		return comments[len(comments)-1].ID
+	}
+
+	out := runEiscli(t, fake, "pr", "comments", "documentservice", id)
+	general, inline, found := strings.Cut(out, "store.go")
+	if !found || !strings.Contains(general, "Why is this abandoned?") || !strings.Contains(general, "↳ Re Viewer") {
+		t.Fatalf("pr comments output = %q, want general comments before store.go", out)
+	}
+	if !strings.Contains(inline, "Line 30") || !strings.Contains(inline, "☐ Task #"+taskID+": Close the connection") {
+		t.Errorf("pr comments output for store.go = %q", inline)
+	}
+
+	out = runEiscli(t, fake, "pr", "comment", "documentservice", id, "--file", "store.go", "--line", "31", "--body", "Also here")
+	if want := fmt.Sprintf("Added comment #%d to pull request #%s on store.go:31", lastCommentID(), id); !strings.Contains(out, want) {
+		t.Errorf("pr comment output = %q, want %q", out, want)
+	}
+	out = runEiscli(t, fake, "pr", "comment", "documentservice", id, "--reply-to", inlineID, "--body", "Fixed")
+	if want := fmt.Sprintf("Added comment #%d to pull request #%s on store.go:30", lastCommentID(), id); !strings.Contains(out, want) {
+		t.Errorf("pr comment --reply-to output = %q, want %q", out, want)
+	}
+
+	out = runEiscli(t, fake, "pr", "tasks", "resolve", "documentservice", id, taskID)
+	if !strings.Contains(out, "Resolved task #"+taskID) {
+		t.Errorf("pr tasks resolve output = %q", out)
+	}
+	if out := runEiscli(t, fake, "pr", "tasks", "documentservice", id); !strings.Contains(out, "(0 open, 1 resolved)") {
+		t.Errorf("pr tasks output = %q", out)
+	}
+
+	var comments struct {
+		Comments []map[string]any `json:"comments"`
+		Tasks    []map[string]any `json:"tasks"`
+	}
+	decodeOutput(t, runEiscli(t, fake, "pr", "comments", "documentservice", id, "-o", "json"), &comments)
+	if len(comments.Comments) != 5 || len(comments.Tasks) != 1 || comments.Tasks[0]["state"] != "RESOLVED" {
+		t.Errorf("pr comments -o json = %+v", comments)
+	}
+}
+
+// initGitRepository creates a clone of a bare repository with a commit on
+// main and a commit on the branch, which is checked out but not pushed. It
+// changes into the clone and returns its directory and that of the bare
+// repository.
+func initGitRepository(t *testing.T, branch, message string) (string, string) {
+	t.Helper()
+
+	originDir := t.TempDir()
+	if _, err := gogit.PlainInit(originDir, true); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	repo, err := gogit.PlainInitWithOptions(dir, &gogit.PlainInitOptions{
+		InitOptions: gogit.InitOptions{DefaultBranch: plumbing.NewBranchReferenceName("main")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.CreateRemote(&gitconfig.RemoteConfig{Name: "origin", URLs: []string{originDir}}); err != nil {
+		t.Fatal(err)
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	commit := func(name, message string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(message), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := worktree.Add(name); err != nil {
+			t.Fatal(err)
+		}
+		_, err := worktree.Commit(message, &gogit.CommitOptions{
+			Author: &object.Signature{Name: "Test", Email: "test@example.com", When: time.Now()},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	commit("README.md", "Initial commit")
+	err = repo.Push(&gogit.PushOptions{RefSpecs: []gitconfig.RefSpec{"refs/heads/main:refs/heads/main"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := worktree.Checkout(&gogit.CheckoutOptions{Branch: plumbing.NewBranchReferenceName(branch), Create: true}); err != nil {
+		t.Fatal(err)
+	}
+	commit("change.txt", message)
+
+	t.Chdir(dir)
+	return dir, originDir
+}
+
+// withStdin feeds input to the prompts of the command run by fn
+func withStdin(t *testing.T, input string, fn func()) {
+	t.Helper()
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.WriteString(input); err != nil {
+		t.Fatal(err)
+	}
+	_ = w.Close()
+
+	stdin := os.Stdin
+	os.Stdin = r
+	defer func() {
+		os.Stdin = stdin
+		_ = r.Close()
+	}()
+	fn()
+}
+
+func TestPRCreateAgainstFakeBitbucket(t *testing.T) {
+	fake := newFakeBitbucket(t)
+	dir, _ := initGitRepository(t, "feature/EIS-7-retry-requests", "Retry failed requests\n\nUp to three times.")
+	if err := os.MkdirAll(filepath.Join(dir, ".bitbucket"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".bitbucket", "pull_request_template.md"), []byte("## Summary\n\n## Testing\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("VISUAL", "")
+	t.Setenv("EDITOR", "")
+
+	var out string
+	// Push the branch and take the suggested title
+	withStdin(t, "\n\n", func() {
+		out = runEiscli(t, fake, "pr", "create", "documentservice", "--base", "main", "--draft", "--reviewer", "Re Viewer")
+	})
+	if !strings.Contains(out, "Jira issue: EIS-7") || !strings.Contains(out, "PR Title [EIS-7 Retry failed requests]") {
+		t.Errorf("pr create output = %q, want the Jira key and suggested title", out)
+	}
+
+	prs := fake.PullRequests("documentservice")
+	pr := prs[len(prs)-1]
+	if pr.Title != "EIS-7 Retry failed requests" || !pr.Draft || pr.Description != "## Summary\n\n## Testing" {
+		t.Errorf("created pull request = %+v", pr)
+	}
+	if len(pr.Reviewers) != 1 || pr.Reviewers[0].Username != "reviewer" {
+		t.Errorf("reviewers = %+v, want reviewer", pr.Reviewers)
+	}
+
+	if out := runEiscli(t, fake, "pr", "create", "documentservice", "--base", "main", "--title", "x", "--body", "y", "--reviewer", "nobody"); !strings.Contains(out, "no member matches 'nobody'") {
+		t.Errorf("pr create with an unknown reviewer output = %q", out)
+	}
+}
+
+func TestPRCreatePushAndOpenPullRequest(t *testing.T) {
+	fake := newFakeBitbucket(t)
+	_, originDir := initGitRepository(t, "feature/EIS-8-timeouts", "Time out requests")
+	count := len(fake.PullRequests("documentservice"))
+	args := []string{"pr", "create", "documentservice", "--base", "main", "--title", "EIS-8 Time out requests", "--body", "After 10s"}
+
+	out := runEiscli(t, fake, append(args, "--no-push")...)
+	if !strings.Contains(out, "Branch 'feature/EIS-8-timeouts' is not on 'origin' yet") || !strings.Contains(out, "--no-push") {
+		t.Errorf("pr create --no-push output = %q", out)
+	}
+	if len(fake.PullRequests("documentservice")) != count {
+		t.Fatal("pr create --no-push created a pull request of an unpushed branch")
+	}
+
+	withStdin(t, "y\n", func() {
+		out = runEiscli(t, fake, args...)
+	})
+	if !strings.Contains(out, "Pushed 'origin/feature/EIS-8-timeouts'") || !strings.Contains(out, "Pull request created successfully") {
+		t.Errorf("pr create with push output = %q", out)
+	}
+	origin, err := gogit.PlainOpen(originDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := origin.Reference(plumbing.NewBranchReferenceName("feature/EIS-8-timeouts"), true); err != nil {
+		t.Errorf("branch not pushed: %v", err)
+	}
+
+	// The open pull request is shown instead of creating another one, and updated
+	out = runEiscli(t, fake, "pr", "create", "documentservice", "--base", "main", "--no-push")
+	if !strings.Contains(out, "already has an open pull request") || !strings.Contains(out, "EIS-8 Time out requests") {
+		t.Errorf("pr create of a branch with an open pull request output = %q", out)
+	}
+	out = runEiscli(t, fake, "pr", "create", "documentservice", "--base", "main", "--title", "EIS-8 Time out slow requests")
+	if !strings.Contains(out, "Pull request updated") {
+		t.Errorf("pr create --title of a branch with an open pull request output = %q", out)
+	}
+
+	prs := fake.PullRequests("documentservice")
+	if len(prs) != count+1 || prs[count].Title != "EIS-8 Time out slow requests" || prs[count].Description != "After 10s" {
+		t.Errorf("pull requests after updating = %d, last %+v", len(prs), prs[len(prs)-1])
+	}
+}
+
+func TestPRInboxAgainstFakeBitbucket(t *testing.T) {
+	fake := newFakeBitbucket(t)
+	var inbox []struct {
+		Repository   string `json:"repository"`
+		PullRequests []struct {
+			Role        string `json:"role"`
+			Approvals   int    `json:"approvals"`
+			BuildState  string `json:"build_state"`
+			PullRequest struct {
+				Title string `json:"title"`
+			} `json:"pull_request"`
+		} `json:"pull_requests"`
+	}
+	fake.AddPullRequest("documentservice", bitbuckettest.PullRequest{Title: "EIS-9 Own change", SourceBranch: "feature/EIS-9"})
+	decodeOutput(t, runEiscli(t, fake, "pr", "inbox", "-o", "json"), &inbox)
+
+	if len(inbox) != 2 || inbox[0].Repository != "documentservice" || inbox[1].Repository != "policyservice" {
+		t.Fatalf("inbox = %+v, want documentservice and policyservice", inbox)
+	}
+	// The review of another author comes before the pull requests of the current user
+	if prs := inbox[0].PullRequests; len(prs) != 2 {
+		t.Errorf("documentservice inbox = %+v, want EIS-5 and EIS-9", prs)
+	} else {
+		if pending := prs[0]; pending.Role != "review pending" || pending.PullRequest.Title != "EIS-5 Cache documents" {
+			t.Errorf("first documentservice pull request = %+v, want the pending review of EIS-5", pending)
+		}
+		if authored := prs[1]; authored.Role != "author" || authored.PullRequest.Title != "EIS-9 Own change" {
+			t.Errorf("second documentservice pull request = %+v, want EIS-9 as author", authored)
+		}
+	}
+	if authored := inbox[1].PullRequests; len(authored) != 1 || authored[0].Role != "author" || authored[0].Approvals != 1 || authored[0].BuildState != "FAILED" {
+		t.Errorf("policyservice inbox = %+v, want EIS-1 with an approval and a failed build", authored)
+	}
+
+	out := runEiscli(t, fake, "pr", "inbox", "--role", "pending")
+	if !strings.Contains(out, "EIS-5 Cache documents") || strings.Contains(out, "EIS-1 Open change") || !strings.Contains(out, "1 waiting for your review") {
+		t.Errorf("pr inbox --role pending output = %q", out)
+	}
+}
+
+func TestVarsSetAndUnsetAgainstFakeBitbucket(t *testing.T) {
+	fake := newFakeBitbucket(t)
+	fake.AddEnvironment("documentservice", bitbuckettest.Environment{Name: "Production", Type: "Production"})
+	fake.AddDeploymentVariable("documentservice", "Production", bitbuckettest.Variable{Key: "API_HOST", Value: "api.example.com"})
+	if vars := fake.Variables("documentservice"); len(vars) != 0 {
+		t.Fatalf("documentservice has repository variables %+v, want none", vars)
+	}
+
+	// Repository variables are created, then updated in place
+	out := runEiscli(t, fake, "vars", "set", "documentservice", "LOG_LEVEL=debug")
+	if !strings.Contains(out, "Created LOG_LEVEL=debug in documentservice (repository)") {
+		t.Errorf("vars set output = %q", out)
+	}
+	out = runEiscli(t, fake, "vars", "set", "documentservice", "LOG_LEVEL=info")
+	if !strings.Contains(out, "Updated LOG_LEVEL in documentservice (repository): 'debug' → 'info'") {
+		t.Errorf("vars set of an existing variable output = %q", out)
+	}
+	if vars := fake.Variables("documentservice"); len(vars) != 1 || vars[0].Value != "info" || vars[0].Secured {
+		t.Errorf("repository variables = %+v, want LOG_LEVEL=info", vars)
+	}
+
+	// Secrets are detected from the name
+	runEiscli(t, fake, "vars", "set", "documentservice", "API_TOKEN=s3cret")
+	if vars := fake.Variables("documentservice"); len(vars) != 2 || !vars[1].Secured {
+		t.Errorf("repository variables = %+v, want API_TOKEN secured", vars)
+	}
+
+	out = runEiscli(t, fake, "vars", "unset", "documentservice", "LOG_LEVEL")
+	if !strings.Contains(out, "Removed LOG_LEVEL from documentservice (repository)") {
+		t.Errorf("vars unset output = %q", out)
+	}
+	if out := runEiscli(t, fake, "vars", "unset", "documentservice", "LOG_LEVEL"); !strings.Contains(out, "variable LOG_LEVEL not found") {
+		t.Errorf("vars unset of a missing variable output = %q", out)
+	}
+
+	// Production variables are only changed after confirmation
+	prodArgs := []string{"vars", "set", "documentservice", "API_HOST=api2.example.com", "--type", "deployment", "--env", "Production"}
+	withStdin(t, "n\n", func() {
+		out = runEiscli(t, fake, prodArgs...)
+	})
+	if !strings.Contains(out, "Canceled") {
+		t.Errorf("declined vars set in Production output = %q", out)
+	}
+	if vars := fake.DeploymentVariables("documentservice", "Production"); len(vars) != 1 || vars[0].Value != "api.example.com" {
+		t.Fatalf("Production variables after declining = %+v, want API_HOST unchanged", vars)
+	}
+	withStdin(t, "y\n", func() {
+		out = runEiscli(t, fake, prodArgs...)
+	})
+	if vars := fake.DeploymentVariables("documentservice", "Production"); len(vars) != 1 || vars[0].Value != "api2.example.com" {
+		t.Errorf("Production variables = %+v, want API_HOST updated\n%s", vars, out)
+	}
+	runEiscli(t, fake, "vars", "unset", "documentservice", "API_HOST", "--type", "deployment", "--env", "Production", "--yes")
+	if vars := fake.DeploymentVariables("documentservice", "Production"); len(vars) != 0 {
+		t.Errorf("Production variables = %+v, want none", vars)
+	}
+
+	// Workspace variables
+	if vars := fake.WorkspaceVariables(); len(vars) != 0 {
+		t.Fatalf("workspace variables = %+v, want none", vars)
+	}
+	runEiscli(t, fake, "vars", "set", "SENTRY_ENV=test", "--type", "workspace")
+	runEiscli(t, fake, "vars", "set", "SENTRY_ENV=prod", "--type", "workspace")
+	if vars := fake.WorkspaceVariables(); len(vars) != 1 || vars[0].Value != "prod" {
+		t.Errorf("workspace variables = %+v, want SENTRY_ENV=prod", vars)
+	}
+	runEiscli(t, fake, "vars", "unset", "SENTRY_ENV", "--type", "workspace")
+	if vars := fake.WorkspaceVariables(); len(vars) != 0 {
+		t.Errorf("workspace variables = %+v, want none", vars)
+	}
+}
@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Output formats supported by the global --output flag
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+	outputYAML  = "yaml"
+)
+
+var outputFormat string
+
+// commandFailed is set when a command reported an error with errorf, so that
+// Execute can exit non-zero although cobra Run functions cannot return errors
+var commandFailed bool
+
+// validateOutputFormat checks the --output flag value
+func validateOutputFormat() error {
+	switch strings.ToLower(outputFormat) {
+	case outputTable, outputJSON, outputYAML:
+		outputFormat = strings.ToLower(outputFormat)
+		return nil
+	default:
+		return fmt.Errorf("invalid output format %q (must be one of: table, json, yaml)", outputFormat)
+	}
+}
+
+// isMachineOutput returns true if results should be printed as JSON or YAML instead of tables
+func isMachineOutput() bool {
+	return outputFormat == outputJSON || outputFormat == outputYAML
+}
+
+// printStructured writes v to stdout in the selected machine-readable format
+func printStructured(v interface{}) error {
+	switch outputFormat {
+	case outputYAML:
+		encoder := yaml.NewEncoder(os.Stdout)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(v); err != nil {
+			return fmt.Errorf("failed to encode YAML output: %w", err)
+		}
+		return encoder.Close()
+	default:
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(v); err != nil {
+			return fmt.Errorf("failed to encode JSON output: %w", err)
+		}
+		return nil
+	}
+}
+
+// printOutput prints v as JSON or YAML and reports encoding errors with errorf
+func printOutput(v interface{}) {
+	if err := printStructured(v); err != nil {
+		errorf("Error: %v\n", err)
+	}
+}
+
+// infof prints a progress message. With --output json/yaml it goes to stderr
+// so that stdout only contains the structured result.
+func infof(format string, args ...interface{}) {
+	if isMachineOutput() {
+		fmt.Fprintf(os.Stderr, format, args...)
+		return
+	}
+	fmt.Printf(format, args...)
+}
+
+// errorf reports a command error. With --output json/yaml it goes to stderr
+// so that stdout only contains the structured result, and the command exits
+// with a non-zero status.
+func errorf(format string, args ...interface{}) {
+	if isMachineOutput() {
+		commandFailed = true
+		fmt.Fprintf(os.Stderr, format, args...)
+		return
+	}
+	fmt.Printf(format, args...)
+}
@@ -0,0 +1,187 @@
+package cmd
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+// withOutputFormat sets --output for the duration of a test
+func withOutputFormat(t *testing.T, format string) {
+	t.Helper()
+
+	previous := outputFormat
+	outputFormat = format
+	t.Cleanup(func() { outputFormat = previous })
+}
+
+// withCommandFailed resets the failure flag set by errorf for the duration of a test
+func withCommandFailed(t *testing.T) {
+	t.Helper()
+
+	previous := commandFailed
+	commandFailed = false
+	t.Cleanup(func() { commandFailed = previous })
+}
+
+// captureOutput returns what fn writes to stdout and stderr
+func captureOutput(t *testing.T, fn func()) (string, string) {
+	t.Helper()
+
+	capture := func(f **os.File) func() string {
+		r, w, err := os.Pipe()
+		if err != nil {
+			t.Fatal(err)
+		}
+		original := *f
+		*f = w
+
+		output := make(chan string)
+		go func() {
+			var buf bytes.Buffer
+			_, _ = io.Copy(&buf, r)
+			output <- buf.String()
+		}()
+
+		return func() string {
+			*f = original
+			_ = w.Close()
+			return <-output
+		}
+	}
+
+	stopStdout := capture(&os.Stdout)
+	stopStderr := capture(&os.Stderr)
+	fn()
+	return stopStdout(), stopStderr()
+}
+
+func TestValidateOutputFormat(t *testing.T) {
+	tests := []struct {
+		format  string
+		want    string
+		wantErr bool
+	}{
+		{"table", "table", false},
+		{"json", "json", false},
+		{"YAML", "yaml", false},
+		{"xml", "xml", true},
+		{"", "", true},
+	}
+
+	for _, tt := range tests {
+		withOutputFormat(t, tt.format)
+		err := validateOutputFormat()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("validateOutputFormat() with %q: error = %v, want error %t", tt.format, err, tt.wantErr)
+		}
+		if outputFormat != tt.want {
+			t.Errorf("validateOutputFormat() with %q: format = %q, want %q", tt.format, outputFormat, tt.want)
+		}
+		if err != nil && !strings.Contains(err.Error(), "table, json, yaml") {
+			t.Errorf("validateOutputFormat() error = %v, want the valid formats listed", err)
+		}
+	}
+}
+
+func TestIsMachineOutput(t *testing.T) {
+	for format, want := range map[string]bool{"table": false, "json": true, "yaml": true} {
+		withOutputFormat(t, format)
+		if got := isMachineOutput(); got != want {
+			t.Errorf("isMachineOutput() with %q = %t, want %t", format, got, want)
+		}
+	}
+}
+
+func TestPrintStructured(t *testing.T) {
+	value := struct {
+		Name   string   `json:"name" yaml:"name"`
+		Builds []string `json:"builds" yaml:"builds"`
+	}{"policyservice", []string{"#1", "#2"}}
+
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{"json", "{\n  \"name\": \"policyservice\",\n  \"builds\": [\n    \"#1\",\n    \"#2\"\n  ]\n}\n"},
+		{"yaml", "name: policyservice\nbuilds:\n  - '#1'\n  - '#2'\n"},
+	}
+
+	for _, tt := range tests {
+		withOutputFormat(t, tt.format)
+		var err error
+		stdout, stderr := captureOutput(t, func() { err = printStructured(value) })
+		if err != nil {
+			t.Fatalf("printStructured() with %q: %v", tt.format, err)
+		}
+		if stdout != tt.want {
+			t.Errorf("printStructured() with %q wrote %q, want %q", tt.format, stdout, tt.want)
+		}
+		if stderr != "" {
+			t.Errorf("printStructured() with %q wrote %q to stderr, want nothing", tt.format, stderr)
+		}
+	}
+}
+
+func TestPrintOutputReportsEncodingErrors(t *testing.T) {
+	withOutputFormat(t, "json")
+	withCommandFailed(t)
+
+	stdout, stderr := captureOutput(t, func() { printOutput(func() {}) })
+	if stdout != "" {
+		t.Errorf("printOutput() wrote %q to stdout, want nothing", stdout)
+	}
+	if !strings.HasPrefix(stderr, "Error: failed to encode JSON output") {
+		t.Errorf("printOutput() wrote %q to stderr, want the encoding error", stderr)
+	}
+	if !commandFailed {
+		t.Error("printOutput() did not mark the command as failed")
+	}
+}
+
+func TestInfof(t *testing.T) {
+	tests := []struct {
+		format     string
+		wantStdout string
+		wantStderr string
+	}{
+		{"table", "Fetching 3 repositories\n", ""},
+		{"json", "", "Fetching 3 repositories\n"},
+		{"yaml", "", "Fetching 3 repositories\n"},
+	}
+
+	for _, tt := range tests {
+		withOutputFormat(t, tt.format)
+		stdout, stderr := captureOutput(t, func() { infof("Fetching %d repositories\n", 3) })
+		if stdout != tt.wantStdout || stderr != tt.wantStderr {
+			t.Errorf("infof() with %q wrote stdout %q, stderr %q, want %q, %q", tt.format, stdout, stderr, tt.wantStdout, tt.wantStderr)
+		}
+	}
+}
+
+func TestErrorf(t *testing.T) {
+	tests := []struct {
+		format     string
+		wantStdout string
+		wantStderr string
+		wantFailed bool
+	}{
+		{"table", "Error: pull request #42 not found\n", "", false},
+		{"json", "", "Error: pull request #42 not found\n", true},
+		{"yaml", "", "Error: pull request #42 not found\n", true},
+	}
+
+	for _, tt := range tests {
+		withOutputFormat(t, tt.format)
+		withCommandFailed(t)
+		stdout, stderr := captureOutput(t, func() { errorf("Error: pull request #%d not found\n", 42) })
+		if stdout != tt.wantStdout || stderr != tt.wantStderr {
+			t.Errorf("errorf() with %q wrote stdout %q, stderr %q, want %q, %q", tt.format, stdout, stderr, tt.wantStdout, tt.wantStderr)
+		}
+		if commandFailed != tt.wantFailed {
+			t.Errorf("errorf() with %q: command failed = %t, want %t", tt.format, commandFailed, tt.wantFailed)
+		}
+	}
+}
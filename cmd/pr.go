@@ -1,7 +1,6 @@
 package cmd
 
 import (
-	"bufio"
 	"fmt"
 	"os"
 	"strings"
@@ -9,7 +8,6 @@ import (
 
 	"bitbucket.org/cover42/eiscli/internal/bitbucket"
 	"bitbucket.org/cover42/eiscli/internal/browser"
-	"bitbucket.org/cover42/eiscli/internal/config"
 	"bitbucket.org/cover42/eiscli/internal/git"
 	"github.com/fatih/color"
 	"github.com/olekukonko/tablewriter"
@@ -21,6 +19,9 @@ var (
 	prDescription string
 	prBaseBranch  string
 	prOpenBrowser bool
+	prDraft       bool
+	prReviewers   []string
+	prNoPush      bool
 	prListState   string
 	prListLimit   int
 	prListAuthor  string
@@ -31,8 +32,8 @@ var prCmd = &cobra.Command{
 	Short: "Manage pull requests",
 	Long: `Manage Bitbucket pull requests.
 
-This command provides subcommands to create and list pull requests,
-similar to GitHub CLI's 'gh pr' functionality.`,
+This command provides subcommands to create, list, view, comment on, review
+and merge pull requests, similar to GitHub CLI's 'gh pr' functionality.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		_ = cmd.Help()
 	},
@@ -47,9 +48,30 @@ If service-name is not provided, it will be auto-detected from the git repositor
 in the current directory (based on the git remote URL).
 
 By default, prompts for title and description. Use --title and --body flags
-to provide them directly.`,
+to provide them directly.
+
+The suggested title is the subject of the first commit of the branch, or else
+the branch name; a Jira key in the branch name like EIS-123 is put in front.
+The description starts from the repository's .bitbucket/pull_request_template.md
+or the template set as pull_request.template in the configuration, and is
+written in $EDITOR if one is set.
+
+The default reviewers of the repository are always added; use --reviewer to
+add more.
+
+The branch has to be on Bitbucket: if it has not been pushed, or has commits
+that are not pushed, you are offered to push it (--no-push fails instead).
+If the branch already has an open pull request, it is shown, and updated
+with the --title, --body and --reviewer given.
+
+Examples:
+  eiscli pr create
+  eiscli pr create --title "EIS-123 Add retries" --body "Retries failed requests"
+  eiscli pr create --draft --reviewer alice --reviewer "Bob Builder"`,
 	Args: cobra.MaximumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+
 		serviceName := ""
 		if len(args) > 0 {
 			serviceName = args[0]
@@ -59,7 +81,7 @@ to provide them directly.`,
 		if serviceName == "" {
 			detectedSlug, err := git.DetectRepositorySlug()
 			if err != nil {
-				fmt.Println("Error: No service name provided and could not auto-detect from git repository")
+				errorf("Error: No service name provided and could not auto-detect from git repository\n")
 				fmt.Printf("  %v\n", err)
 				fmt.Println("\nUsage:")
 				fmt.Println("  1. Run this command from within a git repository, or")
@@ -73,35 +95,25 @@ to provide them directly.`,
 		// Get current branch
 		currentBranch, err := git.GetCurrentBranch()
 		if err != nil {
-			fmt.Printf("Error: Failed to get current branch: %v\n", err)
+			errorf("Error: Failed to get current branch: %v\n", err)
 			return
 		}
 		fmt.Printf("Current branch: %s\n", currentBranch)
 
-		// Load configuration
-		cfg, err := config.Load()
-		if err != nil {
-			fmt.Printf("Error loading configuration: %v\n", err)
+		cfg, ok := loadConfig()
+		if !ok {
 			return
 		}
 
-		// Validate configuration
-		if err := cfg.Validate(); err != nil {
-			fmt.Printf("Configuration error: %v\n", err)
-			return
-		}
-
-		// Create Bitbucket client
-		client, err := bitbucket.NewClient(cfg)
-		if err != nil {
-			fmt.Printf("Error creating Bitbucket client: %v\n", err)
+		client, ok := newBitbucketClient()
+		if !ok {
 			return
 		}
 
 		// Get default branch
 		defaultBranch := prBaseBranch
 		if defaultBranch == "" {
-			defaultBranch, err = client.GetDefaultBranch(serviceName)
+			defaultBranch, err = client.GetDefaultBranch(ctx, serviceName)
 			if err != nil {
 				fmt.Printf("Warning: Failed to get default branch from API: %v\n", err)
 				fmt.Println("Defaulting to 'main'. Use --base to specify a different branch.")
@@ -112,57 +124,101 @@ to provide them directly.`,
 
 		// Check if we're already on the base branch
 		if currentBranch == defaultBranch {
-			fmt.Printf("Error: Current branch '%s' is the same as base branch '%s'\n", currentBranch, defaultBranch)
+			errorf("Error: Current branch '%s' is the same as base branch '%s'\n", currentBranch, defaultBranch)
 			fmt.Println("Please switch to a different branch before creating a pull request.")
 			return
 		}
 
+		// Look up the reviewers first, so that a typo does not waste the description
+		reviewers, err := resolveReviewers(ctx, client, prReviewers)
+		if err != nil {
+			errorf("Error: %v\n", err)
+			return
+		}
+
+		// Bitbucket creates the pull request from the pushed branch
+		sourceBranch, ok := ensureBranchPushed(client, currentBranch)
+		if !ok {
+			return
+		}
+
+		existing, err := client.FindOpenPullRequest(ctx, serviceName, sourceBranch)
+		if err != nil {
+			fmt.Printf("Warning: Failed to look for an open pull request of '%s': %v\n", sourceBranch, err)
+		}
+		if existing != nil {
+			showOrUpdateOpenPR(ctx, client, serviceName, existing, reviewers)
+			return
+		}
+
+		if keys := jiraKeys(currentBranch); len(keys) > 0 {
+			fmt.Printf("Jira issue: %s\n", strings.Join(keys, ", "))
+		}
+
 		// Get title and description
 		title := prTitle
 		description := prDescription
 
 		if title == "" {
-			title, err = promptForTitle()
+			// The commits only improve the suggestion, so errors are ignored
+			commitMessages, _ := git.GetBranchCommitMessages(defaultBranch)
+			title, err = promptForTitle(suggestPRTitle(currentBranch, commitMessages))
 			if err != nil {
-				fmt.Printf("Error: %v\n", err)
+				errorf("Error: %v\n", err)
 				return
 			}
 		}
 
 		if description == "" {
-			description, err = promptForDescription()
+			description, err = composePRDescription(cfg)
 			if err != nil {
-				fmt.Printf("Error: %v\n", err)
+				errorf("Error: %v\n", err)
 				return
 			}
 		}
 
 		// Create the pull request
-		fmt.Printf("\nCreating pull request from '%s' to '%s'...\n", currentBranch, defaultBranch)
-		pr, err := client.CreatePullRequest(serviceName, currentBranch, defaultBranch, title, description)
+		fmt.Printf("\nCreating pull request from '%s' to '%s'...\n", sourceBranch, defaultBranch)
+		pr, err := client.CreatePullRequest(ctx, serviceName, &bitbucket.CreatePullRequestOptions{
+			SourceBranch:      sourceBranch,
+			DestinationBranch: defaultBranch,
+			Title:             title,
+			Description:       description,
+			Draft:             prDraft,
+			Reviewers:         reviewers,
+		})
 		if err != nil {
-			fmt.Printf("Error: Failed to create pull request: %v\n", err)
+			errorf("Error: Failed to create pull request: %v\n", err)
 			return
 		}
 
 		// Display success message
 		greenColor := color.New(color.FgGreen).SprintFunc()
 		fmt.Printf("\n%s Pull request created successfully!\n", greenColor("✓"))
-		fmt.Printf("PR #%d: %s\n", pr.ID, pr.Title)
+		if pr.Draft {
+			fmt.Printf("PR #%d (draft): %s\n", pr.ID, pr.Title)
+		} else {
+			fmt.Printf("PR #%d: %s\n", pr.ID, pr.Title)
+		}
 		fmt.Printf("URL: %s\n", pr.WebURL)
 
 		// Open in browser if requested
 		if prOpenBrowser {
-			fmt.Println("\nOpening pull request in browser...")
-			err := browser.Open(pr.WebURL)
-			if err != nil {
-				fmt.Printf("Warning: Failed to open browser: %v\n", err)
-				fmt.Println("Please copy and paste the URL above into your browser.")
-			}
+			openPullRequestInBrowser(pr)
 		}
 	},
 }
 
+// openPullRequestInBrowser opens the web page of a pull request
+func openPullRequestInBrowser(pr *bitbucket.PullRequest) {
+	fmt.Println("\nOpening pull request in browser...")
+	err := browser.Open(pr.WebURL)
+	if err != nil {
+		fmt.Printf("Warning: Failed to open browser: %v\n", err)
+		fmt.Println("Please copy and paste the URL above into your browser.")
+	}
+}
+
 var prListCmd = &cobra.Command{
 	Use:   "list [service-name]",
 	Short: "List pull requests",
@@ -178,9 +234,12 @@ used to filter PRs created by the current user (matched by Bitbucket UUID/userna
 Examples:
   eiscli pr list --author "@me"
   eiscli pr list --author "username"
-  eiscli pr list --state OPEN --author "@me"`,
+  eiscli pr list --state OPEN --author "@me"
+  eiscli pr list --output json`,
 	Args: cobra.MaximumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+
 		serviceName := ""
 		if len(args) > 0 {
 			serviceName = args[0]
@@ -190,7 +249,7 @@ Examples:
 		if serviceName == "" {
 			detectedSlug, err := git.DetectRepositorySlug()
 			if err != nil {
-				fmt.Println("Error: No service name provided and could not auto-detect from git repository")
+				errorf("Error: No service name provided and could not auto-detect from git repository\n")
 				fmt.Printf("  %v\n", err)
 				fmt.Println("\nUsage:")
 				fmt.Println("  1. Run this command from within a git repository, or")
@@ -198,26 +257,11 @@ Examples:
 				return
 			}
 			serviceName = detectedSlug
-			fmt.Printf("Auto-detected service from git repository: %s\n", serviceName)
-		}
-
-		// Load configuration
-		cfg, err := config.Load()
-		if err != nil {
-			fmt.Printf("Error loading configuration: %v\n", err)
-			return
+			infof("Auto-detected service from git repository: %s\n", serviceName)
 		}
 
-		// Validate configuration
-		if err := cfg.Validate(); err != nil {
-			fmt.Printf("Configuration error: %v\n", err)
-			return
-		}
-
-		// Create Bitbucket client
-		client, err := bitbucket.NewClient(cfg)
-		if err != nil {
-			fmt.Printf("Error creating Bitbucket client: %v\n", err)
+		client, ok := newBitbucketClient()
+		if !ok {
 			return
 		}
 
@@ -239,10 +283,15 @@ Examples:
 		if prListAuthor != "" {
 			filterMsg += fmt.Sprintf(", author: %s", prListAuthor)
 		}
-		fmt.Printf("Fetching pull requests (%s)...\n", filterMsg)
-		prs, err := client.ListPullRequests(serviceName, opts)
+		infof("Fetching pull requests (%s)...\n", filterMsg)
+		prs, err := client.ListPullRequests(ctx, serviceName, opts)
 		if err != nil {
-			fmt.Printf("Error: Failed to fetch pull requests: %v\n", err)
+			errorf("Error: Failed to fetch pull requests: %v\n", err)
+			return
+		}
+
+		if isMachineOutput() {
+			printOutput(prs)
 			return
 		}
 
@@ -257,14 +306,23 @@ Examples:
 	},
 }
 
-func promptForTitle() (string, error) {
-	reader := bufio.NewReader(os.Stdin)
-	fmt.Print("PR Title: ")
+// promptForTitle reads the title from stdin. An empty answer takes the
+// suggested title, if any.
+func promptForTitle(suggested string) (string, error) {
+	reader := stdinReader()
+	if suggested != "" {
+		fmt.Printf("PR Title [%s]: ", suggested)
+	} else {
+		fmt.Print("PR Title: ")
+	}
 	title, err := reader.ReadString('\n')
 	if err != nil {
 		return "", fmt.Errorf("failed to read title: %w", err)
 	}
 	title = strings.TrimSpace(title)
+	if title == "" {
+		title = suggested
+	}
 
 	if title == "" {
 		return "", fmt.Errorf("title cannot be empty")
@@ -274,7 +332,7 @@ func promptForTitle() (string, error) {
 }
 
 func promptForDescription() (string, error) {
-	reader := bufio.NewReader(os.Stdin)
+	reader := stdinReader()
 	fmt.Println("PR Description (press Enter twice to finish, or leave empty):")
 	description := ""
 	emptyLineCount := 0
@@ -331,10 +389,7 @@ func displayPullRequestsTable(prs []*bitbucket.PullRequest) {
 
 	for _, pr := range prs {
 		// Truncate title if too long
-		title := pr.Title
-		if len(title) > 50 {
-			title = title[:47] + "..."
-		}
+		title := truncateValue(pr.Title, 50)
 
 		// Format branch info
 		branchInfo := fmt.Sprintf("%s → %s", pr.SourceBranch, pr.DestinationBranch)
@@ -343,15 +398,7 @@ func displayPullRequestsTable(prs []*bitbucket.PullRequest) {
 		updatedTime := formatTimeAgo(pr.UpdatedOn)
 
 		// Color code state
-		state := pr.State
-		switch strings.ToUpper(pr.State) {
-		case "OPEN":
-			state = color.New(color.FgGreen).Sprint(state)
-		case "MERGED":
-			state = color.New(color.FgBlue).Sprint(state)
-		case "DECLINED":
-			state = color.New(color.FgRed).Sprint(state)
-		}
+		state := formatPRState(pr.State)
 
 		// Format PR ID as clickable hyperlink
 		prID := formatClickablePRID(pr.ID, pr.WebURL)
@@ -407,6 +454,9 @@ func init() {
 	prCreateCmd.Flags().StringVarP(&prDescription, "body", "b", "", "Pull request description")
 	prCreateCmd.Flags().StringVarP(&prBaseBranch, "base", "", "", "Base branch (default: repository default branch)")
 	prCreateCmd.Flags().BoolVarP(&prOpenBrowser, "web", "w", false, "Open pull request in browser after creation")
+	prCreateCmd.Flags().BoolVar(&prDraft, "draft", false, "Create the pull request as a draft")
+	prCreateCmd.Flags().StringSliceVarP(&prReviewers, "reviewer", "r", nil, "Add a reviewer by username or display name (repeatable)")
+	prCreateCmd.Flags().BoolVar(&prNoPush, "no-push", false, "Fail instead of offering to push the branch")
 
 	// pr list flags
 	prListCmd.Flags().StringVarP(&prListState, "state", "s", "", "Filter by state (OPEN, MERGED, DECLINED, SUPERSEDED). Default: OPEN")
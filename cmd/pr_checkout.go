@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"bitbucket.org/cover42/eiscli/internal/bitbucket"
+	"bitbucket.org/cover42/eiscli/internal/git"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+var (
+	prCheckoutBranch string
+	prCheckoutForce  bool
+)
+
+var prCheckoutCmd = &cobra.Command{
+	Use:   "checkout [service-name] <id>",
+	Short: "Check out the branch of a pull request",
+	Long: `Fetch the source branch of a pull request into the git repository in the
+current directory and switch to it.
+
+The local branch is named like the source branch and tracks it, so that git
+pull and git push work. An existing local branch is fast-forwarded; if it has
+commits that are not in the pull request, it is only reset with --force.
+
+For pull requests from a fork, a remote named after the workspace of the fork
+is added and the local branch is named <workspace>-<branch>.
+
+Examples:
+  # Check out pull request #42
+  eiscli pr checkout 42
+
+  # Use another local branch name
+  eiscli pr checkout 42 --branch review/42`,
+	Args: cobra.RangeArgs(1, 2),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+
+		// Unlike pr view, there is no fallback to the pull request of the current branch
+		if _, prID, err := parseServiceAndNumberArgs(args, "pull request ID"); err == nil && prID <= 0 {
+			errorf("Error: A pull request ID is required\n")
+			return
+		}
+
+		serviceName, client, pr, ok := resolvePullRequestArgs(ctx, args)
+		if !ok {
+			return
+		}
+
+		// The branch has to be fetched into a clone of the repository
+		slug, err := git.DetectRepositorySlug()
+		if err != nil {
+			errorf("Error: %v\n", err)
+			return
+		}
+		if !strings.EqualFold(slug, serviceName) {
+			errorf("Error: the current directory is a clone of '%s', not '%s'\n", slug, serviceName)
+			return
+		}
+
+		checkout, err := pullRequestCheckout(pr)
+		if err != nil {
+			errorf("Error: %v\n", err)
+			return
+		}
+		checkout.Username, checkout.Password, err = client.GitCredentials()
+		if err != nil {
+			errorf("Error: %v\n", err)
+			return
+		}
+
+		if !strings.EqualFold(pr.State, "OPEN") {
+			yellowColor := color.New(color.FgYellow).SprintFunc()
+			fmt.Printf("%s Pull request #%d is %s\n", yellowColor("⚠"), pr.ID, strings.ToLower(pr.State))
+		}
+
+		fmt.Printf("Fetching %s from %s...\n", checkout.Branch, checkout.Remote)
+		if err := git.CheckoutRemoteBranch(checkout); err != nil {
+			if errors.Is(err, git.ErrBranchDiverged) {
+				errorf("Error: %v, use --force to reset it to the pull request\n", err)
+				return
+			}
+			errorf("Error: %v\n", err)
+			return
+		}
+
+		greenColor := color.New(color.FgGreen).SprintFunc()
+		fmt.Printf("%s Switched to branch %s (pull request #%d: %s)\n", greenColor("✓"), checkout.LocalBranch, pr.ID, pr.Title)
+	},
+}
+
+// pullRequestCheckout returns where to fetch the source branch of a pull
+// request from and the local branch to check it out as
+func pullRequestCheckout(pr *bitbucket.PullRequest) (*git.RemoteBranchCheckout, error) {
+	checkout := &git.RemoteBranchCheckout{
+		Remote:      "origin",
+		Branch:      pr.SourceBranch,
+		LocalBranch: pr.SourceBranch,
+		Force:       prCheckoutForce,
+	}
+
+	if pr.IsFromFork() {
+		owner, _, _ := strings.Cut(pr.SourceRepository, "/")
+		remoteURL, err := git.RemoteURLForRepository(pr.SourceRepository)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build the URL of fork %s: %w", pr.SourceRepository, err)
+		}
+		checkout.Remote = owner
+		checkout.RemoteURL = remoteURL
+		checkout.LocalBranch = owner + "-" + pr.SourceBranch
+	}
+
+	if prCheckoutBranch != "" {
+		checkout.LocalBranch = prCheckoutBranch
+	}
+
+	return checkout, nil
+}
+
+func init() {
+	prCmd.AddCommand(prCheckoutCmd)
+
+	prCheckoutCmd.Flags().StringVarP(&prCheckoutBranch, "branch", "b", "", "Local branch name (default: the source branch)")
+	prCheckoutCmd.Flags().BoolVarP(&prCheckoutForce, "force", "f", false, "Reset the local branch if it has diverged from the pull request")
+}
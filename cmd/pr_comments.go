@@ -0,0 +1,471 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"bitbucket.org/cover42/eiscli/internal/bitbucket"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+var (
+	prCommentBody    string
+	prCommentFile    string
+	prCommentLine    int
+	prCommentReplyTo int
+)
+
+// pullRequestCommentsOutput is the --output json/yaml form of pr comments
+type pullRequestCommentsOutput struct {
+	Comments []*bitbucket.PullRequestComment `json:"comments" yaml:"comments"`
+	Tasks    []*bitbucket.PullRequestTask    `json:"tasks" yaml:"tasks"`
+	Errors   map[string]string               `json:"errors,omitempty" yaml:"errors,omitempty"`
+}
+
+// pullRequestComments holds the comments and tasks of a pull request
+type pullRequestComments struct {
+	Comments []*bitbucket.PullRequestComment
+	Tasks    []*bitbucket.PullRequestTask
+	TasksErr error
+}
+
+// commentThread is a comment with its replies and the tasks attached to it
+type commentThread struct {
+	Comment *bitbucket.PullRequestComment
+	Replies []*commentThread
+	Tasks   []*bitbucket.PullRequestTask
+}
+
+var prCommentsCmd = &cobra.Command{
+	Use:   "comments [service-name] [id]",
+	Short: "Show the comments of a pull request",
+	Long: `Show the comments of a pull request as threads. General comments come first,
+followed by inline comments grouped by file and line. Tasks are shown below the
+comment they belong to.
+
+If id is not provided, the open pull request of the current git branch is used.
+If service-name is not provided, it will be auto-detected from the git repository
+in the current directory.
+
+Examples:
+  eiscli pr comments 42
+  eiscli pr comments 42 --output json`,
+	Args: cobra.MaximumNArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+
+		serviceName, client, pr, ok := resolvePullRequestArgs(ctx, args)
+		if !ok {
+			return
+		}
+
+		view, err := fetchCommentsAndTasks(ctx, client, serviceName, pr.ID)
+		if err != nil {
+			errorf("Error: %v\n", err)
+			return
+		}
+
+		if isMachineOutput() {
+			out := &pullRequestCommentsOutput{Comments: view.Comments, Tasks: view.Tasks}
+			if out.Tasks == nil {
+				out.Tasks = []*bitbucket.PullRequestTask{}
+			}
+			if view.TasksErr != nil {
+				out.Errors = map[string]string{"tasks": view.TasksErr.Error()}
+			}
+			printOutput(out)
+			return
+		}
+
+		fmt.Printf("\n%s %s\n", formatClickablePRID(pr.ID, pr.WebURL), color.New(color.Bold).Sprint(pr.Title))
+		if view.TasksErr != nil {
+			yellowColor := color.New(color.FgYellow).SprintFunc()
+			fmt.Printf("%s Could not fetch tasks: %v\n", yellowColor("⚠"), view.TasksErr)
+		}
+		displayCommentThreads(buildCommentThreads(view.Comments, view.Tasks))
+	},
+}
+
+var prCommentCmd = &cobra.Command{
+	Use:   "comment [service-name] [id] --body <text>",
+	Short: "Comment on a pull request",
+	Long: `Add a comment to a pull request.
+
+Without --file the comment is a general comment. With --file it is placed on the
+file, and with --line on a line of the new version of the file. Use --reply-to to
+answer a comment; the reply belongs to the thread of that comment.
+
+If id is not provided, the open pull request of the current git branch is used.
+If service-name is not provided, it will be auto-detected from the git repository
+in the current directory.
+
+Examples:
+  # General comment
+  eiscli pr comment 42 --body "Looks good, one question below"
+
+  # Inline comment on line 12 of main.go
+  eiscli pr comment 42 --file main.go --line 12 --body "Should this retry?"
+
+  # Reply to comment 1234
+  eiscli pr comment 42 --reply-to 1234 --body "Done"`,
+	Args: cobra.MaximumNArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+
+		if strings.TrimSpace(prCommentBody) == "" {
+			errorf("Error: --body is required\n")
+			return
+		}
+
+		serviceName, client, pr, ok := resolvePullRequestArgs(ctx, args)
+		if !ok {
+			return
+		}
+
+		comment, err := client.CreatePullRequestComment(ctx, serviceName, pr.ID, &bitbucket.CommentOptions{
+			Body:     prCommentBody,
+			Path:     prCommentFile,
+			Line:     prCommentLine,
+			ParentID: prCommentReplyTo,
+		})
+		if err != nil {
+			errorf("Error: %v\n", err)
+			return
+		}
+
+		if isMachineOutput() {
+			printOutput(comment)
+			return
+		}
+
+		greenColor := color.New(color.FgGreen).SprintFunc()
+		fmt.Printf("%s Added comment #%d to pull request #%d", greenColor("✓"), comment.ID, pr.ID)
+		if location := commentLocation(comment); location != "" {
+			fmt.Printf(" on %s", location)
+		}
+		fmt.Println()
+		if comment.WebURL != "" {
+			fmt.Printf("  URL: %s\n", comment.WebURL)
+		}
+	},
+}
+
+var prTasksCmd = &cobra.Command{
+	Use:   "tasks [service-name] [id]",
+	Short: "List the tasks of a pull request",
+	Long: `List the open and resolved tasks of a pull request.
+
+If id is not provided, the open pull request of the current git branch is used.
+If service-name is not provided, it will be auto-detected from the git repository
+in the current directory.
+
+Examples:
+  eiscli pr tasks 42
+
+  # Resolve or reopen task 7 of pull request #42
+  eiscli pr tasks resolve 42 7
+  eiscli pr tasks reopen 42 7`,
+	Args: cobra.MaximumNArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+
+		serviceName, client, pr, ok := resolvePullRequestArgs(ctx, args)
+		if !ok {
+			return
+		}
+
+		tasks, err := client.ListPullRequestTasks(ctx, serviceName, pr.ID)
+		if err != nil {
+			errorf("Error: %v\n", err)
+			return
+		}
+
+		if isMachineOutput() {
+			printOutput(tasks)
+			return
+		}
+
+		open := 0
+		for _, task := range tasks {
+			if !task.Resolved() {
+				open++
+			}
+		}
+		fmt.Printf("\nTasks of pull request #%d (%d open, %d resolved):\n", pr.ID, open, len(tasks)-open)
+		if len(tasks) == 0 {
+			fmt.Println("  No tasks.")
+			return
+		}
+		for _, task := range tasks {
+			fmt.Printf("  %s\n", formatTask(task))
+		}
+	},
+}
+
+var prTasksResolveCmd = &cobra.Command{
+	Use:   "resolve [service-name] <id> <task-id>",
+	Short: "Resolve a task of a pull request",
+	Args:  cobra.RangeArgs(2, 3),
+	Run: func(cmd *cobra.Command, args []string) {
+		updatePullRequestTask(cmd.Context(), args, true)
+	},
+}
+
+var prTasksReopenCmd = &cobra.Command{
+	Use:   "reopen [service-name] <id> <task-id>",
+	Short: "Reopen a resolved task of a pull request",
+	Args:  cobra.RangeArgs(2, 3),
+	Run: func(cmd *cobra.Command, args []string) {
+		updatePullRequestTask(cmd.Context(), args, false)
+	},
+}
+
+// updatePullRequestTask resolves or reopens the task given in
+// "[service-name] <id> <task-id>" arguments
+func updatePullRequestTask(ctx context.Context, args []string, resolve bool) {
+	taskID, err := strconv.Atoi(strings.TrimPrefix(args[len(args)-1], "#"))
+	if err != nil || taskID <= 0 {
+		errorf("Error: invalid task ID: %s\n", args[len(args)-1])
+		return
+	}
+
+	serviceName, client, pr, ok := resolvePullRequestArgs(ctx, args[:len(args)-1])
+	if !ok {
+		return
+	}
+
+	var task *bitbucket.PullRequestTask
+	if resolve {
+		task, err = client.ResolvePullRequestTask(ctx, serviceName, pr.ID, taskID)
+	} else {
+		task, err = client.ReopenPullRequestTask(ctx, serviceName, pr.ID, taskID)
+	}
+	if err != nil {
+		errorf("Error: %v\n", err)
+		return
+	}
+
+	if isMachineOutput() {
+		printOutput(task)
+		return
+	}
+
+	action := "Reopened"
+	if resolve {
+		action = "Resolved"
+	}
+	greenColor := color.New(color.FgGreen).SprintFunc()
+	fmt.Printf("%s %s task #%d: %s\n", greenColor("✓"), action, task.ID, task.Body)
+}
+
+// fetchCommentsAndTasks fetches the comments and tasks of a pull request
+// concurrently. Tasks are optional, so their error is kept in TasksErr.
+func fetchCommentsAndTasks(ctx context.Context, client *bitbucket.Client, serviceName string, prID int) (*pullRequestComments, error) {
+	view := &pullRequestComments{}
+	var commentsErr error
+
+	var wg sync.WaitGroup
+	run := func(fn func()) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			fn()
+		}()
+	}
+
+	run(func() {
+		view.Comments, commentsErr = client.ListPullRequestComments(ctx, serviceName, prID)
+	})
+
+	run(func() {
+		view.Tasks, view.TasksErr = client.ListPullRequestTasks(ctx, serviceName, prID)
+	})
+
+	wg.Wait()
+	if commentsErr != nil {
+		return nil, commentsErr
+	}
+	return view, nil
+}
+
+// buildCommentThreads arranges comments into threads: general comments first,
+// then inline comments ordered by file and line. Replies and threads on the
+// same place are ordered by time. Deleted comments without replies are dropped.
+func buildCommentThreads(comments []*bitbucket.PullRequestComment, tasks []*bitbucket.PullRequestTask) []*commentThread {
+	threads := make(map[int]*commentThread, len(comments))
+	for _, comment := range comments {
+		threads[comment.ID] = &commentThread{Comment: comment}
+	}
+	for _, task := range tasks {
+		if thread, ok := threads[task.CommentID]; ok {
+			thread.Tasks = append(thread.Tasks, task)
+		}
+	}
+
+	var roots []*commentThread
+	for _, comment := range comments {
+		thread := threads[comment.ID]
+		if parent, ok := threads[comment.ParentID]; ok && comment.ParentID != 0 {
+			parent.Replies = append(parent.Replies, thread)
+		} else {
+			roots = append(roots, thread)
+		}
+	}
+
+	byTime := func(list []*commentThread) {
+		sort.SliceStable(list, func(i, j int) bool {
+			return list[i].Comment.CreatedOn.Before(list[j].Comment.CreatedOn)
+		})
+	}
+	for _, thread := range threads {
+		byTime(thread.Replies)
+	}
+	byTime(roots)
+	sort.SliceStable(roots, func(i, j int) bool {
+		a, b := roots[i].Comment, roots[j].Comment
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		return commentSortLine(a) < commentSortLine(b)
+	})
+
+	var pruned []*commentThread
+	for _, root := range roots {
+		if pruneDeletedComments(root) {
+			pruned = append(pruned, root)
+		}
+	}
+	return pruned
+}
+
+// commentSortLine orders comments on the whole file first, then by line
+func commentSortLine(c *bitbucket.PullRequestComment) int {
+	if c.Line > 0 {
+		return c.Line
+	}
+	return c.OldLine
+}
+
+// pruneDeletedComments drops deleted comments without replies from a thread
+// and returns false if nothing is left of it
+func pruneDeletedComments(thread *commentThread) bool {
+	var replies []*commentThread
+	for _, reply := range thread.Replies {
+		if pruneDeletedComments(reply) {
+			replies = append(replies, reply)
+		}
+	}
+	thread.Replies = replies
+	return !thread.Comment.Deleted || len(replies) > 0 || len(thread.Tasks) > 0
+}
+
+// displayCommentThreads prints comment threads grouped by file and line
+func displayCommentThreads(threads []*commentThread) {
+	if len(threads) == 0 {
+		fmt.Println("\nNo comments.")
+		return
+	}
+
+	bold := color.New(color.Bold).SprintFunc()
+	cyanColor := color.New(color.FgCyan).SprintFunc()
+
+	path, location := "-", ""
+	for _, thread := range threads {
+		comment := thread.Comment
+		if comment.Path != path {
+			path = comment.Path
+			location = ""
+			if path == "" {
+				fmt.Printf("\n%s\n", bold("General"))
+			} else {
+				fmt.Printf("\n%s\n", bold(path))
+			}
+		}
+		if comment.IsInline() {
+			if l := commentLineLabel(comment); l != location {
+				location = l
+				fmt.Printf("  %s\n", cyanColor(location))
+			}
+		}
+		displayCommentThread(thread, "    ")
+	}
+}
+
+// displayCommentThread prints a comment, its tasks and its replies indented
+func displayCommentThread(thread *commentThread, indent string) {
+	gray := color.New(color.FgHiBlack).SprintFunc()
+
+	comment := thread.Comment
+	header := fmt.Sprintf("%s · %s", color.New(color.Bold).Sprint(comment.Author), formatTimeAgo(comment.CreatedOn))
+	fmt.Printf("%s%s %s\n", indent, header, gray(fmt.Sprintf("#%d", comment.ID)))
+
+	body := strings.TrimSpace(comment.Body)
+	if comment.Deleted {
+		body = gray("(deleted)")
+	}
+	for _, line := range strings.Split(body, "\n") {
+		fmt.Printf("%s  %s\n", indent, line)
+	}
+	for _, task := range thread.Tasks {
+		fmt.Printf("%s  %s\n", indent, formatTask(task))
+	}
+
+	for _, reply := range thread.Replies {
+		displayCommentThread(reply, indent+"  ↳ ")
+	}
+}
+
+// commentLineLabel describes the line of an inline comment
+func commentLineLabel(c *bitbucket.PullRequestComment) string {
+	switch {
+	case c.Line > 0:
+		return fmt.Sprintf("Line %d", c.Line)
+	case c.OldLine > 0:
+		return fmt.Sprintf("Removed line %d", c.OldLine)
+	default:
+		return "File"
+	}
+}
+
+// commentLocation describes where an inline comment is placed, e.g. main.go:12
+func commentLocation(c *bitbucket.PullRequestComment) string {
+	switch {
+	case !c.IsInline():
+		return ""
+	case c.Line > 0:
+		return fmt.Sprintf("%s:%d", c.Path, c.Line)
+	default:
+		return c.Path
+	}
+}
+
+// formatTask formats a task with a checkbox
+func formatTask(task *bitbucket.PullRequestTask) string {
+	if task.Resolved() {
+		greenColor := color.New(color.FgGreen).SprintFunc()
+		resolved := "resolved"
+		if task.ResolvedBy != "" {
+			resolved = "resolved by " + task.ResolvedBy
+		}
+		return fmt.Sprintf("%s Task #%d: %s (%s)", greenColor("☑"), task.ID, task.Body, resolved)
+	}
+	return fmt.Sprintf("☐ Task #%d: %s (%s)", task.ID, task.Body, task.Creator)
+}
+
+func init() {
+	prCmd.AddCommand(prCommentsCmd)
+	prCmd.AddCommand(prCommentCmd)
+	prCmd.AddCommand(prTasksCmd)
+	prTasksCmd.AddCommand(prTasksResolveCmd)
+	prTasksCmd.AddCommand(prTasksReopenCmd)
+
+	prCommentCmd.Flags().StringVarP(&prCommentBody, "body", "b", "", "Comment text in Markdown (required)")
+	prCommentCmd.Flags().StringVar(&prCommentFile, "file", "", "File to comment on, relative to the repository root")
+	prCommentCmd.Flags().IntVar(&prCommentLine, "line", 0, "Line in the new version of --file to comment on")
+	prCommentCmd.Flags().IntVar(&prCommentReplyTo, "reply-to", 0, "ID of the comment to reply to")
+}
@@ -0,0 +1,273 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"bitbucket.org/cover42/eiscli/internal/bitbucket"
+	"bitbucket.org/cover42/eiscli/internal/config"
+	"bitbucket.org/cover42/eiscli/internal/editor"
+	"bitbucket.org/cover42/eiscli/internal/git"
+	"github.com/fatih/color"
+)
+
+// prTemplatePath is where a repository keeps its pull request description template
+const prTemplatePath = ".bitbucket/pull_request_template.md"
+
+// jiraKeyPattern matches Jira issue keys like EIS-123
+var jiraKeyPattern = regexp.MustCompile(`\b[A-Z][A-Z0-9]+-[1-9][0-9]*\b`)
+
+// jiraKeys returns the distinct Jira issue keys in s, in order of appearance
+func jiraKeys(s string) []string {
+	var keys []string
+	for _, key := range jiraKeyPattern.FindAllString(s, -1) {
+		if !slices.Contains(keys, key) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// suggestPRTitle suggests a pull request title: the subject of the first
+// commit of the branch, or else the branch name made readable. The Jira key
+// of the branch is put in front unless the title already has it, e.g.
+// "EIS-123 Add retries" for feature/EIS-123-add-retries.
+func suggestPRTitle(branch string, commitMessages []string) string {
+	title := ""
+	if len(commitMessages) > 0 {
+		title, _, _ = strings.Cut(strings.TrimSpace(commitMessages[0]), "\n")
+		title = strings.TrimSpace(title)
+	}
+	if title == "" {
+		title = titleFromBranch(branch)
+	}
+
+	keys := jiraKeys(branch)
+	if len(keys) == 0 || strings.Contains(title, keys[0]) {
+		return title
+	}
+	return strings.TrimSpace(keys[0] + " " + title)
+}
+
+// titleFromBranch turns the last part of a branch name into a sentence
+// without its Jira key, e.g. "Add retries" for feature/EIS-123-add_retries
+func titleFromBranch(branch string) string {
+	name := branch
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	name = jiraKeyPattern.ReplaceAllString(name, "")
+	name = strings.Join(strings.FieldsFunc(name, func(r rune) bool {
+		return r == '-' || r == '_' || unicode.IsSpace(r)
+	}), " ")
+
+	first, size := utf8.DecodeRuneInString(name)
+	if first == utf8.RuneError {
+		return name
+	}
+	return string(unicode.ToUpper(first)) + name[size:]
+}
+
+// loadPRTemplate reads the description template of new pull requests: the
+// repository's .bitbucket/pull_request_template.md, or else the file set as
+// pull_request.template in the configuration. It returns the template and
+// its path, or empty strings if there is none.
+func loadPRTemplate(cfg *config.Config) (string, string, error) {
+	if root, err := git.GetRepositoryRoot(); err == nil {
+		path := filepath.Join(root, filepath.FromSlash(prTemplatePath))
+		content, err := os.ReadFile(path)
+		if err == nil {
+			return string(content), prTemplatePath, nil
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return "", "", fmt.Errorf("failed to read %s: %w", prTemplatePath, err)
+		}
+	}
+
+	path := cfg.PullRequest.Template
+	if path == "" {
+		return "", "", nil
+	}
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", "", fmt.Errorf("failed to get home directory: %w", err)
+		}
+		path = filepath.Join(home, rest)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read pull request template: %w", err)
+	}
+	return string(content), path, nil
+}
+
+// composePRDescription asks for the description of a new pull request,
+// starting from the template. With $EDITOR set it offers to write it in the
+// editor; otherwise the template is used as it is, or the description is
+// read from stdin if there is no template.
+func composePRDescription(cfg *config.Config) (string, error) {
+	template, source, err := loadPRTemplate(cfg)
+	if err != nil {
+		fmt.Printf("Warning: %v\n", err)
+	}
+	if source != "" {
+		fmt.Printf("Description template: %s\n", source)
+	}
+
+	if command := editor.Command(); command != "" {
+		edit, err := promptYesNo(fmt.Sprintf("Write the description in %s?", command), true)
+		if err != nil {
+			return "", err
+		}
+		if edit {
+			description, err := editor.Edit("PULL_REQUEST_DESCRIPTION.md", template)
+			if err != nil {
+				return "", err
+			}
+			return strings.TrimSpace(description), nil
+		}
+	}
+
+	if template != "" {
+		return strings.TrimSpace(template), nil
+	}
+	return promptForDescription()
+}
+
+// resolveReviewers looks up the workspace members given with --reviewer and
+// returns their UUIDs
+func resolveReviewers(ctx context.Context, client *bitbucket.Client, names []string) ([]string, error) {
+	members, err := client.FindWorkspaceMembers(ctx, names)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --reviewer: %w", err)
+	}
+
+	uuids := make([]string, 0, len(members))
+	for _, member := range members {
+		uuids = append(uuids, member.UUID)
+	}
+	return uuids, nil
+}
+
+// ensureBranchPushed checks that the branch and its commits are on the remote
+// and offers to push them. It returns the branch on the remote, and false if
+// no pull request can be created from it.
+func ensureBranchPushed(client *bitbucket.Client, branch string) (string, bool) {
+	status, err := git.GetBranchPushStatus(branch)
+	if err != nil {
+		fmt.Printf("Warning: Failed to check whether '%s' is pushed: %v\n", branch, err)
+		return branch, true
+	}
+
+	remoteBranch := status.Remote + "/" + status.RemoteBranch
+	if status.Diverged() {
+		errorf("Error: '%s' and '%s' have diverged (%d and %d different commits)\n", branch, remoteBranch, status.Ahead, status.Behind)
+		fmt.Println("Pull or rebase the branch before creating a pull request.")
+		return "", false
+	}
+	if !status.NeedsPush() {
+		return status.RemoteBranch, true
+	}
+
+	if status.OnRemote {
+		fmt.Printf("Branch '%s' has %d commit(s) that are not on '%s'.\n", branch, status.Ahead, remoteBranch)
+	} else {
+		fmt.Printf("Branch '%s' is not on '%s' yet.\n", branch, status.Remote)
+	}
+	if prNoPush {
+		errorf("Error: push the branch first, or run without --no-push to push it now\n")
+		return "", false
+	}
+
+	push, err := promptYesNo(fmt.Sprintf("Push '%s' to '%s'?", branch, status.Remote), true)
+	if err != nil {
+		errorf("Error: %v\n", err)
+		return "", false
+	}
+	if !push {
+		if status.OnRemote {
+			fmt.Println("The pull request will not contain the unpushed commits.")
+			return status.RemoteBranch, true
+		}
+		errorf("Error: the branch has to be pushed before a pull request can be created\n")
+		return "", false
+	}
+
+	username, password, err := client.GitCredentials()
+	if err != nil {
+		errorf("Error: %v\n", err)
+		return "", false
+	}
+	fmt.Printf("Pushing '%s' to '%s'...\n", branch, remoteBranch)
+	err = git.PushBranch(&git.BranchPush{
+		Remote:       status.Remote,
+		Branch:       branch,
+		RemoteBranch: status.RemoteBranch,
+		Username:     username,
+		Password:     password,
+	})
+	if err != nil {
+		errorf("Error: %v\n", err)
+		return "", false
+	}
+
+	greenColor := color.New(color.FgGreen).SprintFunc()
+	fmt.Printf("%s Pushed '%s'\n", greenColor("✓"), remoteBranch)
+	return status.RemoteBranch, true
+}
+
+// showOrUpdateOpenPR shows the open pull request of a branch instead of
+// creating another one, and updates it with the title, description and
+// reviewers given on the command line
+func showOrUpdateOpenPR(ctx context.Context, client *bitbucket.Client, serviceName string, pr *bitbucket.PullRequest, reviewers []string) {
+	yellowColor := color.New(color.FgYellow).SprintFunc()
+	fmt.Printf("\n%s Branch '%s' already has an open pull request\n", yellowColor("⚠"), pr.SourceBranch)
+
+	opts := &bitbucket.UpdatePullRequestOptions{Title: prTitle, Description: prDescription}
+	if len(reviewers) > 0 {
+		// Only a single pull request lists all its reviewers
+		current, err := client.GetPullRequest(ctx, serviceName, pr.ID)
+		if err != nil {
+			errorf("Error: %v\n", err)
+			return
+		}
+		opts.Reviewers = current.Reviewers
+		for _, uuid := range reviewers {
+			if !slices.Contains(opts.Reviewers, uuid) {
+				opts.Reviewers = append(opts.Reviewers, uuid)
+			}
+		}
+	}
+
+	if opts.Title != "" || opts.Description != "" || opts.Reviewers != nil {
+		updated, err := client.UpdatePullRequest(ctx, serviceName, pr.ID, opts)
+		if err != nil {
+			errorf("Error: %v\n", err)
+			return
+		}
+		pr = updated
+
+		greenColor := color.New(color.FgGreen).SprintFunc()
+		fmt.Printf("%s Pull request updated\n", greenColor("✓"))
+	}
+
+	fmt.Printf("PR #%d: %s\n", pr.ID, pr.Title)
+	fmt.Printf("URL: %s\n", pr.WebURL)
+	if opts.Title == "" && opts.Description == "" && opts.Reviewers == nil {
+		fmt.Println("Use --title, --body or --reviewer to update it.")
+	}
+
+	if prOpenBrowser {
+		openPullRequestInBrowser(pr)
+	}
+}
@@ -0,0 +1,166 @@
+package cmd
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+var prDiffCmd = &cobra.Command{
+	Use:   "diff [service-name] [id] [paths...]",
+	Short: "Show the diff of a pull request",
+	Long: `Show the unified diff of a pull request, colored when writing to a terminal.
+
+The diff can be limited to paths: a path matches a file, all files below a
+directory, or files matching a glob pattern like '*.go'.
+
+If id is not provided, the open pull request of the current git branch is shown.
+If service-name is not provided, it will be auto-detected from the git repository
+in the current directory. Paths can only be given together with an id.
+
+Examples:
+  # Show the diff of pull request #42
+  eiscli pr diff 42
+
+  # Only the changes below internal/ and of the Makefile
+  eiscli pr diff 42 internal Makefile
+
+  # Save the diff as a patch file
+  eiscli pr diff policyservice 42 > pr-42.patch`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+
+		prArgs, paths, err := splitPRDiffArgs(args)
+		if err != nil {
+			errorf("Error: %v\n", err)
+			return
+		}
+
+		serviceName, client, pr, ok := resolvePullRequestArgs(ctx, prArgs)
+		if !ok {
+			return
+		}
+
+		diff, err := client.GetPullRequestDiff(ctx, serviceName, pr.ID)
+		if err != nil {
+			errorf("Error: %v\n", err)
+			return
+		}
+
+		diff = filterDiff(diff, paths)
+		if diff == "" {
+			infof("No changes.\n")
+			return
+		}
+		fmt.Print(colorizeDiff(diff))
+	},
+}
+
+// splitPRDiffArgs splits "[service-name] [id] [paths...]" arguments into the
+// pull request arguments and the paths. The id is the first or second
+// argument; paths may only follow it.
+func splitPRDiffArgs(args []string) ([]string, []string, error) {
+	isID := func(arg string) bool {
+		_, err := strconv.Atoi(strings.TrimPrefix(arg, "#"))
+		return err == nil
+	}
+
+	switch {
+	case len(args) == 0:
+		return nil, nil, nil
+	case isID(args[0]):
+		return args[:1], args[1:], nil
+	case len(args) == 1:
+		return args, nil, nil
+	case isID(args[1]):
+		return args[:2], args[2:], nil
+	default:
+		return nil, nil, fmt.Errorf("invalid pull request ID: %s", args[1])
+	}
+}
+
+// filterDiff keeps the files of a unified diff that match one of the paths.
+// Without paths the diff is returned unchanged.
+func filterDiff(diff string, paths []string) string {
+	if len(paths) == 0 {
+		return diff
+	}
+
+	var b strings.Builder
+	keep := false
+	for _, line := range strings.SplitAfter(diff, "\n") {
+		if header, found := strings.CutPrefix(line, "diff --git "); found {
+			oldPath, newPath, _ := strings.Cut(strings.TrimSpace(header), " b/")
+			oldPath = strings.TrimPrefix(oldPath, "a/")
+			keep = diffPathMatches(oldPath, paths) || diffPathMatches(newPath, paths)
+		}
+		if keep {
+			b.WriteString(line)
+		}
+	}
+	return b.String()
+}
+
+// diffPathMatches returns true if a file equals, is below or matches the glob
+// pattern of one of the paths
+func diffPathMatches(file string, paths []string) bool {
+	for _, p := range paths {
+		p = strings.TrimSuffix(strings.TrimPrefix(p, "./"), "/")
+		if file == p || strings.HasPrefix(file, p+"/") {
+			return true
+		}
+		if matched, _ := path.Match(p, file); matched {
+			return true
+		}
+		if matched, _ := path.Match(p, path.Base(file)); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// colorizeDiff colors the lines of a unified diff like git diff. The header
+// of a file (diff --git, index, ---, +++ and the like) runs up to its first
+// hunk; inside hunks, lines starting with "--- " or "+++ " are removed or
+// added lines.
+func colorizeDiff(diff string) string {
+	bold := color.New(color.Bold).SprintFunc()
+	cyanColor := color.New(color.FgCyan).SprintFunc()
+	greenColor := color.New(color.FgGreen).SprintFunc()
+	redColor := color.New(color.FgRed).SprintFunc()
+
+	var b strings.Builder
+	inHunk := false
+	for _, line := range strings.SplitAfter(diff, "\n") {
+		text, newline := strings.CutSuffix(line, "\n")
+		switch {
+		case strings.HasPrefix(text, "diff --git "):
+			inHunk = false
+			text = bold(text)
+		case strings.HasPrefix(text, "@@"):
+			inHunk = true
+			text = cyanColor(text)
+		case !inHunk:
+			if text != "" {
+				text = bold(text)
+			}
+		case strings.HasPrefix(text, "+"):
+			text = greenColor(text)
+		case strings.HasPrefix(text, "-"):
+			text = redColor(text)
+		}
+		b.WriteString(text)
+		if newline {
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+func init() {
+	prCmd.AddCommand(prDiffCmd)
+}
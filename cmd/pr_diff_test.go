@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+func TestSplitPRDiffArgs(t *testing.T) {
+	tests := []struct {
+		name      string
+		args      []string
+		wantPR    []string
+		wantPaths []string
+		wantErr   bool
+	}{
+		{"no arguments", nil, nil, nil, false},
+		{"service only", []string{"policyservice"}, []string{"policyservice"}, nil, false},
+		{"id only", []string{"42"}, []string{"42"}, nil, false},
+		{"id with hash", []string{"#42", "main.go"}, []string{"#42"}, []string{"main.go"}, false},
+		{"id and paths", []string{"42", "internal", "*.go"}, []string{"42"}, []string{"internal", "*.go"}, false},
+		{"service, id and paths", []string{"policyservice", "42", "Makefile"}, []string{"policyservice", "42"}, []string{"Makefile"}, false},
+		{"paths without id", []string{"policyservice", "main.go"}, nil, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prArgs, paths, err := splitPRDiffArgs(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("splitPRDiffArgs(%q) error = %v, want error %t", tt.args, err, tt.wantErr)
+			}
+			if !slices.Equal(prArgs, tt.wantPR) || !slices.Equal(paths, tt.wantPaths) {
+				t.Errorf("splitPRDiffArgs(%q) = %q, %q, want %q, %q", tt.args, prArgs, paths, tt.wantPR, tt.wantPaths)
+			}
+		})
+	}
+}
+
+func TestDiffPathMatches(t *testing.T) {
+	tests := []struct {
+		file  string
+		paths []string
+		want  bool
+	}{
+		{"main.go", []string{"main.go"}, true},
+		{"main.go", []string{"./main.go"}, true},
+		{"internal/git/log.go", []string{"internal"}, true},
+		{"internal/git/log.go", []string{"internal/"}, true},
+		{"internal/git/log.go", []string{"internal/git"}, true},
+		{"internalx/log.go", []string{"internal"}, false},
+		{"internal/git/log.go", []string{"*.go"}, true},
+		{"internal/git/log.go", []string{"internal/*/log.go"}, true},
+		{"internal/git/log.go", []string{"internal/*.go"}, false},
+		{"docs/README.md", []string{"*.go", "docs"}, true},
+		{"docs/README.md", []string{"*.go", "Makefile"}, false},
+	}
+
+	for _, tt := range tests {
+		if got := diffPathMatches(tt.file, tt.paths); got != tt.want {
+			t.Errorf("diffPathMatches(%q, %q) = %t, want %t", tt.file, tt.paths, got, tt.want)
+		}
+	}
+}
+
+func TestFilterDiff(t *testing.T) {
+	diff := `diff --git a/main.go b/main.go
+--- a/main.go
++++ b/main.go
+@@ -1 +1 @@
+-old
++new
+diff --git a/old/name.go b/internal/name.go
+similarity index 90%
+rename from old/name.go
+rename to internal/name.go
+diff --git a/docs/guide.md b/docs/guide.md
+--- a/docs/guide.md
++++ b/docs/guide.md
+@@ -1 +1 @@
+--- rule
++++ rule
+`
+
+	tests := []struct {
+		name      string
+		paths     []string
+		wantFiles []string
+	}{
+		{"no paths", nil, []string{"main.go", "old/name.go", "docs/guide.md"}},
+		{"exact file", []string{"main.go"}, []string{"main.go"}},
+		{"rename by new path", []string{"internal"}, []string{"old/name.go"}},
+		{"rename by old path", []string{"old/name.go"}, []string{"old/name.go"}},
+		{"glob", []string{"*.md"}, []string{"docs/guide.md"}},
+		{"no match", []string{"vendor"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var files []string
+			for _, line := range strings.Split(filterDiff(diff, tt.paths), "\n") {
+				if header, found := strings.CutPrefix(line, "diff --git a/"); found {
+					file, _, _ := strings.Cut(header, " ")
+					files = append(files, file)
+				}
+			}
+			if !slices.Equal(files, tt.wantFiles) {
+				t.Errorf("filterDiff(%q) kept %q, want %q", tt.paths, files, tt.wantFiles)
+			}
+		})
+	}
+
+	// Lines of a kept file that look like headers stay with it
+	if got := filterDiff(diff, []string{"docs"}); !strings.HasSuffix(got, "--- rule\n+++ rule\n") {
+		t.Errorf("filterDiff() = %q, want the hunk lines of docs/guide.md", got)
+	}
+}
+
+func TestColorizeDiff(t *testing.T) {
+	noColor := color.NoColor
+	color.NoColor = false
+	defer func() { color.NoColor = noColor }()
+
+	bold := color.New(color.Bold).SprintFunc()
+	cyanColor := color.New(color.FgCyan).SprintFunc()
+	greenColor := color.New(color.FgGreen).SprintFunc()
+	redColor := color.New(color.FgRed).SprintFunc()
+
+	diff := "diff --git a/q.sql b/q.sql\nindex 1..2 100644\n--- a/q.sql\n+++ b/q.sql\n@@ -1,2 +1,2 @@\n--- old comment\n+++ new comment\n select 1;\n"
+	want := strings.Join([]string{
+		bold("diff --git a/q.sql b/q.sql"),
+		bold("index 1..2 100644"),
+		bold("--- a/q.sql"),
+		bold("+++ b/q.sql"),
+		cyanColor("@@ -1,2 +1,2 @@"),
+		redColor("--- old comment"),
+		greenColor("+++ new comment"),
+		" select 1;",
+		"",
+	}, "\n")
+
+	if got := colorizeDiff(diff); got != want {
+		t.Errorf("colorizeDiff() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,307 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"bitbucket.org/cover42/eiscli/internal/bitbucket"
+	"github.com/fatih/color"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+// inboxConcurrency limits parallel API requests when collecting the inbox
+const inboxConcurrency = 8
+
+// Roles of the current user in a pull request of the inbox, in display order
+const (
+	inboxRolePending  = "review pending" // Reviewer who has not approved or requested changes yet
+	inboxRoleReviewer = "reviewer"       // Reviewer who has reviewed
+	inboxRoleAuthor   = "author"
+)
+
+var inboxRoleOrder = []string{inboxRolePending, inboxRoleReviewer, inboxRoleAuthor}
+
+var prInboxRole string
+
+// inboxPullRequest is an open pull request that involves the current user
+type inboxPullRequest struct {
+	Role        string                 `json:"role" yaml:"role"`
+	Approvals   int                    `json:"approvals" yaml:"approvals"`
+	BuildState  string                 `json:"build_state,omitempty" yaml:"build_state,omitempty"` // SUCCESSFUL, FAILED, INPROGRESS or empty without builds
+	BuildError  string                 `json:"build_error,omitempty" yaml:"build_error,omitempty"`
+	PullRequest *bitbucket.PullRequest `json:"pull_request" yaml:"pull_request"`
+}
+
+// inboxRepository groups the inbox pull requests of a repository
+type inboxRepository struct {
+	Repository   string              `json:"repository" yaml:"repository"`
+	PullRequests []*inboxPullRequest `json:"pull_requests" yaml:"pull_requests"`
+	Error        string              `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+var prInboxCmd = &cobra.Command{
+	Use:   "inbox",
+	Short: "List open pull requests across the workspace that involve you",
+	Long: `List the open pull requests of all repositories in the workspace that you
+authored or are a reviewer of, grouped by repository.
+
+The role column shows whether your review is pending, you have reviewed the
+pull request already, or you are its author. Pull requests waiting for your
+review come first. Each pull request shows its age, how many reviewers
+approved it and the state of the builds of its source commit.
+
+Examples:
+  # Everything that involves you
+  eiscli pr inbox
+
+  # Only pull requests waiting for your review
+  eiscli pr inbox --role pending
+
+  # As JSON, for scripts
+  eiscli pr inbox --output json`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+
+		roles, ok := inboxRoles(prInboxRole)
+		if !ok {
+			errorf("Error: invalid role '%s', use pending, reviewer or author\n", prInboxRole)
+			return
+		}
+
+		client, ok := newBitbucketClient()
+		if !ok {
+			return
+		}
+
+		user, err := client.GetCurrentUser(ctx)
+		if err != nil {
+			errorf("Error: failed to get current user: %v\n", err)
+			return
+		}
+
+		repos, err := client.ListRepositories(ctx)
+		if err != nil {
+			errorf("Error fetching repositories: %v\n", err)
+			return
+		}
+
+		infof("Collecting open pull requests of %d repositories...\n", len(repos))
+		inbox := collectInbox(ctx, client, user, repos, roles)
+
+		if isMachineOutput() {
+			printOutput(inbox)
+			return
+		}
+
+		displayInbox(inbox)
+	},
+}
+
+// inboxRoles returns the roles selected with --role; all roles if empty
+func inboxRoles(role string) ([]string, bool) {
+	switch strings.ToLower(role) {
+	case "":
+		return inboxRoleOrder, true
+	case "pending":
+		return []string{inboxRolePending}, true
+	case "reviewer":
+		return []string{inboxRolePending, inboxRoleReviewer}, true
+	case "author":
+		return []string{inboxRoleAuthor}, true
+	default:
+		return nil, false
+	}
+}
+
+// collectInbox fetches the open pull requests of all repositories in
+// parallel and keeps those in which the user has one of the roles. Only
+// repositories with such pull requests, or that failed to load, are returned.
+func collectInbox(ctx context.Context, client *bitbucket.Client, user *bitbucket.UserInfo, repos []*bitbucket.Repository, roles []string) []*inboxRepository {
+	results := make([]*inboxRepository, len(repos))
+	sem := make(chan struct{}, inboxConcurrency)
+	var wg sync.WaitGroup
+
+	for i, repo := range repos {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			result := &inboxRepository{Repository: repo.Slug, PullRequests: []*inboxPullRequest{}}
+			results[i] = result
+
+			prs, err := client.ListOpenPullRequests(ctx, repo.Slug)
+			if err != nil {
+				result.Error = err.Error()
+				return
+			}
+
+			for _, pr := range prs {
+				role := inboxRole(user, pr)
+				if !slices.Contains(roles, role) {
+					continue
+				}
+
+				item := &inboxPullRequest{Role: role, Approvals: reviewerApprovals(pr), PullRequest: pr}
+				if pr.SourceCommit != "" {
+					builds, err := client.ListCommitStatuses(ctx, repo.Slug, pr.SourceCommit)
+					if err != nil {
+						item.BuildError = err.Error()
+					} else {
+						item.BuildState = summarizeBuilds(builds)
+					}
+				}
+				result.PullRequests = append(result.PullRequests, item)
+			}
+
+			// Pending reviews first, then the oldest pull requests
+			sort.SliceStable(result.PullRequests, func(a, b int) bool {
+				x, y := result.PullRequests[a], result.PullRequests[b]
+				if x.Role != y.Role {
+					return slices.Index(inboxRoleOrder, x.Role) < slices.Index(inboxRoleOrder, y.Role)
+				}
+				return x.PullRequest.CreatedOn.Before(y.PullRequest.CreatedOn)
+			})
+		}()
+	}
+	wg.Wait()
+
+	inbox := make([]*inboxRepository, 0, len(results))
+	for _, r := range results {
+		if len(r.PullRequests) > 0 || r.Error != "" {
+			inbox = append(inbox, r)
+		}
+	}
+	sort.Slice(inbox, func(i, j int) bool {
+		return inbox[i].Repository < inbox[j].Repository
+	})
+	return inbox
+}
+
+// inboxRole returns the role of the user in a pull request, or "" if the user
+// is neither its author nor a reviewer
+func inboxRole(user *bitbucket.UserInfo, pr *bitbucket.PullRequest) string {
+	isUser := func(id string) bool {
+		return id != "" && (id == user.UUID || id == user.Username)
+	}
+
+	if isUser(pr.AuthorUUID) {
+		return inboxRoleAuthor
+	}
+	if !slices.ContainsFunc(pr.Reviewers, isUser) {
+		return ""
+	}
+	for _, participant := range pr.Participants {
+		if isUser(participant.UUID) && (participant.Approved || participant.RequestedChanges()) {
+			return inboxRoleReviewer
+		}
+	}
+	return inboxRolePending
+}
+
+// reviewerApprovals counts the reviewers who approved the pull request. Other
+// participants can approve too, but are not shown against the reviewers.
+func reviewerApprovals(pr *bitbucket.PullRequest) int {
+	approvals := 0
+	for _, participant := range pr.Participants {
+		if participant.Role == "REVIEWER" && participant.Approved {
+			approvals++
+		}
+	}
+	return approvals
+}
+
+// summarizeBuilds reduces the latest status of each build of a commit to one
+// state: FAILED if any failed or was stopped, INPROGRESS if any is running,
+// else SUCCESSFUL. It returns "" if there are no builds.
+func summarizeBuilds(builds []*bitbucket.BuildStatus) string {
+	state := ""
+	for _, build := range latestBuildStatuses(builds) {
+		switch strings.ToUpper(build.State) {
+		case "FAILED", "STOPPED":
+			return "FAILED"
+		case "INPROGRESS":
+			state = "INPROGRESS"
+		case "SUCCESSFUL":
+			if state == "" {
+				state = "SUCCESSFUL"
+			}
+		}
+	}
+	return state
+}
+
+func displayInbox(inbox []*inboxRepository) {
+	greenColor := color.New(color.FgGreen).SprintFunc()
+	redColor := color.New(color.FgRed).SprintFunc()
+	yellowColor := color.New(color.FgYellow).SprintFunc()
+	boldColor := color.New(color.Bold).SprintFunc()
+
+	total, pending := 0, 0
+	for _, repo := range inbox {
+		if repo.Error != "" {
+			fmt.Printf("\n%s %s: %s\n", yellowColor("⚠"), repo.Repository, repo.Error)
+			continue
+		}
+
+		fmt.Printf("\n%s\n", boldColor(repo.Repository))
+		table := tablewriter.NewWriter(os.Stdout)
+		table.Header("ID", "Title", "Author", "Role", "Age", "Approvals", "Build")
+
+		for _, item := range repo.PullRequests {
+			pr := item.PullRequest
+			total++
+
+			title := truncateValue(pr.Title, 50)
+			if pr.Draft {
+				title += " (draft)"
+			}
+
+			role := item.Role
+			if role == inboxRolePending {
+				pending++
+				role = yellowColor(role)
+			}
+
+			approvals := fmt.Sprintf("%d/%d", item.Approvals, len(pr.Reviewers))
+			if item.Approvals > 0 {
+				approvals = greenColor("✓ " + approvals)
+			}
+
+			build := "-"
+			switch {
+			case item.BuildError != "":
+				build = yellowColor("?")
+			case item.BuildState == "SUCCESSFUL":
+				build = greenColor(getStatusIcon(item.BuildState) + " " + item.BuildState)
+			case item.BuildState == "FAILED":
+				build = redColor(getStatusIcon(item.BuildState) + " " + item.BuildState)
+			case item.BuildState != "":
+				build = yellowColor(getStatusIcon(item.BuildState) + " " + item.BuildState)
+			}
+
+			table.Append(formatClickablePRID(pr.ID, pr.WebURL), title, pr.Author, role, formatTimeAgo(pr.CreatedOn), approvals, build)
+		}
+		table.Render()
+	}
+
+	if total == 0 {
+		fmt.Println("\nNo open pull requests involve you.")
+		return
+	}
+	fmt.Printf("\nTotal: %d pull request(s), %d waiting for your review\n", total, pending)
+}
+
+func init() {
+	prCmd.AddCommand(prInboxCmd)
+
+	prInboxCmd.Flags().StringVar(&prInboxRole, "role", "", "Only show pull requests where you are: pending (your review is pending), reviewer or author")
+}
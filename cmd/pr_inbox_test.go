@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"bitbucket.org/cover42/eiscli/internal/bitbucket"
+)
+
+func TestInboxRoles(t *testing.T) {
+	tests := []struct {
+		role   string
+		want   []string
+		wantOK bool
+	}{
+		{"", []string{inboxRolePending, inboxRoleReviewer, inboxRoleAuthor}, true},
+		{"pending", []string{inboxRolePending}, true},
+		{"Reviewer", []string{inboxRolePending, inboxRoleReviewer}, true},
+		{"AUTHOR", []string{inboxRoleAuthor}, true},
+		{"owner", nil, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := inboxRoles(tt.role)
+		if ok != tt.wantOK || !slices.Equal(got, tt.want) {
+			t.Errorf("inboxRoles(%q) = %q, %t, want %q, %t", tt.role, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestInboxRole(t *testing.T) {
+	user := &bitbucket.UserInfo{UUID: "{me}", Username: "me"}
+
+	tests := []struct {
+		name string
+		pr   *bitbucket.PullRequest
+		want string
+	}{
+		{
+			name: "author",
+			pr:   &bitbucket.PullRequest{AuthorUUID: "{me}", Reviewers: []string{"{other}"}},
+			want: inboxRoleAuthor,
+		},
+		{
+			name: "not involved",
+			pr: &bitbucket.PullRequest{
+				AuthorUUID:   "{other}",
+				Reviewers:    []string{"{reviewer}"},
+				Participants: []*bitbucket.PullRequestParticipant{{UUID: "{me}", Role: "PARTICIPANT", Approved: true}},
+			},
+			want: "",
+		},
+		{
+			name: "reviewer without review",
+			pr: &bitbucket.PullRequest{
+				AuthorUUID:   "{other}",
+				Reviewers:    []string{"{me}"},
+				Participants: []*bitbucket.PullRequestParticipant{{UUID: "{me}", Role: "REVIEWER"}},
+			},
+			want: inboxRolePending,
+		},
+		{
+			name: "reviewer by username",
+			pr:   &bitbucket.PullRequest{AuthorUUID: "{other}", Reviewers: []string{"me"}},
+			want: inboxRolePending,
+		},
+		{
+			name: "reviewer who approved",
+			pr: &bitbucket.PullRequest{
+				AuthorUUID:   "{other}",
+				Reviewers:    []string{"{me}"},
+				Participants: []*bitbucket.PullRequestParticipant{{UUID: "{me}", Role: "REVIEWER", Approved: true, State: "approved"}},
+			},
+			want: inboxRoleReviewer,
+		},
+		{
+			name: "reviewer who requested changes",
+			pr: &bitbucket.PullRequest{
+				AuthorUUID:   "{other}",
+				Reviewers:    []string{"{me}"},
+				Participants: []*bitbucket.PullRequestParticipant{{UUID: "{me}", Role: "REVIEWER", State: "changes_requested"}},
+			},
+			want: inboxRoleReviewer,
+		},
+		{
+			name: "another reviewer approved",
+			pr: &bitbucket.PullRequest{
+				AuthorUUID:   "{other}",
+				Reviewers:    []string{"{me}", "{reviewer}"},
+				Participants: []*bitbucket.PullRequestParticipant{{UUID: "{reviewer}", Role: "REVIEWER", Approved: true}},
+			},
+			want: inboxRolePending,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := inboxRole(user, tt.pr); got != tt.want {
+				t.Errorf("inboxRole() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReviewerApprovals(t *testing.T) {
+	pr := &bitbucket.PullRequest{
+		Reviewers: []string{"{a}", "{b}", "{c}"},
+		Participants: []*bitbucket.PullRequestParticipant{
+			{UUID: "{a}", Role: "REVIEWER", Approved: true},
+			{UUID: "{b}", Role: "REVIEWER", State: "changes_requested"},
+			{UUID: "{c}", Role: "REVIEWER"},
+			{UUID: "{d}", Role: "PARTICIPANT", Approved: true},
+		},
+	}
+
+	if got := reviewerApprovals(pr); got != 1 {
+		t.Errorf("reviewerApprovals() = %d, want 1", got)
+	}
+}
+
+func TestSummarizeBuilds(t *testing.T) {
+	now := time.Now()
+	build := func(key, state string, age time.Duration) *bitbucket.BuildStatus {
+		return &bitbucket.BuildStatus{Key: key, State: state, UpdatedOn: now.Add(-age)}
+	}
+
+	tests := []struct {
+		name   string
+		builds []*bitbucket.BuildStatus
+		want   string
+	}{
+		{"no builds", nil, ""},
+		{"all successful", []*bitbucket.BuildStatus{build("a", "SUCCESSFUL", 0), build("b", "SUCCESSFUL", 0)}, "SUCCESSFUL"},
+		{"running", []*bitbucket.BuildStatus{build("a", "SUCCESSFUL", 0), build("b", "INPROGRESS", 0)}, "INPROGRESS"},
+		{"failed wins over running", []*bitbucket.BuildStatus{build("a", "INPROGRESS", 0), build("b", "FAILED", 0)}, "FAILED"},
+		{"stopped counts as failed", []*bitbucket.BuildStatus{build("a", "STOPPED", 0)}, "FAILED"},
+		{"lower case state", []*bitbucket.BuildStatus{build("a", "successful", 0)}, "SUCCESSFUL"},
+		{"rerun after a failure", []*bitbucket.BuildStatus{build("a", "FAILED", time.Hour), build("a", "SUCCESSFUL", 0)}, "SUCCESSFUL"},
+		{"failure after a success", []*bitbucket.BuildStatus{build("a", "SUCCESSFUL", time.Hour), build("a", "FAILED", 0)}, "FAILED"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := summarizeBuilds(tt.builds); got != tt.want {
+				t.Errorf("summarizeBuilds() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
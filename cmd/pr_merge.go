@@ -0,0 +1,239 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"bitbucket.org/cover42/eiscli/internal/bitbucket"
+	"bitbucket.org/cover42/eiscli/internal/git"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+var (
+	prMergeStrategy          string
+	prMergeCloseSourceBranch bool
+	prMergeMessage           string
+	prMergeForce             bool
+)
+
+var prMergeCmd = &cobra.Command{
+	Use:   "merge [service-name] [id]",
+	Short: "Merge a pull request",
+	Long: `Merge a pull request.
+
+The builds of the source commit are checked first: the merge is refused when a
+build failed, was stopped, is still running or has an unknown state, unless
+--force is given. Only the latest status of each build counts, so a failed
+build that was rerun and passed does not stop the merge.
+
+If the merged branch is checked out in the current directory, you are offered
+to switch to the default branch and delete the local branch.
+
+If id is not provided, the open pull request of the current git branch is used.
+If service-name is not provided, it will be auto-detected from the git repository
+in the current directory.
+
+Examples:
+  # Merge the pull request of the current branch
+  eiscli pr merge
+
+  # Squash pull request #42 and delete its branch on Bitbucket
+  eiscli pr merge 42 --strategy squash --close-source-branch
+
+  # Merge with a custom commit message although a build failed
+  eiscli pr merge 42 --message "EIS-42 Add retries" --force`,
+	Args: cobra.MaximumNArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+
+		if prMergeStrategy != "" && !slices.Contains(bitbucket.MergeStrategies, prMergeStrategy) {
+			errorf("Error: invalid merge strategy '%s', must be one of: %s\n", prMergeStrategy, strings.Join(bitbucket.MergeStrategies, ", "))
+			return
+		}
+
+		serviceName, client, pr, ok := resolvePullRequestArgs(ctx, args)
+		if !ok {
+			return
+		}
+
+		if !strings.EqualFold(pr.State, "OPEN") {
+			errorf("Error: pull request #%d is %s\n", pr.ID, strings.ToLower(pr.State))
+			return
+		}
+
+		if !checkMergeBuilds(ctx, client, serviceName, pr) {
+			return
+		}
+
+		infof("Merging pull request #%d: %s...\n", pr.ID, pr.Title)
+		merged, err := client.MergePullRequest(ctx, serviceName, pr.ID, &bitbucket.MergeOptions{
+			Strategy:          prMergeStrategy,
+			Message:           prMergeMessage,
+			CloseSourceBranch: prMergeCloseSourceBranch,
+		})
+		if err != nil {
+			errorf("Error: %v\n", err)
+			return
+		}
+
+		if isMachineOutput() {
+			printOutput(merged)
+			return
+		}
+
+		greenColor := color.New(color.FgGreen).SprintFunc()
+		if strings.EqualFold(merged.State, "MERGED") {
+			fmt.Printf("%s Merged pull request #%d into %s\n", greenColor("✓"), pr.ID, pr.DestinationBranch)
+		} else {
+			// Large merges are completed asynchronously by Bitbucket
+			fmt.Printf("%s Merge of pull request #%d was accepted and is in progress\n", greenColor("✓"), pr.ID)
+		}
+		if prMergeCloseSourceBranch {
+			fmt.Printf("%s Branch %s is deleted on Bitbucket\n", greenColor("✓"), pr.SourceBranch)
+		}
+
+		if !strings.EqualFold(merged.State, "MERGED") {
+			fmt.Printf("Check 'eiscli pr view %s %d' until it is merged before deleting the local branch %s\n", serviceName, pr.ID, pr.SourceBranch)
+			return
+		}
+		cleanUpMergedBranch(pr)
+	},
+}
+
+// checkMergeBuilds checks the builds of the source commit and returns false if
+// the merge should not go ahead. Without --force, failed, stopped or running
+// builds and unknown build statuses stop the merge. Problems are printed.
+func checkMergeBuilds(ctx context.Context, client *bitbucket.Client, serviceName string, pr *bitbucket.PullRequest) bool {
+	if pr.SourceCommit == "" {
+		return true
+	}
+
+	yellowColor := color.New(color.FgYellow).SprintFunc()
+	redColor := color.New(color.FgRed).SprintFunc()
+
+	builds, err := client.ListCommitStatuses(ctx, serviceName, pr.SourceCommit)
+	if err != nil {
+		if prMergeForce {
+			infof("%s Could not check builds: %v\n", yellowColor("⚠"), err)
+			return true
+		}
+		errorf("Error: could not check builds, use --force to merge anyway: %v\n", err)
+		return false
+	}
+
+	failed, running, unknown := 0, 0, 0
+	for _, build := range latestBuildStatuses(builds) {
+		switch strings.ToUpper(build.State) {
+		case "SUCCESSFUL":
+		case "FAILED", "STOPPED":
+			failed++
+			infof("%s Build %s %s\n", redColor(getStatusIcon(build.State)), build.Name, strings.ToLower(build.State))
+		case "INPROGRESS":
+			running++
+			infof("%s Build %s is still running\n", yellowColor(getStatusIcon(build.State)), build.Name)
+		default:
+			unknown++
+			infof("%s Build %s has the unknown state '%s'\n", yellowColor(getStatusIcon(build.State)), build.Name, build.State)
+		}
+	}
+	if failed == 0 && running == 0 && unknown == 0 {
+		return true
+	}
+
+	if prMergeForce {
+		infof("%s Merging anyway because of --force\n", yellowColor("⚠"))
+		return true
+	}
+
+	switch {
+	case failed > 0:
+		errorf("Error: pull request #%d has failing builds, use --force to merge anyway\n", pr.ID)
+	case running > 0:
+		errorf("Error: pull request #%d has builds that are still running, use --force to merge anyway\n", pr.ID)
+	default:
+		errorf("Error: pull request #%d has builds in an unknown state, use --force to merge anyway\n", pr.ID)
+	}
+	return false
+}
+
+// latestBuildStatuses keeps the most recently updated status of each build
+// key, so that a build that was rerun only counts with its last result. The
+// order of the first status of each key is kept.
+func latestBuildStatuses(builds []*bitbucket.BuildStatus) []*bitbucket.BuildStatus {
+	latest := make([]*bitbucket.BuildStatus, 0, len(builds))
+	index := make(map[string]int)
+	for _, build := range builds {
+		key := build.Key
+		if key == "" {
+			key = build.Name
+		}
+		i, seen := index[key]
+		if !seen {
+			index[key] = len(latest)
+			latest = append(latest, build)
+			continue
+		}
+		if build.UpdatedOn.After(latest[i].UpdatedOn) {
+			latest[i] = build
+		}
+	}
+	return latest
+}
+
+// cleanUpMergedBranch offers to switch to the default branch and delete the
+// local branch if the merged branch is checked out
+func cleanUpMergedBranch(pr *bitbucket.PullRequest) {
+	current, err := git.GetCurrentBranch()
+	if err != nil || current != pr.SourceBranch {
+		return
+	}
+
+	defaultBranch, err := git.GetDefaultBranch()
+	if err != nil {
+		defaultBranch = pr.DestinationBranch
+	}
+	if defaultBranch == "" || defaultBranch == current {
+		return
+	}
+
+	// Commits that are not in the pull request would be lost with the branch.
+	// Without the source commit of the pull request that cannot be ruled out.
+	deleteDefault := true
+	yellowColor := color.New(color.FgYellow).SprintFunc()
+	if pr.SourceCommit == "" {
+		fmt.Printf("%s Could not check whether local branch %s has commits that are not in the pull request\n", yellowColor("⚠"), current)
+		deleteDefault = false
+	} else if head, err := git.GetHeadCommit(); err != nil || !strings.HasPrefix(head, pr.SourceCommit) {
+		fmt.Printf("%s Local branch %s has commits that are not in the pull request\n", yellowColor("⚠"), current)
+		deleteDefault = false
+	}
+
+	confirmed, err := promptYesNo(fmt.Sprintf("Switch to %s and delete local branch %s?", defaultBranch, current), deleteDefault)
+	if err != nil || !confirmed {
+		return
+	}
+
+	if err := git.CheckoutBranch(defaultBranch); err != nil {
+		errorf("Error: %v\n", err)
+		return
+	}
+	if err := git.DeleteBranch(current); err != nil {
+		errorf("Error: %v\n", err)
+		return
+	}
+
+	greenColor := color.New(color.FgGreen).SprintFunc()
+	fmt.Printf("%s Switched to %s and deleted %s. Run 'git pull' to fetch the merge.\n", greenColor("✓"), defaultBranch, current)
+}
+
+func init() {
+	prCmd.AddCommand(prMergeCmd)
+
+	prMergeCmd.Flags().StringVar(&prMergeStrategy, "strategy", "", "Merge strategy: merge_commit, squash or fast_forward (default: repository setting)")
+	prMergeCmd.Flags().BoolVar(&prMergeCloseSourceBranch, "close-source-branch", false, "Delete the source branch on Bitbucket after merging")
+	prMergeCmd.Flags().StringVarP(&prMergeMessage, "message", "m", "", "Commit message of the merge")
+	prMergeCmd.Flags().BoolVarP(&prMergeForce, "force", "f", false, "Merge even if builds of the source commit failed")
+}
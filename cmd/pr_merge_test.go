@@ -0,0 +1,30 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"bitbucket.org/cover42/eiscli/internal/bitbucket"
+)
+
+func TestLatestBuildStatuses(t *testing.T) {
+	now := time.Now()
+	builds := []*bitbucket.BuildStatus{
+		{Key: "build", Name: "Pipeline #2", State: "SUCCESSFUL", UpdatedOn: now},
+		{Key: "lint", Name: "Lint", State: "FAILED", UpdatedOn: now.Add(-time.Hour)},
+		{Key: "build", Name: "Pipeline #1", State: "FAILED", UpdatedOn: now.Add(-2 * time.Hour)},
+		{Key: "lint", Name: "Lint", State: "INPROGRESS", UpdatedOn: now.Add(-time.Minute)},
+		{Name: "Unkeyed", State: "STOPPED", UpdatedOn: now},
+	}
+
+	got := latestBuildStatuses(builds)
+	want := []string{"Pipeline #2 SUCCESSFUL", "Lint INPROGRESS", "Unkeyed STOPPED"}
+	if len(got) != len(want) {
+		t.Fatalf("latestBuildStatuses() returned %d statuses, want %d", len(got), len(want))
+	}
+	for i, build := range got {
+		if s := build.Name + " " + build.State; s != want[i] {
+			t.Errorf("status %d = %s, want %s", i, s, want[i])
+		}
+	}
+}
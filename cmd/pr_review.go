@@ -0,0 +1,195 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"bitbucket.org/cover42/eiscli/internal/bitbucket"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+var prDeclineYes bool
+
+var prApproveCmd = &cobra.Command{
+	Use:   "approve [service-name] [id]",
+	Short: "Approve a pull request",
+	Long: `Approve a pull request as the authenticated user.
+
+If id is not provided, the open pull request of the current git branch is used.
+If service-name is not provided, it will be auto-detected from the git repository
+in the current directory.
+
+Examples:
+  # Approve pull request #42
+  eiscli pr approve 42
+
+  # Approve a pull request of another service
+  eiscli pr approve policyservice 42`,
+	Args: cobra.MaximumNArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+
+		serviceName, client, pr, ok := resolvePullRequestArgs(ctx, args)
+		if !ok {
+			return
+		}
+
+		if err := client.ApprovePullRequest(ctx, serviceName, pr.ID); err != nil {
+			errorf("Error: %v\n", err)
+			return
+		}
+
+		greenColor := color.New(color.FgGreen).SprintFunc()
+		fmt.Printf("%s Approved pull request #%d: %s\n", greenColor("✓"), pr.ID, pr.Title)
+	},
+}
+
+var prUnapproveCmd = &cobra.Command{
+	Use:   "unapprove [service-name] [id]",
+	Short: "Withdraw your approval of a pull request",
+	Long: `Withdraw the approval of the authenticated user from a pull request.
+
+If id is not provided, the open pull request of the current git branch is used.
+If service-name is not provided, it will be auto-detected from the git repository
+in the current directory.
+
+Examples:
+  eiscli pr unapprove 42`,
+	Args: cobra.MaximumNArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+
+		serviceName, client, pr, ok := resolvePullRequestArgs(ctx, args)
+		if !ok {
+			return
+		}
+
+		if err := client.UnapprovePullRequest(ctx, serviceName, pr.ID); err != nil {
+			errorf("Error: %v\n", err)
+			return
+		}
+
+		greenColor := color.New(color.FgGreen).SprintFunc()
+		fmt.Printf("%s Withdrew approval of pull request #%d: %s\n", greenColor("✓"), pr.ID, pr.Title)
+	},
+}
+
+var prRequestChangesCmd = &cobra.Command{
+	Use:   "request-changes [service-name] [id]",
+	Short: "Request changes on a pull request",
+	Long: `Request changes on a pull request as the authenticated user.
+
+If id is not provided, the open pull request of the current git branch is used.
+If service-name is not provided, it will be auto-detected from the git repository
+in the current directory.
+
+Examples:
+  eiscli pr request-changes 42`,
+	Args: cobra.MaximumNArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+
+		serviceName, client, pr, ok := resolvePullRequestArgs(ctx, args)
+		if !ok {
+			return
+		}
+
+		if err := client.RequestChanges(ctx, serviceName, pr.ID); err != nil {
+			errorf("Error: %v\n", err)
+			return
+		}
+
+		yellowColor := color.New(color.FgYellow).SprintFunc()
+		fmt.Printf("%s Requested changes on pull request #%d: %s\n", yellowColor("✗"), pr.ID, pr.Title)
+	},
+}
+
+var prDeclineCmd = &cobra.Command{
+	Use:   "decline [service-name] [id]",
+	Short: "Decline a pull request",
+	Long: `Decline a pull request. Declined pull requests cannot be reopened, so you
+are asked for confirmation unless --yes is given.
+
+If id is not provided, the open pull request of the current git branch is used.
+If service-name is not provided, it will be auto-detected from the git repository
+in the current directory.
+
+Examples:
+  eiscli pr decline 42
+
+  # Without confirmation
+  eiscli pr decline 42 --yes`,
+	Args: cobra.MaximumNArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+
+		serviceName, client, pr, ok := resolvePullRequestArgs(ctx, args)
+		if !ok {
+			return
+		}
+
+		if !prDeclineYes {
+			confirmed, err := promptYesNo(fmt.Sprintf("Decline pull request #%d (%s)?", pr.ID, pr.Title), false)
+			if err != nil {
+				errorf("Error: %v\n", err)
+				return
+			}
+			if !confirmed {
+				fmt.Println("Canceled.")
+				return
+			}
+		}
+
+		declined, err := client.DeclinePullRequest(ctx, serviceName, pr.ID)
+		if err != nil {
+			errorf("Error: %v\n", err)
+			return
+		}
+
+		if isMachineOutput() {
+			printOutput(declined)
+			return
+		}
+
+		redColor := color.New(color.FgRed).SprintFunc()
+		fmt.Printf("%s Declined pull request #%d: %s\n", redColor("✗"), pr.ID, pr.Title)
+	},
+}
+
+// resolvePullRequestArgs parses "[service-name] [id]" arguments, creates a
+// client and fetches the pull request. Errors are printed.
+func resolvePullRequestArgs(ctx context.Context, args []string) (string, *bitbucket.Client, *bitbucket.PullRequest, bool) {
+	serviceArgs, prID, err := parseServiceAndNumberArgs(args, "pull request ID")
+	if err != nil {
+		errorf("Error: %v\n", err)
+		return "", nil, nil, false
+	}
+
+	serviceName := getServiceName(serviceArgs)
+	if serviceName == "" {
+		return "", nil, nil, false
+	}
+
+	client, ok := newBitbucketClient()
+	if !ok {
+		return "", nil, nil, false
+	}
+
+	pr, err := resolvePullRequest(ctx, client, serviceName, prID)
+	if err != nil {
+		errorf("Error: %v\n", err)
+		return "", nil, nil, false
+	}
+
+	return serviceName, client, pr, true
+}
+
+func init() {
+	prCmd.AddCommand(prApproveCmd)
+	prCmd.AddCommand(prUnapproveCmd)
+	prCmd.AddCommand(prRequestChangesCmd)
+	prCmd.AddCommand(prDeclineCmd)
+
+	prDeclineCmd.Flags().BoolVarP(&prDeclineYes, "yes", "y", false, "Decline without asking for confirmation")
+}
@@ -0,0 +1,325 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"bitbucket.org/cover42/eiscli/internal/bitbucket"
+	"bitbucket.org/cover42/eiscli/internal/git"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+// prViewCommitLimit caps the commits listed by pr view
+const prViewCommitLimit = 20
+
+// pullRequestView holds everything shown by pr view.
+// Each section carries its own error so one failing API does not hide the rest.
+type pullRequestView struct {
+	PullRequest *bitbucket.PullRequest
+
+	Files    []*bitbucket.DiffStat
+	FilesErr error
+
+	Commits    []*bitbucket.Commit
+	CommitsErr error
+
+	Builds    []*bitbucket.BuildStatus
+	BuildsErr error
+}
+
+// pullRequestViewOutput is the --output json/yaml form of pullRequestView.
+// Section errors are reported in Errors keyed by section name.
+type pullRequestViewOutput struct {
+	PullRequest *bitbucket.PullRequest   `json:"pull_request" yaml:"pull_request"`
+	Files       []*bitbucket.DiffStat    `json:"files" yaml:"files"`
+	Commits     []*bitbucket.Commit      `json:"commits" yaml:"commits"`
+	Builds      []*bitbucket.BuildStatus `json:"builds" yaml:"builds"`
+	Errors      map[string]string        `json:"errors,omitempty" yaml:"errors,omitempty"`
+}
+
+var prViewCmd = &cobra.Command{
+	Use:   "view [service-name] [id]",
+	Short: "Show a pull request",
+	Long: `Show a pull request with its description, reviewers and their approval
+state, the changed files, commits, build statuses of the source commit and the
+number of open tasks and comments.
+
+If id is not provided, the open pull request of the current git branch is shown.
+If service-name is not provided, it will be auto-detected from the git repository
+in the current directory.
+
+Examples:
+  # Show the pull request of the current branch
+  eiscli pr view
+
+  # Show pull request #42
+  eiscli pr view 42
+
+  # Machine-readable output
+  eiscli pr view 42 --output json`,
+	Args: cobra.MaximumNArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+
+		serviceArgs, prID, err := parseServiceAndNumberArgs(args, "pull request ID")
+		if err != nil {
+			errorf("Error: %v\n", err)
+			return
+		}
+
+		serviceName := getServiceName(serviceArgs)
+		if serviceName == "" {
+			return
+		}
+
+		client, ok := newBitbucketClient()
+		if !ok {
+			return
+		}
+
+		pr, err := resolvePullRequest(ctx, client, serviceName, prID)
+		if err != nil {
+			errorf("Error: %v\n", err)
+			return
+		}
+
+		view := collectPullRequestView(ctx, client, serviceName, pr)
+		if isMachineOutput() {
+			printOutput(view.output())
+			return
+		}
+		displayPullRequestView(view)
+	},
+}
+
+// resolvePullRequest fetches the pull request with the ID or, without an ID,
+// the open pull request of the current git branch
+func resolvePullRequest(ctx context.Context, client *bitbucket.Client, serviceName string, prID int) (*bitbucket.PullRequest, error) {
+	if prID > 0 {
+		return client.GetPullRequest(ctx, serviceName, prID)
+	}
+
+	branch, err := git.GetCurrentBranch()
+	if err != nil {
+		return nil, fmt.Errorf("could not determine current branch, provide a pull request ID: %w", err)
+	}
+
+	pr, err := client.FindOpenPullRequest(ctx, serviceName, branch)
+	if err != nil {
+		return nil, err
+	}
+	if pr == nil {
+		return nil, fmt.Errorf("no open pull request for branch '%s', provide a pull request ID", branch)
+	}
+
+	// The search result lacks the participants
+	return client.GetPullRequest(ctx, serviceName, pr.ID)
+}
+
+// collectPullRequestView fetches the files, commits and the latest status of
+// each build of a pull request concurrently
+func collectPullRequestView(ctx context.Context, client *bitbucket.Client, serviceName string, pr *bitbucket.PullRequest) *pullRequestView {
+	view := &pullRequestView{PullRequest: pr}
+
+	var wg sync.WaitGroup
+	run := func(fn func()) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			fn()
+		}()
+	}
+
+	run(func() {
+		view.Files, view.FilesErr = client.ListPullRequestDiffStat(ctx, serviceName, pr.ID)
+	})
+
+	run(func() {
+		view.Commits, view.CommitsErr = client.ListPullRequestCommits(ctx, serviceName, pr.ID)
+	})
+
+	if pr.SourceCommit != "" {
+		run(func() {
+			builds, err := client.ListCommitStatuses(ctx, serviceName, pr.SourceCommit)
+			// Only the latest result of a rerun build counts, as for pr merge and pr inbox
+			view.Builds, view.BuildsErr = latestBuildStatuses(builds), err
+		})
+	}
+
+	wg.Wait()
+	return view
+}
+
+// output converts the view into its serializable form
+func (v *pullRequestView) output() *pullRequestViewOutput {
+	out := &pullRequestViewOutput{
+		PullRequest: v.PullRequest,
+		Files:       v.Files,
+		Commits:     v.Commits,
+		Builds:      v.Builds,
+		Errors:      make(map[string]string),
+	}
+	if out.Files == nil {
+		out.Files = []*bitbucket.DiffStat{}
+	}
+	if out.Commits == nil {
+		out.Commits = []*bitbucket.Commit{}
+	}
+	if out.Builds == nil {
+		out.Builds = []*bitbucket.BuildStatus{}
+	}
+
+	addErr := func(section string, err error) {
+		if err != nil {
+			out.Errors[section] = err.Error()
+		}
+	}
+	addErr("files", v.FilesErr)
+	addErr("commits", v.CommitsErr)
+	addErr("builds", v.BuildsErr)
+
+	return out
+}
+
+// displayPullRequestView prints a pull request with all sections
+func displayPullRequestView(view *pullRequestView) {
+	greenColor := color.New(color.FgGreen).SprintFunc()
+	redColor := color.New(color.FgRed).SprintFunc()
+	yellowColor := color.New(color.FgYellow).SprintFunc()
+	bold := color.New(color.Bold).SprintFunc()
+
+	warn := func(what string, err error) {
+		fmt.Printf("  %s Could not fetch %s: %v\n", yellowColor("⚠"), what, err)
+	}
+
+	pr := view.PullRequest
+	fmt.Printf("\n%s %s [%s]\n", formatClickablePRID(pr.ID, pr.WebURL), bold(pr.Title), formatPRState(pr.State))
+	fmt.Printf("%s wants to merge %s → %s · updated %s\n", pr.Author, pr.SourceBranch, pr.DestinationBranch, formatTimeAgo(pr.UpdatedOn))
+	fmt.Printf("URL: %s\n", pr.WebURL)
+
+	if description := strings.TrimSpace(pr.Description); description != "" {
+		fmt.Println()
+		for _, line := range strings.Split(description, "\n") {
+			fmt.Printf("  %s\n", line)
+		}
+	}
+
+	// Reviewers
+	fmt.Println("\nReviewers:")
+	reviewers := 0
+	for _, participant := range pr.Participants {
+		if participant.Role != "REVIEWER" {
+			continue
+		}
+		reviewers++
+		switch {
+		case participant.Approved:
+			fmt.Printf("  %s %s (approved)\n", greenColor("✓"), participant.Name)
+		case participant.RequestedChanges():
+			fmt.Printf("  %s %s (changes requested)\n", redColor("✗"), participant.Name)
+		default:
+			fmt.Printf("  ○ %s\n", participant.Name)
+		}
+	}
+	if reviewers == 0 {
+		fmt.Println("  No reviewers.")
+	}
+
+	// Builds of the source commit
+	fmt.Println("\nBuilds:")
+	switch {
+	case view.BuildsErr != nil:
+		warn("build statuses", view.BuildsErr)
+	case len(view.Builds) == 0:
+		fmt.Println("  No builds found.")
+	default:
+		for _, build := range view.Builds {
+			fmt.Printf("  %s %-40s %-12s %s\n", getStatusIcon(build.State), build.Name, build.State, formatTimeAgo(build.UpdatedOn))
+		}
+	}
+
+	// Changed files
+	switch {
+	case view.FilesErr != nil:
+		fmt.Println("\nFiles:")
+		warn("diffstat", view.FilesErr)
+	case len(view.Files) == 0:
+		fmt.Println("\nFiles:")
+		fmt.Println("  No changes.")
+	default:
+		added, removed := 0, 0
+		for _, file := range view.Files {
+			added += file.LinesAdded
+			removed += file.LinesRemoved
+		}
+		fmt.Printf("\nFiles (%d changed, %s %s):\n", len(view.Files), greenColor(fmt.Sprintf("+%d", added)), redColor(fmt.Sprintf("-%d", removed)))
+		for _, file := range view.Files {
+			path := file.Path
+			if file.OldPath != "" {
+				path = fmt.Sprintf("%s → %s", file.OldPath, file.Path)
+			}
+			fmt.Printf("  %s %-60s %s %s\n", diffStatusLetter(file.Status), path,
+				greenColor(fmt.Sprintf("+%d", file.LinesAdded)), redColor(fmt.Sprintf("-%d", file.LinesRemoved)))
+		}
+	}
+
+	// Commits
+	switch {
+	case view.CommitsErr != nil:
+		fmt.Println("\nCommits:")
+		warn("commits", view.CommitsErr)
+	default:
+		fmt.Printf("\nCommits (%d):\n", len(view.Commits))
+		for i, commit := range view.Commits {
+			if i == prViewCommitLimit {
+				fmt.Printf("  ... and %d more\n", len(view.Commits)-prViewCommitLimit)
+				break
+			}
+			hash := commit.Hash
+			if len(hash) > 7 {
+				hash = hash[:7]
+			}
+			date := "N/A"
+			if commit.Date != nil {
+				date = formatTimeAgo(*commit.Date)
+			}
+			fmt.Printf("  %s %s (%s, %s)\n", yellowColor(hash), commit.Summary(), commit.Author, date)
+		}
+	}
+
+	fmt.Printf("\nOpen tasks: %d · Comments: %d\n", pr.TaskCount, pr.CommentCount)
+}
+
+// formatPRState colors a pull request state
+func formatPRState(state string) string {
+	switch strings.ToUpper(state) {
+	case "OPEN":
+		return color.New(color.FgGreen).Sprint(state)
+	case "MERGED":
+		return color.New(color.FgBlue).Sprint(state)
+	case "DECLINED":
+		return color.New(color.FgRed).Sprint(state)
+	}
+	return state
+}
+
+// diffStatusLetter abbreviates a diffstat status like git status --short
+func diffStatusLetter(status string) string {
+	switch status {
+	case "added":
+		return color.New(color.FgGreen).Sprint("A")
+	case "removed":
+		return color.New(color.FgRed).Sprint("D")
+	case "renamed":
+		return color.New(color.FgCyan).Sprint("R")
+	default:
+		return color.New(color.FgYellow).Sprint("M")
+	}
+}
+
+func init() {
+	prCmd.AddCommand(prViewCmd)
+}
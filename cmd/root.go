@@ -1,9 +1,14 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
 
+	"bitbucket.org/cover42/eiscli/internal/config"
 	"github.com/spf13/cobra"
 )
 
@@ -13,15 +18,50 @@ var rootCmd = &cobra.Command{
 	Long: `EIS CLI is a command-line tool for developers working with the EIS platform.
 It helps manage services, repositories, pipelines, and deployment configurations.`,
 	Version: "0.3.0",
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return validateOutputFormat()
+	},
 }
 
-// Execute runs the root command
+// Execute runs the root command with a context that is canceled on Ctrl-C or SIGTERM.
+// Commands stop at the next safe point; a second Ctrl-C terminates immediately.
 func Execute() error {
-	return rootCmd.Execute()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		select {
+		case <-signals:
+			// Restore default handling so that another signal kills the process
+			signal.Stop(signals)
+			fmt.Fprintln(os.Stderr, "\nInterrupted, stopping... (press Ctrl-C again to exit immediately)")
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	if err := rootCmd.ExecuteContext(ctx); err != nil {
+		return err
+	}
+	if commandFailed {
+		return errors.New("command failed")
+	}
+	return nil
 }
 
 func init() {
 	rootCmd.CompletionOptions.DisableDefaultCmd = true
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", outputTable, "Output format for read commands (table, json, yaml)")
+	rootCmd.PersistentFlags().String("api-url", "", "Bitbucket API base URL, e.g. of a local stand-in server (default "+config.DefaultAPIURL+")")
+	_ = config.BindFlag("bitbucket.api_url", rootCmd.PersistentFlags().Lookup("api-url"))
+	rootCmd.PersistentFlags().Bool("debug", false, "Log every API request (method, URL, status, latency, size) to stderr")
+	_ = config.BindFlag("debug.enabled", rootCmd.PersistentFlags().Lookup("debug"))
+	rootCmd.PersistentFlags().String("debug-dump", "", "Write full API request/response pairs to this directory, with secrets redacted")
+	_ = config.BindFlag("debug.dump_dir", rootCmd.PersistentFlags().Lookup("debug-dump"))
+	rootCmd.PersistentFlags().Bool("no-cache", false, "Fetch fresh repository and project listings instead of using the cache")
+	_ = config.BindFlag("cache.disabled", rootCmd.PersistentFlags().Lookup("no-cache"))
 }
 
 // ExecuteContext is used for testing
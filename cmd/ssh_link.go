@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"strings"
@@ -51,6 +52,8 @@ Examples:
   eiscli ssh-link --force`,
 	Args: cobra.MaximumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+
 		serviceName := ""
 		if len(args) > 0 {
 			serviceName = args[0]
@@ -60,7 +63,7 @@ Examples:
 		if serviceName == "" {
 			detectedSlug, err := git.DetectRepositorySlug()
 			if err != nil {
-				fmt.Println("Error: No service name provided and could not auto-detect from git repository")
+				errorf("Error: No service name provided and could not auto-detect from git repository\n")
 				fmt.Printf("  %v\n", err)
 				fmt.Println("\nUsage:")
 				fmt.Println("  1. Run this command from within a git repository, or")
@@ -71,8 +74,8 @@ Examples:
 			fmt.Printf("Auto-detected service from git repository: %s\n\n", serviceName)
 		}
 
-		if err := executeSSHLink(serviceName, sshLinkTarget, sshLinkForce, sshLinkDryRun); err != nil {
-			fmt.Printf("\nError: %v\n", err)
+		if err := executeSSHLink(ctx, serviceName, sshLinkTarget, sshLinkForce, sshLinkDryRun); err != nil {
+			errorf("\nError: %v\n", err)
 			os.Exit(1)
 		}
 	},
@@ -85,7 +88,7 @@ func init() {
 	sshLinkCmd.Flags().BoolVar(&sshLinkDryRun, "dry-run", false, "Show what would be done without making changes")
 }
 
-func executeSSHLink(serviceName, targetRepo string, force, dryRun bool) error {
+func executeSSHLink(ctx context.Context, serviceName, targetRepo string, force, dryRun bool) error {
 	greenColor := color.New(color.FgGreen).SprintFunc()
 	yellowColor := color.New(color.FgYellow).SprintFunc()
 	cyanColor := color.New(color.FgCyan).SprintFunc()
@@ -118,7 +121,7 @@ func executeSSHLink(serviceName, targetRepo string, force, dryRun bool) error {
 	// Step 1: Check if SSH key pair exists in service repo
 	fmt.Printf("\nChecking SSH key pair in %s...\n", serviceName)
 
-	existingKeyPair, err := client.GetPipelineSSHKeyPair(serviceName)
+	existingKeyPair, err := client.GetPipelineSSHKeyPair(ctx, serviceName)
 	if err != nil {
 		return fmt.Errorf("failed to check SSH key pair: %w", err)
 	}
@@ -155,7 +158,7 @@ func executeSSHLink(serviceName, targetRepo string, force, dryRun bool) error {
 		} else {
 			fmt.Printf("  Creating SSH key pair in %s...\n", serviceName)
 
-			createdKeyPair, err := client.CreatePipelineSSHKeyPair(serviceName, keyPair.PrivateKey, keyPair.PublicKey)
+			createdKeyPair, err := client.CreatePipelineSSHKeyPair(ctx, serviceName, keyPair.PrivateKey, keyPair.PublicKey)
 			if err != nil {
 				return fmt.Errorf("failed to create SSH key pair: %w", err)
 			}
@@ -170,12 +173,12 @@ func executeSSHLink(serviceName, targetRepo string, force, dryRun bool) error {
 
 	deployKeyLabel := fmt.Sprintf("%s-pipeline", serviceName)
 
-	existingDeployKey, err := client.FindDeployKeyByLabel(targetRepo, deployKeyLabel)
+	existingDeployKey, err := client.FindDeployKeyByLabel(ctx, targetRepo, deployKeyLabel)
 	if err != nil {
 		return fmt.Errorf("failed to check deploy keys: %w", err)
 	}
 
-	deployKeys, err := client.ListDeployKeys(targetRepo)
+	deployKeys, err := client.ListDeployKeys(ctx, targetRepo)
 	if err != nil {
 		return fmt.Errorf("failed to list deploy keys: %w", err)
 	}
@@ -204,7 +207,7 @@ func executeSSHLink(serviceName, targetRepo string, force, dryRun bool) error {
 	if dryRun {
 		fmt.Printf("  %s Would add deploy key with label '%s'\n", yellowColor("[DRY RUN]"), deployKeyLabel)
 	} else {
-		_, err := client.AddDeployKey(targetRepo, publicKey, deployKeyLabel)
+		_, err := client.AddDeployKey(ctx, targetRepo, publicKey, deployKeyLabel)
 		if err != nil {
 			return fmt.Errorf("failed to add deploy key: %w", err)
 		}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"bitbucket.org/cover42/eiscli/internal/aws"
 	"bitbucket.org/cover42/eiscli/internal/config"
@@ -19,6 +20,20 @@ var (
 	ecrAllRegions bool
 )
 
+// ecrRepositoryOutput describes an ECR repository for --output json/yaml
+type ecrRepositoryOutput struct {
+	Region        string     `json:"region" yaml:"region"`
+	Exists        bool       `json:"exists" yaml:"exists"`
+	Name          string     `json:"name" yaml:"name"`
+	URI           string     `json:"uri,omitempty" yaml:"uri,omitempty"`
+	ARN           string     `json:"arn,omitempty" yaml:"arn,omitempty"`
+	CreatedAt     *time.Time `json:"created_at,omitempty" yaml:"created_at,omitempty"`
+	ScanOnPush    bool       `json:"scan_on_push" yaml:"scan_on_push"`
+	TagMutability string     `json:"tag_mutability,omitempty" yaml:"tag_mutability,omitempty"`
+	ConsoleURL    string     `json:"console_url,omitempty" yaml:"console_url,omitempty"`
+	Error         string     `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
 var svcECRCmd = &cobra.Command{
 	Use:   "ecr [service-name]",
 	Short: "Manage ECR registry for a service",
@@ -27,15 +42,18 @@ var svcECRCmd = &cobra.Command{
 The command uses AWS_PROFILE environment variable or the default AWS profile from config.
 Repositories can be managed in different AWS regions (eu-central-1 or eu-central-2).
 
-If service-name is not provided, it will be auto-detected from the git repository.`,
+If service-name is not provided, it will be auto-detected from the git repository.
+
+Use --output json or --output yaml for machine-readable output. Combined with --create,
+a missing repository is created with the service name without prompting.`,
 	Args: cobra.MaximumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
-		ctx := context.Background()
+		ctx := cmd.Context()
 
 		// Load configuration
 		cfg, err := config.Load()
 		if err != nil {
-			fmt.Printf("Error loading configuration: %v\n", err)
+			errorf("Error loading configuration: %v\n", err)
 			return
 		}
 
@@ -45,6 +63,11 @@ If service-name is not provided, it will be auto-detected from the git repositor
 			return
 		}
 
+		if isMachineOutput() {
+			printECRStatus(ctx, cfg, serviceName)
+			return
+		}
+
 		if ecrAllRegions {
 			checkAllRegions(ctx, cfg, serviceName)
 		} else {
@@ -67,7 +90,7 @@ func checkSingleRegion(ctx context.Context, cfg *config.Config, serviceName, reg
 	// Create ECR client
 	ecrClient, err := aws.NewECRClient(ctx, profile, region)
 	if err != nil {
-		fmt.Printf("❌ Error creating ECR client: %v\n", err)
+		errorf("❌ Error creating ECR client: %v\n", err)
 		fmt.Println("\nMake sure:")
 		fmt.Printf("  1. AWS profile '%s' is configured in ~/.aws/config\n", profile)
 		fmt.Println("  2. You have valid AWS credentials")
@@ -78,7 +101,7 @@ func checkSingleRegion(ctx context.Context, cfg *config.Config, serviceName, reg
 	// Check if repository exists
 	exists, repo, err := ecrClient.RepositoryExists(ctx, serviceName)
 	if err != nil {
-		fmt.Printf("❌ Error checking repository: %v\n", err)
+		errorf("❌ Error checking repository: %v\n", err)
 		return
 	}
 
@@ -116,13 +139,13 @@ func checkAllRegions(ctx context.Context, cfg *config.Config, serviceName string
 
 		ecrClient, err := aws.NewECRClient(ctx, profile, reg.region)
 		if err != nil {
-			fmt.Printf("❌ Error: %v\n", err)
+			errorf("❌ Error: %v\n", err)
 			continue
 		}
 
 		exists, repo, err := ecrClient.RepositoryExists(ctx, serviceName)
 		if err != nil {
-			fmt.Printf("❌ Error: %v\n", err)
+			errorf("❌ Error: %v\n", err)
 			continue
 		}
 
@@ -139,6 +162,69 @@ func checkAllRegions(ctx context.Context, cfg *config.Config, serviceName string
 	}
 }
 
+// printECRStatus prints the ECR repository state as JSON or YAML
+func printECRStatus(ctx context.Context, cfg *config.Config, serviceName string) {
+	profile := os.Getenv("AWS_PROFILE")
+	if profile == "" {
+		profile = cfg.AWS.DefaultProfile
+	}
+
+	if !ecrAllRegions {
+		printOutput(collectECRRepositoryOutput(ctx, profile, serviceName, ecrRegion, ecrCreate))
+		return
+	}
+
+	results := make([]*ecrRepositoryOutput, 0, 2)
+	for _, region := range []string{"eu-central-1", "eu-central-2"} {
+		results = append(results, collectECRRepositoryOutput(ctx, profile, serviceName, region, false))
+	}
+	printOutput(results)
+}
+
+// collectECRRepositoryOutput looks up (and optionally creates) the repository in one region
+func collectECRRepositoryOutput(ctx context.Context, profile, serviceName, region string, create bool) *ecrRepositoryOutput {
+	result := &ecrRepositoryOutput{Region: region, Name: serviceName}
+
+	ecrClient, err := aws.NewECRClient(ctx, profile, region)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	exists, repo, err := ecrClient.RepositoryExists(ctx, serviceName)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	if !exists && create {
+		repo, err = ecrClient.CreateRepository(ctx, serviceName)
+		if err != nil {
+			result.Error = err.Error()
+			return result
+		}
+		exists = true
+	}
+
+	result.Exists = exists
+	if exists && repo != nil {
+		if repo.RepositoryUri != nil {
+			result.URI = *repo.RepositoryUri
+		}
+		if repo.RepositoryArn != nil {
+			result.ARN = *repo.RepositoryArn
+		}
+		result.CreatedAt = repo.CreatedAt
+		if repo.ImageScanningConfiguration != nil {
+			result.ScanOnPush = repo.ImageScanningConfiguration.ScanOnPush
+		}
+		result.TagMutability = string(repo.ImageTagMutability)
+		result.ConsoleURL = ecrClient.GetConsoleURL(serviceName)
+	}
+
+	return result
+}
+
 func displayRepositoryInfo(client *aws.ECRClient, repo *types.Repository) {
 	fmt.Printf("✅ ECR repository exists!\n\n")
 	fmt.Printf("Repository Details:\n")
@@ -173,7 +259,7 @@ func createRepository(ctx context.Context, client *aws.ECRClient, defaultName st
 	reader := bufio.NewReader(os.Stdin)
 	input, err := reader.ReadString('\n')
 	if err != nil {
-		fmt.Printf("❌ Error reading input: %v\n", err)
+		errorf("❌ Error reading input: %v\n", err)
 		return
 	}
 
@@ -186,7 +272,7 @@ func createRepository(ctx context.Context, client *aws.ECRClient, defaultName st
 
 	repo, err := client.CreateRepository(ctx, repoName)
 	if err != nil {
-		fmt.Printf("❌ Error creating repository: %v\n", err)
+		errorf("❌ Error creating repository: %v\n", err)
 		return
 	}
 
@@ -38,12 +38,12 @@ The command will fail if the ECR repository doesn't exist. Use 'eiscli ecr --cre
 If service-name is not provided, it will be auto-detected from the git repository.`,
 	Args: cobra.MaximumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
-		ctx := context.Background()
+		ctx := cmd.Context()
 
 		// Load configuration
 		cfg, err := config.Load()
 		if err != nil {
-			fmt.Printf("❌ Error loading configuration: %v\n", err)
+			errorf("❌ Error loading configuration: %v\n", err)
 			return
 		}
 
@@ -56,20 +56,20 @@ If service-name is not provided, it will be auto-detected from the git repositor
 		if updateWorkspaceVarAllRegions {
 			// Update both regions
 			if err := updateWorkspaceVariableForRegion(ctx, cfg, serviceName, "eu-central-1"); err != nil {
-				fmt.Printf("❌ Failed for eu-central-1: %v\n", err)
+				errorf("❌ Failed for eu-central-1: %v\n", err)
 				return
 			}
 
 			fmt.Println()
 
 			if err := updateWorkspaceVariableForRegion(ctx, cfg, serviceName, "eu-central-2"); err != nil {
-				fmt.Printf("❌ Failed for eu-central-2: %v\n", err)
+				errorf("❌ Failed for eu-central-2: %v\n", err)
 				return
 			}
 		} else {
 			// Update single region
 			if err := updateWorkspaceVariableForRegion(ctx, cfg, serviceName, updateWorkspaceVarRegion); err != nil {
-				fmt.Printf("❌ Failed: %v\n", err)
+				errorf("❌ Failed: %v\n", err)
 				return
 			}
 		}
@@ -86,7 +86,7 @@ func getServiceNameForWorkspaceVar(args []string) string {
 	// Auto-detect from git repository
 	detectedSlug, err := git.DetectRepositorySlug()
 	if err != nil {
-		fmt.Println("❌ Error: No service name provided and could not auto-detect from git repository")
+		errorf("❌ Error: No service name provided and could not auto-detect from git repository\n")
 		fmt.Printf("  %v\n", err)
 		fmt.Println("\nUsage:")
 		fmt.Println("  1. Run this command from within a git repository, or")
@@ -149,7 +149,7 @@ func updateWorkspaceVariableForRegion(ctx context.Context, cfg *config.Config, s
 	fmt.Printf("🔄 Updating workspace variable: %s\n", variableName)
 
 	// Create or update the variable (not secured as it's a public ECR URI)
-	wasUpdated, oldValue, err := bbClient.CreateOrUpdateWorkspaceVariable(variableName, ecrURI, false)
+	wasUpdated, oldValue, err := bbClient.CreateOrUpdateWorkspaceVariable(ctx, variableName, ecrURI, false)
 	if err != nil {
 		return fmt.Errorf("failed to create/update workspace variable: %w", err)
 	}
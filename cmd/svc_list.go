@@ -3,46 +3,39 @@ package cmd
 import (
 	"fmt"
 
-	"bitbucket.org/cover42/eiscli/internal/bitbucket"
-	"bitbucket.org/cover42/eiscli/internal/config"
 	"github.com/spf13/cobra"
 )
 
 var svcListCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List all repositories in the workspace",
-	Long:  `Display all repositories available in the configured Bitbucket workspace.`,
+	Long: `Display all repositories available in the configured Bitbucket workspace.
+
+Use --output json or --output yaml for machine-readable output.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		// Load configuration
-		cfg, err := config.Load()
-		if err != nil {
-			fmt.Printf("Error loading configuration: %v\n", err)
-			fmt.Println("\nPlease set the following environment variables:")
-			fmt.Println("  EISCLI_BITBUCKET_USERNAME")
-			fmt.Println("  EISCLI_BITBUCKET_APP_PASSWORD")
-			fmt.Println("  EISCLI_BITBUCKET_WORKSPACE")
-			return
-		}
+		ctx := cmd.Context()
 
-		// Validate configuration
-		if err := cfg.Validate(); err != nil {
-			fmt.Printf("Configuration error: %v\n", err)
+		cfg, ok := loadConfig()
+		if !ok {
 			return
 		}
 
-		// Create Bitbucket client
-		client, err := bitbucket.NewClient(cfg)
-		if err != nil {
-			fmt.Printf("Error creating Bitbucket client: %v\n", err)
+		client, ok := newBitbucketClient()
+		if !ok {
 			return
 		}
 
-		fmt.Printf("Fetching repositories from workspace: %s\n\n", cfg.Bitbucket.Workspace)
+		infof("Fetching repositories from workspace: %s\n\n", cfg.Bitbucket.Workspace)
 
 		// List repositories
-		repos, err := client.ListRepositories()
+		repos, err := client.ListRepositories(ctx)
 		if err != nil {
-			fmt.Printf("Error fetching repositories: %v\n", err)
+			errorf("Error fetching repositories: %v\n", err)
+			return
+		}
+
+		if isMachineOutput() {
+			printOutput(repos)
 			return
 		}
 
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"os"
 	"os/exec"
@@ -29,6 +30,8 @@ This command will clone the baseapiv2 template, initialize a new git repository,
 and optionally create a Bitbucket repository with proper permissions.`,
 	Args: cobra.MaximumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+
 		serviceName := ""
 		if len(args) > 0 {
 			serviceName = args[0]
@@ -40,8 +43,8 @@ and optionally create a Bitbucket repository with proper permissions.`,
 			os.Exit(1)
 		}
 
-		if err := createNewService(serviceName); err != nil {
-			fmt.Printf("Error: %v\n", err)
+		if err := createNewService(ctx, serviceName); err != nil {
+			errorf("Error: %v\n", err)
 			os.Exit(1)
 		}
 	},
@@ -51,7 +54,7 @@ func init() {
 	svcCmd.AddCommand(svcNewCmd)
 }
 
-func createNewService(serviceName string) error {
+func createNewService(ctx context.Context, serviceName string) error {
 	// Get current working directory
 	cwd, err := os.Getwd()
 	if err != nil {
@@ -93,7 +96,7 @@ func createNewService(serviceName string) error {
 
 	response = strings.TrimSpace(strings.ToLower(response))
 	if response == "y" || response == "yes" {
-		if err := createBitbucketRepository(serviceName, destDir); err != nil {
+		if err := createBitbucketRepository(ctx, serviceName, destDir); err != nil {
 			return fmt.Errorf("failed to create Bitbucket repository: %w", err)
 		}
 	} else {
@@ -119,7 +122,7 @@ func createNewService(serviceName string) error {
 	// Default to "yes" if user just presses Enter (empty response)
 	if sshResponse == "" || sshResponse == "y" || sshResponse == "yes" {
 		fmt.Printf("\n")
-		if err := executeSSHLink(serviceName, "protorepo", false, false); err != nil {
+		if err := executeSSHLink(ctx, serviceName, "protorepo", false, false); err != nil {
 			fmt.Printf("\nWarning: Failed to link SSH key: %v\n", err)
 			fmt.Printf("You can run 'eiscli ssh-link %s' later to set up SSH access.\n", serviceName)
 		}
@@ -166,7 +169,7 @@ func reinitializeGitRepo(repoDir string) error {
 	return nil
 }
 
-func createBitbucketRepository(serviceName, repoDir string) error {
+func createBitbucketRepository(ctx context.Context, serviceName, repoDir string) error {
 	// Load configuration
 	cfg, err := config.Load()
 	if err != nil {
@@ -180,7 +183,7 @@ func createBitbucketRepository(serviceName, repoDir string) error {
 	}
 
 	// Select project interactively
-	selectedProject, err := selectProject(client, defaultProject)
+	selectedProject, err := selectProject(ctx, client, defaultProject)
 	if err != nil {
 		return fmt.Errorf("failed to select project: %w", err)
 	}
@@ -196,7 +199,7 @@ func createBitbucketRepository(serviceName, repoDir string) error {
 	fmt.Printf("\nCreating Bitbucket repository...\n")
 
 	// Create the repository
-	repo, err := client.CreateRepository(serviceName, projectKey, true)
+	repo, err := client.CreateRepository(ctx, serviceName, projectKey, true)
 	if err != nil {
 		return fmt.Errorf("failed to create repository: %w", err)
 	}
@@ -205,7 +208,7 @@ func createBitbucketRepository(serviceName, repoDir string) error {
 
 	// Add remote origin first (needed for pushing)
 	fmt.Printf("Adding remote origin...\n")
-	remoteURL := fmt.Sprintf("https://bitbucket.org/%s/%s.git", cfg.Bitbucket.Workspace, serviceName)
+	remoteURL := bitbucket.BuildRepositoryURL(cfg.Bitbucket.WebURL, cfg.Bitbucket.Workspace, serviceName) + ".git"
 	cmd := exec.Command("git", "remote", "add", "origin", remoteURL)
 	cmd.Dir = repoDir
 	cmd.Stdout = os.Stdout
@@ -261,14 +264,14 @@ func createBitbucketRepository(serviceName, repoDir string) error {
 		fmt.Printf("\nSetting repository permissions...\n")
 
 		// Set DevelopmentMergeAndWriteAccess group permissions
-		if err := client.SetRepositoryPermissions(serviceName, "DevelopmentMergeAndWriteAccess", "write"); err != nil {
+		if err := client.SetRepositoryPermissions(ctx, serviceName, "DevelopmentMergeAndWriteAccess", "write"); err != nil {
 			fmt.Printf("Warning: Failed to set permissions for DevelopmentMergeAndWriteAccess: %v\n", err)
 		} else {
 			fmt.Printf("✓ Set permissions for DevelopmentMergeAndWriteAccess\n")
 		}
 
 		// Set ProductionMergeAndWriteAccess group permissions
-		if err := client.SetRepositoryPermissions(serviceName, "ProductionMergeAndWriteAccess", "write"); err != nil {
+		if err := client.SetRepositoryPermissions(ctx, serviceName, "ProductionMergeAndWriteAccess", "write"); err != nil {
 			fmt.Printf("Warning: Failed to set permissions for ProductionMergeAndWriteAccess: %v\n", err)
 		} else {
 			fmt.Printf("✓ Set permissions for ProductionMergeAndWriteAccess\n")
@@ -277,7 +280,7 @@ func createBitbucketRepository(serviceName, repoDir string) error {
 
 	// Set default branch to master (only after branch exists)
 	fmt.Printf("Setting default branch to master...\n")
-	if err := client.SetRepositoryDefaultBranch(serviceName, "master"); err != nil {
+	if err := client.SetRepositoryDefaultBranch(ctx, serviceName, "master"); err != nil {
 		fmt.Printf("Warning: Failed to set default branch: %v\n", err)
 		fmt.Printf("  You may need to set it manually in Bitbucket repository settings.\n")
 	} else {
@@ -289,10 +292,10 @@ func createBitbucketRepository(serviceName, repoDir string) error {
 
 // selectProject allows the user to interactively select a project from the workspace
 // Returns the selected project or nil if user chooses to skip project assignment
-func selectProject(client *bitbucket.Client, defaultProjectName string) (*bitbucket.Project, error) {
+func selectProject(ctx context.Context, client *bitbucket.Client, defaultProjectName string) (*bitbucket.Project, error) {
 	fmt.Printf("\nFetching projects from workspace...\n")
 
-	projects, err := client.ListProjects()
+	projects, err := client.ListProjects(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list projects: %w", err)
 	}
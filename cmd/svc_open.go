@@ -31,11 +31,11 @@ Subcommands:
 
 		cfg, err := config.Load()
 		if err != nil {
-			fmt.Printf("Error: Failed to load configuration: %v\n", err)
+			errorf("Error: Failed to load configuration: %v\n", err)
 			return
 		}
 
-		url := bitbucket.BuildRepositoryURL(cfg.Bitbucket.Workspace, serviceName)
+		url := bitbucket.BuildRepositoryURL(cfg.Bitbucket.WebURL, cfg.Bitbucket.Workspace, serviceName)
 		openURL(url, serviceName, "repository")
 	},
 }
@@ -56,11 +56,11 @@ in the current directory.`,
 
 		cfg, err := config.Load()
 		if err != nil {
-			fmt.Printf("Error: Failed to load configuration: %v\n", err)
+			errorf("Error: Failed to load configuration: %v\n", err)
 			return
 		}
 
-		url := bitbucket.BuildPipelinesURL(cfg.Bitbucket.Workspace, serviceName)
+		url := bitbucket.BuildPipelinesURL(cfg.Bitbucket.WebURL, cfg.Bitbucket.Workspace, serviceName)
 		openURL(url, serviceName, "pipelines")
 	},
 }
@@ -81,11 +81,11 @@ in the current directory.`,
 
 		cfg, err := config.Load()
 		if err != nil {
-			fmt.Printf("Error: Failed to load configuration: %v\n", err)
+			errorf("Error: Failed to load configuration: %v\n", err)
 			return
 		}
 
-		url := bitbucket.BuildPullRequestsURL(cfg.Bitbucket.Workspace, serviceName)
+		url := bitbucket.BuildPullRequestsURL(cfg.Bitbucket.WebURL, cfg.Bitbucket.Workspace, serviceName)
 		openURL(url, serviceName, "pull requests")
 	},
 }
@@ -110,17 +110,17 @@ in the current directory.`,
 
 		cfg, err := config.Load()
 		if err != nil {
-			fmt.Printf("Error: Failed to load configuration: %v\n", err)
+			errorf("Error: Failed to load configuration: %v\n", err)
 			return
 		}
 
 		var url string
 		var pageType string
 		if openVarsType == "repository" {
-			url = bitbucket.BuildRepositoryVariablesURL(cfg.Bitbucket.Workspace, serviceName)
+			url = bitbucket.BuildRepositoryVariablesURL(cfg.Bitbucket.WebURL, cfg.Bitbucket.Workspace, serviceName)
 			pageType = "repository variables"
 		} else {
-			url = bitbucket.BuildDeploymentVariablesURL(cfg.Bitbucket.Workspace, serviceName)
+			url = bitbucket.BuildDeploymentVariablesURL(cfg.Bitbucket.WebURL, cfg.Bitbucket.Workspace, serviceName)
 			pageType = "deployment variables"
 		}
 		openURL(url, serviceName, pageType)
@@ -143,11 +143,11 @@ in the current directory.`,
 
 		cfg, err := config.Load()
 		if err != nil {
-			fmt.Printf("Error: Failed to load configuration: %v\n", err)
+			errorf("Error: Failed to load configuration: %v\n", err)
 			return
 		}
 
-		url := bitbucket.BuildSettingsURL(cfg.Bitbucket.Workspace, serviceName)
+		url := bitbucket.BuildSettingsURL(cfg.Bitbucket.WebURL, cfg.Bitbucket.Workspace, serviceName)
 		openURL(url, serviceName, "settings")
 	},
 }
@@ -1,13 +1,14 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"time"
 
 	"bitbucket.org/cover42/eiscli/internal/bitbucket"
-	"bitbucket.org/cover42/eiscli/internal/config"
 	"bitbucket.org/cover42/eiscli/internal/git"
+	"github.com/fatih/color"
 	"github.com/spf13/cobra"
 )
 
@@ -15,6 +16,13 @@ var (
 	pipelineLimit    int
 	pipelineShowLog  bool
 	pipelineLogLines int
+	pipelineBranch   string
+	pipelineState    string
+	pipelineTrigger  string
+	pipelineCreator  string
+	pipelineSince    string
+
+	pipelineRerunFailedOnly bool
 )
 
 var pipelinesCmd = &cobra.Command{
@@ -26,9 +34,19 @@ Shows build status, duration, commit information, clickable Bitbucket links, and
 If service-name is not provided, it will be auto-detected from the git repository
 in the current directory (based on the git remote URL).
 
-Use the --logs flag to see detailed pipeline steps and their status.`,
+Use the --logs flag to see detailed pipeline steps and their status.
+Use --output json or --output yaml for machine-readable output.
+
+Filters:
+  --branch master       Only pipelines that ran for a branch
+  --state FAILED        Only pipelines with a status (e.g. SUCCESSFUL, FAILED, STOPPED, IN_PROGRESS)
+  --trigger schedule    Only pipelines started by a trigger (push, manual, schedule)
+  --creator @me         Only pipelines started by you (or a user UUID)
+  --since 7d            Only pipelines created in the last 12h, 7d, 2w, ... or since a date (YYYY-MM-DD)`,
 	Args: cobra.MaximumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+
 		serviceName := ""
 		if len(args) > 0 {
 			serviceName = args[0]
@@ -38,7 +56,7 @@ Use the --logs flag to see detailed pipeline steps and their status.`,
 		if serviceName == "" {
 			detectedSlug, err := git.DetectRepositorySlug()
 			if err != nil {
-				fmt.Println("Error: No service name provided and could not auto-detect from git repository")
+				errorf("Error: No service name provided and could not auto-detect from git repository\n")
 				fmt.Printf("  %v\n", err)
 				fmt.Println("\nUsage:")
 				fmt.Println("  1. Run this command from within a git repository, or")
@@ -46,52 +64,38 @@ Use the --logs flag to see detailed pipeline steps and their status.`,
 				return
 			}
 			serviceName = detectedSlug
-			fmt.Printf("Auto-detected service from git repository: %s\n", serviceName)
+			infof("Auto-detected service from git repository: %s\n", serviceName)
 		}
 
-		// Load configuration
-		cfg, err := config.Load()
-		if err != nil {
-			fmt.Printf("Error loading configuration: %v\n", err)
-			fmt.Println("\nPlease set the following environment variables:")
-			fmt.Println("  EISCLI_BITBUCKET_USERNAME")
-			fmt.Println("  EISCLI_BITBUCKET_APP_PASSWORD")
-			fmt.Println("  EISCLI_BITBUCKET_WORKSPACE")
+		client, ok := newBitbucketClient()
+		if !ok {
 			return
 		}
 
-		// Validate configuration
-		if err := cfg.Validate(); err != nil {
-			fmt.Printf("Configuration error: %v\n", err)
-			return
-		}
-
-		// Create Bitbucket client
-		client, err := bitbucket.NewClient(cfg)
+		opts, err := buildPipelinesOptions(ctx, client, serviceName)
 		if err != nil {
-			fmt.Printf("Error creating Bitbucket client: %v\n", err)
+			errorf("Error: %v\n", err)
 			return
 		}
 
-		fmt.Printf("Fetching pipeline builds for service: %s (last %d builds)\n", serviceName, pipelineLimit)
+		infof("Fetching pipeline builds for service: %s (last %d builds)\n", serviceName, pipelineLimit)
 
 		// Fetch pipelines (with or without steps/logs)
 		var pipelines []*bitbucket.Pipeline
 		if pipelineShowLog {
-			fmt.Println("Fetching pipeline steps and logs...")
-			pipelines, err = client.ListPipelinesWithSteps(&bitbucket.PipelinesOptions{
-				RepoSlug: serviceName,
-				Limit:    pipelineLimit,
-			}, pipelineLogLines)
+			infof("Fetching pipeline steps and logs...\n")
+			pipelines, err = client.ListPipelinesWithSteps(ctx, opts, pipelineLogLines)
 		} else {
-			pipelines, err = client.ListPipelines(&bitbucket.PipelinesOptions{
-				RepoSlug: serviceName,
-				Limit:    pipelineLimit,
-			})
+			pipelines, err = client.ListPipelines(ctx, opts)
 		}
 
 		if err != nil {
-			fmt.Printf("\nError fetching pipelines: %v\n", err)
+			errorf("\nError fetching pipelines: %v\n", err)
+			return
+		}
+
+		if isMachineOutput() {
+			printOutput(pipelines)
 			return
 		}
 
@@ -112,6 +116,173 @@ Use the --logs flag to see detailed pipeline steps and their status.`,
 	},
 }
 
+// buildPipelinesOptions creates the list options from the filter flags
+func buildPipelinesOptions(ctx context.Context, client *bitbucket.Client, serviceName string) (*bitbucket.PipelinesOptions, error) {
+	opts := &bitbucket.PipelinesOptions{
+		RepoSlug: serviceName,
+		Limit:    pipelineLimit,
+		Branch:   pipelineBranch,
+		Status:   strings.ToUpper(pipelineState),
+		Trigger:  strings.ToUpper(pipelineTrigger),
+	}
+
+	if pipelineSince != "" {
+		since, err := parseSince(pipelineSince, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		opts.Since = since
+	}
+
+	switch {
+	case pipelineCreator == "":
+	case pipelineCreator == "@me":
+		user, err := client.GetCurrentUser(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get current user for --creator @me: %w", err)
+		}
+		opts.CreatorUUID = user.UUID
+	case strings.HasPrefix(pipelineCreator, "{") && strings.HasSuffix(pipelineCreator, "}"):
+		opts.CreatorUUID = pipelineCreator
+	default:
+		return nil, fmt.Errorf("invalid --creator value %q (use @me or a user UUID in braces)", pipelineCreator)
+	}
+
+	return opts, nil
+}
+
+var pipelineStopCmd = &cobra.Command{
+	Use:   "stop [service-name] <build-number>",
+	Short: "Stop a running pipeline",
+	Long: `Stop a running or pending pipeline.
+
+If service-name is not provided, it will be auto-detected from the git repository
+in the current directory.
+
+Examples:
+  # Stop build #123 of the current repository
+  eiscli pipelines stop 123
+
+  # Stop build #123 of another service
+  eiscli pipelines stop my-service 123`,
+	Args: cobra.RangeArgs(1, 2),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+
+		serviceName, buildNumber, ok := resolveServiceAndBuild(args)
+		if !ok {
+			return
+		}
+
+		client, ok := newBitbucketClient()
+		if !ok {
+			return
+		}
+
+		infof("Stopping pipeline #%d of %s...\n", buildNumber, serviceName)
+
+		pipeline, err := client.StopPipeline(ctx, serviceName, buildNumber)
+		if err != nil {
+			errorf("Error: Failed to stop pipeline: %v\n", err)
+			return
+		}
+
+		if isMachineOutput() {
+			printOutput(pipeline)
+			return
+		}
+
+		greenColor := color.New(color.FgGreen).SprintFunc()
+		fmt.Printf("%s Stop requested for pipeline #%d\n", greenColor("✓"), pipeline.BuildNumber)
+		if pipeline.WebURL != "" {
+			fmt.Printf("  URL: %s\n", pipeline.WebURL)
+		}
+	},
+}
+
+var pipelineRerunCmd = &cobra.Command{
+	Use:   "rerun [service-name] <build-number>",
+	Short: "Rerun a pipeline",
+	Long: `Rerun a pipeline.
+
+A new pipeline is started for the same branch or tag, commit and custom
+pipeline as the given build. Variables passed to custom pipelines are not
+carried over; use 'eiscli pipelines run --custom ... --var ...' for those.
+Pull request pipelines cannot be rerun this way; rerun them from the pull
+request in Bitbucket.
+
+--failed-steps-only is not supported: the Bitbucket API can only start a new
+pipeline, so rerunning just the failed steps of a pipeline is only possible in
+the Bitbucket web interface.
+
+If service-name is not provided, it will be auto-detected from the git repository
+in the current directory.
+
+Examples:
+  # Start a new run of build #123
+  eiscli pipelines rerun 123`,
+	Args: cobra.RangeArgs(1, 2),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+
+		if pipelineRerunFailedOnly {
+			errorf("Error: --failed-steps-only is not supported by the Bitbucket API; rerun the failed steps in the Bitbucket web interface, or omit the flag to start a new pipeline\n")
+			return
+		}
+
+		serviceName, buildNumber, ok := resolveServiceAndBuild(args)
+		if !ok {
+			return
+		}
+
+		client, ok := newBitbucketClient()
+		if !ok {
+			return
+		}
+
+		infof("Rerunning pipeline #%d of %s...\n", buildNumber, serviceName)
+
+		pipeline, err := client.RerunPipeline(ctx, serviceName, buildNumber)
+		if err != nil {
+			errorf("Error: Failed to rerun pipeline: %v\n", err)
+			return
+		}
+
+		if isMachineOutput() {
+			printOutput(pipeline)
+			return
+		}
+
+		greenColor := color.New(color.FgGreen).SprintFunc()
+		fmt.Printf("%s Pipeline #%d started (rerun of #%d)\n", greenColor("✓"), pipeline.BuildNumber, buildNumber)
+		if pipeline.WebURL != "" {
+			fmt.Printf("  URL: %s\n", pipeline.WebURL)
+		}
+		fmt.Printf("\nFollow it with: eiscli pipelines watch %s %d\n", serviceName, pipeline.BuildNumber)
+	},
+}
+
+// resolveServiceAndBuild parses "[service-name] <build-number>" arguments,
+// auto-detecting the service name when omitted. Errors are printed.
+func resolveServiceAndBuild(args []string) (string, int, bool) {
+	serviceArgs, buildNumber, err := parseServiceAndNumberArgs(args, "build number")
+	if err != nil {
+		errorf("Error: %v\n", err)
+		return "", 0, false
+	}
+	if buildNumber <= 0 {
+		errorf("Error: A build number is required\n")
+		return "", 0, false
+	}
+
+	serviceName := getServiceName(serviceArgs)
+	if serviceName == "" {
+		return "", 0, false
+	}
+
+	return serviceName, buildNumber, true
+}
+
 func displayPipeline(p *bitbucket.Pipeline, index int) {
 	// Status icon
 	statusIcon := getStatusIcon(p.State.Name)
@@ -130,9 +301,7 @@ func displayPipeline(p *bitbucket.Pipeline, index int) {
 	if p.Target.Commit != nil {
 		commitHash = p.Target.Commit.Hash[:7]                       // Short hash
 		commitMsg = strings.Split(p.Target.Commit.Message, "\n")[0] // First line only
-		if len(commitMsg) > 60 {
-			commitMsg = commitMsg[:57] + "..."
-		}
+		commitMsg = truncateValue(commitMsg, 60)
 	}
 
 	// Display pipeline info
@@ -178,6 +347,14 @@ func displayPipeline(p *bitbucket.Pipeline, index int) {
 	}
 }
 
+// pipelineStatus returns the result of a finished pipeline or its current state
+func pipelineStatus(p *bitbucket.Pipeline) string {
+	if p.State.Result != nil && p.State.Result.Name != "" {
+		return p.State.Result.Name
+	}
+	return p.State.Name
+}
+
 func getStatusIcon(status string) string {
 	switch strings.ToUpper(status) {
 	case "COMPLETED":
@@ -190,7 +367,7 @@ func getStatusIcon(status string) string {
 		return "✗"
 	case "STOPPED":
 		return "■"
-	case "IN_PROGRESS":
+	case "IN_PROGRESS", "INPROGRESS":
 		return "●"
 	case "PENDING":
 		return "○"
@@ -224,4 +401,13 @@ func init() {
 	pipelinesCmd.Flags().IntVarP(&pipelineLimit, "limit", "l", 5, "Number of pipeline builds to display")
 	pipelinesCmd.Flags().BoolVarP(&pipelineShowLog, "logs", "s", false, "Show pipeline steps and log snippets")
 	pipelinesCmd.Flags().IntVar(&pipelineLogLines, "log-lines", 25, "Number of log lines to display per step")
+	pipelinesCmd.Flags().StringVar(&pipelineBranch, "branch", "", "Only show pipelines for this branch")
+	pipelinesCmd.Flags().StringVar(&pipelineState, "state", "", "Only show pipelines with this status (e.g. FAILED, SUCCESSFUL, IN_PROGRESS)")
+	pipelinesCmd.Flags().StringVar(&pipelineTrigger, "trigger", "", "Only show pipelines started by this trigger (push, manual, schedule)")
+	pipelinesCmd.Flags().StringVar(&pipelineCreator, "creator", "", "Only show pipelines started by this user (@me or a user UUID)")
+	pipelinesCmd.Flags().StringVar(&pipelineSince, "since", "", "Only show pipelines created since a duration (12h, 7d, 2w) or date (YYYY-MM-DD)")
+
+	pipelinesCmd.AddCommand(pipelineStopCmd)
+	pipelinesCmd.AddCommand(pipelineRerunCmd)
+	pipelineRerunCmd.Flags().BoolVar(&pipelineRerunFailedOnly, "failed-steps-only", false, "Rerun only the failed steps (not supported by the Bitbucket API)")
 }
@@ -0,0 +1,250 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"bitbucket.org/cover42/eiscli/internal/bitbucket"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+var (
+	pipelineLogsStep string
+	pipelineLogsOut  string
+	pipelineLogsGrep string
+)
+
+// stepLogSource identifies one log of a step: the build container or a service container
+type stepLogSource struct {
+	index   int
+	step    *bitbucket.PipelineStep
+	service *bitbucket.PipelineStepService
+}
+
+// label returns a human-readable name for the log
+func (s stepLogSource) label() string {
+	if s.service != nil {
+		return fmt.Sprintf("%s (service: %s)", s.step.Name, s.service.Name)
+	}
+	return s.step.Name
+}
+
+// fileName returns the file name the log is saved as with --out
+func (s stepLogSource) fileName() string {
+	name := fmt.Sprintf("%02d-%s", s.index, sanitizeFileName(s.step.Name, "step"))
+	if s.service != nil {
+		name += "-" + sanitizeFileName(s.service.Name, "service")
+	}
+	return name + ".log"
+}
+
+var pipelineLogsCmd = &cobra.Command{
+	Use:   "logs [service-name] <build-number>",
+	Short: "Download the full logs of a pipeline",
+	Long: `Download the complete logs of every step of a pipeline, including the logs
+of service containers (e.g. docker or databases) that ran alongside a step.
+
+Without --out the logs are printed to stdout. With --out each log is written to
+its own file in the given directory. Use --grep to search all logs of the build
+for a regular expression; matching lines are printed with their step name.
+
+If service-name is not provided, it will be auto-detected from the git repository
+in the current directory.
+
+Examples:
+  # Print all logs of build #123
+  eiscli pipelines logs 123
+
+  # Save the logs of the "Build and test" step to ./logs
+  eiscli pipelines logs 123 --step "Build and test" --out ./logs
+
+  # Search all step logs for errors
+  eiscli pipelines logs 123 --grep "(?i)error"`,
+	Args: cobra.RangeArgs(1, 2),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+
+		serviceName, buildNumber, ok := resolveServiceAndBuild(args)
+		if !ok {
+			return
+		}
+
+		var pattern *regexp.Regexp
+		if pipelineLogsGrep != "" {
+			var err error
+			pattern, err = regexp.Compile(pipelineLogsGrep)
+			if err != nil {
+				errorf("Error: Invalid --grep pattern: %v\n", err)
+				return
+			}
+		}
+
+		client, ok := newBitbucketClient()
+		if !ok {
+			return
+		}
+
+		pipeline, err := client.GetPipelineByBuildNumber(ctx, serviceName, buildNumber)
+		if err != nil {
+			errorf("Error: %v\n", err)
+			return
+		}
+
+		steps, err := client.GetPipelineSteps(ctx, serviceName, pipeline.UUID)
+		if err != nil {
+			errorf("Error fetching pipeline steps: %v\n", err)
+			return
+		}
+
+		sources, err := collectStepLogSources(steps, pipelineLogsStep)
+		if err != nil {
+			errorf("Error: %v\n", err)
+			return
+		}
+
+		if pipelineLogsOut != "" {
+			if err := os.MkdirAll(pipelineLogsOut, 0755); err != nil {
+				errorf("Error creating output directory: %v\n", err)
+				return
+			}
+		}
+
+		greenColor := color.New(color.FgGreen).SprintFunc()
+		cyanColor := color.New(color.FgCyan).SprintFunc()
+
+		failed := 0
+		matches := 0
+		for _, source := range sources {
+			logUUID := source.step.UUID
+			if source.service != nil {
+				logUUID = source.service.UUID
+			}
+
+			data, err := client.GetFullStepLog(ctx, serviceName, pipeline.UUID, source.step.UUID, logUUID)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error fetching log for %s: %v\n", source.label(), err)
+				failed++
+				continue
+			}
+
+			if pipelineLogsOut != "" {
+				path := filepath.Join(pipelineLogsOut, source.fileName())
+				if err := os.WriteFile(path, data, 0644); err != nil {
+					fmt.Fprintf(os.Stderr, "Error writing %s: %v\n", path, err)
+					failed++
+					continue
+				}
+				if pattern == nil {
+					fmt.Printf("%s %s → %s (%d bytes)\n", greenColor("✓"), source.label(), path, len(data))
+				}
+			}
+
+			switch {
+			case pattern != nil:
+				matches += grepStepLog(data, pattern, cyanColor(source.label()))
+			case pipelineLogsOut == "":
+				fmt.Printf("%s\n", cyanColor(fmt.Sprintf("==> %s <==", source.label())))
+				_, _ = os.Stdout.Write(data)
+				if len(data) > 0 && data[len(data)-1] != '\n' {
+					fmt.Println()
+				}
+				fmt.Println()
+			}
+		}
+
+		if pattern != nil {
+			fmt.Fprintf(os.Stderr, "\n%d matching line(s) in %d log(s) of pipeline #%d\n", matches, len(sources)-failed, pipeline.BuildNumber)
+		}
+
+		if failed > 0 {
+			fmt.Fprintf(os.Stderr, "\nFailed to download %d of %d log(s)\n", failed, len(sources))
+			os.Exit(1)
+		}
+	},
+}
+
+// collectStepLogSources lists the build and service container logs of the given steps,
+// optionally limited to the step with the given name (case-insensitive)
+func collectStepLogSources(steps []*bitbucket.PipelineStep, stepName string) ([]stepLogSource, error) {
+	var sources []stepLogSource
+	var names []string
+
+	for i, step := range steps {
+		names = append(names, step.Name)
+		if stepName != "" && !strings.EqualFold(step.Name, stepName) {
+			continue
+		}
+		// Steps that never started have no logs
+		if step.State == "PENDING" || step.Result == "NOT_RUN" {
+			continue
+		}
+
+		sources = append(sources, stepLogSource{index: i + 1, step: step})
+		for _, service := range step.Services {
+			sources = append(sources, stepLogSource{index: i + 1, step: step, service: service})
+		}
+	}
+
+	if len(sources) == 0 {
+		if stepName != "" {
+			return nil, fmt.Errorf("no step named %q with logs (steps: %s)", stepName, strings.Join(names, ", "))
+		}
+		return nil, fmt.Errorf("pipeline has no step logs yet")
+	}
+
+	return sources, nil
+}
+
+// grepStepLog prints the lines of a log that match pattern, prefixed with the
+// log label and line number, and returns the number of matches. The log is
+// split in memory, so lines of any length are searched.
+func grepStepLog(data []byte, pattern *regexp.Regexp, label string) int {
+	matches := 0
+	lineNumber := 0
+	for len(data) > 0 {
+		var raw []byte
+		raw, data, _ = bytes.Cut(data, []byte("\n"))
+		lineNumber++
+		line := strings.TrimSuffix(string(raw), "\r")
+		if pattern.MatchString(line) {
+			fmt.Printf("%s:%d: %s\n", label, lineNumber, line)
+			matches++
+		}
+	}
+
+	return matches
+}
+
+// sanitizeFileName turns a step or service name into a safe file name component
+func sanitizeFileName(name, fallback string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '.', r == '_', r == '-':
+			b.WriteRune(r)
+		default:
+			b.WriteRune('-')
+		}
+	}
+
+	result := strings.Trim(b.String(), "-.")
+	for strings.Contains(result, "--") {
+		result = strings.ReplaceAll(result, "--", "-")
+	}
+	if result == "" {
+		return fallback
+	}
+	return result
+}
+
+func init() {
+	pipelinesCmd.AddCommand(pipelineLogsCmd)
+	pipelineLogsCmd.Flags().StringVar(&pipelineLogsStep, "step", "", "Only download the logs of the step with this name")
+	pipelineLogsCmd.Flags().StringVar(&pipelineLogsOut, "out", "", "Directory to write one log file per step and service container")
+	pipelineLogsCmd.Flags().StringVar(&pipelineLogsGrep, "grep", "", "Print only log lines matching this regular expression")
+}
@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestGrepStepLog(t *testing.T) {
+	longLine := "payload " + strings.Repeat("x", 11*1024*1024)
+	log := strings.Join([]string{
+		"+ go build ./...",
+		"ERROR: connection refused\r",
+		longLine,
+		"retrying",
+		"ERROR: giving up",
+	}, "\n") + "\n"
+
+	tests := []struct {
+		name        string
+		pattern     string
+		wantMatches int
+		wantLines   []string
+	}{
+		{"matches with line numbers", "^ERROR", 2, []string{"build:2: ERROR: connection refused", "build:5: ERROR: giving up"}},
+		{"lines after a very long line", "retrying", 1, []string{"build:4: retrying"}},
+		{"very long line", "^payload x+$", 1, nil},
+		{"no match", "panic", 0, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var matches int
+			stdout, _ := captureOutput(t, func() {
+				matches = grepStepLog([]byte(log), regexp.MustCompile(tt.pattern), "build")
+			})
+			if matches != tt.wantMatches {
+				t.Errorf("grepStepLog() = %d matches, want %d", matches, tt.wantMatches)
+			}
+			for _, line := range tt.wantLines {
+				if !strings.Contains(stdout, line+"\n") {
+					t.Errorf("grepStepLog() output does not contain %q", line)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"bitbucket.org/cover42/eiscli/internal/bitbucket"
+	"bitbucket.org/cover42/eiscli/internal/git"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+var (
+	pipelineRunBranch string
+	pipelineRunCommit string
+	pipelineRunCustom string
+	pipelineRunVars   []string
+)
+
+var pipelineRunCmd = &cobra.Command{
+	Use:   "run [service-name]",
+	Short: "Trigger a pipeline run",
+	Long: `Trigger a new pipeline run in Bitbucket.
+
+By default the pipeline for the current git branch is started. Use --branch to
+build another branch, --commit to build a specific commit, and --custom to run
+a custom pipeline defined in bitbucket-pipelines.yml. Variables can be passed to
+custom pipelines with --var KEY=VALUE (repeatable).
+
+If service-name is not provided, it will be auto-detected from the git repository
+in the current directory (based on the git remote URL).
+
+Examples:
+  # Run the pipeline for the current branch
+  eiscli pipelines run
+
+  # Run the pipeline for a specific branch
+  eiscli pipelines run --branch master
+
+  # Run a custom pipeline with variables
+  eiscli pipelines run --custom deploy-to-test --var VERSION=1.2.3 --var DRY_RUN=false
+
+  # Build a specific commit
+  eiscli pipelines run --commit 3f2a9c1d`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+
+		serviceName := getServiceName(args)
+		if serviceName == "" {
+			return
+		}
+
+		variables, err := parsePipelineVariables(pipelineRunVars)
+		if err != nil {
+			errorf("Error: %v\n", err)
+			return
+		}
+
+		// Default to the current git branch when nothing else is given
+		branch := pipelineRunBranch
+		if branch == "" && pipelineRunCommit == "" {
+			branch, err = git.GetCurrentBranch()
+			if err != nil {
+				errorf("Error: Could not determine current branch: %v\n", err)
+				fmt.Println("Use --branch or --commit to choose what to build.")
+				return
+			}
+		}
+
+		client, ok := newBitbucketClient()
+		if !ok {
+			return
+		}
+
+		infof("Triggering %s\n", describePipelineTarget(branch, pipelineRunCommit, pipelineRunCustom))
+
+		pipeline, err := client.TriggerPipeline(ctx, &bitbucket.TriggerPipelineOptions{
+			RepoSlug:  serviceName,
+			Branch:    branch,
+			Commit:    pipelineRunCommit,
+			Custom:    pipelineRunCustom,
+			Variables: variables,
+		})
+		if err != nil {
+			errorf("Error: Failed to trigger pipeline: %v\n", err)
+			return
+		}
+
+		if isMachineOutput() {
+			printOutput(pipeline)
+			return
+		}
+
+		greenColor := color.New(color.FgGreen).SprintFunc()
+		fmt.Printf("\n%s Pipeline #%d started\n", greenColor("✓"), pipeline.BuildNumber)
+		if pipeline.WebURL != "" {
+			fmt.Printf("  URL: %s\n", pipeline.WebURL)
+		}
+		fmt.Printf("\nFollow it with: eiscli pipelines watch %s %d\n", serviceName, pipeline.BuildNumber)
+	},
+}
+
+// parsePipelineVariables parses KEY=VALUE pairs from --var flags
+func parsePipelineVariables(pairs []string) (map[string]string, error) {
+	variables := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		key, value, found := strings.Cut(pair, "=")
+		key = strings.TrimSpace(key)
+		if !found || key == "" {
+			return nil, fmt.Errorf("invalid variable %q (expected KEY=VALUE)", pair)
+		}
+		variables[key] = value
+	}
+	return variables, nil
+}
+
+// describePipelineTarget returns a human-readable description of what is being built
+func describePipelineTarget(branch, commit, custom string) string {
+	var parts []string
+	if custom != "" {
+		parts = append(parts, fmt.Sprintf("custom pipeline '%s'", custom))
+	} else {
+		parts = append(parts, "pipeline")
+	}
+	if branch != "" {
+		parts = append(parts, fmt.Sprintf("on branch %s", branch))
+	}
+	if commit != "" {
+		parts = append(parts, fmt.Sprintf("at commit %s", commit))
+	}
+	return strings.Join(parts, " ")
+}
+
+func init() {
+	pipelinesCmd.AddCommand(pipelineRunCmd)
+	pipelineRunCmd.Flags().StringVarP(&pipelineRunBranch, "branch", "b", "", "Branch to build (default: current git branch)")
+	pipelineRunCmd.Flags().StringVarP(&pipelineRunCommit, "commit", "c", "", "Commit hash to build")
+	pipelineRunCmd.Flags().StringVar(&pipelineRunCustom, "custom", "", "Name of a custom pipeline to run")
+	pipelineRunCmd.Flags().StringArrayVar(&pipelineRunVars, "var", nil, "Pipeline variable as KEY=VALUE (custom pipelines only, repeatable)")
+}
@@ -0,0 +1,271 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"bitbucket.org/cover42/eiscli/internal/bitbucket"
+	"github.com/fatih/color"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+var (
+	scheduleBranch   string
+	schedulePipeline string
+	scheduleCron     string
+	scheduleYes      bool
+)
+
+var pipelineSchedulesCmd = &cobra.Command{
+	Use:   "schedules",
+	Short: "Manage scheduled pipelines",
+	Long: `List, create, enable, disable and delete scheduled pipelines of a service.
+
+Schedules run a custom pipeline from bitbucket-pipelines.yml on a branch. Cron
+expressions are evaluated in UTC by Bitbucket and may be given in standard
+5-field format (minute hour day-of-month month day-of-week) or as a Quartz
+expression; they are validated locally before calling the API.
+
+If service-name is not provided, it will be auto-detected from the git repository
+in the current directory.`,
+}
+
+var pipelineSchedulesListCmd = &cobra.Command{
+	Use:   "list [service-name]",
+	Short: "List scheduled pipelines",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+
+		serviceName := getServiceName(args)
+		if serviceName == "" {
+			return
+		}
+
+		client, ok := newBitbucketClient()
+		if !ok {
+			return
+		}
+
+		schedules, err := client.ListPipelineSchedules(ctx, serviceName)
+		if err != nil {
+			errorf("Error: %v\n", err)
+			return
+		}
+
+		if isMachineOutput() {
+			printOutput(schedules)
+			return
+		}
+
+		if len(schedules) == 0 {
+			fmt.Printf("\nNo scheduled pipelines found for %s.\n", serviceName)
+			return
+		}
+
+		greenColor := color.New(color.FgGreen).SprintFunc()
+		yellowColor := color.New(color.FgYellow).SprintFunc()
+
+		fmt.Printf("\nScheduled pipelines for %s:\n\n", serviceName)
+		table := tablewriter.NewWriter(os.Stdout)
+		table.Header("ID", "Branch", "Pipeline", "Cron (UTC)", "Status")
+		for _, schedule := range schedules {
+			status := yellowColor("disabled")
+			if schedule.Enabled {
+				status = greenColor("enabled")
+			}
+			table.Append(shortScheduleID(schedule.UUID), schedule.Branch, schedule.Selector, schedule.CronPattern, status)
+		}
+		table.Render()
+	},
+}
+
+var pipelineSchedulesCreateCmd = &cobra.Command{
+	Use:   "create [service-name]",
+	Short: "Create a scheduled pipeline",
+	Long: `Create a schedule that runs a custom pipeline on a branch.
+
+Examples:
+  # Run the "nightly" pipeline on master every day at 02:00 UTC
+  eiscli pipelines schedules create --branch master --pipeline nightly --cron "0 2 * * *"
+
+  # Run on weekdays at 06:30 UTC
+  eiscli pipelines schedules create --branch master --pipeline smoke-tests --cron "30 6 * * 1-5"`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+
+		// Validate the cron expression before doing anything else
+		cronPattern, err := bitbucket.ParseCronExpression(scheduleCron)
+		if err != nil {
+			errorf("Error: %v\n", err)
+			return
+		}
+
+		serviceName := getServiceName(args)
+		if serviceName == "" {
+			return
+		}
+
+		client, ok := newBitbucketClient()
+		if !ok {
+			return
+		}
+
+		schedule, err := client.CreatePipelineSchedule(ctx, &bitbucket.CreatePipelineScheduleOptions{
+			RepoSlug: serviceName,
+			Branch:   scheduleBranch,
+			Selector: schedulePipeline,
+			Cron:     cronPattern,
+		})
+		if err != nil {
+			errorf("Error: %v\n", err)
+			return
+		}
+
+		if isMachineOutput() {
+			printOutput(schedule)
+			return
+		}
+
+		greenColor := color.New(color.FgGreen).SprintFunc()
+		fmt.Printf("%s Schedule %s created: pipeline '%s' on %s at '%s' (UTC)\n",
+			greenColor("✓"), shortScheduleID(schedule.UUID), schedulePipeline, scheduleBranch, cronPattern)
+	},
+}
+
+var pipelineSchedulesEnableCmd = &cobra.Command{
+	Use:   "enable [service-name] <schedule-id>",
+	Short: "Enable a scheduled pipeline",
+	Args:  cobra.RangeArgs(1, 2),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+
+		setScheduleEnabled(ctx, args, true)
+	},
+}
+
+var pipelineSchedulesDisableCmd = &cobra.Command{
+	Use:   "disable [service-name] <schedule-id>",
+	Short: "Disable a scheduled pipeline",
+	Args:  cobra.RangeArgs(1, 2),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+
+		setScheduleEnabled(ctx, args, false)
+	},
+}
+
+var pipelineSchedulesDeleteCmd = &cobra.Command{
+	Use:   "delete [service-name] <schedule-id>",
+	Short: "Delete a scheduled pipeline",
+	Args:  cobra.RangeArgs(1, 2),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+
+		serviceName, client, schedule, ok := resolveSchedule(ctx, args)
+		if !ok {
+			return
+		}
+
+		if !scheduleYes {
+			confirmed, err := promptYesNo(fmt.Sprintf("Delete schedule %s (pipeline '%s' on %s at '%s')?",
+				shortScheduleID(schedule.UUID), schedule.Selector, schedule.Branch, schedule.CronPattern), false)
+			if err != nil {
+				errorf("Error: %v\n", err)
+				return
+			}
+			if !confirmed {
+				fmt.Println("Canceled.")
+				return
+			}
+		}
+
+		if err := client.DeletePipelineSchedule(ctx, serviceName, schedule.UUID); err != nil {
+			errorf("Error: %v\n", err)
+			return
+		}
+
+		greenColor := color.New(color.FgGreen).SprintFunc()
+		fmt.Printf("%s Schedule %s deleted\n", greenColor("✓"), shortScheduleID(schedule.UUID))
+	},
+}
+
+// setScheduleEnabled enables or disables the schedule given in args
+func setScheduleEnabled(ctx context.Context, args []string, enabled bool) {
+	serviceName, client, schedule, ok := resolveSchedule(ctx, args)
+	if !ok {
+		return
+	}
+
+	updated, err := client.SetPipelineScheduleEnabled(ctx, serviceName, schedule.UUID, enabled)
+	if err != nil {
+		errorf("Error: %v\n", err)
+		return
+	}
+
+	if isMachineOutput() {
+		printOutput(updated)
+		return
+	}
+
+	action := "disabled"
+	if enabled {
+		action = "enabled"
+	}
+	greenColor := color.New(color.FgGreen).SprintFunc()
+	fmt.Printf("%s Schedule %s %s\n", greenColor("✓"), shortScheduleID(schedule.UUID), action)
+}
+
+// resolveSchedule parses "[service-name] <schedule-id>" arguments and looks up the schedule.
+// Errors are printed.
+func resolveSchedule(ctx context.Context, args []string) (string, *bitbucket.Client, *bitbucket.PipelineSchedule, bool) {
+	scheduleID := args[len(args)-1]
+	serviceName := getServiceName(args[:len(args)-1])
+	if serviceName == "" {
+		return "", nil, nil, false
+	}
+
+	client, ok := newBitbucketClient()
+	if !ok {
+		return "", nil, nil, false
+	}
+
+	schedule, err := client.FindPipelineSchedule(ctx, serviceName, scheduleID)
+	if err != nil {
+		errorf("Error: %v\n", err)
+		return "", nil, nil, false
+	}
+
+	return serviceName, client, schedule, true
+}
+
+// shortScheduleID returns the first block of a schedule UUID, which is enough to identify it
+func shortScheduleID(uuid string) string {
+	id := strings.Trim(uuid, "{}")
+	if prefix, _, found := strings.Cut(id, "-"); found {
+		return prefix
+	}
+	return id
+}
+
+func init() {
+	pipelinesCmd.AddCommand(pipelineSchedulesCmd)
+	pipelineSchedulesCmd.AddCommand(pipelineSchedulesListCmd)
+	pipelineSchedulesCmd.AddCommand(pipelineSchedulesCreateCmd)
+	pipelineSchedulesCmd.AddCommand(pipelineSchedulesEnableCmd)
+	pipelineSchedulesCmd.AddCommand(pipelineSchedulesDisableCmd)
+	pipelineSchedulesCmd.AddCommand(pipelineSchedulesDeleteCmd)
+
+	pipelineSchedulesCreateCmd.Flags().StringVarP(&scheduleBranch, "branch", "b", "", "Branch to run the pipeline on (required)")
+	pipelineSchedulesCreateCmd.Flags().StringVarP(&schedulePipeline, "pipeline", "p", "", "Custom pipeline from bitbucket-pipelines.yml (required)")
+	pipelineSchedulesCreateCmd.Flags().StringVar(&scheduleCron, "cron", "", "Cron expression in UTC, e.g. \"0 2 * * *\" (required)")
+	_ = pipelineSchedulesCreateCmd.MarkFlagRequired("branch")
+	_ = pipelineSchedulesCreateCmd.MarkFlagRequired("pipeline")
+	_ = pipelineSchedulesCreateCmd.MarkFlagRequired("cron")
+
+	pipelineSchedulesDeleteCmd.Flags().BoolVarP(&scheduleYes, "yes", "y", false, "Delete without asking for confirmation")
+}
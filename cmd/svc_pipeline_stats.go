@@ -0,0 +1,400 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"bitbucket.org/cover42/eiscli/internal/bitbucket"
+	"github.com/fatih/color"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// statsConcurrency limits parallel API requests when collecting statistics
+	statsConcurrency = 8
+	// statsSlowestSteps is the number of slowest steps shown
+	statsSlowestSteps = 5
+)
+
+var (
+	pipelineStatsSince     string
+	pipelineStatsWorkspace bool
+	pipelineStatsMaxBuilds int
+)
+
+// pipelineStats holds aggregated statistics of a repository's pipelines
+type pipelineStats struct {
+	Service      string       `json:"service" yaml:"service"`
+	Builds       int          `json:"builds" yaml:"builds"`
+	Successful   int          `json:"successful" yaml:"successful"`
+	Failed       int          `json:"failed" yaml:"failed"`
+	Stopped      int          `json:"stopped" yaml:"stopped"`
+	Running      int          `json:"running" yaml:"running"`
+	SuccessRate  float64      `json:"success_rate" yaml:"success_rate"` // Percentage of finished, non-stopped builds
+	P50Sec       int          `json:"p50_seconds" yaml:"p50_seconds"`
+	P95Sec       int          `json:"p95_seconds" yaml:"p95_seconds"`
+	BuildMinutes float64      `json:"build_minutes" yaml:"build_minutes"`
+	SlowestSteps []*stepStats `json:"slowest_steps,omitempty" yaml:"slowest_steps,omitempty"`
+	StepErrors   int          `json:"step_errors,omitempty" yaml:"step_errors,omitempty"` // Pipelines whose steps could not be fetched
+	Error        string       `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// stepStats holds aggregated durations of a pipeline step across builds
+type stepStats struct {
+	Name   string `json:"name" yaml:"name"`
+	Runs   int    `json:"runs" yaml:"runs"`
+	AvgSec int    `json:"avg_seconds" yaml:"avg_seconds"`
+	P95Sec int    `json:"p95_seconds" yaml:"p95_seconds"`
+	MaxSec int    `json:"max_seconds" yaml:"max_seconds"`
+}
+
+var pipelineStatsCmd = &cobra.Command{
+	Use:   "stats [service-name]",
+	Short: "Show pipeline duration and build-minute statistics",
+	Long: `Show pipeline statistics for a period of time: success rate, median (p50) and
+p95 duration, the slowest steps and the build minutes consumed.
+
+With --workspace the statistics are aggregated for every repository in the
+workspace and sorted by build minutes, to find the services that use most of
+the build-minute budget. Step durations are not fetched in workspace mode.
+
+If service-name is not provided, it will be auto-detected from the git repository
+in the current directory.
+
+Examples:
+  # Statistics of the current repository for the last 30 days
+  eiscli pipelines stats
+
+  # Statistics of a service for the last week
+  eiscli pipelines stats my-service --since 7d
+
+  # Build minutes per repository across the workspace
+  eiscli pipelines stats --workspace --since 30d`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+
+		since, err := parseSince(pipelineStatsSince, time.Now())
+		if err != nil {
+			errorf("Error: %v\n", err)
+			return
+		}
+
+		serviceName := ""
+		if !pipelineStatsWorkspace {
+			serviceName = getServiceName(args)
+			if serviceName == "" {
+				return
+			}
+		} else if len(args) > 0 {
+			errorf("Error: A service name cannot be combined with --workspace\n")
+			return
+		}
+
+		client, ok := newBitbucketClient()
+		if !ok {
+			return
+		}
+
+		if pipelineStatsWorkspace {
+			runWorkspacePipelineStats(ctx, client, since)
+			return
+		}
+
+		infof("Collecting pipeline statistics for %s since %s...\n", serviceName, since.Format("2006-01-02"))
+
+		pipelines, err := client.ListPipelines(ctx, &bitbucket.PipelinesOptions{
+			RepoSlug: serviceName,
+			Limit:    pipelineStatsMaxBuilds,
+			Since:    since,
+		})
+		if err != nil {
+			errorf("Error fetching pipelines: %v\n", err)
+			return
+		}
+
+		stats := computePipelineStats(serviceName, pipelines)
+		stats.SlowestSteps, stats.StepErrors = collectStepStats(ctx, client, serviceName, pipelines)
+		if stats.StepErrors > 0 {
+			fmt.Fprintf(os.Stderr, "Warning: failed to fetch the steps of %d pipeline(s), the slowest steps are based on the others\n", stats.StepErrors)
+		}
+
+		if isMachineOutput() {
+			printOutput(stats)
+			return
+		}
+
+		if stats.Builds == 0 {
+			fmt.Printf("\nNo pipelines found for %s since %s.\n", serviceName, since.Format("2006-01-02"))
+			return
+		}
+
+		displayPipelineStats(stats, since)
+	},
+}
+
+// runWorkspacePipelineStats collects and prints statistics for every repository in the workspace
+func runWorkspacePipelineStats(ctx context.Context, client *bitbucket.Client, since time.Time) {
+	repos, err := client.ListRepositories(ctx)
+	if err != nil {
+		errorf("Error fetching repositories: %v\n", err)
+		return
+	}
+
+	infof("Collecting pipeline statistics for %d repositories since %s...\n", len(repos), since.Format("2006-01-02"))
+
+	results := make([]*pipelineStats, len(repos))
+	sem := make(chan struct{}, statsConcurrency)
+	var wg sync.WaitGroup
+
+	for i, repo := range repos {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			pipelines, err := client.ListPipelines(ctx, &bitbucket.PipelinesOptions{
+				RepoSlug: repo.Slug,
+				Limit:    pipelineStatsMaxBuilds,
+				Since:    since,
+			})
+			if err != nil {
+				results[i] = &pipelineStats{Service: repo.Slug, Error: err.Error()}
+				return
+			}
+			results[i] = computePipelineStats(repo.Slug, pipelines)
+		}()
+	}
+	wg.Wait()
+
+	// Only keep repositories that ran pipelines (or failed to load)
+	stats := make([]*pipelineStats, 0, len(results))
+	for _, s := range results {
+		if s.Builds > 0 || s.Error != "" {
+			stats = append(stats, s)
+		}
+	}
+
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].BuildMinutes != stats[j].BuildMinutes {
+			return stats[i].BuildMinutes > stats[j].BuildMinutes
+		}
+		return stats[i].Service < stats[j].Service
+	})
+
+	if isMachineOutput() {
+		printOutput(stats)
+		return
+	}
+
+	if len(stats) == 0 {
+		fmt.Printf("\nNo pipelines found in the workspace since %s.\n", since.Format("2006-01-02"))
+		return
+	}
+
+	displayWorkspacePipelineStats(stats, since)
+}
+
+// computePipelineStats aggregates build counts, durations and build minutes
+func computePipelineStats(serviceName string, pipelines []*bitbucket.Pipeline) *pipelineStats {
+	stats := &pipelineStats{
+		Service: serviceName,
+		Builds:  len(pipelines),
+	}
+
+	var durations []int
+	buildSecs := 0
+	for _, p := range pipelines {
+		buildSecs += p.BuildSecsUsed
+
+		if p.State.Name != "COMPLETED" {
+			stats.Running++
+			continue
+		}
+
+		switch pipelineStatus(p) {
+		case "SUCCESSFUL":
+			stats.Successful++
+		case "STOPPED":
+			stats.Stopped++
+		default:
+			stats.Failed++
+		}
+
+		if p.DurationSec > 0 {
+			durations = append(durations, p.DurationSec)
+		}
+	}
+
+	if finished := stats.Successful + stats.Failed; finished > 0 {
+		stats.SuccessRate = float64(stats.Successful) * 100 / float64(finished)
+	}
+
+	sort.Ints(durations)
+	stats.P50Sec = percentile(durations, 50)
+	stats.P95Sec = percentile(durations, 95)
+	stats.BuildMinutes = float64(buildSecs) / 60
+
+	return stats
+}
+
+// collectStepStats fetches the steps of completed pipelines and returns the
+// slowest steps by average duration, and the number of pipelines whose steps
+// could not be fetched
+func collectStepStats(ctx context.Context, client *bitbucket.Client, serviceName string, pipelines []*bitbucket.Pipeline) ([]*stepStats, int) {
+	var mu sync.Mutex
+	durationsByStep := make(map[string][]int)
+	failed := 0
+
+	sem := make(chan struct{}, statsConcurrency)
+	var wg sync.WaitGroup
+
+	for _, p := range pipelines {
+		if p.State.Name != "COMPLETED" {
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			steps, err := client.GetPipelineSteps(ctx, serviceName, p.UUID)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				// Step durations are best effort
+				failed++
+				return
+			}
+			for _, step := range steps {
+				if step.DurationSec > 0 {
+					durationsByStep[step.Name] = append(durationsByStep[step.Name], step.DurationSec)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	return slowestSteps(durationsByStep), failed
+}
+
+// slowestSteps aggregates the durations of each step and returns the
+// statsSlowestSteps steps with the highest average duration
+func slowestSteps(durationsByStep map[string][]int) []*stepStats {
+	steps := make([]*stepStats, 0, len(durationsByStep))
+	for name, durations := range durationsByStep {
+		sort.Ints(durations)
+		total := 0
+		for _, d := range durations {
+			total += d
+		}
+		steps = append(steps, &stepStats{
+			Name:   name,
+			Runs:   len(durations),
+			AvgSec: total / len(durations),
+			P95Sec: percentile(durations, 95),
+			MaxSec: durations[len(durations)-1],
+		})
+	}
+
+	sort.Slice(steps, func(i, j int) bool {
+		if steps[i].AvgSec != steps[j].AvgSec {
+			return steps[i].AvgSec > steps[j].AvgSec
+		}
+		return steps[i].Name < steps[j].Name
+	})
+
+	if len(steps) > statsSlowestSteps {
+		steps = steps[:statsSlowestSteps]
+	}
+	return steps
+}
+
+// percentile returns the p-th percentile (nearest rank) of sorted values, or 0 if empty
+func percentile(sorted []int, p float64) int {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// displayPipelineStats prints the statistics of a single repository
+func displayPipelineStats(stats *pipelineStats, since time.Time) {
+	cyanColor := color.New(color.FgCyan).SprintFunc()
+
+	fmt.Printf("\n%s pipelines since %s\n\n", cyanColor(stats.Service), since.Format("2006-01-02"))
+	fmt.Printf("  Builds:         %d (%d successful, %d failed, %d stopped, %d running)\n",
+		stats.Builds, stats.Successful, stats.Failed, stats.Stopped, stats.Running)
+	fmt.Printf("  Success rate:   %s\n", formatSuccessRate(stats))
+	fmt.Printf("  Duration p50:   %s\n", formatDuration(stats.P50Sec))
+	fmt.Printf("  Duration p95:   %s\n", formatDuration(stats.P95Sec))
+	fmt.Printf("  Build minutes:  %.1f\n", stats.BuildMinutes)
+
+	if len(stats.SlowestSteps) == 0 {
+		return
+	}
+
+	fmt.Println("\nSlowest steps:")
+	table := tablewriter.NewWriter(os.Stdout)
+	table.Header("Step", "Runs", "Average", "p95", "Max")
+	for _, step := range stats.SlowestSteps {
+		table.Append(step.Name, strconv.Itoa(step.Runs), formatDuration(step.AvgSec), formatDuration(step.P95Sec), formatDuration(step.MaxSec))
+	}
+	table.Render()
+}
+
+// displayWorkspacePipelineStats prints one row of statistics per repository
+func displayWorkspacePipelineStats(stats []*pipelineStats, since time.Time) {
+	fmt.Printf("\nPipelines since %s, by build minutes\n\n", since.Format("2006-01-02"))
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.Header("Service", "Builds", "Success", "p50", "p95", "Build Minutes")
+
+	totalMinutes := 0.0
+	var failedRepos []*pipelineStats
+	for _, s := range stats {
+		if s.Error != "" {
+			failedRepos = append(failedRepos, s)
+			continue
+		}
+		totalMinutes += s.BuildMinutes
+		table.Append(s.Service, strconv.Itoa(s.Builds), formatSuccessRate(s), formatDuration(s.P50Sec), formatDuration(s.P95Sec), fmt.Sprintf("%.1f", s.BuildMinutes))
+	}
+	table.Render()
+
+	fmt.Printf("\nTotal build minutes: %.1f\n", totalMinutes)
+
+	for _, s := range failedRepos {
+		fmt.Fprintf(os.Stderr, "Warning: failed to fetch pipelines for %s: %s\n", s.Service, s.Error)
+	}
+}
+
+// formatSuccessRate formats the success rate, or N/A when no build finished
+func formatSuccessRate(stats *pipelineStats) string {
+	if stats.Successful+stats.Failed == 0 {
+		return "N/A"
+	}
+	return fmt.Sprintf("%.1f%%", stats.SuccessRate)
+}
+
+func init() {
+	pipelinesCmd.AddCommand(pipelineStatsCmd)
+	pipelineStatsCmd.Flags().StringVar(&pipelineStatsSince, "since", "30d", "Period to analyse as a duration (12h, 7d, 2w) or date (YYYY-MM-DD)")
+	pipelineStatsCmd.Flags().BoolVar(&pipelineStatsWorkspace, "workspace", false, "Aggregate statistics for all repositories in the workspace")
+	pipelineStatsCmd.Flags().IntVar(&pipelineStatsMaxBuilds, "max-builds", 1000, "Maximum number of builds to analyse per repository")
+}
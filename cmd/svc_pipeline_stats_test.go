@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	"bitbucket.org/cover42/eiscli/internal/bitbucket"
+)
+
+func TestPercentile(t *testing.T) {
+	tests := []struct {
+		name   string
+		sorted []int
+		p      float64
+		want   int
+	}{
+		{"empty", nil, 50, 0},
+		{"single value", []int{42}, 95, 42},
+		{"median of odd count", []int{10, 20, 30}, 50, 20},
+		{"median of even count is the lower middle", []int{10, 20, 30, 40}, 50, 20},
+		{"p95 of ten values is the largest", []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, 95, 10},
+		{"p95 of twenty values", []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20}, 95, 19},
+		{"p0 is the smallest", []int{5, 6, 7}, 0, 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := percentile(tt.sorted, tt.p); got != tt.want {
+				t.Errorf("percentile(%v, %v) = %d, want %d", tt.sorted, tt.p, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestComputePipelineStats(t *testing.T) {
+	completed := func(result string, durationSec, buildSecs int) *bitbucket.Pipeline {
+		return &bitbucket.Pipeline{
+			State:         bitbucket.PipelineState{Name: "COMPLETED", Result: &bitbucket.PipelineResult{Name: result}},
+			DurationSec:   durationSec,
+			BuildSecsUsed: buildSecs,
+		}
+	}
+	running := &bitbucket.Pipeline{State: bitbucket.PipelineState{Name: "IN_PROGRESS"}, BuildSecsUsed: 60}
+
+	tests := []struct {
+		name      string
+		pipelines []*bitbucket.Pipeline
+		want      pipelineStats
+	}{
+		{
+			name:      "no pipelines",
+			pipelines: nil,
+			want:      pipelineStats{Service: "svc"},
+		},
+		{
+			name: "stopped and running builds are left out of the success rate",
+			pipelines: []*bitbucket.Pipeline{
+				completed("SUCCESSFUL", 100, 120),
+				completed("SUCCESSFUL", 300, 300),
+				completed("FAILED", 200, 180),
+				completed("ERROR", 50, 60),
+				completed("STOPPED", 10, 0),
+				running,
+			},
+			want: pipelineStats{
+				Service:      "svc",
+				Builds:       6,
+				Successful:   2,
+				Failed:       2,
+				Stopped:      1,
+				Running:      1,
+				SuccessRate:  50,
+				P50Sec:       100,
+				P95Sec:       300,
+				BuildMinutes: 12,
+			},
+		},
+		{
+			name:      "only stopped builds have no success rate",
+			pipelines: []*bitbucket.Pipeline{completed("STOPPED", 30, 30)},
+			want:      pipelineStats{Service: "svc", Builds: 1, Stopped: 1, P50Sec: 30, P95Sec: 30, BuildMinutes: 0.5},
+		},
+		{
+			name:      "builds without duration are left out of the percentiles",
+			pipelines: []*bitbucket.Pipeline{completed("SUCCESSFUL", 0, 0), completed("SUCCESSFUL", 90, 0)},
+			want:      pipelineStats{Service: "svc", Builds: 2, Successful: 2, SuccessRate: 100, P50Sec: 90, P95Sec: 90},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := computePipelineStats("svc", tt.pipelines)
+			if !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("computePipelineStats() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSlowestSteps(t *testing.T) {
+	steps := slowestSteps(map[string][]int{
+		"Build":   {120, 60, 90},
+		"Test":    {300, 100},
+		"Lint":    {10},
+		"Deploy":  {90, 90},
+		"Migrate": {90},
+		"Notify":  {1},
+	})
+
+	want := []stepStats{
+		{Name: "Test", Runs: 2, AvgSec: 200, P95Sec: 300, MaxSec: 300},
+		{Name: "Build", Runs: 3, AvgSec: 90, P95Sec: 120, MaxSec: 120},
+		{Name: "Deploy", Runs: 2, AvgSec: 90, P95Sec: 90, MaxSec: 90},
+		{Name: "Migrate", Runs: 1, AvgSec: 90, P95Sec: 90, MaxSec: 90},
+		{Name: "Lint", Runs: 1, AvgSec: 10, P95Sec: 10, MaxSec: 10},
+	}
+	if len(steps) != statsSlowestSteps {
+		t.Fatalf("slowestSteps() returned %d steps, want the top %d", len(steps), statsSlowestSteps)
+	}
+	for i, step := range steps {
+		if *step != want[i] {
+			t.Errorf("step %d = %+v, want %+v", i, *step, want[i])
+		}
+	}
+
+	if steps := slowestSteps(map[string][]int{}); len(steps) != 0 {
+		t.Errorf("slowestSteps() without steps = %+v, want none", steps)
+	}
+}
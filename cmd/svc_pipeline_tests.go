@@ -0,0 +1,149 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"bitbucket.org/cover42/eiscli/internal/bitbucket"
+	"github.com/fatih/color"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+// testFailureMessageLines limits how many lines of a failure message are shown
+const testFailureMessageLines = 10
+
+// pipelineTestsOutput is the machine-readable output of the tests command
+type pipelineTestsOutput struct {
+	BuildNumber int                         `json:"build_number" yaml:"build_number"`
+	Reports     []*bitbucket.StepTestReport `json:"reports" yaml:"reports"`
+}
+
+var pipelineTestsCmd = &cobra.Command{
+	Use:   "tests [service-name] <build-number>",
+	Short: "Show test results of a pipeline",
+	Long: `Show the test reports of a pipeline.
+
+Prints the passed, failed and skipped test counts of every step that produced a
+test report, followed by the failing test cases and their failure messages.
+
+If service-name is not provided, it will be auto-detected from the git repository
+in the current directory.
+
+Examples:
+  # Show the test results of build #123
+  eiscli pipelines tests 123
+
+  # Show the test results of build #123 of another service
+  eiscli pipelines tests my-service 123`,
+	Args: cobra.RangeArgs(1, 2),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+
+		serviceName, buildNumber, ok := resolveServiceAndBuild(args)
+		if !ok {
+			return
+		}
+
+		client, ok := newBitbucketClient()
+		if !ok {
+			return
+		}
+
+		pipeline, err := client.GetPipelineByBuildNumber(ctx, serviceName, buildNumber)
+		if err != nil {
+			errorf("Error: %v\n", err)
+			return
+		}
+
+		infof("Fetching test reports for pipeline #%d...\n", pipeline.BuildNumber)
+
+		reports, err := client.GetPipelineTestReports(ctx, serviceName, pipeline.UUID)
+		if err != nil {
+			errorf("Error fetching test reports: %v\n", err)
+			return
+		}
+
+		if isMachineOutput() {
+			printOutput(pipelineTestsOutput{BuildNumber: pipeline.BuildNumber, Reports: reports})
+			return
+		}
+
+		if len(reports) == 0 {
+			fmt.Printf("\nNo test reports found for pipeline #%d.\n", pipeline.BuildNumber)
+			return
+		}
+
+		displayTestReports(pipeline, reports)
+	},
+}
+
+// displayTestReports prints the per-step test counts and the failing test cases
+func displayTestReports(pipeline *bitbucket.Pipeline, reports []*bitbucket.StepTestReport) {
+	greenColor := color.New(color.FgGreen).SprintFunc()
+	redColor := color.New(color.FgRed).SprintFunc()
+	yellowColor := color.New(color.FgYellow).SprintFunc()
+
+	fmt.Printf("\nTest results for pipeline #%d (%s: %s)\n\n", pipeline.BuildNumber, pipeline.Target.RefType, pipeline.Target.RefName)
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.Header("Step", "Passed", "Failed", "Skipped", "Total")
+
+	var total bitbucket.TestReportSummary
+	for _, report := range reports {
+		summary := report.Summary
+		if summary == nil {
+			table.Append(report.StepName, "-", "-", "-", "-")
+			continue
+		}
+		failed := summary.Failed + summary.Errors
+		table.Append(report.StepName, strconv.Itoa(summary.Passed), strconv.Itoa(failed), strconv.Itoa(summary.Skipped), strconv.Itoa(summary.Total))
+
+		total.Passed += summary.Passed
+		total.Failed += failed
+		total.Skipped += summary.Skipped
+		total.Total += summary.Total
+	}
+	table.Render()
+
+	for _, report := range reports {
+		if report.Error != "" {
+			fmt.Printf("%s %s: %s\n", yellowColor("⚠"), report.StepName, report.Error)
+		}
+	}
+
+	fmt.Printf("\n%s passed, %s failed, %s skipped\n",
+		greenColor(total.Passed), redColor(total.Failed), yellowColor(total.Skipped))
+
+	if total.Failed == 0 {
+		return
+	}
+
+	fmt.Println("\nFailing tests:")
+	for _, report := range reports {
+		for _, testCase := range report.FailedCases {
+			name := testCase.FullyQualifiedName
+			if name == "" {
+				name = testCase.Name
+			}
+			fmt.Printf("\n  %s %s › %s\n", redColor("✗"), report.StepName, name)
+
+			if testCase.FailureMessage == "" {
+				continue
+			}
+			lines := strings.Split(testCase.FailureMessage, "\n")
+			if len(lines) > testFailureMessageLines {
+				lines = append(lines[:testFailureMessageLines], fmt.Sprintf("... (%d more lines)", len(lines)-testFailureMessageLines))
+			}
+			for _, line := range lines {
+				fmt.Printf("      %s\n", line)
+			}
+		}
+	}
+}
+
+func init() {
+	pipelinesCmd.AddCommand(pipelineTestsCmd)
+}
@@ -0,0 +1,211 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"bitbucket.org/cover42/eiscli/internal/bitbucket"
+	"bitbucket.org/cover42/eiscli/internal/git"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+// watchLogAttempts is how often the log of a completed step is fetched before
+// the rest of it is given up on
+const watchLogAttempts = 3
+
+var (
+	pipelineWatchInterval time.Duration
+	pipelineWatchNoLogs   bool
+)
+
+// stepWatchState tracks what has already been printed for a step
+type stepWatchState struct {
+	status    string
+	logOffset int64
+	logDone   bool
+	logErrors int // Failed log fetches since the step completed
+}
+
+var pipelineWatchCmd = &cobra.Command{
+	Use:   "watch [service-name] [build-number]",
+	Short: "Follow a running pipeline live",
+	Long: `Follow a pipeline while it runs.
+
+Polls the pipeline and its steps, prints step state changes and streams new log
+output of each step as it runs. The command exits with a non-zero code when the
+pipeline does not finish successfully.
+
+If build-number is not provided, the latest pipeline for the current git branch
+is watched. If service-name is not provided, it will be auto-detected from the
+git repository in the current directory.
+
+Examples:
+  # Watch the latest pipeline of the current branch
+  eiscli pipelines watch
+
+  # Watch build #123
+  eiscli pipelines watch 123
+
+  # Only show step state changes
+  eiscli pipelines watch 123 --no-logs`,
+	Args: cobra.MaximumNArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+
+		serviceArgs, buildNumber, err := parseServiceAndNumberArgs(args, "build number")
+		if err != nil {
+			errorf("Error: %v\n", err)
+			return
+		}
+
+		serviceName := getServiceName(serviceArgs)
+		if serviceName == "" {
+			return
+		}
+
+		client, ok := newBitbucketClient()
+		if !ok {
+			return
+		}
+
+		var pipeline *bitbucket.Pipeline
+		if buildNumber > 0 {
+			pipeline, err = client.GetPipelineByBuildNumber(ctx, serviceName, buildNumber)
+		} else {
+			branch, branchErr := git.GetCurrentBranch()
+			if branchErr != nil {
+				errorf("Error: Could not determine current branch: %v\n", branchErr)
+				fmt.Println("Provide a build number: eiscli pipelines watch <build-number>")
+				return
+			}
+			pipeline, err = client.GetLatestPipelineForBranch(ctx, serviceName, branch)
+		}
+		if err != nil {
+			errorf("Error: %v\n", err)
+			return
+		}
+
+		if pipelineWatchInterval < time.Second {
+			pipelineWatchInterval = time.Second
+		}
+
+		fmt.Printf("Watching pipeline #%d (%s: %s)\n", pipeline.BuildNumber, pipeline.Target.RefType, pipeline.Target.RefName)
+		if pipeline.WebURL != "" {
+			fmt.Printf("URL: %s\n", pipeline.WebURL)
+		}
+		fmt.Println()
+
+		final, err := watchPipeline(ctx, client, serviceName, pipeline)
+		if err != nil {
+			errorf("\nError: %v\n", err)
+			os.Exit(1)
+		}
+
+		status := pipelineStatus(final)
+		fmt.Printf("\nPipeline #%d finished: %s %s (%s)\n",
+			final.BuildNumber, getStatusIcon(status), status, formatDuration(final.DurationSec))
+
+		if status != "SUCCESSFUL" {
+			os.Exit(1)
+		}
+	},
+}
+
+// watchPipeline polls a pipeline until it completes, printing step changes and new log output
+func watchPipeline(ctx context.Context, client *bitbucket.Client, serviceName string, pipeline *bitbucket.Pipeline) (*bitbucket.Pipeline, error) {
+	greenColor := color.New(color.FgGreen).SprintFunc()
+	redColor := color.New(color.FgRed).SprintFunc()
+	cyanColor := color.New(color.FgCyan).SprintFunc()
+
+	states := make(map[string]*stepWatchState)
+
+	for {
+		logsPending := false
+
+		// Fetch the pipeline before the steps: once it reports COMPLETED, the
+		// steps fetched afterwards are final and their logs complete.
+		current, err := client.GetPipeline(ctx, serviceName, pipeline.UUID)
+		if err != nil {
+			return nil, err
+		}
+
+		steps, err := client.GetPipelineSteps(ctx, serviceName, pipeline.UUID)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, step := range steps {
+			state, ok := states[step.UUID]
+			if !ok {
+				state = &stepWatchState{}
+				states[step.UUID] = state
+			}
+
+			status := step.State
+			if step.Result != "" {
+				status = step.Result
+			}
+			if status != state.status {
+				state.status = status
+				icon := getStatusIcon(status)
+				switch status {
+				case "SUCCESSFUL":
+					icon = greenColor(icon)
+				case "FAILED", "ERROR":
+					icon = redColor(icon)
+				}
+				fmt.Printf("[%s] %s %s: %s\n", time.Now().Format("15:04:05"), icon, cyanColor(step.Name), status)
+			}
+
+			if pipelineWatchNoLogs || state.logDone {
+				continue
+			}
+			if step.State != "IN_PROGRESS" && step.State != "COMPLETED" {
+				continue
+			}
+
+			data, err := client.GetStepLogFrom(ctx, serviceName, pipeline.UUID, step.UUID, state.logOffset)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: failed to fetch log for step %s: %v\n", step.Name, err)
+				if step.State != "COMPLETED" {
+					continue
+				}
+				// Retry on the next tick, as the rest of the log is not
+				// fetched again once the step is done
+				state.logErrors++
+				if state.logErrors < watchLogAttempts {
+					logsPending = true
+					continue
+				}
+				fmt.Fprintf(os.Stderr, "Warning: the log of step %s is incomplete, see 'eiscli pipelines logs %s %d'\n",
+					step.Name, serviceName, pipeline.BuildNumber)
+			} else if len(data) > 0 {
+				_, _ = os.Stdout.Write(data)
+				state.logOffset += int64(len(data))
+			}
+
+			if step.State == "COMPLETED" {
+				state.logDone = true
+			}
+		}
+
+		if current.State.Name == "COMPLETED" && !logsPending {
+			return current, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(pipelineWatchInterval):
+		}
+	}
+}
+
+func init() {
+	pipelinesCmd.AddCommand(pipelineWatchCmd)
+	pipelineWatchCmd.Flags().DurationVar(&pipelineWatchInterval, "interval", 5*time.Second, "Polling interval")
+	pipelineWatchCmd.Flags().BoolVar(&pipelineWatchNoLogs, "no-logs", false, "Only show step state changes, without log output")
+}
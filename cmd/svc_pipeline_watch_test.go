@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"bitbucket.org/cover42/eiscli/internal/bitbucket"
+	"bitbucket.org/cover42/eiscli/internal/bitbucket/bitbuckettest"
+	"bitbucket.org/cover42/eiscli/internal/config"
+)
+
+// newFlakyLogClient returns a client of a fake Bitbucket whose step logs fail
+// the first failures times they are fetched
+func newFlakyLogClient(t *testing.T, server *bitbuckettest.Server, failures int32) *bitbucket.Client {
+	t.Helper()
+
+	target, err := url.Parse(server.APIURL())
+	if err != nil {
+		t.Fatal(err)
+	}
+	proxy := httputil.NewSingleHostReverseProxy(&url.URL{Scheme: target.Scheme, Host: target.Host})
+	var logRequests atomic.Int32
+	flaky := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.URL.Path, "/logs/") && logRequests.Add(1) <= failures {
+			http.Error(w, `{"error": {"message": "unavailable"}}`, http.StatusServiceUnavailable)
+			return
+		}
+		proxy.ServeHTTP(w, r)
+	}))
+	t.Cleanup(flaky.Close)
+
+	// Not config.Load, which caches its result before --api-url is applied
+	cfg := &config.Config{}
+	cfg.Bitbucket.Workspace = "workspace"
+	cfg.Bitbucket.Username = "user"
+	cfg.Bitbucket.AppPassword = "password"
+	cfg.Bitbucket.APIURL = flaky.URL + target.Path
+	client, err := bitbucket.NewClient(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func TestWatchPipelineRetriesLogOfCompletedStep(t *testing.T) {
+	interval := pipelineWatchInterval
+	pipelineWatchInterval = time.Millisecond
+	defer func() { pipelineWatchInterval = interval }()
+
+	tests := []struct {
+		name       string
+		failures   int32
+		wantLog    bool
+		wantStderr string
+	}{
+		{"log fetched after a failure", 1, true, "failed to fetch log for step Build"},
+		{"log given up on", watchLogAttempts, false, "the log of step Build is incomplete"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := bitbuckettest.New("workspace")
+			defer server.Close()
+			completedOn := time.Now().UTC()
+			added := server.AddPipeline("watchservice", bitbuckettest.Pipeline{
+				Branch:      "main",
+				Result:      "SUCCESSFUL",
+				CompletedOn: &completedOn,
+				Steps:       []*bitbuckettest.Step{{Name: "Build", State: "COMPLETED", Result: "SUCCESSFUL", Log: "go build ./...\n"}},
+			})
+
+			client := newFlakyLogClient(t, server, tt.failures)
+			pipeline, err := client.GetPipelineByBuildNumber(context.Background(), "watchservice", added.BuildNumber)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			stdout, stderr := captureOutput(t, func() {
+				_, err = watchPipeline(context.Background(), client, "watchservice", pipeline)
+			})
+			if err != nil {
+				t.Fatalf("watchPipeline() error = %v", err)
+			}
+			if got := strings.Contains(stdout, "go build ./..."); got != tt.wantLog {
+				t.Errorf("watchPipeline() printed the log: %t, want %t\n%s", got, tt.wantLog, stdout)
+			}
+			if !strings.Contains(stderr, tt.wantStderr) {
+				t.Errorf("watchPipeline() stderr = %q, want %q", stderr, tt.wantStderr)
+			}
+		})
+	}
+}
@@ -1,20 +1,101 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"os"
+	"strings"
+	"sync"
 
+	"bitbucket.org/cover42/eiscli/internal/aws"
+	"bitbucket.org/cover42/eiscli/internal/bitbucket"
+	"bitbucket.org/cover42/eiscli/internal/config"
 	"bitbucket.org/cover42/eiscli/internal/git"
+	"github.com/fatih/color"
 	"github.com/spf13/cobra"
 )
 
+const (
+	// statusPipelineLookback is how many recent pipelines are scanned to find the latest build per branch
+	statusPipelineLookback = 50
+	// statusPullRequestLimit caps the number of open pull requests fetched for the count
+	statusPullRequestLimit = 50
+	// statusDeployKeyTarget is the repository the pipeline deploy key is expected on
+	statusDeployKeyTarget = "protorepo"
+)
+
+// serviceStatus holds everything shown by the status dashboard.
+// Each section carries its own error so one failing API does not hide the rest.
+type serviceStatus struct {
+	Service string
+
+	Repository    *bitbucket.Repository
+	RepositoryErr error
+
+	Pipelines    []*bitbucket.Pipeline // latest pipeline per branch, most recent first
+	PipelinesErr error
+
+	ECR []*ecrRegionStatus
+
+	Environments    []*environmentStatus
+	EnvironmentsErr error
+
+	SSHKeyPair    *bitbucket.SSHKeyPair
+	SSHKeyPairErr error
+
+	DeployKey    *bitbucket.DeployKey
+	DeployKeyErr error
+
+	OpenPullRequests    int
+	OpenPullRequestsErr error
+}
+
+// ecrRegionStatus holds the ECR repository state for one region
+type ecrRegionStatus struct {
+	Name   string `json:"name" yaml:"name"`
+	Region string `json:"region" yaml:"region"`
+	Exists bool   `json:"exists" yaml:"exists"`
+	URI    string `json:"uri,omitempty" yaml:"uri,omitempty"`
+	Err    error  `json:"-" yaml:"-"`
+}
+
+// environmentStatus holds a deployment environment and its variable count
+type environmentStatus struct {
+	Environment   *bitbucket.Environment `json:"environment" yaml:"environment"`
+	VariableCount int                    `json:"variable_count" yaml:"variable_count"`
+	Err           error                  `json:"-" yaml:"-"`
+}
+
+// serviceStatusOutput is the --output json/yaml form of serviceStatus.
+// Section errors are reported in Errors keyed by section name.
+type serviceStatusOutput struct {
+	Service          string                `json:"service" yaml:"service"`
+	Repository       *bitbucket.Repository `json:"repository,omitempty" yaml:"repository,omitempty"`
+	Pipelines        []*bitbucket.Pipeline `json:"pipelines" yaml:"pipelines"`
+	ECR              []*ecrRegionStatus    `json:"ecr" yaml:"ecr"`
+	Environments     []*environmentStatus  `json:"environments" yaml:"environments"`
+	SSHKeyConfigured bool                  `json:"ssh_key_configured" yaml:"ssh_key_configured"`
+	DeployKey        *bitbucket.DeployKey  `json:"deploy_key,omitempty" yaml:"deploy_key,omitempty"`
+	OpenPullRequests int                   `json:"open_pull_requests" yaml:"open_pull_requests"`
+	Errors           map[string]string     `json:"errors,omitempty" yaml:"errors,omitempty"`
+}
+
 var svcStatusCmd = &cobra.Command{
 	Use:   "status [service-name]",
 	Short: "Check the status of an EIS service",
-	Long: `Display the status of a service including:
-  - Repository information from Bitbucket
-  - ECR registry details from AWS
-  - Pipeline variables and configuration
-  - Current deployment status
+	Long: `Display a status dashboard for a service including:
+  - Repository information and default branch from Bitbucket
+  - Latest pipeline build per branch
+  - ECR registry presence in eu-central-1 and eu-central-2
+  - Deployment environments and their variable counts
+  - Pipeline SSH key and protorepo deploy key
+  - Number of open pull requests
+
+All sections are fetched concurrently. If a section cannot be fetched
+(for example missing AWS credentials), a warning is shown for that section
+and the remaining sections are still displayed.
+
+Use --output json or --output yaml for machine-readable output.
 
 If service-name is not provided, it will be auto-detected from the git repository
 in the current directory (based on the git remote URL).`,
@@ -29,7 +110,7 @@ in the current directory (based on the git remote URL).`,
 		if serviceName == "" {
 			detectedSlug, err := git.DetectRepositorySlug()
 			if err != nil {
-				fmt.Println("Error: No service name provided and could not auto-detect from git repository")
+				errorf("Error: No service name provided and could not auto-detect from git repository\n")
 				fmt.Printf("  %v\n", err)
 				fmt.Println("\nUsage:")
 				fmt.Println("  1. Run this command from within a git repository, or")
@@ -37,20 +118,355 @@ in the current directory (based on the git remote URL).`,
 				return
 			}
 			serviceName = detectedSlug
-			fmt.Printf("Auto-detected service from git repository: %s\n", serviceName)
+			infof("Auto-detected service from git repository: %s\n", serviceName)
+		}
+
+		// Load configuration
+		cfg, err := config.Load()
+		if err != nil {
+			errorf("Error loading configuration: %v\n", err)
+			return
+		}
+
+		// A Bitbucket client error only disables the Bitbucket sections, ECR is still checked
+		client, clientErr := bitbucket.NewClient(cfg)
+		if clientErr != nil {
+			clientErr = fmt.Errorf("failed to create Bitbucket client: %w", clientErr)
 		}
 
-		fmt.Printf("Checking status for service: %s\n", serviceName)
-		fmt.Println("\nRepository Information:")
-		fmt.Println("  VCS: Bitbucket")
-		fmt.Println("  Status: This feature is under development...")
-		fmt.Println("\nECR Registry:")
-		fmt.Println("  Status: This feature is under development...")
-		fmt.Println("\nPipeline Variables:")
-		fmt.Println("  Status: This feature is under development...")
+		infof("Checking status for service: %s\n", serviceName)
+
+		status := collectServiceStatus(cmd.Context(), cfg, client, clientErr, serviceName)
+		if isMachineOutput() {
+			printOutput(status.output())
+			return
+		}
+		displayServiceStatus(cfg, status)
 	},
 }
 
+// collectServiceStatus fetches all dashboard sections concurrently
+func collectServiceStatus(ctx context.Context, cfg *config.Config, client *bitbucket.Client, clientErr error, serviceName string) *serviceStatus {
+	status := &serviceStatus{
+		Service: serviceName,
+		ECR: []*ecrRegionStatus{
+			{Name: "Frankfurt", Region: "eu-central-1"},
+			{Name: "Zurich", Region: "eu-central-2"},
+		},
+	}
+
+	var wg sync.WaitGroup
+	run := func(fn func()) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			fn()
+		}()
+	}
+
+	// ECR does not depend on Bitbucket
+	profile := os.Getenv("AWS_PROFILE")
+	if profile == "" {
+		profile = cfg.AWS.DefaultProfile
+	}
+	for _, region := range status.ECR {
+		run(func() { fetchECRRegionStatus(ctx, profile, serviceName, region) })
+	}
+
+	if clientErr != nil {
+		status.RepositoryErr = clientErr
+		status.PipelinesErr = clientErr
+		status.EnvironmentsErr = clientErr
+		status.SSHKeyPairErr = clientErr
+		status.DeployKeyErr = clientErr
+		status.OpenPullRequestsErr = clientErr
+		wg.Wait()
+		return status
+	}
+
+	run(func() {
+		status.Repository, status.RepositoryErr = client.GetRepository(ctx, serviceName)
+	})
+
+	run(func() {
+		pipelines, err := client.ListPipelines(ctx, &bitbucket.PipelinesOptions{
+			RepoSlug: serviceName,
+			Limit:    statusPipelineLookback,
+		})
+		status.Pipelines, status.PipelinesErr = latestPipelinePerBranch(pipelines), err
+	})
+
+	run(func() {
+		status.Environments, status.EnvironmentsErr = fetchEnvironmentStatuses(ctx, client, serviceName)
+	})
+
+	run(func() {
+		status.SSHKeyPair, status.SSHKeyPairErr = client.GetPipelineSSHKeyPair(ctx, serviceName)
+	})
+
+	run(func() {
+		label := fmt.Sprintf("%s-pipeline", serviceName)
+		status.DeployKey, status.DeployKeyErr = client.FindDeployKeyByLabel(ctx, statusDeployKeyTarget, label)
+	})
+
+	run(func() {
+		prs, err := client.ListPullRequests(ctx, serviceName, &bitbucket.PullRequestOptions{
+			State: "OPEN",
+			Limit: statusPullRequestLimit,
+		})
+		status.OpenPullRequests, status.OpenPullRequestsErr = len(prs), err
+	})
+
+	wg.Wait()
+	return status
+}
+
+// fetchECRRegionStatus checks whether the service's ECR repository exists in a region
+func fetchECRRegionStatus(ctx context.Context, profile, serviceName string, region *ecrRegionStatus) {
+	ecrClient, err := aws.NewECRClient(ctx, profile, region.Region)
+	if err != nil {
+		region.Err = err
+		return
+	}
+
+	exists, repo, err := ecrClient.RepositoryExists(ctx, serviceName)
+	if err != nil {
+		region.Err = err
+		return
+	}
+
+	region.Exists = exists
+	if exists && repo != nil && repo.RepositoryUri != nil {
+		region.URI = *repo.RepositoryUri
+	}
+}
+
+// fetchEnvironmentStatuses lists deployment environments with the number of variables in each
+func fetchEnvironmentStatuses(ctx context.Context, client *bitbucket.Client, serviceName string) ([]*environmentStatus, error) {
+	environments, err := client.GetDeploymentEnvironments(ctx, serviceName)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]*environmentStatus, len(environments))
+	var wg sync.WaitGroup
+	for i, env := range environments {
+		statuses[i] = &environmentStatus{Environment: env}
+		wg.Add(1)
+		go func(envStatus *environmentStatus) {
+			defer wg.Done()
+			variables, err := client.GetDeploymentVariablesForEnv(ctx, serviceName, envStatus.Environment.UUID)
+			envStatus.VariableCount, envStatus.Err = len(variables), err
+		}(statuses[i])
+	}
+	wg.Wait()
+
+	return statuses, nil
+}
+
+// latestPipelinePerBranch keeps the first (most recent) pipeline seen for each
+// ref. Pull request pipelines have no ref and are kept per pull request.
+func latestPipelinePerBranch(pipelines []*bitbucket.Pipeline) []*bitbucket.Pipeline {
+	seen := make(map[string]bool)
+	latest := make([]*bitbucket.Pipeline, 0)
+
+	for _, p := range pipelines {
+		ref := p.Target.RefName
+		if p.Target.PullRequest != nil {
+			ref = fmt.Sprintf("pullrequest:%d", p.Target.PullRequest.ID)
+		} else if ref == "" {
+			ref = p.Target.RefType
+		}
+		if seen[ref] {
+			continue
+		}
+		seen[ref] = true
+		latest = append(latest, p)
+	}
+
+	return latest
+}
+
+// output converts the status into its serializable form
+func (s *serviceStatus) output() *serviceStatusOutput {
+	out := &serviceStatusOutput{
+		Service:          s.Service,
+		Repository:       s.Repository,
+		Pipelines:        s.Pipelines,
+		ECR:              s.ECR,
+		Environments:     s.Environments,
+		SSHKeyConfigured: s.SSHKeyPair != nil,
+		DeployKey:        s.DeployKey,
+		OpenPullRequests: s.OpenPullRequests,
+		Errors:           make(map[string]string),
+	}
+	if out.Pipelines == nil {
+		out.Pipelines = []*bitbucket.Pipeline{}
+	}
+	if out.Environments == nil {
+		out.Environments = []*environmentStatus{}
+	}
+
+	addErr := func(section string, err error) {
+		if err != nil {
+			out.Errors[section] = err.Error()
+		}
+	}
+	addErr("repository", s.RepositoryErr)
+	addErr("pipelines", s.PipelinesErr)
+	addErr("environments", s.EnvironmentsErr)
+	addErr("ssh_key", s.SSHKeyPairErr)
+	addErr("deploy_key", s.DeployKeyErr)
+	addErr("pull_requests", s.OpenPullRequestsErr)
+	for _, region := range s.ECR {
+		addErr("ecr."+region.Region, region.Err)
+	}
+	for _, env := range s.Environments {
+		addErr("environments."+env.Environment.Name, env.Err)
+	}
+
+	return out
+}
+
+// displayServiceStatus prints the dashboard
+func displayServiceStatus(cfg *config.Config, status *serviceStatus) {
+	greenColor := color.New(color.FgGreen).SprintFunc()
+	redColor := color.New(color.FgRed).SprintFunc()
+	yellowColor := color.New(color.FgYellow).SprintFunc()
+	cyanColor := color.New(color.FgCyan).SprintFunc()
+
+	warn := func(what string, err error) {
+		fmt.Printf("  %s Could not fetch %s: %v\n", yellowColor("⚠"), what, err)
+	}
+
+	// Repository
+	fmt.Println("\nRepository:")
+	if status.RepositoryErr != nil {
+		warn("repository", status.RepositoryErr)
+	} else {
+		repo := status.Repository
+		visibility := "public"
+		if repo.IsPrivate {
+			visibility = "private"
+		}
+		fmt.Printf("  %s %s (%s)\n", greenColor("✓"), cyanColor(repo.FullName), visibility)
+		if repo.Description != "" {
+			fmt.Printf("    Description:    %s\n", repo.Description)
+		}
+		if repo.ProjectName != "" {
+			fmt.Printf("    Project:        %s (%s)\n", repo.ProjectName, repo.ProjectKey)
+		}
+		if repo.Language != "" {
+			fmt.Printf("    Language:       %s\n", repo.Language)
+		}
+		mainBranch := repo.MainBranch
+		if mainBranch == "" {
+			mainBranch = "N/A"
+		}
+		fmt.Printf("    Default branch: %s\n", mainBranch)
+		fmt.Printf("    Last updated:   %s\n", formatTimeAgo(repo.UpdatedOn))
+		fmt.Printf("    URL:            %s\n", bitbucket.BuildRepositoryURL(cfg.Bitbucket.WebURL, cfg.Bitbucket.Workspace, status.Service))
+	}
+
+	// Pipelines
+	fmt.Println("\nLatest Pipelines (per branch):")
+	switch {
+	case status.PipelinesErr != nil:
+		warn("pipelines", status.PipelinesErr)
+	case len(status.Pipelines) == 0:
+		fmt.Println("  No pipelines found.")
+	default:
+		for _, p := range status.Pipelines {
+			state := pipelineStatus(p)
+			fmt.Printf("  %s %-30s #%-6d %-12s %s\n",
+				getStatusIcon(state), p.Target.Ref(), p.BuildNumber, state, formatTimeAgo(p.CreatedOn))
+		}
+	}
+
+	// ECR
+	fmt.Println("\nECR Registry:")
+	for _, region := range status.ECR {
+		label := fmt.Sprintf("%s (%s)", region.Name, region.Region)
+		switch {
+		case region.Err != nil:
+			warn("ECR status for "+label, region.Err)
+		case region.Exists:
+			fmt.Printf("  %s %s: %s\n", greenColor("✓"), label, region.URI)
+		default:
+			fmt.Printf("  %s %s: repository not found\n", redColor("✗"), label)
+		}
+	}
+
+	// Deployment environments
+	fmt.Println("\nDeployment Environments:")
+	switch {
+	case status.EnvironmentsErr != nil:
+		warn("deployment environments", status.EnvironmentsErr)
+	case len(status.Environments) == 0:
+		fmt.Println("  No deployment environments found.")
+	default:
+		for _, envStatus := range status.Environments {
+			env := envStatus.Environment
+			if envStatus.Err != nil {
+				fmt.Printf("  %-20s %-12s %s\n", env.Name, env.Type, yellowColor("⚠ could not fetch variables"))
+				continue
+			}
+			fmt.Printf("  %-20s %-12s %d variable(s)\n", env.Name, env.Type, envStatus.VariableCount)
+		}
+	}
+
+	// SSH access
+	fmt.Println("\nPipeline SSH Access:")
+	switch {
+	case status.SSHKeyPairErr != nil:
+		warn("pipeline SSH key", status.SSHKeyPairErr)
+	case status.SSHKeyPair == nil:
+		fmt.Printf("  %s No pipeline SSH key pair configured\n", redColor("✗"))
+	default:
+		fmt.Printf("  %s Pipeline SSH key pair configured\n", greenColor("✓"))
+	}
+
+	label := fmt.Sprintf("%s-pipeline", status.Service)
+	switch {
+	case status.DeployKeyErr != nil:
+		warn(statusDeployKeyTarget+" deploy key", status.DeployKeyErr)
+	case status.DeployKey == nil:
+		fmt.Printf("  %s No deploy key '%s' on %s\n", redColor("✗"), label, statusDeployKeyTarget)
+	case status.SSHKeyPair != nil && !sameSSHPublicKey(status.SSHKeyPair.PublicKey, status.DeployKey.Key):
+		fmt.Printf("  %s Deploy key '%s' on %s does not match the pipeline SSH key\n",
+			yellowColor("⚠"), label, statusDeployKeyTarget)
+	default:
+		fmt.Printf("  %s Deploy key '%s' present on %s\n", greenColor("✓"), label, statusDeployKeyTarget)
+	}
+
+	if status.SSHKeyPair == nil || status.DeployKey == nil {
+		if status.SSHKeyPairErr == nil && status.DeployKeyErr == nil {
+			fmt.Printf("    To fix: eiscli ssh-link %s\n", status.Service)
+		}
+	}
+
+	// Pull requests
+	fmt.Println("\nPull Requests:")
+	switch {
+	case status.OpenPullRequestsErr != nil:
+		warn("pull requests", status.OpenPullRequestsErr)
+	case status.OpenPullRequests >= statusPullRequestLimit:
+		fmt.Printf("  %d+ open pull request(s)\n", statusPullRequestLimit)
+	default:
+		fmt.Printf("  %d open pull request(s)\n", status.OpenPullRequests)
+	}
+}
+
+// sameSSHPublicKey compares two public keys ignoring the trailing comment
+func sameSSHPublicKey(a, b string) bool {
+	fieldsA := strings.Fields(a)
+	fieldsB := strings.Fields(b)
+	if len(fieldsA) < 2 || len(fieldsB) < 2 {
+		return strings.TrimSpace(a) == strings.TrimSpace(b)
+	}
+	return fieldsA[0] == fieldsB[0] && fieldsA[1] == fieldsB[1]
+}
+
 func init() {
 	svcCmd.AddCommand(svcStatusCmd)
 }
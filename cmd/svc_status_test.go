@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"testing"
+
+	"bitbucket.org/cover42/eiscli/internal/bitbucket"
+)
+
+func TestLatestPipelinePerBranch(t *testing.T) {
+	pipeline := func(buildNumber int, refType, refName string) *bitbucket.Pipeline {
+		return &bitbucket.Pipeline{BuildNumber: buildNumber, Target: bitbucket.PipelineTarget{RefType: refType, RefName: refName}}
+	}
+	pullRequestPipeline := func(buildNumber int, source string, id int) *bitbucket.Pipeline {
+		return &bitbucket.Pipeline{BuildNumber: buildNumber, Target: bitbucket.PipelineTarget{
+			Type:        "pipeline_pullrequest_target",
+			Source:      source,
+			Destination: "main",
+			PullRequest: &bitbucket.PipelinePullRequest{ID: id},
+		}}
+	}
+
+	// Pipelines come newest first
+	pipelines := []*bitbucket.Pipeline{
+		pipeline(10, "branch", "main"),
+		pipeline(9, "branch", "feature/a"),
+		pipeline(8, "branch", "main"),
+		pullRequestPipeline(7, "feature/b", 12),
+		pipeline(6, "tag", "v1.0.0"),
+		pullRequestPipeline(5, "feature/c", 14),
+		pullRequestPipeline(4, "feature/b", 12),
+		pipeline(3, "branch", "feature/a"),
+	}
+
+	got := latestPipelinePerBranch(pipelines)
+	want := []struct {
+		buildNumber int
+		ref         string
+	}{
+		{10, "main"},
+		{9, "feature/a"},
+		{7, "feature/b (PR #12)"},
+		{6, "v1.0.0"},
+		{5, "feature/c (PR #14)"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("latestPipelinePerBranch() returned %d pipelines, want %d", len(got), len(want))
+	}
+	for i, p := range got {
+		if p.BuildNumber != want[i].buildNumber || p.Target.Ref() != want[i].ref {
+			t.Errorf("pipeline %d is build #%d of %q, want #%d of %q", i, p.BuildNumber, p.Target.Ref(), want[i].buildNumber, want[i].ref)
+		}
+	}
+
+	if got := latestPipelinePerBranch(nil); len(got) != 0 {
+		t.Errorf("latestPipelinePerBranch(nil) = %v, want none", got)
+	}
+}
+
+func TestSameSSHPublicKey(t *testing.T) {
+	const key = "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIKey1"
+
+	tests := []struct {
+		name string
+		a, b string
+		want bool
+	}{
+		{"identical", key + " deploy@eis", key + " deploy@eis", true},
+		{"different comments", key + " deploy@eis", key + " pipelines@bitbucket", true},
+		{"comment on one side only", key, key + " deploy@eis", true},
+		{"comment with spaces", key + " EIS deploy key", key, true},
+		{"surrounding whitespace and newline", "  " + key + "\n", key, true},
+		{"tabs and repeated spaces", "ssh-ed25519\t\tAAAAC3NzaC1lZDI1NTE5AAAAIKey1  deploy@eis", key, true},
+		{"different key", key + " deploy@eis", "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIKey2 deploy@eis", false},
+		{"different type", key, "ssh-rsa AAAAC3NzaC1lZDI1NTE5AAAAIKey1", false},
+		{"incomplete keys compared as text", " AAAAKey ", "AAAAKey", true},
+		{"incomplete and complete key", "AAAAC3NzaC1lZDI1NTE5AAAAIKey1", key, false},
+		{"empty", "", key, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sameSSHPublicKey(tt.a, tt.b); got != tt.want {
+				t.Errorf("sameSSHPublicKey(%q, %q) = %t, want %t", tt.a, tt.b, got, tt.want)
+			}
+			if got := sameSSHPublicKey(tt.b, tt.a); got != tt.want {
+				t.Errorf("sameSSHPublicKey(%q, %q) = %t, want %t", tt.b, tt.a, got, tt.want)
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"strings"
@@ -20,6 +21,13 @@ var (
 	envTypeOverride string
 )
 
+// variableListing groups variables by where they are defined, used for --output json/yaml
+type variableListing struct {
+	Scope       string                 `json:"scope" yaml:"scope"` // workspace, repository, deployment
+	Environment *bitbucket.Environment `json:"environment,omitempty" yaml:"environment,omitempty"`
+	Variables   []*bitbucket.Variable  `json:"variables" yaml:"variables"`
+}
+
 var varsCmd = &cobra.Command{
 	Use:   "vars [service-name]",
 	Short: "List Bitbucket deployment and repository variables",
@@ -36,38 +44,24 @@ Use --all to show all environments (deployment variables only).
 If the specified environment doesn't exist, you'll be prompted to create it.
 Use --auto-create-env to create missing environments without prompting.
 Use --env-type to override the inferred environment type.
+Use --output json or --output yaml for machine-readable output. Secured values are never included.
+
+Use 'eiscli vars set KEY=VALUE' and 'eiscli vars unset KEY' to change variables.
 
 If service-name is not provided, it will be auto-detected from the git repository
 in the current directory (based on the git remote URL).`,
 	Args: cobra.MaximumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
-		// Load configuration first (needed for all types)
-		cfg, err := config.Load()
-		if err != nil {
-			fmt.Printf("Error loading configuration: %v\n", err)
-			fmt.Println("\nPlease set the following environment variables:")
-			fmt.Println("  EISCLI_BITBUCKET_USERNAME")
-			fmt.Println("  EISCLI_BITBUCKET_APP_PASSWORD")
-			fmt.Println("  EISCLI_BITBUCKET_WORKSPACE")
-			return
-		}
+		ctx := cmd.Context()
 
-		// Validate configuration
-		if err := cfg.Validate(); err != nil {
-			fmt.Printf("Configuration error: %v\n", err)
-			return
-		}
-
-		// Create Bitbucket client
-		client, err := bitbucket.NewClient(cfg)
-		if err != nil {
-			fmt.Printf("Error creating Bitbucket client: %v\n", err)
+		client, ok := newBitbucketClient()
+		if !ok {
 			return
 		}
 
 		// Handle workspace variables separately (no service-name needed)
 		if variableType == "workspace" {
-			displayWorkspaceVariables(client)
+			displayWorkspaceVariables(ctx, client)
 			return
 		}
 
@@ -81,7 +75,7 @@ in the current directory (based on the git remote URL).`,
 		if serviceName == "" {
 			detectedSlug, err := git.DetectRepositorySlug()
 			if err != nil {
-				fmt.Println("Error: No service name provided and could not auto-detect from git repository")
+				errorf("Error: No service name provided and could not auto-detect from git repository\n")
 				fmt.Printf("  %v\n", err)
 				fmt.Println("\nUsage:")
 				fmt.Println("  1. Run this command from within a git repository, or")
@@ -89,27 +83,32 @@ in the current directory (based on the git remote URL).`,
 				return
 			}
 			serviceName = detectedSlug
-			fmt.Printf("Auto-detected service from git repository: %s\n\n", serviceName)
+			infof("Auto-detected service from git repository: %s\n\n", serviceName)
 		}
 
 		// Handle based on variable type
 		switch variableType {
 		case "repository":
-			displayRepositoryVariables(client, serviceName)
+			displayRepositoryVariables(ctx, client, serviceName)
 		case "deployment":
-			displayDeploymentVariables(client, serviceName)
+			displayDeploymentVariables(ctx, client, serviceName)
 		default: // "combined" or empty - this is the new default
-			displayCombinedVariables(client, serviceName)
+			displayCombinedVariables(ctx, client, serviceName)
 		}
 	},
 }
 
-func displayWorkspaceVariables(client *bitbucket.Client) {
-	fmt.Println("Workspace Variables")
+func displayWorkspaceVariables(ctx context.Context, client *bitbucket.Client) {
+	infof("Workspace Variables\n")
 
-	variables, err := client.GetWorkspaceVariables()
+	variables, err := client.GetWorkspaceVariables(ctx)
 	if err != nil {
-		fmt.Printf("Error fetching workspace variables: %v\n", err)
+		errorf("Error fetching workspace variables: %v\n", err)
+		return
+	}
+
+	if isMachineOutput() {
+		printOutput([]*variableListing{{Scope: "workspace", Variables: variables}})
 		return
 	}
 
@@ -138,24 +137,24 @@ func displayWorkspaceVariables(client *bitbucket.Client) {
 	fmt.Printf("\nTotal: %d variable(s)\n", len(variables))
 }
 
-func displayCombinedVariables(client *bitbucket.Client, serviceName string) {
-	fmt.Printf("Variables for: %s (Repository + Test Environment)\n\n", serviceName)
+func displayCombinedVariables(ctx context.Context, client *bitbucket.Client, serviceName string) {
+	infof("Variables for: %s (Repository + Test Environment)\n\n", serviceName)
 
 	// Fetch repository variables
-	repoVariables, repoErr := client.GetRepositoryVariables(serviceName)
+	repoVariables, repoErr := client.GetRepositoryVariables(ctx, serviceName)
 	if repoErr != nil {
-		fmt.Printf("Warning: Could not fetch repository variables: %v\n", repoErr)
+		infof("Warning: Could not fetch repository variables: %v\n", repoErr)
 		repoVariables = []*bitbucket.Variable{}
 	}
 
 	// Fetch deployment environments to find Test environment
+	var testEnv *bitbucket.Environment
 	var deploymentVariables []*bitbucket.Variable
-	environments, envErr := client.GetDeploymentEnvironments(serviceName)
+	environments, envErr := client.GetDeploymentEnvironments(ctx, serviceName)
 	if envErr != nil {
-		fmt.Printf("Warning: Could not fetch deployment environments: %v\n", envErr)
+		infof("Warning: Could not fetch deployment environments: %v\n", envErr)
 	} else {
 		// Find Test environment
-		var testEnv *bitbucket.Environment
 		for _, env := range environments {
 			if strings.EqualFold(env.Name, "Test") {
 				testEnv = env
@@ -164,10 +163,22 @@ func displayCombinedVariables(client *bitbucket.Client, serviceName string) {
 		}
 
 		if testEnv != nil {
-			deploymentVariables, _ = client.GetDeploymentVariablesForEnv(serviceName, testEnv.UUID)
+			deploymentVariables, _ = client.GetDeploymentVariablesForEnv(ctx, serviceName, testEnv.UUID)
 		}
 	}
 
+	if isMachineOutput() {
+		listings := []*variableListing{{Scope: "repository", Variables: repoVariables}}
+		if testEnv != nil {
+			if deploymentVariables == nil {
+				deploymentVariables = []*bitbucket.Variable{}
+			}
+			listings = append(listings, &variableListing{Scope: "deployment", Environment: testEnv, Variables: deploymentVariables})
+		}
+		printOutput(listings)
+		return
+	}
+
 	// Check if we have any variables
 	totalVars := len(repoVariables) + len(deploymentVariables)
 	if totalVars == 0 {
@@ -210,12 +221,17 @@ func displayCombinedVariables(client *bitbucket.Client, serviceName string) {
 		totalVars, len(repoVariables), len(deploymentVariables))
 }
 
-func displayRepositoryVariables(client *bitbucket.Client, serviceName string) {
-	fmt.Printf("Repository Variables for: %s\n\n", serviceName)
+func displayRepositoryVariables(ctx context.Context, client *bitbucket.Client, serviceName string) {
+	infof("Repository Variables for: %s\n\n", serviceName)
 
-	variables, err := client.GetRepositoryVariables(serviceName)
+	variables, err := client.GetRepositoryVariables(ctx, serviceName)
 	if err != nil {
-		fmt.Printf("Error fetching repository variables: %v\n", err)
+		errorf("Error fetching repository variables: %v\n", err)
+		return
+	}
+
+	if isMachineOutput() {
+		printOutput([]*variableListing{{Scope: "repository", Variables: variables}})
 		return
 	}
 
@@ -244,11 +260,11 @@ func displayRepositoryVariables(client *bitbucket.Client, serviceName string) {
 	fmt.Printf("\nTotal: %d variable(s)\n", len(variables))
 }
 
-func displayDeploymentVariables(client *bitbucket.Client, serviceName string) {
+func displayDeploymentVariables(ctx context.Context, client *bitbucket.Client, serviceName string) {
 	// Fetch all environments
-	environments, err := client.GetDeploymentEnvironments(serviceName)
+	environments, err := client.GetDeploymentEnvironments(ctx, serviceName)
 	if err != nil {
-		fmt.Printf("Error fetching deployment environments: %v\n", err)
+		errorf("Error fetching deployment environments: %v\n", err)
 		return
 	}
 
@@ -259,7 +275,7 @@ func displayDeploymentVariables(client *bitbucket.Client, serviceName string) {
 
 	// If --all flag is set, display variables for all environments
 	if showAllEnvs {
-		displayAllEnvironmentVariables(client, serviceName, environments)
+		displayAllEnvironmentVariables(ctx, client, serviceName, environments)
 		return
 	}
 
@@ -283,6 +299,7 @@ func displayDeploymentVariables(client *bitbucket.Client, serviceName string) {
 
 		// Try to ensure environment exists (will prompt or auto-create)
 		createdEnv, err := bitbucket.EnsureEnvironmentExists(
+			ctx,
 			client,
 			serviceName,
 			environmentName,
@@ -291,7 +308,7 @@ func displayDeploymentVariables(client *bitbucket.Client, serviceName string) {
 		)
 
 		if err != nil {
-			fmt.Printf("\nError: %v\n", err)
+			errorf("\nError: %v\n", err)
 			return
 		}
 
@@ -300,11 +317,16 @@ func displayDeploymentVariables(client *bitbucket.Client, serviceName string) {
 	}
 
 	// Fetch variables for the target environment
-	fmt.Printf("Deployment Variables for: %s (Environment: %s)\n\n", serviceName, targetEnv.Name)
+	infof("Deployment Variables for: %s (Environment: %s)\n\n", serviceName, targetEnv.Name)
 
-	variables, err := client.GetDeploymentVariablesForEnv(serviceName, targetEnv.UUID)
+	variables, err := client.GetDeploymentVariablesForEnv(ctx, serviceName, targetEnv.UUID)
 	if err != nil {
-		fmt.Printf("Error fetching deployment variables: %v\n", err)
+		errorf("Error fetching deployment variables: %v\n", err)
+		return
+	}
+
+	if isMachineOutput() {
+		printOutput([]*variableListing{{Scope: "deployment", Environment: targetEnv, Variables: variables}})
 		return
 	}
 
@@ -333,8 +355,22 @@ func displayDeploymentVariables(client *bitbucket.Client, serviceName string) {
 	fmt.Printf("\nTotal: %d variable(s)\n", len(variables))
 }
 
-func displayAllEnvironmentVariables(client *bitbucket.Client, serviceName string, environments []*bitbucket.Environment) {
-	fmt.Printf("Deployment Variables for: %s (All Environments)\n\n", serviceName)
+func displayAllEnvironmentVariables(ctx context.Context, client *bitbucket.Client, serviceName string, environments []*bitbucket.Environment) {
+	infof("Deployment Variables for: %s (All Environments)\n\n", serviceName)
+
+	if isMachineOutput() {
+		listings := make([]*variableListing, 0, len(environments))
+		for _, env := range environments {
+			variables, err := client.GetDeploymentVariablesForEnv(ctx, serviceName, env.UUID)
+			if err != nil {
+				infof("Warning: Could not fetch variables for %s: %v\n", env.Name, err)
+				continue
+			}
+			listings = append(listings, &variableListing{Scope: "deployment", Environment: env, Variables: variables})
+		}
+		printOutput(listings)
+		return
+	}
 
 	totalVars := 0
 
@@ -342,7 +378,7 @@ func displayAllEnvironmentVariables(client *bitbucket.Client, serviceName string
 		fmt.Printf("Environment: %s (%s)\n", env.Name, env.Type)
 		fmt.Println(strings.Repeat("-", 80))
 
-		variables, err := client.GetDeploymentVariablesForEnv(serviceName, env.UUID)
+		variables, err := client.GetDeploymentVariablesForEnv(ctx, serviceName, env.UUID)
 		if err != nil {
 			fmt.Printf("  Error fetching variables: %v\n\n", err)
 			continue
@@ -2,12 +2,12 @@ package cmd
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"os"
 	"strings"
 
 	"bitbucket.org/cover42/eiscli/internal/bitbucket"
-	"bitbucket.org/cover42/eiscli/internal/config"
 	"bitbucket.org/cover42/eiscli/internal/git"
 	"bitbucket.org/cover42/eiscli/internal/kubernetes"
 	"github.com/fatih/color"
@@ -44,6 +44,8 @@ If service-name is not provided, it will be auto-detected from the git repositor
 in the current directory (based on the git remote URL).`,
 	Args: cobra.MaximumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+
 		serviceName := ""
 		if len(args) > 0 {
 			serviceName = args[0]
@@ -53,7 +55,7 @@ in the current directory (based on the git remote URL).`,
 		if serviceName == "" {
 			detectedSlug, err := git.DetectRepositorySlug()
 			if err != nil {
-				fmt.Println("Error: No service name provided and could not auto-detect from git repository")
+				errorf("Error: No service name provided and could not auto-detect from git repository\n")
 				fmt.Printf("  %v\n", err)
 				fmt.Println("\nUsage:")
 				fmt.Println("  1. Run this command from within a git repository, or")
@@ -64,27 +66,13 @@ in the current directory (based on the git remote URL).`,
 			fmt.Printf("Auto-detected service from git repository: %s\n\n", serviceName)
 		}
 
-		// Load configuration
-		cfg, err := config.Load()
-		if err != nil {
-			fmt.Printf("Error loading configuration: %v\n", err)
-			fmt.Println("\nPlease set the following environment variables:")
-			fmt.Println("  EISCLI_BITBUCKET_USERNAME")
-			fmt.Println("  EISCLI_BITBUCKET_APP_PASSWORD")
-			fmt.Println("  EISCLI_BITBUCKET_WORKSPACE")
-			return
-		}
-
-		// Validate configuration
-		if err := cfg.Validate(); err != nil {
-			fmt.Printf("Configuration error: %v\n", err)
+		cfg, ok := loadConfig()
+		if !ok {
 			return
 		}
 
-		// Create Bitbucket client
-		client, err := bitbucket.NewClient(cfg)
-		if err != nil {
-			fmt.Printf("Error creating Bitbucket client: %v\n", err)
+		client, ok := newBitbucketClient()
+		if !ok {
 			return
 		}
 
@@ -92,7 +80,7 @@ in the current directory (based on the git remote URL).`,
 		var targetEnv *bitbucket.Environment
 		if addVariableType == "deployment" {
 			if addEnvironmentName == "" {
-				fmt.Println("Error: --env flag is required when using --type deployment")
+				errorf("Error: --env flag is required when using --type deployment\n")
 				fmt.Println("\nUsage: eiscli vars add [service-name] --type deployment --env <environment>")
 				return
 			}
@@ -105,6 +93,7 @@ in the current directory (based on the git remote URL).`,
 
 			// Ensure environment exists (will create if necessary)
 			createdEnv, err := bitbucket.EnsureEnvironmentExists(
+				ctx,
 				client,
 				serviceName,
 				addEnvironmentName,
@@ -112,7 +101,7 @@ in the current directory (based on the git remote URL).`,
 				addEnvTypeOverride,
 			)
 			if err != nil {
-				fmt.Printf("\nError: %v\n", err)
+				errorf("\nError: %v\n", err)
 				return
 			}
 			targetEnv = createdEnv
@@ -122,7 +111,7 @@ in the current directory (based on the git remote URL).`,
 		// Collect variables interactively
 		variables, err := collectVariablesInteractively()
 		if err != nil {
-			fmt.Printf("\nError: %v\n", err)
+			errorf("\nError: %v\n", err)
 			return
 		}
 
@@ -136,13 +125,13 @@ in the current directory (based on the git remote URL).`,
 
 		// Confirm and create
 		if addVariableType == "deployment" {
-			if err := confirmAndCreateDeploymentVariables(client, serviceName, targetEnv.UUID, variables); err != nil {
-				fmt.Printf("\nError: %v\n", err)
+			if err := confirmAndCreateDeploymentVariables(ctx, client, serviceName, targetEnv.UUID, variables); err != nil {
+				errorf("\nError: %v\n", err)
 				os.Exit(1)
 			}
 		} else {
-			if err := confirmAndCreateRepositoryVariables(client, serviceName, variables); err != nil {
-				fmt.Printf("\nError: %v\n", err)
+			if err := confirmAndCreateRepositoryVariables(ctx, client, serviceName, variables); err != nil {
+				errorf("\nError: %v\n", err)
 				os.Exit(1)
 			}
 		}
@@ -243,7 +232,7 @@ func displayVariablesTable(variables []VariableToAdd) {
 	fmt.Printf("\nTotal: %d variable(s)\n", len(variables))
 }
 
-func confirmAndCreateRepositoryVariables(client *bitbucket.Client, serviceName string, variables []VariableToAdd) error {
+func confirmAndCreateRepositoryVariables(ctx context.Context, client *bitbucket.Client, serviceName string, variables []VariableToAdd) error {
 	// Final confirmation
 	fmt.Println("\n" + strings.Repeat("=", 80))
 	fmt.Printf("Create these %d repository variable(s)? [y/N]: ", len(variables))
@@ -262,10 +251,10 @@ func confirmAndCreateRepositoryVariables(client *bitbucket.Client, serviceName s
 
 	// Create variables
 	fmt.Println("\nCreating repository variables...")
-	return createRepositoryVariables(client, serviceName, variables)
+	return createRepositoryVariables(ctx, client, serviceName, variables)
 }
 
-func confirmAndCreateDeploymentVariables(client *bitbucket.Client, serviceName, envUUID string, variables []VariableToAdd) error {
+func confirmAndCreateDeploymentVariables(ctx context.Context, client *bitbucket.Client, serviceName, envUUID string, variables []VariableToAdd) error {
 	// Final confirmation
 	fmt.Println("\n" + strings.Repeat("=", 80))
 	fmt.Printf("Create these %d deployment variable(s)? [y/N]: ", len(variables))
@@ -284,10 +273,10 @@ func confirmAndCreateDeploymentVariables(client *bitbucket.Client, serviceName,
 
 	// Create variables
 	fmt.Println("\nCreating deployment variables...")
-	return createDeploymentVariables(client, serviceName, envUUID, variables)
+	return createDeploymentVariables(ctx, client, serviceName, envUUID, variables)
 }
 
-func createRepositoryVariables(client *bitbucket.Client, serviceName string, variables []VariableToAdd) error {
+func createRepositoryVariables(ctx context.Context, client *bitbucket.Client, serviceName string, variables []VariableToAdd) error {
 	successCount := 0
 	failCount := 0
 
@@ -295,7 +284,7 @@ func createRepositoryVariables(client *bitbucket.Client, serviceName string, var
 	redColor := color.New(color.FgRed).SprintFunc()
 
 	for _, v := range variables {
-		err := client.CreateRepositoryVariable(serviceName, v.Key, v.Value, v.Secured)
+		err := client.CreateRepositoryVariable(ctx, serviceName, v.Key, v.Value, v.Secured)
 		if err != nil {
 			fmt.Printf("  %s Failed to create %s: %v\n", redColor("✗"), v.Key, err)
 			failCount++
@@ -322,7 +311,7 @@ func createRepositoryVariables(client *bitbucket.Client, serviceName string, var
 	return nil
 }
 
-func createDeploymentVariables(client *bitbucket.Client, serviceName, envUUID string, variables []VariableToAdd) error {
+func createDeploymentVariables(ctx context.Context, client *bitbucket.Client, serviceName, envUUID string, variables []VariableToAdd) error {
 	successCount := 0
 	failCount := 0
 
@@ -330,7 +319,7 @@ func createDeploymentVariables(client *bitbucket.Client, serviceName, envUUID st
 	redColor := color.New(color.FgRed).SprintFunc()
 
 	for _, v := range variables {
-		err := client.CreateDeploymentVariable(serviceName, envUUID, v.Key, v.Value, v.Secured)
+		err := client.CreateDeploymentVariable(ctx, serviceName, envUUID, v.Key, v.Value, v.Secured)
 		if err != nil {
 			fmt.Printf("  %s Failed to create %s: %v\n", redColor("✗"), v.Key, err)
 			failCount++
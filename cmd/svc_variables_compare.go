@@ -1,13 +1,13 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"sort"
 	"strings"
 
 	"bitbucket.org/cover42/eiscli/internal/bitbucket"
-	"bitbucket.org/cover42/eiscli/internal/config"
 	"bitbucket.org/cover42/eiscli/internal/git"
 	"github.com/fatih/color"
 	"github.com/olekukonko/tablewriter"
@@ -70,9 +70,11 @@ Examples:
   eiscli vars compare my-service --with other-service --type production`,
 	Args: cobra.MaximumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+
 		// Validate --with flag
 		if compareWithService == "" {
-			fmt.Println("Error: --with flag is required to specify the service to compare against")
+			errorf("Error: --with flag is required to specify the service to compare against\n")
 			fmt.Println("\nUsage: eiscli vars compare [source-service] --with <target-service>")
 			return
 		}
@@ -83,31 +85,12 @@ Examples:
 			"staging": true, "production": true,
 		}
 		if !validTypes[strings.ToLower(compareVarType)] {
-			fmt.Printf("Error: Invalid type '%s'. Valid types: combined, repository, test, staging, production\n", compareVarType)
-			return
-		}
-
-		// Load configuration
-		cfg, err := config.Load()
-		if err != nil {
-			fmt.Printf("Error loading configuration: %v\n", err)
-			fmt.Println("\nPlease set the following environment variables:")
-			fmt.Println("  EISCLI_BITBUCKET_USERNAME")
-			fmt.Println("  EISCLI_BITBUCKET_APP_PASSWORD")
-			fmt.Println("  EISCLI_BITBUCKET_WORKSPACE")
-			return
-		}
-
-		// Validate configuration
-		if err := cfg.Validate(); err != nil {
-			fmt.Printf("Configuration error: %v\n", err)
+			errorf("Error: Invalid type '%s'. Valid types: combined, repository, test, staging, production\n", compareVarType)
 			return
 		}
 
-		// Create Bitbucket client
-		client, err := bitbucket.NewClient(cfg)
-		if err != nil {
-			fmt.Printf("Error creating Bitbucket client: %v\n", err)
+		client, ok := newBitbucketClient()
+		if !ok {
 			return
 		}
 
@@ -121,7 +104,7 @@ Examples:
 		if sourceService == "" {
 			detectedSlug, err := git.DetectRepositorySlug()
 			if err != nil {
-				fmt.Println("Error: No service name provided and could not auto-detect from git repository")
+				errorf("Error: No service name provided and could not auto-detect from git repository\n")
 				fmt.Printf("  %v\n", err)
 				fmt.Println("\nUsage:")
 				fmt.Println("  1. Run this command from within a git repository, or")
@@ -134,31 +117,31 @@ Examples:
 
 		// Ensure source and target are different
 		if sourceService == compareWithService {
-			fmt.Println("Error: Source and target services must be different")
+			errorf("Error: Source and target services must be different\n")
 			return
 		}
 
 		// Execute comparison
-		executeVariablesComparison(client, sourceService, compareWithService, strings.ToLower(compareVarType))
+		executeVariablesComparison(ctx, client, sourceService, compareWithService, strings.ToLower(compareVarType))
 	},
 }
 
 // fetchServiceVariables fetches variables for a service based on the type filter
-func fetchServiceVariables(client *bitbucket.Client, serviceName, varType string) (map[string]*ComparedVariable, error) {
+func fetchServiceVariables(ctx context.Context, client *bitbucket.Client, serviceName, varType string) (map[string]*ComparedVariable, error) {
 	switch varType {
 	case "repository":
-		return fetchRepositoryVariables(client, serviceName)
+		return fetchRepositoryVariables(ctx, client, serviceName)
 	case "test", "staging", "production":
-		return fetchDeploymentVariables(client, serviceName, varType)
+		return fetchDeploymentVariables(ctx, client, serviceName, varType)
 	default: // "combined"
-		return fetchCombinedVariables(client, serviceName)
+		return fetchCombinedVariables(ctx, client, serviceName)
 	}
 }
 
-func fetchRepositoryVariables(client *bitbucket.Client, serviceName string) (map[string]*ComparedVariable, error) {
+func fetchRepositoryVariables(ctx context.Context, client *bitbucket.Client, serviceName string) (map[string]*ComparedVariable, error) {
 	variables := make(map[string]*ComparedVariable)
 
-	repoVars, err := client.GetRepositoryVariables(serviceName)
+	repoVars, err := client.GetRepositoryVariables(ctx, serviceName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch repository variables: %w", err)
 	}
@@ -180,13 +163,13 @@ func fetchRepositoryVariables(client *bitbucket.Client, serviceName string) (map
 	return variables, nil
 }
 
-func fetchDeploymentVariables(client *bitbucket.Client, serviceName, envName string) (map[string]*ComparedVariable, error) {
+func fetchDeploymentVariables(ctx context.Context, client *bitbucket.Client, serviceName, envName string) (map[string]*ComparedVariable, error) {
 	variables := make(map[string]*ComparedVariable)
 
 	// Capitalize environment name for display and matching
 	displayName := capitalizeFirst(envName)
 
-	environments, err := client.GetDeploymentEnvironments(serviceName)
+	environments, err := client.GetDeploymentEnvironments(ctx, serviceName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch deployment environments: %w", err)
 	}
@@ -203,7 +186,7 @@ func fetchDeploymentVariables(client *bitbucket.Client, serviceName, envName str
 		return variables, nil // No matching environment, return empty
 	}
 
-	deployVars, err := client.GetDeploymentVariablesForEnv(serviceName, targetEnv.UUID)
+	deployVars, err := client.GetDeploymentVariablesForEnv(ctx, serviceName, targetEnv.UUID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch %s environment variables: %w", displayName, err)
 	}
@@ -225,11 +208,11 @@ func fetchDeploymentVariables(client *bitbucket.Client, serviceName, envName str
 	return variables, nil
 }
 
-func fetchCombinedVariables(client *bitbucket.Client, serviceName string) (map[string]*ComparedVariable, error) {
+func fetchCombinedVariables(ctx context.Context, client *bitbucket.Client, serviceName string) (map[string]*ComparedVariable, error) {
 	variables := make(map[string]*ComparedVariable)
 
 	// Fetch repository variables
-	repoVars, err := client.GetRepositoryVariables(serviceName)
+	repoVars, err := client.GetRepositoryVariables(ctx, serviceName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch repository variables: %w", err)
 	}
@@ -249,7 +232,7 @@ func fetchCombinedVariables(client *bitbucket.Client, serviceName string) (map[s
 	}
 
 	// Fetch Test environment variables
-	environments, err := client.GetDeploymentEnvironments(serviceName)
+	environments, err := client.GetDeploymentEnvironments(ctx, serviceName)
 	if err != nil {
 		// Don't fail, just warn
 		fmt.Printf("Warning: Could not fetch deployment environments for %s: %v\n", serviceName, err)
@@ -265,7 +248,7 @@ func fetchCombinedVariables(client *bitbucket.Client, serviceName string) (map[s
 	}
 
 	if testEnv != nil {
-		deployVars, err := client.GetDeploymentVariablesForEnv(serviceName, testEnv.UUID)
+		deployVars, err := client.GetDeploymentVariablesForEnv(ctx, serviceName, testEnv.UUID)
 		if err != nil {
 			fmt.Printf("Warning: Could not fetch Test environment variables for %s: %v\n", serviceName, err)
 		} else {
@@ -288,7 +271,7 @@ func fetchCombinedVariables(client *bitbucket.Client, serviceName string) (map[s
 	return variables, nil
 }
 
-func executeVariablesComparison(client *bitbucket.Client, sourceService, targetService, varType string) {
+func executeVariablesComparison(ctx context.Context, client *bitbucket.Client, sourceService, targetService, varType string) {
 	var typeLabel string
 	if varType == "combined" {
 		typeLabel = "Repository + Test"
@@ -301,17 +284,17 @@ func executeVariablesComparison(client *bitbucket.Client, sourceService, targetS
 
 	// Fetch variables from source service
 	fmt.Printf("\nFetching variables from %s...\n", sourceService)
-	sourceVars, err := fetchServiceVariables(client, sourceService, varType)
+	sourceVars, err := fetchServiceVariables(ctx, client, sourceService, varType)
 	if err != nil {
-		fmt.Printf("Error fetching variables from %s: %v\n", sourceService, err)
+		errorf("Error fetching variables from %s: %v\n", sourceService, err)
 		return
 	}
 
 	// Fetch variables from target service
 	fmt.Printf("Fetching variables from %s...\n", targetService)
-	targetVarsRaw, err := fetchServiceVariables(client, targetService, varType)
+	targetVarsRaw, err := fetchServiceVariables(ctx, client, targetService, varType)
 	if err != nil {
-		fmt.Printf("Error fetching variables from %s: %v\n", targetService, err)
+		errorf("Error fetching variables from %s: %v\n", targetService, err)
 		return
 	}
 
@@ -496,13 +479,6 @@ func displayUniqueVariablesTable(sourceOnly, targetOnly []*ComparedVariable, sou
 	fmt.Println("\nLegend: " + blueColor("Blue") + " = only in source, " + magentaColor("Magenta") + " = only in target")
 }
 
-func truncateValue(value string, maxLen int) string {
-	if len(value) <= maxLen {
-		return value
-	}
-	return value[:maxLen-3] + "..."
-}
-
 // capitalizeFirst capitalizes the first letter of a string
 func capitalizeFirst(s string) string {
 	if s == "" {
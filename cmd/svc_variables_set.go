@@ -0,0 +1,333 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"bitbucket.org/cover42/eiscli/internal/bitbucket"
+	"bitbucket.org/cover42/eiscli/internal/git"
+	"bitbucket.org/cover42/eiscli/internal/kubernetes"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+var (
+	changeVariableType    string
+	changeEnvironmentName string
+	changeVariableYes     bool
+	setVariableSecured    bool
+)
+
+// variableTarget is where vars set and vars unset change a variable
+type variableTarget struct {
+	Scope       string // workspace, repository, deployment
+	Service     string
+	Environment *bitbucket.Environment // Only for deployment variables
+}
+
+func (t *variableTarget) String() string {
+	switch t.Scope {
+	case "workspace":
+		return "the workspace"
+	case "deployment":
+		return fmt.Sprintf("%s (environment %s)", t.Service, t.Environment.Name)
+	default:
+		return fmt.Sprintf("%s (repository)", t.Service)
+	}
+}
+
+// isProduction returns true for variables of a Production-type environment
+func (t *variableTarget) isProduction() bool {
+	return t.Environment != nil && strings.EqualFold(t.Environment.Type, bitbucket.EnvironmentTypeProduction)
+}
+
+var svcVariablesSetCmd = &cobra.Command{
+	Use:   "set [service-name] KEY=VALUE",
+	Short: "Create or update a variable",
+	Long: `Set the value of a repository, deployment or workspace variable. The variable
+is created if it does not exist yet.
+
+A new variable is marked as secured if its name contains PASSWORD, SECRET, KEY,
+TOKEN, etc.; an existing one keeps its setting. Use --secured or --secured=false
+to choose.
+
+Changing a variable of a Production-type environment asks for confirmation,
+unless --yes is given.
+
+If service-name is not provided, it will be auto-detected from the git repository
+in the current directory (based on the git remote URL).
+
+Examples:
+  eiscli vars set LOG_LEVEL=info
+  eiscli vars set policyservice API_HOST=api.example.com --type deployment --env Production
+  eiscli vars set SENTRY_DSN=https://... --type workspace --secured`,
+	Args: cobra.RangeArgs(1, 2),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+
+		key, value, ok := strings.Cut(args[len(args)-1], "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			errorf("Error: expected KEY=VALUE, got '%s'\n", args[len(args)-1])
+			return
+		}
+		if value == "" {
+			errorf("Error: the value of %s is empty, use 'eiscli vars unset %s' to remove it\n", key, key)
+			return
+		}
+
+		client, target, ok := resolveVariableTarget(ctx, args[:len(args)-1])
+		if !ok {
+			return
+		}
+
+		variables, err := listTargetVariables(ctx, client, target)
+		if err != nil {
+			errorf("Error: %v\n", err)
+			return
+		}
+		existing := findVariable(variables, key)
+
+		secured := kubernetes.IsSecuredVariable(key)
+		if cmd.Flags().Changed("secured") {
+			secured = setVariableSecured
+		} else if existing != nil {
+			secured = existing.Secured
+		}
+
+		if existing != nil && !existing.Secured && !secured && existing.Value == value {
+			fmt.Printf("%s is already set to '%s' in %s\n", key, value, target)
+			return
+		}
+
+		action := "Create"
+		if existing != nil {
+			action = "Update"
+		}
+		if !confirmVariableChange(target, fmt.Sprintf("%s %s", action, key)) {
+			return
+		}
+
+		if existing != nil {
+			err = updateTargetVariable(ctx, client, target, existing.UUID, key, value, secured)
+		} else {
+			err = createTargetVariable(ctx, client, target, key, value, secured)
+		}
+		if err != nil {
+			errorf("Error: %v\n", err)
+			return
+		}
+
+		greenColor := color.New(color.FgGreen).SprintFunc()
+		shown := value
+		if secured {
+			shown = "********"
+		}
+		switch {
+		case existing == nil:
+			fmt.Printf("%s Created %s=%s in %s\n", greenColor("✓"), key, shown, target)
+		case existing.Secured || secured:
+			fmt.Printf("%s Updated %s=%s in %s\n", greenColor("✓"), key, shown, target)
+		default:
+			fmt.Printf("%s Updated %s in %s: '%s' → '%s'\n", greenColor("✓"), key, target, existing.Value, value)
+		}
+	},
+}
+
+var svcVariablesUnsetCmd = &cobra.Command{
+	Use:   "unset [service-name] KEY",
+	Short: "Remove a variable",
+	Long: `Remove a repository, deployment or workspace variable.
+
+Removing a variable of a Production-type environment asks for confirmation,
+unless --yes is given.
+
+If service-name is not provided, it will be auto-detected from the git repository
+in the current directory (based on the git remote URL).
+
+Examples:
+  eiscli vars unset LOG_LEVEL
+  eiscli vars unset policyservice API_HOST --type deployment --env Staging
+  eiscli vars unset SENTRY_DSN --type workspace`,
+	Args: cobra.RangeArgs(1, 2),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+
+		key := strings.TrimSpace(args[len(args)-1])
+		client, target, ok := resolveVariableTarget(ctx, args[:len(args)-1])
+		if !ok {
+			return
+		}
+
+		variables, err := listTargetVariables(ctx, client, target)
+		if err != nil {
+			errorf("Error: %v\n", err)
+			return
+		}
+		existing := findVariable(variables, key)
+		if existing == nil {
+			errorf("Error: variable %s not found in %s\n", key, target)
+			return
+		}
+
+		if !confirmVariableChange(target, fmt.Sprintf("Remove %s", key)) {
+			return
+		}
+
+		if err := deleteTargetVariable(ctx, client, target, existing.UUID); err != nil {
+			errorf("Error: %v\n", err)
+			return
+		}
+
+		greenColor := color.New(color.FgGreen).SprintFunc()
+		fmt.Printf("%s Removed %s from %s\n", greenColor("✓"), key, target)
+	},
+}
+
+// resolveVariableTarget creates the client and works out the scope of the
+// variable from --type, --env and the optional service name
+func resolveVariableTarget(ctx context.Context, args []string) (*bitbucket.Client, *variableTarget, bool) {
+	target := &variableTarget{Scope: strings.ToLower(changeVariableType)}
+	switch target.Scope {
+	case "repository", "deployment", "workspace":
+	default:
+		errorf("Error: invalid type '%s', use repository, deployment or workspace\n", changeVariableType)
+		return nil, nil, false
+	}
+
+	if target.Scope == "workspace" && len(args) > 0 {
+		errorf("Error: workspace variables do not belong to a service, leave out the service name\n")
+		return nil, nil, false
+	}
+	if target.Scope == "deployment" && changeEnvironmentName == "" {
+		errorf("Error: --env flag is required when using --type deployment\n")
+		return nil, nil, false
+	}
+
+	if target.Scope != "workspace" {
+		if len(args) > 0 {
+			target.Service = args[0]
+		} else {
+			detectedSlug, err := git.DetectRepositorySlug()
+			if err != nil {
+				errorf("Error: No service name provided and could not auto-detect from git repository\n")
+				fmt.Printf("  %v\n", err)
+				return nil, nil, false
+			}
+			target.Service = detectedSlug
+			infof("Auto-detected service from git repository: %s\n", target.Service)
+		}
+	}
+
+	client, ok := newBitbucketClient()
+	if !ok {
+		return nil, nil, false
+	}
+
+	if target.Scope == "deployment" {
+		environments, err := client.GetDeploymentEnvironments(ctx, target.Service)
+		if err != nil {
+			errorf("Error: %v\n", err)
+			return nil, nil, false
+		}
+		for _, env := range environments {
+			if strings.EqualFold(env.Name, changeEnvironmentName) {
+				target.Environment = env
+				break
+			}
+		}
+		if target.Environment == nil {
+			errorf("Error: environment '%s' not found in %s\n", changeEnvironmentName, target.Service)
+			return nil, nil, false
+		}
+	}
+
+	return client, target, true
+}
+
+// confirmVariableChange asks before changing a variable of a Production-type
+// environment, unless --yes is given. Other variables are changed right away.
+func confirmVariableChange(target *variableTarget, action string) bool {
+	if !target.isProduction() || changeVariableYes {
+		return true
+	}
+
+	yellowColor := color.New(color.FgYellow).SprintFunc()
+	fmt.Printf("%s %s is a Production environment\n", yellowColor("⚠"), target.Environment.Name)
+	confirmed, err := promptYesNo(fmt.Sprintf("%s in %s?", action, target), false)
+	if err != nil {
+		errorf("Error: %v\n", err)
+		return false
+	}
+	if !confirmed {
+		fmt.Println("Canceled. No variables were changed.")
+	}
+	return confirmed
+}
+
+// findVariable returns the variable with the key, or nil
+func findVariable(variables []*bitbucket.Variable, key string) *bitbucket.Variable {
+	for _, variable := range variables {
+		if variable.Key == key {
+			return variable
+		}
+	}
+	return nil
+}
+
+func listTargetVariables(ctx context.Context, client *bitbucket.Client, target *variableTarget) ([]*bitbucket.Variable, error) {
+	switch target.Scope {
+	case "workspace":
+		return client.GetWorkspaceVariables(ctx)
+	case "deployment":
+		return client.GetDeploymentVariablesForEnv(ctx, target.Service, target.Environment.UUID)
+	default:
+		return client.GetRepositoryVariables(ctx, target.Service)
+	}
+}
+
+func createTargetVariable(ctx context.Context, client *bitbucket.Client, target *variableTarget, key, value string, secured bool) error {
+	switch target.Scope {
+	case "workspace":
+		return client.CreateWorkspaceVariable(ctx, key, value, secured)
+	case "deployment":
+		return client.CreateDeploymentVariable(ctx, target.Service, target.Environment.UUID, key, value, secured)
+	default:
+		return client.CreateRepositoryVariable(ctx, target.Service, key, value, secured)
+	}
+}
+
+func updateTargetVariable(ctx context.Context, client *bitbucket.Client, target *variableTarget, uuid, key, value string, secured bool) error {
+	switch target.Scope {
+	case "workspace":
+		return client.UpdateWorkspaceVariable(ctx, uuid, key, value, secured)
+	case "deployment":
+		return client.UpdateDeploymentVariable(ctx, target.Service, target.Environment.UUID, uuid, key, value, secured)
+	default:
+		return client.UpdateRepositoryVariable(ctx, target.Service, uuid, key, value, secured)
+	}
+}
+
+func deleteTargetVariable(ctx context.Context, client *bitbucket.Client, target *variableTarget, uuid string) error {
+	switch target.Scope {
+	case "workspace":
+		return client.DeleteWorkspaceVariable(ctx, uuid)
+	case "deployment":
+		return client.DeleteDeploymentVariable(ctx, target.Service, target.Environment.UUID, uuid)
+	default:
+		return client.DeleteRepositoryVariable(ctx, target.Service, uuid)
+	}
+}
+
+func init() {
+	varsCmd.AddCommand(svcVariablesSetCmd)
+	varsCmd.AddCommand(svcVariablesUnsetCmd)
+
+	for _, c := range []*cobra.Command{svcVariablesSetCmd, svcVariablesUnsetCmd} {
+		c.Flags().StringVarP(&changeVariableType, "type", "t", "repository", "Type of variable (repository, deployment, workspace)")
+		c.Flags().StringVarP(&changeEnvironmentName, "env", "e", "", "Environment name (required for deployment variables)")
+		c.Flags().BoolVarP(&changeVariableYes, "yes", "y", false, "Change Production variables without asking for confirmation")
+	}
+	svcVariablesSetCmd.Flags().BoolVar(&setVariableSecured, "secured", false, "Mark the variable as secured (default: detected from its name for new variables)")
+}
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"os"
 	"sort"
@@ -43,7 +44,8 @@ to the corresponding Bitbucket deployment environment.
 
 By default, shows a preview of changes (like terraform plan). Use --apply to actually create the variables.
 
-The command only ADDS missing variables and never removes existing ones.
+The command only ADDS missing variables; it does not change or remove existing ones.
+Use 'eiscli vars set' to change a value and 'eiscli vars unset' to remove a variable.
 Variables with names containing PASSWORD, SECRET, KEY, TOKEN, etc. are automatically marked as secured.
 
 If the target environment doesn't exist, you'll be prompted to create it.
@@ -61,6 +63,8 @@ If service-name is not provided, it will be auto-detected from the git repositor
 in the current directory (based on the git remote URL).`,
 	Args: cobra.MaximumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+
 		serviceName := ""
 		if len(args) > 0 {
 			serviceName = args[0]
@@ -70,7 +74,7 @@ in the current directory (based on the git remote URL).`,
 		if serviceName == "" {
 			detectedSlug, err := git.DetectRepositorySlug()
 			if err != nil {
-				fmt.Println("Error: No service name provided and could not auto-detect from git repository")
+				errorf("Error: No service name provided and could not auto-detect from git repository\n")
 				fmt.Printf("  %v\n", err)
 				fmt.Println("\nUsage:")
 				fmt.Println("  1. Run this command from within a git repository, or")
@@ -83,39 +87,20 @@ in the current directory (based on the git remote URL).`,
 
 		// Validate environment parameter
 		if syncEnvironment == "" {
-			fmt.Println("Error: --env flag is required")
+			errorf("Error: --env flag is required\n")
 			fmt.Println("\nUsage: eiscli vars sync [service-name] --env <environment>")
 			fmt.Println("\nAvailable environments: testing, staging, prod, prod-zurich, dev")
 			return
 		}
 
-		// Load configuration
-		cfg, err := config.Load()
-		if err != nil {
-			fmt.Printf("Error loading configuration: %v\n", err)
-			fmt.Println("\nPlease set the following environment variables:")
-			fmt.Println("  EISCLI_BITBUCKET_USERNAME")
-			fmt.Println("  EISCLI_BITBUCKET_APP_PASSWORD")
-			fmt.Println("  EISCLI_BITBUCKET_WORKSPACE")
-			return
-		}
-
-		// Validate configuration
-		if err := cfg.Validate(); err != nil {
-			fmt.Printf("Configuration error: %v\n", err)
-			return
-		}
-
-		// Create Bitbucket client
-		client, err := bitbucket.NewClient(cfg)
-		if err != nil {
-			fmt.Printf("Error creating Bitbucket client: %v\n", err)
+		client, ok := newBitbucketClient()
+		if !ok {
 			return
 		}
 
 		// Execute sync
-		if err := executeSyncPlan(client, serviceName, syncEnvironment, kubernetesPath, applySyncChanges); err != nil {
-			fmt.Printf("\nError: %v\n", err)
+		if err := executeSyncPlan(ctx, client, serviceName, syncEnvironment, kubernetesPath, applySyncChanges); err != nil {
+			errorf("\nError: %v\n", err)
 			os.Exit(1)
 		}
 	},
@@ -172,7 +157,7 @@ func displayFinalPreview(varsToSync []VariableToSync) {
 	table.Render()
 }
 
-func executeSyncPlan(client *bitbucket.Client, serviceName, overlayName, k8sPath string, apply bool) error {
+func executeSyncPlan(ctx context.Context, client *bitbucket.Client, serviceName, overlayName, k8sPath string, apply bool) error {
 	// Step 1: Map overlay name to Bitbucket environment name
 	envName := kubernetes.MapOverlayToEnvironment(overlayName)
 	fmt.Printf("Syncing variables for environment: %s (overlay: %s)\n", envName, overlayName)
@@ -217,6 +202,7 @@ func executeSyncPlan(client *bitbucket.Client, serviceName, overlayName, k8sPath
 
 	// Ensure environment exists (will create if necessary)
 	targetEnv, err := bitbucket.EnsureEnvironmentExists(
+		ctx,
 		client,
 		serviceName,
 		envName,
@@ -230,7 +216,7 @@ func executeSyncPlan(client *bitbucket.Client, serviceName, overlayName, k8sPath
 	fmt.Printf("\nTarget Bitbucket environment: %s (UUID: %s)\n\n", targetEnv.Name, targetEnv.UUID)
 
 	// Step 4: Fetch existing variables
-	existingVars, err := client.GetDeploymentVariablesForEnv(serviceName, targetEnv.UUID)
+	existingVars, err := client.GetDeploymentVariablesForEnv(ctx, serviceName, targetEnv.UUID)
 	if err != nil {
 		return fmt.Errorf("failed to fetch existing deployment variables: %w", err)
 	}
@@ -327,7 +313,7 @@ func executeSyncPlan(client *bitbucket.Client, serviceName, overlayName, k8sPath
 	}
 
 	fmt.Println("\nCreating variables...")
-	return applyVariableSync(client, serviceName, targetEnv.UUID, newVarsToCreate)
+	return applyVariableSync(ctx, client, serviceName, targetEnv.UUID, newVarsToCreate)
 }
 
 func displaySyncPreview(varsToDisplay []VariableToSync, newVarCount int) {
@@ -364,16 +350,25 @@ func displaySyncPreview(varsToDisplay []VariableToSync, newVarCount int) {
 	table.Render()
 }
 
-func applyVariableSync(client *bitbucket.Client, serviceName, envUUID string, varsToSync []VariableToSync) error {
+func applyVariableSync(ctx context.Context, client *bitbucket.Client, serviceName, envUUID string, varsToSync []VariableToSync) error {
 	successCount := 0
 	failCount := 0
+	skipCount := 0
 
 	greenColor := color.New(color.FgGreen).SprintFunc()
 	redColor := color.New(color.FgRed).SprintFunc()
+	yellowColor := color.New(color.FgYellow).SprintFunc()
 
-	for _, v := range varsToSync {
-		// Use the collected value for new variables
-		err := client.CreateDeploymentVariable(serviceName, envUUID, v.Key, v.Value, v.Secured)
+	for i, v := range varsToSync {
+		// Stop between writes when interrupted, so no variable is left half-written
+		if ctx.Err() != nil {
+			skipCount = len(varsToSync) - i
+			break
+		}
+
+		// Use the collected value for new variables. The write itself is not
+		// canceled once started.
+		err := client.CreateDeploymentVariable(context.WithoutCancel(ctx), serviceName, envUUID, v.Key, v.Value, v.Secured)
 		if err != nil {
 			fmt.Printf("  %s Failed to create %s: %v\n", redColor("✗"), v.Key, err)
 			failCount++
@@ -388,14 +383,18 @@ func applyVariableSync(client *bitbucket.Client, serviceName, envUUID string, va
 	}
 
 	fmt.Println()
+	summary := []string{greenColor(fmt.Sprintf("%d created", successCount))}
 	if failCount > 0 {
-		fmt.Printf("Summary: %s, %s\n",
-			greenColor(fmt.Sprintf("%d created", successCount)),
-			redColor(fmt.Sprintf("%d failed", failCount)))
-	} else {
-		fmt.Printf("Summary: %s\n", greenColor(fmt.Sprintf("%d created", successCount)))
+		summary = append(summary, redColor(fmt.Sprintf("%d failed", failCount)))
+	}
+	if skipCount > 0 {
+		summary = append(summary, yellowColor(fmt.Sprintf("%d skipped (interrupted)", skipCount)))
 	}
+	fmt.Printf("Summary: %s\n", strings.Join(summary, ", "))
 
+	if skipCount > 0 {
+		return fmt.Errorf("sync interrupted: %d variable(s) were not created, run the sync again to create them", skipCount)
+	}
 	if failCount > 0 {
 		return fmt.Errorf("some variables failed to create")
 	}
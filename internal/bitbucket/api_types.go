@@ -0,0 +1,593 @@
+package bitbucket
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// This file holds the JSON shapes of the Bitbucket API responses the client
+// decodes. They mirror the API and are converted to the exported models,
+// which are flattened for display and machine-readable output. Models whose
+// JSON shape already matches the API (Variable, DeployKey, ...) are decoded
+// directly and have no api type here.
+
+// apiUser is a user or account as embedded in other API objects
+type apiUser struct {
+	UUID        string `json:"uuid"`
+	Username    string `json:"username"`
+	DisplayName string `json:"display_name"`
+	Nickname    string `json:"nickname"`
+	Email       string `json:"email"`
+}
+
+// name returns the display name of the user, falling back to the username
+func (u *apiUser) name() string {
+	if u == nil {
+		return ""
+	}
+	if u.DisplayName != "" {
+		return u.DisplayName
+	}
+	return u.Username
+}
+
+// toUserInfo converts the API user to a UserInfo
+func (u *apiUser) toUserInfo() *UserInfo {
+	return &UserInfo{
+		UUID:        u.UUID,
+		Username:    u.Username,
+		DisplayName: u.DisplayName,
+	}
+}
+
+// apiWorkspaceMembership is a member of a workspace
+type apiWorkspaceMembership struct {
+	User *apiUser `json:"user"`
+}
+
+// apiLink is a single entry of an API object's links
+type apiLink struct {
+	Href string `json:"href"`
+}
+
+// apiLinks holds the links of an API object
+type apiLinks struct {
+	HTML apiLink `json:"html"`
+}
+
+// apiNamed is an API object that is referenced by its name, e.g. a branch
+type apiNamed struct {
+	Name string `json:"name"`
+}
+
+// apiPipeline is a pipeline as returned by the pipelines endpoints
+type apiPipeline struct {
+	UUID             string            `json:"uuid"`
+	BuildNumber      int               `json:"build_number"`
+	BuildSecondsUsed int               `json:"build_seconds_used"`
+	State            PipelineState     `json:"state"`
+	CreatedOn        time.Time         `json:"created_on"`
+	CompletedOn      *time.Time        `json:"completed_on"`
+	Target           apiPipelineTarget `json:"target"`
+	Trigger          PipelineTrigger   `json:"trigger"`
+	Creator          *apiUser          `json:"creator"`
+	Repository       *apiRepository    `json:"repository"`
+}
+
+// toPipeline converts the API pipeline to a Pipeline
+func (p *apiPipeline) toPipeline(webURL string) *Pipeline {
+	pipeline := &Pipeline{
+		UUID:          p.UUID,
+		BuildNumber:   p.BuildNumber,
+		BuildSecsUsed: p.BuildSecondsUsed,
+		State:         p.State,
+		CreatedOn:     p.CreatedOn,
+		CompletedOn:   p.CompletedOn,
+		Target:        p.Target.toPipelineTarget(),
+		Trigger:       p.Trigger,
+		Creator:       p.Creator.name(),
+	}
+
+	if p.CompletedOn != nil {
+		pipeline.DurationSec = int(p.CompletedOn.Sub(p.CreatedOn).Seconds())
+	}
+
+	if p.Repository != nil && p.Repository.FullName != "" {
+		pipeline.Repository = p.Repository.FullName
+		// Construct web URL: https://bitbucket.org/{workspace}/{repo_slug}/pipelines/results/{build_number}
+		pipeline.WebURL = fmt.Sprintf("%s/%s/pipelines/results/%d",
+			webURL, p.Repository.FullName, p.BuildNumber)
+	}
+
+	return pipeline
+}
+
+// apiPipelineTarget is the target of a pipeline or schedule. Its commit is
+// decoded as an apiCommit, as the API returns the author as an object.
+type apiPipelineTarget struct {
+	Type        string               `json:"type"`
+	RefType     string               `json:"ref_type"`
+	RefName     string               `json:"ref_name"`
+	Source      string               `json:"source"`
+	Destination string               `json:"destination"`
+	PullRequest *PipelinePullRequest `json:"pullrequest"`
+	Commit      *apiCommit           `json:"commit"`
+	Selector    *PipelineSelector    `json:"selector"`
+}
+
+// toPipelineTarget converts the API target to a PipelineTarget
+func (t *apiPipelineTarget) toPipelineTarget() PipelineTarget {
+	target := PipelineTarget{
+		Type:        t.Type,
+		RefType:     t.RefType,
+		RefName:     t.RefName,
+		Source:      t.Source,
+		Destination: t.Destination,
+		PullRequest: t.PullRequest,
+		Selector:    t.Selector,
+	}
+	if t.Commit != nil {
+		target.Commit = t.Commit.toCommit()
+	}
+	return target
+}
+
+// apiPipelineStep is a step of a pipeline
+type apiPipelineStep struct {
+	UUID              string                 `json:"uuid"`
+	Name              string                 `json:"name"`
+	State             PipelineState          `json:"state"`
+	DurationInSeconds int                    `json:"duration_in_seconds"`
+	Services          []*PipelineStepService `json:"services"`
+}
+
+// toPipelineStep converts the API step to a PipelineStep
+func (s *apiPipelineStep) toPipelineStep() *PipelineStep {
+	step := &PipelineStep{
+		UUID:        s.UUID,
+		Name:        s.Name,
+		State:       s.State.Name,
+		DurationSec: s.DurationInSeconds,
+	}
+	if s.State.Result != nil {
+		step.Result = s.State.Result.Name
+	}
+
+	for _, service := range s.Services {
+		if service.UUID != "" {
+			step.Services = append(step.Services, service)
+		}
+	}
+
+	return step
+}
+
+// apiRepository is a repository as returned by the repositories endpoints
+type apiRepository struct {
+	Slug        string    `json:"slug"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	FullName    string    `json:"full_name"`
+	Language    string    `json:"language"`
+	IsPrivate   bool      `json:"is_private"`
+	Size        int64     `json:"size"`
+	MainBranch  *apiNamed `json:"mainbranch"`
+	Project     *struct {
+		Key  string `json:"key"`
+		Name string `json:"name"`
+	} `json:"project"`
+	CreatedOn time.Time `json:"created_on"`
+	UpdatedOn time.Time `json:"updated_on"`
+}
+
+// toRepository converts the API repository to a Repository
+func (r *apiRepository) toRepository() *Repository {
+	repo := &Repository{
+		Slug:        r.Slug,
+		Name:        r.Name,
+		Description: r.Description,
+		FullName:    r.FullName,
+		Language:    r.Language,
+		IsPrivate:   r.IsPrivate,
+		Size:        r.Size,
+		CreatedOn:   r.CreatedOn,
+		UpdatedOn:   r.UpdatedOn,
+	}
+	if r.MainBranch != nil {
+		repo.MainBranch = r.MainBranch.Name
+	}
+	if r.Project != nil {
+		repo.ProjectKey = r.Project.Key
+		repo.ProjectName = r.Project.Name
+	}
+
+	return repo
+}
+
+// apiEnvironment is a deployment environment
+type apiEnvironment struct {
+	UUID            string `json:"uuid"`
+	Name            string `json:"name"`
+	EnvironmentType struct {
+		Name string `json:"name"`
+		Rank int    `json:"rank"`
+	} `json:"environment_type"`
+}
+
+// toEnvironment converts the API environment to an Environment
+func (e *apiEnvironment) toEnvironment() *Environment {
+	return &Environment{
+		UUID: e.UUID,
+		Name: e.Name,
+		Type: e.EnvironmentType.Name,
+	}
+}
+
+// apiBranchRef is the source or destination of a pull request
+type apiBranchRef struct {
+	Branch apiNamed `json:"branch"`
+	Commit *struct {
+		Hash string `json:"hash"`
+	} `json:"commit"`
+	Repository *struct {
+		FullName string `json:"full_name"`
+	} `json:"repository"`
+}
+
+// apiParticipant is a reviewer or other participant of a pull request
+type apiParticipant struct {
+	User     *apiUser `json:"user"`
+	Role     string   `json:"role"`
+	Approved bool     `json:"approved"`
+	State    *string  `json:"state"`
+}
+
+// apiPullRequest is a pull request as returned by the pullrequests endpoints
+type apiPullRequest struct {
+	ID           int               `json:"id"`
+	Title        string            `json:"title"`
+	Description  string            `json:"description"`
+	State        string            `json:"state"`
+	Draft        bool              `json:"draft"`
+	Source       apiBranchRef      `json:"source"`
+	Destination  apiBranchRef      `json:"destination"`
+	Author       *apiUser          `json:"author"`
+	Reviewers    []*apiUser        `json:"reviewers"`
+	Participants []*apiParticipant `json:"participants"`
+	CommentCount int               `json:"comment_count"`
+	TaskCount    int               `json:"task_count"`
+	CreatedOn    time.Time         `json:"created_on"`
+	UpdatedOn    time.Time         `json:"updated_on"`
+	Links        apiLinks          `json:"links"`
+}
+
+// authoredBy reports whether the pull request author matches the given UUID or
+// username, or the given email
+func (p *apiPullRequest) authoredBy(author, authorEmail string) bool {
+	if p.Author == nil {
+		return false
+	}
+	if author != "" && (p.Author.UUID == author || p.Author.Username == author) {
+		return true
+	}
+	return authorEmail != "" && p.Author.Email == authorEmail
+}
+
+// toPullRequest converts the API pull request to a PullRequest
+func (p *apiPullRequest) toPullRequest(webURL, workspace, repoSlug string) *PullRequest {
+	pr := &PullRequest{
+		ID:                p.ID,
+		Title:             p.Title,
+		Description:       p.Description,
+		State:             p.State,
+		Draft:             p.Draft,
+		SourceBranch:      p.Source.Branch.Name,
+		DestinationBranch: p.Destination.Branch.Name,
+		Author:            p.Author.name(),
+		Reviewers:         make([]string, 0, len(p.Reviewers)),
+		CommentCount:      p.CommentCount,
+		TaskCount:         p.TaskCount,
+		CreatedOn:         p.CreatedOn,
+		UpdatedOn:         p.UpdatedOn,
+		WebURL:            p.Links.HTML.Href,
+	}
+	if p.Source.Commit != nil {
+		pr.SourceCommit = p.Source.Commit.Hash
+	}
+	if p.Source.Repository != nil {
+		pr.SourceRepository = p.Source.Repository.FullName
+	}
+	if p.Destination.Repository != nil {
+		pr.DestinationRepository = p.Destination.Repository.FullName
+	}
+
+	// Prefer UUID for matching, fallback to username
+	for _, reviewer := range p.Reviewers {
+		switch {
+		case reviewer.UUID != "":
+			pr.Reviewers = append(pr.Reviewers, reviewer.UUID)
+		case reviewer.Username != "":
+			pr.Reviewers = append(pr.Reviewers, reviewer.Username)
+		}
+	}
+
+	if p.Author != nil {
+		pr.AuthorUUID = p.Author.UUID
+	}
+
+	// Participants are only included when a single pull request is fetched,
+	// or when they are asked for with the fields parameter
+	for _, participant := range p.Participants {
+		if participant.User == nil {
+			continue
+		}
+		pr.Participants = append(pr.Participants, participant.toParticipant())
+	}
+
+	// If no web URL from links, construct it manually
+	if pr.WebURL == "" && pr.ID > 0 {
+		pr.WebURL = BuildPullRequestURL(webURL, workspace, repoSlug, pr.ID)
+	}
+
+	return pr
+}
+
+// toParticipant converts the API participant to a PullRequestParticipant
+func (p *apiParticipant) toParticipant() *PullRequestParticipant {
+	participant := &PullRequestParticipant{
+		UUID:     p.User.UUID,
+		Name:     p.User.name(),
+		Role:     p.Role,
+		Approved: p.Approved,
+	}
+	if p.State != nil {
+		participant.State = *p.State
+	}
+	return participant
+}
+
+// apiDiffStat is the change of one file in the diffstat of a pull request
+type apiDiffStat struct {
+	Status       string `json:"status"`
+	LinesAdded   int    `json:"lines_added"`
+	LinesRemoved int    `json:"lines_removed"`
+	Old          *struct {
+		Path string `json:"path"`
+	} `json:"old"`
+	New *struct {
+		Path string `json:"path"`
+	} `json:"new"`
+}
+
+// toDiffStat converts the API diffstat entry to a DiffStat. Removed files
+// only have an old path, added files only a new one.
+func (d *apiDiffStat) toDiffStat() *DiffStat {
+	stat := &DiffStat{
+		Status:       d.Status,
+		LinesAdded:   d.LinesAdded,
+		LinesRemoved: d.LinesRemoved,
+	}
+	if d.New != nil {
+		stat.Path = d.New.Path
+	}
+	if d.Old != nil {
+		if stat.Path == "" {
+			stat.Path = d.Old.Path
+		} else if d.Old.Path != stat.Path {
+			stat.OldPath = d.Old.Path
+		}
+	}
+	return stat
+}
+
+// apiCommit is a commit as returned by the commits endpoints
+type apiCommit struct {
+	Hash    string    `json:"hash"`
+	Message string    `json:"message"`
+	Date    time.Time `json:"date"`
+	Author  struct {
+		Raw  string   `json:"raw"`
+		User *apiUser `json:"user"`
+	} `json:"author"`
+}
+
+// toCommit converts the API commit to a Commit. The author is the Bitbucket
+// user if the commit email is linked to one, else the raw git author.
+func (c *apiCommit) toCommit() *Commit {
+	author := c.Author.User.name()
+	if author == "" {
+		author = c.Author.Raw
+		if name, _, found := strings.Cut(author, " <"); found {
+			author = name
+		}
+	}
+
+	commit := &Commit{
+		Hash:    c.Hash,
+		Message: strings.TrimSpace(c.Message),
+		Author:  author,
+	}
+	if !c.Date.IsZero() {
+		commit.Date = &c.Date
+	}
+	return commit
+}
+
+// apiContent is rendered text like a comment body
+type apiContent struct {
+	Raw string `json:"raw"`
+}
+
+// apiComment is a general or inline comment of a pull request
+type apiComment struct {
+	ID      int        `json:"id"`
+	Content apiContent `json:"content"`
+	User    *apiUser   `json:"user"`
+	Deleted bool       `json:"deleted"`
+	Parent  *struct {
+		ID int `json:"id"`
+	} `json:"parent"`
+	Inline *struct {
+		Path string `json:"path"`
+		From *int   `json:"from"`
+		To   *int   `json:"to"`
+	} `json:"inline"`
+	CreatedOn time.Time `json:"created_on"`
+	UpdatedOn time.Time `json:"updated_on"`
+	Links     apiLinks  `json:"links"`
+}
+
+// toComment converts the API comment to a PullRequestComment
+func (c *apiComment) toComment() *PullRequestComment {
+	comment := &PullRequestComment{
+		ID:        c.ID,
+		Author:    c.User.name(),
+		Body:      c.Content.Raw,
+		Deleted:   c.Deleted,
+		CreatedOn: c.CreatedOn,
+		UpdatedOn: c.UpdatedOn,
+		WebURL:    c.Links.HTML.Href,
+	}
+	if c.Parent != nil {
+		comment.ParentID = c.Parent.ID
+	}
+	if c.Inline != nil {
+		comment.Path = c.Inline.Path
+		if c.Inline.To != nil {
+			comment.Line = *c.Inline.To
+		}
+		if c.Inline.From != nil {
+			comment.OldLine = *c.Inline.From
+		}
+	}
+	return comment
+}
+
+// apiTask is a task of a pull request
+type apiTask struct {
+	ID         int        `json:"id"`
+	State      string     `json:"state"`
+	Content    apiContent `json:"content"`
+	Creator    *apiUser   `json:"creator"`
+	CreatedOn  time.Time  `json:"created_on"`
+	ResolvedOn *time.Time `json:"resolved_on"`
+	ResolvedBy *apiUser   `json:"resolved_by"`
+	Comment    *struct {
+		ID int `json:"id"`
+	} `json:"comment"`
+}
+
+// toTask converts the API task to a PullRequestTask
+func (t *apiTask) toTask() *PullRequestTask {
+	task := &PullRequestTask{
+		ID:         t.ID,
+		State:      t.State,
+		Body:       t.Content.Raw,
+		Creator:    t.Creator.name(),
+		CreatedOn:  t.CreatedOn,
+		ResolvedOn: t.ResolvedOn,
+		ResolvedBy: t.ResolvedBy.name(),
+	}
+	if t.Comment != nil {
+		task.CommentID = t.Comment.ID
+	}
+	return task
+}
+
+// apiCommitStatus is a build status reported for a commit
+type apiCommitStatus struct {
+	Key         string    `json:"key"`
+	Name        string    `json:"name"`
+	State       string    `json:"state"`
+	Description string    `json:"description"`
+	URL         string    `json:"url"`
+	UpdatedOn   time.Time `json:"updated_on"`
+}
+
+// toBuildStatus converts the API commit status to a BuildStatus
+func (s *apiCommitStatus) toBuildStatus() *BuildStatus {
+	return &BuildStatus{
+		Key:         s.Key,
+		Name:        s.Name,
+		State:       s.State,
+		Description: s.Description,
+		URL:         s.URL,
+		UpdatedOn:   s.UpdatedOn,
+	}
+}
+
+// apiPipelineSchedule is a pipeline schedule
+type apiPipelineSchedule struct {
+	UUID        string            `json:"uuid"`
+	Enabled     bool              `json:"enabled"`
+	CronPattern string            `json:"cron_pattern"`
+	Target      apiPipelineTarget `json:"target"`
+	CreatedOn   *time.Time        `json:"created_on"`
+	UpdatedOn   *time.Time        `json:"updated_on"`
+}
+
+// toPipelineSchedule converts the API schedule to a PipelineSchedule
+func (s *apiPipelineSchedule) toPipelineSchedule() *PipelineSchedule {
+	schedule := &PipelineSchedule{
+		UUID:        s.UUID,
+		Enabled:     s.Enabled,
+		Branch:      s.Target.RefName,
+		CronPattern: s.CronPattern,
+		CreatedOn:   s.CreatedOn,
+		UpdatedOn:   s.UpdatedOn,
+	}
+	if s.Target.Selector != nil {
+		schedule.Selector = s.Target.Selector.Pattern
+	}
+
+	return schedule
+}
+
+// apiTestReport is the test report summary of a pipeline step
+type apiTestReport struct {
+	NumberOfTestCases           int `json:"number_of_test_cases"`
+	NumberOfSuccessfulTestCases int `json:"number_of_successful_test_cases"`
+	NumberOfFailedTestCases     int `json:"number_of_failed_test_cases"`
+	NumberOfErrorTestCases      int `json:"number_of_error_test_cases"`
+	NumberOfSkippedTestCases    int `json:"number_of_skipped_test_cases"`
+}
+
+// toSummary converts the API test report to a TestReportSummary
+func (r *apiTestReport) toSummary() *TestReportSummary {
+	return &TestReportSummary{
+		Total:   r.NumberOfTestCases,
+		Passed:  r.NumberOfSuccessfulTestCases,
+		Failed:  r.NumberOfFailedTestCases,
+		Errors:  r.NumberOfErrorTestCases,
+		Skipped: r.NumberOfSkippedTestCases,
+	}
+}
+
+// apiTestCaseReason is a failure reason of a test case
+type apiTestCaseReason struct {
+	Message string `json:"message"`
+}
+
+// apiTestCaseReasons is the response of the test case reasons endpoint, which
+// returns either a page of reasons or a single reason
+type apiTestCaseReasons struct {
+	Values []*apiTestCaseReason `json:"values"`
+	apiTestCaseReason
+}
+
+// messages joins the non-empty failure messages
+func (r *apiTestCaseReasons) messages() string {
+	reasons := r.Values
+	if reasons == nil && r.Message != "" {
+		reasons = []*apiTestCaseReason{&r.apiTestCaseReason}
+	}
+
+	var messages []string
+	for _, reason := range reasons {
+		if message := strings.TrimSpace(reason.Message); message != "" {
+			messages = append(messages, message)
+		}
+	}
+	return strings.Join(messages, "\n")
+}
@@ -0,0 +1,112 @@
+// Command fakebitbucket serves the in-memory Bitbucket stand-in of the
+// bitbuckettest package, seeded with a few demo repositories, so that the CLI
+// can be tried out without touching a real workspace:
+//
+//	go run ./internal/bitbucket/bitbuckettest/fakebitbucket -addr 127.0.0.1:7990
+//	eiscli --api-url http://127.0.0.1:7990/2.0 svc list
+//
+// Any username and app password are accepted. State is lost on exit.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"time"
+
+	"bitbucket.org/cover42/eiscli/internal/bitbucket/bitbuckettest"
+)
+
+func main() {
+	addr := flag.String("addr", "127.0.0.1:7990", "Address to listen on")
+	workspace := flag.String("workspace", "cover42", "Workspace to serve")
+	flag.Parse()
+
+	listener, err := net.Listen("tcp", *addr)
+	if err != nil {
+		log.Fatalf("Failed to listen on %s: %v", *addr, err)
+	}
+
+	server := bitbuckettest.NewUnstarted(*workspace)
+	_ = server.Listener.Close()
+	server.Listener = listener
+	server.Start()
+	defer server.Close()
+
+	seed(server)
+
+	fmt.Printf("Fake Bitbucket for workspace %q listening on %s\n\n", *workspace, server.URL)
+	fmt.Println("Point the CLI at it with:")
+	fmt.Printf("  export EISCLI_BITBUCKET_API_URL=%s\n", server.APIURL())
+	fmt.Printf("  export EISCLI_BITBUCKET_WEB_URL=%s\n", server.WebURL())
+	fmt.Printf("  export EISCLI_BITBUCKET_WORKSPACE=%s\n", *workspace)
+	fmt.Println("  export EISCLI_BITBUCKET_USE_OAUTH=false EISCLI_BITBUCKET_USERNAME=demo EISCLI_BITBUCKET_APP_PASSWORD=demo")
+	fmt.Println("\nPress Ctrl-C to stop")
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt)
+	<-signals
+}
+
+// seed adds demo data: two services with pipelines, variables, environments
+// and pull requests
+func seed(server *bitbuckettest.Server) {
+	now := time.Now().UTC()
+	completed := func(ago time.Duration) *time.Time {
+		t := now.Add(-ago)
+		return &t
+	}
+
+	server.AddProject(bitbuckettest.Project{Key: "EIS", Name: "EIS Platform"})
+
+	for _, slug := range []string{"policyservice", "documentservice"} {
+		server.AddRepository(bitbuckettest.Repository{
+			Slug:        slug,
+			Description: "Demo service " + slug,
+			Language:    "go",
+			ProjectKey:  "EIS",
+			IsPrivate:   true,
+			CreatedOn:   now.Add(-90 * 24 * time.Hour),
+		})
+
+		server.AddPipeline(slug, bitbuckettest.Pipeline{
+			Branch:      "main",
+			Commit:      "3f2a9c1d5e7b",
+			Result:      "SUCCESSFUL",
+			CreatedOn:   now.Add(-26 * time.Hour),
+			CompletedOn: completed(25 * time.Hour),
+			Steps: []*bitbuckettest.Step{
+				{Name: "Test", State: "COMPLETED", Result: "SUCCESSFUL", DurationSec: 240, Log: "go test ./...\nok\n"},
+				{Name: "Deploy to Test", State: "COMPLETED", Result: "SUCCESSFUL", DurationSec: 95, Log: "Deploying...\nDone\n"},
+			},
+		})
+		server.AddPipeline(slug, bitbuckettest.Pipeline{
+			Branch:      "feature/EIS-123-retries",
+			Commit:      "8b1e4d2a9f06",
+			Result:      "FAILED",
+			CreatedOn:   now.Add(-2 * time.Hour),
+			CompletedOn: completed(110 * time.Minute),
+			Steps: []*bitbuckettest.Step{
+				{Name: "Test", State: "COMPLETED", Result: "FAILED", DurationSec: 180, Log: "go test ./...\n--- FAIL: TestRetry (0.01s)\nFAIL\n"},
+			},
+		})
+
+		server.AddVariable(slug, bitbuckettest.Variable{Key: "LOG_LEVEL", Value: "info"})
+		server.AddVariable(slug, bitbuckettest.Variable{Key: "DATABASE_PASSWORD", Value: "secret", Secured: true})
+		server.AddEnvironment(slug, bitbuckettest.Environment{Name: "Test", Type: "Test"})
+		server.AddEnvironment(slug, bitbuckettest.Environment{Name: "Staging", Type: "Staging"})
+		server.AddEnvironment(slug, bitbuckettest.Environment{Name: "Production", Type: "Production"})
+		server.AddDeploymentVariable(slug, "Test", bitbuckettest.Variable{Key: "API_HOST", Value: "api.test.example.com"})
+
+		server.AddPullRequest(slug, bitbuckettest.PullRequest{
+			Title:        "EIS-123 Retry failed requests",
+			SourceBranch: "feature/EIS-123-retries",
+			CreatedOn:    now.Add(-3 * time.Hour),
+		})
+	}
+
+	server.AddWorkspaceVariable(bitbuckettest.Variable{Key: "ECR_PASSWORD_EU_CENTRAL_1", Value: "demo", Secured: true})
+}
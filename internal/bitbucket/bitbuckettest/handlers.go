@@ -0,0 +1,1231 @@
+package bitbuckettest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Page sizes of list responses; like Bitbucket, pagelen is capped
+const (
+	defaultPageLen = 10
+	maxPageLen     = 100
+)
+
+// routes registers the supported API endpoints
+func (s *Server) routes() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /2.0/user", s.handle(s.getUser))
+	mux.HandleFunc("GET /2.0/workspaces/{workspace}/members", s.handle(s.listMembers))
+	mux.HandleFunc("GET /2.0/workspaces/{workspace}/projects", s.handle(s.listProjects))
+
+	// Repositories
+	mux.HandleFunc("GET /2.0/repositories/{workspace}", s.handle(s.listRepositories))
+	mux.HandleFunc("GET /2.0/repositories/{workspace}/{repo}", s.handleRepo(s.getRepository))
+	mux.HandleFunc("POST /2.0/repositories/{workspace}/{repo}", s.handle(s.createRepository))
+	mux.HandleFunc("PUT /2.0/repositories/{workspace}/{repo}", s.handleRepo(s.updateRepository))
+	mux.HandleFunc("PUT /2.0/repositories/{workspace}/{repo}/permissions-config/groups/{group}", s.handleRepo(s.setGroupPermission))
+
+	// Pipelines
+	mux.HandleFunc("GET /2.0/repositories/{workspace}/{repo}/pipelines", s.handleRepo(s.listPipelines))
+	mux.HandleFunc("GET /2.0/repositories/{workspace}/{repo}/pipelines/{$}", s.handleRepo(s.listPipelines))
+	mux.HandleFunc("POST /2.0/repositories/{workspace}/{repo}/pipelines/{$}", s.handleRepo(s.triggerPipeline))
+	mux.HandleFunc("GET /2.0/repositories/{workspace}/{repo}/pipelines/{pipeline}", s.handleRepo(s.getPipeline))
+	mux.HandleFunc("POST /2.0/repositories/{workspace}/{repo}/pipelines/{pipeline}/stopPipeline", s.handleRepo(s.stopPipeline))
+	mux.HandleFunc("GET /2.0/repositories/{workspace}/{repo}/pipelines/{pipeline}/steps/{$}", s.handleRepo(s.listSteps))
+	mux.HandleFunc("GET /2.0/repositories/{workspace}/{repo}/pipelines/{pipeline}/steps/{step}/logs/{log}", s.handleRepo(s.getStepLog))
+
+	// Repository variables
+	mux.HandleFunc("GET /2.0/repositories/{workspace}/{repo}/pipelines_config/variables/{$}", s.handleRepo(s.listRepositoryVariables))
+	mux.HandleFunc("POST /2.0/repositories/{workspace}/{repo}/pipelines_config/variables/{$}", s.handleRepo(s.createRepositoryVariable))
+	mux.HandleFunc("PUT /2.0/repositories/{workspace}/{repo}/pipelines_config/variables/{variable}", s.handleRepo(s.updateRepositoryVariable))
+	mux.HandleFunc("DELETE /2.0/repositories/{workspace}/{repo}/pipelines_config/variables/{variable}", s.handleRepo(s.deleteRepositoryVariable))
+
+	// Deployment environments and their variables
+	mux.HandleFunc("GET /2.0/repositories/{workspace}/{repo}/environments/{$}", s.handleRepo(s.listEnvironments))
+	mux.HandleFunc("POST /2.0/repositories/{workspace}/{repo}/environments/{$}", s.handleRepo(s.createEnvironment))
+	mux.HandleFunc("GET /2.0/repositories/{workspace}/{repo}/deployments_config/environments/{environment}/variables", s.handleRepo(s.listDeploymentVariables))
+	mux.HandleFunc("POST /2.0/repositories/{workspace}/{repo}/deployments_config/environments/{environment}/variables", s.handleRepo(s.createDeploymentVariable))
+	mux.HandleFunc("PUT /2.0/repositories/{workspace}/{repo}/deployments_config/environments/{environment}/variables/{variable}", s.handleRepo(s.updateDeploymentVariable))
+	mux.HandleFunc("DELETE /2.0/repositories/{workspace}/{repo}/deployments_config/environments/{environment}/variables/{variable}", s.handleRepo(s.deleteDeploymentVariable))
+
+	// Workspace variables
+	mux.HandleFunc("GET /2.0/workspaces/{workspace}/pipelines-config/variables", s.handle(s.listWorkspaceVariables))
+	mux.HandleFunc("POST /2.0/workspaces/{workspace}/pipelines-config/variables", s.handle(s.createWorkspaceVariable))
+	mux.HandleFunc("GET /2.0/workspaces/{workspace}/pipelines-config/variables/{variable}", s.handle(s.getWorkspaceVariable))
+	mux.HandleFunc("PUT /2.0/workspaces/{workspace}/pipelines-config/variables/{variable}", s.handle(s.updateWorkspaceVariable))
+	mux.HandleFunc("DELETE /2.0/workspaces/{workspace}/pipelines-config/variables/{variable}", s.handle(s.deleteWorkspaceVariable))
+
+	// Pull requests
+	mux.HandleFunc("GET /2.0/repositories/{workspace}/{repo}/pullrequests", s.handleRepo(s.listPullRequests))
+	mux.HandleFunc("POST /2.0/repositories/{workspace}/{repo}/pullrequests", s.handleRepo(s.createPullRequest))
+	mux.HandleFunc("GET /2.0/repositories/{workspace}/{repo}/pullrequests/{id}", s.handleRepo(s.getPullRequest))
+	mux.HandleFunc("PUT /2.0/repositories/{workspace}/{repo}/pullrequests/{id}", s.handleRepo(s.updatePullRequest))
+	mux.HandleFunc("POST /2.0/repositories/{workspace}/{repo}/pullrequests/{id}/approve", s.handleRepo(s.reviewPullRequest("approved")))
+	mux.HandleFunc("DELETE /2.0/repositories/{workspace}/{repo}/pullrequests/{id}/approve", s.handleRepo(s.reviewPullRequest("")))
+	mux.HandleFunc("POST /2.0/repositories/{workspace}/{repo}/pullrequests/{id}/request-changes", s.handleRepo(s.reviewPullRequest("changes_requested")))
+	mux.HandleFunc("POST /2.0/repositories/{workspace}/{repo}/pullrequests/{id}/decline", s.handleRepo(s.declinePullRequest))
+	mux.HandleFunc("POST /2.0/repositories/{workspace}/{repo}/pullrequests/{id}/merge", s.handleRepo(s.mergePullRequest))
+	mux.HandleFunc("GET /2.0/repositories/{workspace}/{repo}/pullrequests/{id}/comments", s.handleRepo(s.listComments))
+	mux.HandleFunc("POST /2.0/repositories/{workspace}/{repo}/pullrequests/{id}/comments", s.handleRepo(s.createComment))
+	mux.HandleFunc("GET /2.0/repositories/{workspace}/{repo}/pullrequests/{id}/tasks", s.handleRepo(s.listTasks))
+	mux.HandleFunc("PUT /2.0/repositories/{workspace}/{repo}/pullrequests/{id}/tasks/{task}", s.handleRepo(s.updateTask))
+	mux.HandleFunc("GET /2.0/repositories/{workspace}/{repo}/pullrequests/{id}/diff", s.handleRepo(s.getPullRequestDiff))
+	mux.HandleFunc("GET /2.0/repositories/{workspace}/{repo}/pullrequests/{id}/diffstat", s.handleRepo(s.getPullRequestDiffStat))
+	mux.HandleFunc("GET /2.0/repositories/{workspace}/{repo}/pullrequests/{id}/commits", s.handleRepo(s.listPullRequestCommits))
+	mux.HandleFunc("GET /2.0/repositories/{workspace}/{repo}/commit/{commit}/statuses", s.handleRepo(s.listCommitStatuses))
+	mux.HandleFunc("GET /2.0/repositories/{workspace}/{repo}/default-reviewers", s.handleRepo(s.listDefaultReviewers))
+
+	// Deploy keys and the pipelines SSH key pair
+	mux.HandleFunc("GET /2.0/repositories/{workspace}/{repo}/deploy-keys", s.handleRepo(s.listDeployKeys))
+	mux.HandleFunc("POST /2.0/repositories/{workspace}/{repo}/deploy-keys", s.handleRepo(s.createDeployKey))
+	mux.HandleFunc("GET /2.0/repositories/{workspace}/{repo}/pipelines_config/ssh/key_pair", s.handleRepo(s.getSSHKeyPair))
+	mux.HandleFunc("PUT /2.0/repositories/{workspace}/{repo}/pipelines_config/ssh/key_pair", s.handleRepo(s.putSSHKeyPair))
+
+	mux.HandleFunc("/", s.handle(func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, http.StatusNotFound, "Resource not found")
+	}))
+
+	return mux
+}
+
+// handle wraps a handler with request recording, authentication and the
+// workspace check. Handlers run with s.mu held.
+func (s *Server) handle(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		s.requests = append(s.requests, r.Method+" "+r.URL.RequestURI())
+		w.Header().Set("X-Request-Id", strconv.Itoa(len(s.requests)))
+
+		if r.Header.Get("Authorization") == "" {
+			writeError(w, http.StatusUnauthorized, "Authentication required")
+			return
+		}
+		if workspace := r.PathValue("workspace"); workspace != "" && workspace != s.Workspace {
+			writeError(w, http.StatusNotFound, fmt.Sprintf("Workspace %s not found", workspace))
+			return
+		}
+
+		handler(w, r)
+	}
+}
+
+// handleRepo is like handle for endpoints below a repository, which it looks up
+func (s *Server) handleRepo(handler func(http.ResponseWriter, *http.Request, *repository)) http.HandlerFunc {
+	return s.handle(func(w http.ResponseWriter, r *http.Request) {
+		repo := s.repo(r.PathValue("repo"))
+		if repo == nil {
+			writeError(w, http.StatusNotFound, fmt.Sprintf("Repository %s/%s not found", s.Workspace, r.PathValue("repo")))
+			return
+		}
+		handler(w, r, repo)
+	})
+}
+
+func (s *Server) getUser(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, userJSON(&s.user))
+}
+
+func (s *Server) listMembers(w http.ResponseWriter, r *http.Request) {
+	values := make([]interface{}, 0, len(s.members)+1)
+	for _, user := range append([]*User{&s.user}, s.members...) {
+		values = append(values, map[string]interface{}{
+			"type": "workspace_membership",
+			"user": userJSON(user),
+		})
+	}
+	writePage(w, r, values)
+}
+
+func (s *Server) listProjects(w http.ResponseWriter, r *http.Request) {
+	values := make([]interface{}, 0, len(s.projects))
+	for _, project := range s.projects {
+		values = append(values, projectJSON(project))
+	}
+	writePage(w, r, values)
+}
+
+func (s *Server) listRepositories(w http.ResponseWriter, r *http.Request) {
+	values := make([]interface{}, 0, len(s.repos))
+	for _, repo := range s.repos {
+		values = append(values, s.repositoryJSON(&repo.Repository))
+	}
+	writePage(w, r, values)
+}
+
+func (s *Server) getRepository(w http.ResponseWriter, r *http.Request, repo *repository) {
+	writeJSON(w, http.StatusOK, s.repositoryJSON(&repo.Repository))
+}
+
+func (s *Server) createRepository(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+		IsPrivate   bool   `json:"is_private"`
+		Project     *struct {
+			Key string `json:"key"`
+		} `json:"project"`
+	}
+	if !decodeBody(w, r, &body) {
+		return
+	}
+
+	slug := r.PathValue("repo")
+	if s.repo(slug) != nil {
+		writeError(w, http.StatusBadRequest, "Repository with this Slug and Owner already exists.")
+		return
+	}
+
+	repo := Repository{Slug: slug, Name: body.Name, Description: body.Description, IsPrivate: body.IsPrivate}
+	if body.Project != nil {
+		if s.project(body.Project.Key) == nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("Project %s not found", body.Project.Key))
+			return
+		}
+		repo.ProjectKey = body.Project.Key
+	}
+
+	stored := s.addRepository(repo)
+	writeJSON(w, http.StatusOK, s.repositoryJSON(&stored.Repository))
+}
+
+func (s *Server) updateRepository(w http.ResponseWriter, r *http.Request, repo *repository) {
+	var body struct {
+		Description *string   `json:"description"`
+		MainBranch  *apiNamed `json:"mainbranch"`
+	}
+	if !decodeBody(w, r, &body) {
+		return
+	}
+
+	if body.Description != nil {
+		repo.Description = *body.Description
+	}
+	if body.MainBranch != nil && body.MainBranch.Name != "" {
+		repo.MainBranch = body.MainBranch.Name
+	}
+	repo.UpdatedOn = time.Now().UTC()
+
+	writeJSON(w, http.StatusOK, s.repositoryJSON(&repo.Repository))
+}
+
+func (s *Server) setGroupPermission(w http.ResponseWriter, r *http.Request, repo *repository) {
+	var body struct {
+		Permission string `json:"permission"`
+	}
+	if !decodeBody(w, r, &body) {
+		return
+	}
+
+	switch body.Permission {
+	case "read", "write", "admin":
+	default:
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid permission %q", body.Permission))
+		return
+	}
+
+	group := r.PathValue("group")
+	repo.permissions[group] = body.Permission
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"type":       "repository_group_permission",
+		"permission": body.Permission,
+		"group":      map[string]interface{}{"type": "group", "slug": group},
+	})
+}
+
+func (s *Server) listPipelines(w http.ResponseWriter, r *http.Request, repo *repository) {
+	query := r.URL.Query()
+	branch := query.Get("target.branch")
+	status := query.Get("status")
+	trigger := query.Get("trigger_type")
+	creator := query.Get("creator.uuid")
+
+	pipelines := make([]*Pipeline, 0, len(repo.pipelines))
+	for _, pipeline := range repo.pipelines {
+		if branch != "" && pipeline.Branch != branch {
+			continue
+		}
+		if status != "" && !strings.EqualFold(pipeline.State, status) && !strings.EqualFold(pipeline.Result, status) {
+			continue
+		}
+		if trigger != "" && !strings.EqualFold(pipeline.Trigger, trigger) {
+			continue
+		}
+		if creator != "" && (pipeline.Creator == nil || pipeline.Creator.UUID != creator) {
+			continue
+		}
+		pipelines = append(pipelines, pipeline)
+	}
+
+	// Newest first unless sorted by ascending creation time
+	ascending := query.Get("sort") == "created_on"
+	sort.SliceStable(pipelines, func(i, j int) bool {
+		if ascending {
+			return pipelines[i].BuildNumber < pipelines[j].BuildNumber
+		}
+		return pipelines[i].BuildNumber > pipelines[j].BuildNumber
+	})
+
+	values := make([]interface{}, 0, len(pipelines))
+	for _, pipeline := range pipelines {
+		values = append(values, s.pipelineJSON(repo.Slug, pipeline))
+	}
+	writePage(w, r, values)
+}
+
+func (s *Server) triggerPipeline(w http.ResponseWriter, r *http.Request, repo *repository) {
+	var body struct {
+		Target struct {
+			RefType string `json:"ref_type"`
+			RefName string `json:"ref_name"`
+			Commit  *struct {
+				Hash string `json:"hash"`
+			} `json:"commit"`
+			Selector *struct {
+				Pattern string `json:"pattern"`
+			} `json:"selector"`
+		} `json:"target"`
+		Variables []struct {
+			Key   string `json:"key"`
+			Value string `json:"value"`
+		} `json:"variables"`
+	}
+	if !decodeBody(w, r, &body) {
+		return
+	}
+
+	creator := s.user
+	pipeline := Pipeline{Trigger: "MANUAL", Creator: &creator}
+	if body.Target.RefType == "branch" {
+		pipeline.Branch = body.Target.RefName
+	}
+	if body.Target.Commit != nil {
+		pipeline.Commit = body.Target.Commit.Hash
+	}
+	if body.Target.Selector != nil {
+		pipeline.Custom = body.Target.Selector.Pattern
+	}
+	if pipeline.Branch == "" && pipeline.Commit == "" {
+		writeError(w, http.StatusBadRequest, "A pipeline target needs a branch or a commit")
+		return
+	}
+	if len(body.Variables) > 0 {
+		pipeline.Variables = make(map[string]string, len(body.Variables))
+		for _, variable := range body.Variables {
+			pipeline.Variables[variable.Key] = variable.Value
+		}
+	}
+
+	writeJSON(w, http.StatusCreated, s.pipelineJSON(repo.Slug, s.addPipeline(repo, pipeline)))
+}
+
+// findPipeline looks up the pipeline of the request by UUID or build number
+func findPipeline(w http.ResponseWriter, r *http.Request, repo *repository) *Pipeline {
+	id := r.PathValue("pipeline")
+	for _, pipeline := range repo.pipelines {
+		if pipeline.UUID == id || strconv.Itoa(pipeline.BuildNumber) == id {
+			return pipeline
+		}
+	}
+	writeError(w, http.StatusNotFound, fmt.Sprintf("Pipeline %s not found", id))
+	return nil
+}
+
+func (s *Server) getPipeline(w http.ResponseWriter, r *http.Request, repo *repository) {
+	if pipeline := findPipeline(w, r, repo); pipeline != nil {
+		writeJSON(w, http.StatusOK, s.pipelineJSON(repo.Slug, pipeline))
+	}
+}
+
+func (s *Server) stopPipeline(w http.ResponseWriter, r *http.Request, repo *repository) {
+	pipeline := findPipeline(w, r, repo)
+	if pipeline == nil {
+		return
+	}
+	if pipeline.State == "COMPLETED" {
+		writeError(w, http.StatusBadRequest, "The pipeline has already completed")
+		return
+	}
+
+	now := time.Now().UTC()
+	pipeline.State = "COMPLETED"
+	pipeline.Result = "STOPPED"
+	pipeline.CompletedOn = &now
+	for _, step := range pipeline.Steps {
+		if step.State != "COMPLETED" {
+			step.State = "COMPLETED"
+			step.Result = "STOPPED"
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) listSteps(w http.ResponseWriter, r *http.Request, repo *repository) {
+	pipeline := findPipeline(w, r, repo)
+	if pipeline == nil {
+		return
+	}
+
+	values := make([]interface{}, 0, len(pipeline.Steps))
+	for _, step := range pipeline.Steps {
+		values = append(values, stepJSON(step))
+	}
+	writePage(w, r, values)
+}
+
+// getStepLog serves the log of a step's build container, honoring Range
+// headers like Bitbucket's log storage does
+func (s *Server) getStepLog(w http.ResponseWriter, r *http.Request, repo *repository) {
+	pipeline := findPipeline(w, r, repo)
+	if pipeline == nil {
+		return
+	}
+
+	for _, step := range pipeline.Steps {
+		if step.UUID == r.PathValue("step") && step.UUID == r.PathValue("log") {
+			w.Header().Set("Content-Type", "application/octet-stream")
+			http.ServeContent(w, r, "", time.Time{}, strings.NewReader(step.Log))
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "Log not found")
+}
+
+func (s *Server) listRepositoryVariables(w http.ResponseWriter, r *http.Request, repo *repository) {
+	writePage(w, r, variablesJSON(repo.variables))
+}
+
+func (s *Server) createRepositoryVariable(w http.ResponseWriter, r *http.Request, repo *repository) {
+	if variable := s.createVariable(w, r, &repo.variables); variable != nil {
+		writeJSON(w, http.StatusCreated, variableJSON(variable))
+	}
+}
+
+func (s *Server) updateRepositoryVariable(w http.ResponseWriter, r *http.Request, repo *repository) {
+	if variable := updateVariable(w, r, repo.variables); variable != nil {
+		writeJSON(w, http.StatusOK, variableJSON(variable))
+	}
+}
+
+func (s *Server) deleteRepositoryVariable(w http.ResponseWriter, r *http.Request, repo *repository) {
+	if deleteVariable(w, r, &repo.variables) {
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (s *Server) listEnvironments(w http.ResponseWriter, r *http.Request, repo *repository) {
+	values := make([]interface{}, 0, len(repo.environments))
+	for _, env := range repo.environments {
+		values = append(values, environmentJSON(env))
+	}
+	writePage(w, r, values)
+}
+
+func (s *Server) createEnvironment(w http.ResponseWriter, r *http.Request, repo *repository) {
+	var body struct {
+		Name            string   `json:"name"`
+		EnvironmentType apiNamed `json:"environment_type"`
+	}
+	if !decodeBody(w, r, &body) {
+		return
+	}
+
+	if body.Name == "" {
+		writeError(w, http.StatusBadRequest, "An environment needs a name")
+		return
+	}
+	if _, ok := environmentRanks[body.EnvironmentType.Name]; !ok {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid environment type %q", body.EnvironmentType.Name))
+		return
+	}
+	if findEnvironment(repo, body.Name) != nil {
+		writeError(w, http.StatusConflict, fmt.Sprintf("Environment %s already exists", body.Name))
+		return
+	}
+
+	env := s.addEnvironment(repo, Environment{Name: body.Name, Type: body.EnvironmentType.Name})
+	writeJSON(w, http.StatusCreated, environmentJSON(env))
+}
+
+// findRequestEnvironment looks up the environment of the request by UUID or name
+func findRequestEnvironment(w http.ResponseWriter, r *http.Request, repo *repository) *Environment {
+	env := findEnvironment(repo, r.PathValue("environment"))
+	if env == nil {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("Environment %s not found", r.PathValue("environment")))
+	}
+	return env
+}
+
+func (s *Server) listDeploymentVariables(w http.ResponseWriter, r *http.Request, repo *repository) {
+	if env := findRequestEnvironment(w, r, repo); env != nil {
+		writePage(w, r, variablesJSON(env.Variables))
+	}
+}
+
+func (s *Server) createDeploymentVariable(w http.ResponseWriter, r *http.Request, repo *repository) {
+	env := findRequestEnvironment(w, r, repo)
+	if env == nil {
+		return
+	}
+	if variable := s.createVariable(w, r, &env.Variables); variable != nil {
+		writeJSON(w, http.StatusCreated, variableJSON(variable))
+	}
+}
+
+func (s *Server) updateDeploymentVariable(w http.ResponseWriter, r *http.Request, repo *repository) {
+	env := findRequestEnvironment(w, r, repo)
+	if env == nil {
+		return
+	}
+	if variable := updateVariable(w, r, env.Variables); variable != nil {
+		writeJSON(w, http.StatusOK, variableJSON(variable))
+	}
+}
+
+func (s *Server) deleteDeploymentVariable(w http.ResponseWriter, r *http.Request, repo *repository) {
+	env := findRequestEnvironment(w, r, repo)
+	if env == nil {
+		return
+	}
+	if deleteVariable(w, r, &env.Variables) {
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (s *Server) listWorkspaceVariables(w http.ResponseWriter, r *http.Request) {
+	writePage(w, r, variablesJSON(s.workspaceVariables))
+}
+
+func (s *Server) getWorkspaceVariable(w http.ResponseWriter, r *http.Request) {
+	for _, variable := range s.workspaceVariables {
+		if variable.UUID == r.PathValue("variable") {
+			writeJSON(w, http.StatusOK, variableJSON(variable))
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, fmt.Sprintf("Variable %s not found", r.PathValue("variable")))
+}
+
+func (s *Server) createWorkspaceVariable(w http.ResponseWriter, r *http.Request) {
+	if variable := s.createVariable(w, r, &s.workspaceVariables); variable != nil {
+		writeJSON(w, http.StatusCreated, variableJSON(variable))
+	}
+}
+
+func (s *Server) updateWorkspaceVariable(w http.ResponseWriter, r *http.Request) {
+	if variable := updateVariable(w, r, s.workspaceVariables); variable != nil {
+		writeJSON(w, http.StatusOK, variableJSON(variable))
+	}
+}
+
+func (s *Server) deleteWorkspaceVariable(w http.ResponseWriter, r *http.Request) {
+	if deleteVariable(w, r, &s.workspaceVariables) {
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// variableBody is the request body of the variable endpoints
+type variableBody struct {
+	Key     string  `json:"key"`
+	Value   *string `json:"value"`
+	Secured *bool   `json:"secured"`
+}
+
+// createVariable adds the variable of the request to variables, rejecting
+// duplicate keys with a 409 like Bitbucket does
+func (s *Server) createVariable(w http.ResponseWriter, r *http.Request, variables *[]*Variable) *Variable {
+	var body variableBody
+	if !decodeBody(w, r, &body) {
+		return nil
+	}
+
+	if body.Key == "" {
+		writeError(w, http.StatusBadRequest, "A variable needs a key")
+		return nil
+	}
+	for _, existing := range *variables {
+		if existing.Key == body.Key {
+			writeError(w, http.StatusConflict, fmt.Sprintf("A variable with the key %s already exists", body.Key))
+			return nil
+		}
+	}
+
+	variable := s.newVariable(Variable{Key: body.Key})
+	if body.Value != nil {
+		variable.Value = *body.Value
+	}
+	if body.Secured != nil {
+		variable.Secured = *body.Secured
+	}
+	*variables = append(*variables, variable)
+	return variable
+}
+
+// updateVariable applies the request to the variable with the UUID of the request
+func updateVariable(w http.ResponseWriter, r *http.Request, variables []*Variable) *Variable {
+	var body variableBody
+	if !decodeBody(w, r, &body) {
+		return nil
+	}
+
+	for _, variable := range variables {
+		if variable.UUID != r.PathValue("variable") {
+			continue
+		}
+		if body.Key != "" {
+			variable.Key = body.Key
+		}
+		if body.Value != nil {
+			variable.Value = *body.Value
+		}
+		if body.Secured != nil {
+			variable.Secured = *body.Secured
+		}
+		return variable
+	}
+
+	writeError(w, http.StatusNotFound, fmt.Sprintf("Variable %s not found", r.PathValue("variable")))
+	return nil
+}
+
+// deleteVariable removes the variable with the UUID of the request
+func deleteVariable(w http.ResponseWriter, r *http.Request, variables *[]*Variable) bool {
+	for i, variable := range *variables {
+		if variable.UUID == r.PathValue("variable") {
+			*variables = append((*variables)[:i], (*variables)[i+1:]...)
+			return true
+		}
+	}
+
+	writeError(w, http.StatusNotFound, fmt.Sprintf("Variable %s not found", r.PathValue("variable")))
+	return false
+}
+
+func variablesJSON(variables []*Variable) []interface{} {
+	values := make([]interface{}, 0, len(variables))
+	for _, variable := range variables {
+		values = append(values, variableJSON(variable))
+	}
+	return values
+}
+
+func (s *Server) listPullRequests(w http.ResponseWriter, r *http.Request, repo *repository) {
+	// Like Bitbucket, only open pull requests are listed unless a state is given
+	states := r.URL.Query()["state"]
+	if len(states) == 0 {
+		states = []string{"OPEN"}
+	}
+
+	// Of the query language, only filtering by source branch is supported
+	var sourceBranch string
+	if match := sourceBranchQuery.FindStringSubmatch(r.URL.Query().Get("q")); match != nil {
+		sourceBranch = match[1]
+	}
+
+	// Participants are left out unless the fields parameter adds them
+	withParticipants := strings.Contains(r.URL.Query().Get("fields"), "values.participants")
+
+	values := make([]interface{}, 0, len(repo.pullRequests))
+	// Most recently updated first
+	for i := len(repo.pullRequests) - 1; i >= 0; i-- {
+		pr := repo.pullRequests[i]
+		if sourceBranch != "" && pr.SourceBranch != sourceBranch {
+			continue
+		}
+		for _, state := range states {
+			if strings.EqualFold(pr.State, state) {
+				data := s.pullRequestJSON(repo.Slug, pr)
+				if withParticipants {
+					data["participants"] = participantsJSON(pr)
+				}
+				values = append(values, data)
+				break
+			}
+		}
+	}
+	writePage(w, r, values)
+}
+
+func (s *Server) createPullRequest(w http.ResponseWriter, r *http.Request, repo *repository) {
+	var body struct {
+		Title       string `json:"title"`
+		Description string `json:"description"`
+		Draft       bool   `json:"draft"`
+		Source      struct {
+			Branch apiNamed `json:"branch"`
+		} `json:"source"`
+		Destination *struct {
+			Branch apiNamed `json:"branch"`
+		} `json:"destination"`
+		Reviewers []struct {
+			UUID string `json:"uuid"`
+		} `json:"reviewers"`
+	}
+	if !decodeBody(w, r, &body) {
+		return
+	}
+
+	if body.Title == "" {
+		writeError(w, http.StatusBadRequest, "A pull request needs a title")
+		return
+	}
+	if body.Source.Branch.Name == "" {
+		writeError(w, http.StatusBadRequest, "A pull request needs a source branch")
+		return
+	}
+
+	pr := PullRequest{
+		Title:        body.Title,
+		Description:  body.Description,
+		Draft:        body.Draft,
+		SourceBranch: body.Source.Branch.Name,
+	}
+	if body.Destination != nil {
+		pr.DestinationBranch = body.Destination.Branch.Name
+	}
+	if pr.SourceBranch == pr.DestinationBranch || (pr.DestinationBranch == "" && pr.SourceBranch == repo.MainBranch) {
+		writeError(w, http.StatusBadRequest, "Source and destination branch must differ")
+		return
+	}
+	for _, reviewer := range body.Reviewers {
+		if reviewer.UUID == s.user.UUID {
+			writeError(w, http.StatusBadRequest, "The author cannot be a reviewer of the pull request")
+			return
+		}
+		pr.Reviewers = append(pr.Reviewers, s.reviewer(repo, reviewer.UUID))
+	}
+
+	writeJSON(w, http.StatusCreated, s.pullRequestJSON(repo.Slug, s.addPullRequest(repo, pr)))
+}
+
+// reviewer returns the default reviewer or workspace member with the UUID,
+// or a user with just the UUID if it is neither
+func (s *Server) reviewer(repo *repository, uuid string) *User {
+	for _, user := range slices.Concat(repo.defaultReviewers, s.members) {
+		if user.UUID == uuid {
+			return user
+		}
+	}
+	return &User{UUID: uuid}
+}
+
+// sourceBranchQuery matches a q parameter like source.branch.name="feature"
+var sourceBranchQuery = regexp.MustCompile(`source\.branch\.name\s*=\s*"([^"]*)"`)
+
+// findPullRequest looks up the pull request of the {id} path value, answering
+// with 404 if there is none
+func findPullRequest(w http.ResponseWriter, r *http.Request, repo *repository) *PullRequest {
+	for _, pr := range repo.pullRequests {
+		if strconv.Itoa(pr.ID) == r.PathValue("id") {
+			return pr
+		}
+	}
+	writeError(w, http.StatusNotFound, fmt.Sprintf("Pull request %s not found", r.PathValue("id")))
+	return nil
+}
+
+func (s *Server) getPullRequest(w http.ResponseWriter, r *http.Request, repo *repository) {
+	pr := findPullRequest(w, r, repo)
+	if pr == nil {
+		return
+	}
+
+	data := s.pullRequestJSON(repo.Slug, pr)
+	data["participants"] = participantsJSON(pr)
+	writeJSON(w, http.StatusOK, data)
+}
+
+func (s *Server) updatePullRequest(w http.ResponseWriter, r *http.Request, repo *repository) {
+	pr := findPullRequest(w, r, repo)
+	if pr == nil {
+		return
+	}
+	if pr.State != "OPEN" {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("You can't update a %s pull request.", strings.ToLower(pr.State)))
+		return
+	}
+
+	var body struct {
+		Title       *string `json:"title"`
+		Description *string `json:"description"`
+		Reviewers   *[]struct {
+			UUID string `json:"uuid"`
+		} `json:"reviewers"`
+	}
+	if !decodeBody(w, r, &body) {
+		return
+	}
+
+	if body.Title != nil {
+		if *body.Title == "" {
+			writeError(w, http.StatusBadRequest, "A pull request needs a title")
+			return
+		}
+		pr.Title = *body.Title
+	}
+	if body.Description != nil {
+		pr.Description = *body.Description
+	}
+	if body.Reviewers != nil {
+		var reviewers []*User
+		for _, reviewer := range *body.Reviewers {
+			if pr.Author != nil && reviewer.UUID == pr.Author.UUID {
+				writeError(w, http.StatusBadRequest, "The author cannot be a reviewer of the pull request")
+				return
+			}
+			user := s.reviewer(repo, reviewer.UUID)
+			for _, existing := range pr.Reviewers {
+				if existing.UUID == reviewer.UUID {
+					user = existing
+				}
+			}
+			reviewers = append(reviewers, user)
+		}
+		pr.Reviewers = reviewers
+	}
+
+	pr.UpdatedOn = time.Now().UTC()
+	writeJSON(w, http.StatusOK, s.pullRequestJSON(repo.Slug, pr))
+}
+
+// reviewPullRequest sets the review state of the current user, who becomes a
+// participant if they are not a reviewer. An empty state withdraws the review.
+func (s *Server) reviewPullRequest(state string) func(http.ResponseWriter, *http.Request, *repository) {
+	return func(w http.ResponseWriter, r *http.Request, repo *repository) {
+		pr := findPullRequest(w, r, repo)
+		if pr == nil {
+			return
+		}
+		if pr.State != "OPEN" {
+			writeError(w, http.StatusBadRequest, "You can only review open pull requests.")
+			return
+		}
+		if pr.Author != nil && pr.Author.UUID == s.user.UUID && state == "approved" {
+			writeError(w, http.StatusBadRequest, "You can't approve your own pull request.")
+			return
+		}
+
+		if pr.ReviewStates == nil {
+			pr.ReviewStates = make(map[string]string)
+		}
+		if state == "" {
+			if pr.ReviewStates[s.user.UUID] == "" {
+				writeError(w, http.StatusNotFound, "You haven't approved this pull request.")
+				return
+			}
+			delete(pr.ReviewStates, s.user.UUID)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		pr.ReviewStates[s.user.UUID] = state
+		if !pr.hasParticipant(s.user.UUID) {
+			user := s.user
+			pr.Participants = append(pr.Participants, &user)
+		}
+		pr.UpdatedOn = time.Now().UTC()
+
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"type":     "participant",
+			"user":     userJSON(&s.user),
+			"approved": state == "approved",
+			"state":    state,
+		})
+	}
+}
+
+func (s *Server) declinePullRequest(w http.ResponseWriter, r *http.Request, repo *repository) {
+	pr := findPullRequest(w, r, repo)
+	if pr == nil {
+		return
+	}
+	if pr.State != "OPEN" {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("You can't decline a %s pull request.", strings.ToLower(pr.State)))
+		return
+	}
+
+	pr.State = "DECLINED"
+	pr.UpdatedOn = time.Now().UTC()
+	writeJSON(w, http.StatusOK, s.pullRequestJSON(repo.Slug, pr))
+}
+
+func (s *Server) mergePullRequest(w http.ResponseWriter, r *http.Request, repo *repository) {
+	pr := findPullRequest(w, r, repo)
+	if pr == nil {
+		return
+	}
+
+	var body struct {
+		MergeStrategy     string `json:"merge_strategy"`
+		Message           string `json:"message"`
+		CloseSourceBranch bool   `json:"close_source_branch"`
+	}
+	if !decodeBody(w, r, &body) {
+		return
+	}
+
+	switch body.MergeStrategy {
+	case "", "merge_commit", "squash", "fast_forward":
+	default:
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("%s is not a valid merge strategy", body.MergeStrategy))
+		return
+	}
+	if pr.State != "OPEN" {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("You can't merge a %s pull request.", strings.ToLower(pr.State)))
+		return
+	}
+
+	pr.State = "MERGED"
+	pr.MergeStrategy = body.MergeStrategy
+	if pr.MergeStrategy == "" {
+		pr.MergeStrategy = "merge_commit"
+	}
+	pr.MergeMessage = body.Message
+	pr.CloseSourceBranch = body.CloseSourceBranch
+	pr.UpdatedOn = time.Now().UTC()
+	writeJSON(w, http.StatusOK, s.pullRequestJSON(repo.Slug, pr))
+}
+
+func (s *Server) listComments(w http.ResponseWriter, r *http.Request, repo *repository) {
+	pr := findPullRequest(w, r, repo)
+	if pr == nil {
+		return
+	}
+
+	values := make([]interface{}, 0, len(pr.Comments))
+	for _, comment := range pr.Comments {
+		values = append(values, s.commentJSON(repo.Slug, pr, comment))
+	}
+	writePage(w, r, values)
+}
+
+func (s *Server) createComment(w http.ResponseWriter, r *http.Request, repo *repository) {
+	pr := findPullRequest(w, r, repo)
+	if pr == nil {
+		return
+	}
+
+	var body struct {
+		Content struct {
+			Raw string `json:"raw"`
+		} `json:"content"`
+		Parent *struct {
+			ID int `json:"id"`
+		} `json:"parent"`
+		Inline *struct {
+			Path string `json:"path"`
+			From *int   `json:"from"`
+			To   *int   `json:"to"`
+		} `json:"inline"`
+	}
+	if !decodeBody(w, r, &body) {
+		return
+	}
+
+	if body.Content.Raw == "" {
+		writeError(w, http.StatusBadRequest, "A comment needs content")
+		return
+	}
+
+	comment := &Comment{Body: body.Content.Raw}
+	if body.Inline != nil {
+		if body.Inline.Path == "" {
+			writeError(w, http.StatusBadRequest, "An inline comment needs a path")
+			return
+		}
+		comment.Path = body.Inline.Path
+		if body.Inline.To != nil {
+			comment.Line = *body.Inline.To
+		}
+		if body.Inline.From != nil {
+			comment.OldLine = *body.Inline.From
+		}
+	}
+	if body.Parent != nil {
+		var parent *Comment
+		for _, existing := range pr.Comments {
+			if existing.ID == body.Parent.ID {
+				parent = existing
+			}
+		}
+		if parent == nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("Parent comment %d not found", body.Parent.ID))
+			return
+		}
+		// Replies belong to the thread of their parent
+		comment.ParentID = parent.ID
+		comment.Path, comment.Line, comment.OldLine = parent.Path, parent.Line, parent.OldLine
+	}
+
+	s.fillComment(pr, comment)
+	pr.Comments = append(pr.Comments, comment)
+	pr.UpdatedOn = time.Now().UTC()
+	writeJSON(w, http.StatusCreated, s.commentJSON(repo.Slug, pr, comment))
+}
+
+func (s *Server) listTasks(w http.ResponseWriter, r *http.Request, repo *repository) {
+	pr := findPullRequest(w, r, repo)
+	if pr == nil {
+		return
+	}
+
+	values := make([]interface{}, 0, len(pr.Tasks))
+	for _, task := range pr.Tasks {
+		values = append(values, taskJSON(task))
+	}
+	writePage(w, r, values)
+}
+
+func (s *Server) updateTask(w http.ResponseWriter, r *http.Request, repo *repository) {
+	pr := findPullRequest(w, r, repo)
+	if pr == nil {
+		return
+	}
+
+	var task *Task
+	for _, existing := range pr.Tasks {
+		if strconv.Itoa(existing.ID) == r.PathValue("task") {
+			task = existing
+		}
+	}
+	if task == nil {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("Task %s not found", r.PathValue("task")))
+		return
+	}
+
+	var body struct {
+		State   string `json:"state"`
+		Content *struct {
+			Raw string `json:"raw"`
+		} `json:"content"`
+	}
+	if !decodeBody(w, r, &body) {
+		return
+	}
+
+	switch body.State {
+	case "":
+	case "RESOLVED":
+		if !task.Resolved {
+			now := time.Now().UTC()
+			user := s.user
+			task.Resolved, task.ResolvedOn, task.ResolvedBy = true, &now, &user
+		}
+	case "UNRESOLVED":
+		task.Resolved, task.ResolvedOn, task.ResolvedBy = false, nil, nil
+	default:
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid task state %q", body.State))
+		return
+	}
+	if body.Content != nil {
+		task.Body = body.Content.Raw
+	}
+
+	writeJSON(w, http.StatusOK, taskJSON(task))
+}
+
+// getPullRequestDiff serves the diff directly; Bitbucket redirects to the
+// diff of the source and destination commits
+func (s *Server) getPullRequestDiff(w http.ResponseWriter, r *http.Request, repo *repository) {
+	pr := findPullRequest(w, r, repo)
+	if pr == nil {
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain")
+	_, _ = w.Write([]byte(pr.Diff))
+}
+
+func (s *Server) getPullRequestDiffStat(w http.ResponseWriter, r *http.Request, repo *repository) {
+	pr := findPullRequest(w, r, repo)
+	if pr == nil {
+		return
+	}
+
+	values := make([]interface{}, 0, len(pr.Files))
+	for _, file := range pr.Files {
+		values = append(values, diffStatJSON(file))
+	}
+	writePage(w, r, values)
+}
+
+func (s *Server) listPullRequestCommits(w http.ResponseWriter, r *http.Request, repo *repository) {
+	pr := findPullRequest(w, r, repo)
+	if pr == nil {
+		return
+	}
+
+	values := make([]interface{}, 0, len(pr.Commits))
+	for _, commit := range pr.Commits {
+		values = append(values, commitJSON(commit))
+	}
+	writePage(w, r, values)
+}
+
+// listCommitStatuses lists the pipelines that ran on the commit, newest first
+func (s *Server) listCommitStatuses(w http.ResponseWriter, r *http.Request, repo *repository) {
+	values := make([]interface{}, 0)
+	for i := len(repo.pipelines) - 1; i >= 0; i-- {
+		if pipeline := repo.pipelines[i]; pipeline.Commit == r.PathValue("commit") {
+			values = append(values, s.commitStatusJSON(repo.Slug, pipeline))
+		}
+	}
+	writePage(w, r, values)
+}
+
+func (s *Server) listDefaultReviewers(w http.ResponseWriter, r *http.Request, repo *repository) {
+	values := make([]interface{}, 0, len(repo.defaultReviewers))
+	for _, user := range repo.defaultReviewers {
+		values = append(values, userJSON(user))
+	}
+	writePage(w, r, values)
+}
+
+func (s *Server) listDeployKeys(w http.ResponseWriter, r *http.Request, repo *repository) {
+	values := make([]interface{}, 0, len(repo.deployKeys))
+	for _, key := range repo.deployKeys {
+		values = append(values, deployKeyJSON(key))
+	}
+	writePage(w, r, values)
+}
+
+func (s *Server) createDeployKey(w http.ResponseWriter, r *http.Request, repo *repository) {
+	var body struct {
+		Key   string `json:"key"`
+		Label string `json:"label"`
+	}
+	if !decodeBody(w, r, &body) {
+		return
+	}
+
+	if !strings.HasPrefix(body.Key, "ssh-") && !strings.HasPrefix(body.Key, "ecdsa-") {
+		writeError(w, http.StatusBadRequest, "Invalid SSH key")
+		return
+	}
+	for _, existing := range repo.deployKeys {
+		if existing.Key == body.Key {
+			writeError(w, http.StatusBadRequest, "Someone has already added that access key to this repository.")
+			return
+		}
+	}
+
+	writeJSON(w, http.StatusOK, deployKeyJSON(s.addDeployKey(repo, DeployKey{Key: body.Key, Label: body.Label})))
+}
+
+func (s *Server) getSSHKeyPair(w http.ResponseWriter, r *http.Request, repo *repository) {
+	if repo.sshPublicKey == "" {
+		writeError(w, http.StatusNotFound, "No SSH key pair configured")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"type": "pipeline_ssh_key_pair", "public_key": repo.sshPublicKey})
+}
+
+func (s *Server) putSSHKeyPair(w http.ResponseWriter, r *http.Request, repo *repository) {
+	var body struct {
+		PrivateKey string `json:"private_key"`
+		PublicKey  string `json:"public_key"`
+	}
+	if !decodeBody(w, r, &body) {
+		return
+	}
+
+	if body.PrivateKey == "" || body.PublicKey == "" {
+		writeError(w, http.StatusBadRequest, "Both private_key and public_key are required")
+		return
+	}
+
+	// Like Bitbucket, the private key is write-only
+	repo.sshPublicKey = body.PublicKey
+	writeJSON(w, http.StatusOK, map[string]interface{}{"type": "pipeline_ssh_key_pair", "public_key": repo.sshPublicKey})
+}
+
+// apiNamed is an object referenced by name in request bodies, e.g. a branch
+type apiNamed struct {
+	Name string `json:"name"`
+}
+
+// decodeBody decodes the JSON request body into v, answering with a 400 if it is invalid
+func decodeBody(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid request body: %v", err))
+		return false
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// writeError answers with an error in the format of the Bitbucket API
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]interface{}{
+		"type":  "error",
+		"error": map[string]interface{}{"message": message},
+	})
+}
+
+// writePage answers with one page of values, selected by the page and pagelen
+// query parameters, and a next link if more values follow
+func writePage(w http.ResponseWriter, r *http.Request, values []interface{}) {
+	query := r.URL.Query()
+
+	pageLen, err := strconv.Atoi(query.Get("pagelen"))
+	if err != nil || pageLen <= 0 {
+		pageLen = defaultPageLen
+	}
+	pageLen = min(pageLen, maxPageLen)
+
+	pageNum, err := strconv.Atoi(query.Get("page"))
+	if err != nil || pageNum <= 0 {
+		pageNum = 1
+	}
+
+	start := min((pageNum-1)*pageLen, len(values))
+	end := min(start+pageLen, len(values))
+
+	data := map[string]interface{}{
+		"size":    len(values),
+		"page":    pageNum,
+		"pagelen": pageLen,
+		"values":  values[start:end],
+	}
+	if end < len(values) {
+		query.Set("page", strconv.Itoa(pageNum+1))
+		next := url.URL{Scheme: "http", Host: r.Host, Path: r.URL.Path, RawQuery: query.Encode()}
+		data["next"] = next.String()
+	}
+
+	// Like Bitbucket, answer a repeated request for an unchanged page with 304
+	body, err := json.Marshal(data)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:8]) + `"`
+	w.Header().Set("ETag", etag)
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(append(body, '\n'))
+}
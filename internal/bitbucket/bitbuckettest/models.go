@@ -0,0 +1,545 @@
+package bitbuckettest
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// User is a Bitbucket account, e.g. the authenticated user or a reviewer
+type User struct {
+	UUID        string
+	Username    string
+	DisplayName string
+}
+
+// Project is a workspace project
+type Project struct {
+	Key         string
+	Name        string
+	Description string
+	UUID        string
+}
+
+// Repository is a repository of the workspace. Zero values are filled in when
+// the repository is added: Name defaults to Slug and MainBranch to "main".
+type Repository struct {
+	Slug        string
+	Name        string
+	Description string
+	Language    string
+	ProjectKey  string
+	MainBranch  string
+	IsPrivate   bool
+	CreatedOn   time.Time
+	UpdatedOn   time.Time
+}
+
+// Pipeline is a pipeline run of a repository
+type Pipeline struct {
+	UUID        string
+	BuildNumber int
+	State       string // PENDING, IN_PROGRESS, COMPLETED
+	Result      string // SUCCESSFUL, FAILED, ERROR, STOPPED; only for COMPLETED pipelines
+	Branch      string
+	Commit      string
+	Custom      string // Custom pipeline name, if any
+	PullRequest int    // ID of the pull request of a pull request pipeline, whose source branch is Branch
+	Trigger     string // PUSH, MANUAL, SCHEDULE
+	Creator     *User
+	Variables   map[string]string // Variables passed when the pipeline was triggered
+	CreatedOn   time.Time
+	CompletedOn *time.Time
+	Steps       []*Step
+}
+
+// Step is a step of a pipeline
+type Step struct {
+	UUID        string
+	Name        string
+	State       string
+	Result      string
+	DurationSec int
+	Log         string
+}
+
+// Variable is a repository, deployment or workspace variable
+type Variable struct {
+	UUID    string
+	Key     string
+	Value   string
+	Secured bool
+}
+
+// Environment is a deployment environment of a repository
+type Environment struct {
+	UUID      string
+	Name      string
+	Type      string // Test, Staging, Production
+	Variables []*Variable
+}
+
+// PullRequest is a pull request of a repository
+type PullRequest struct {
+	ID                int
+	Title             string
+	Description       string
+	State             string // OPEN, MERGED, DECLINED, SUPERSEDED
+	Draft             bool
+	SourceBranch      string
+	SourceCommit      string // Filled in when empty
+	SourceRepository  string // Full name of the fork the pull request is from; empty for the repository itself
+	DestinationBranch string
+	Author            *User
+	Reviewers         []*User
+	Participants      []*User           // Users other than reviewers who reviewed the pull request
+	ReviewStates      map[string]string // Reviewer or participant UUID to approved or changes_requested
+	Commits           []*Commit         // Newest first; one commit is filled in when empty
+	Files             []*FileChange
+	Diff              string // Unified diff
+	Comments          []*Comment
+	Tasks             []*Task
+	CommentCount      int    // Used when Comments is empty
+	TaskCount         int    // Open tasks; used when Tasks is empty
+	MergeStrategy     string // Set when merged through the API
+	MergeMessage      string
+	CloseSourceBranch bool
+	CreatedOn         time.Time
+	UpdatedOn         time.Time
+}
+
+// Commit is a commit of a pull request
+type Commit struct {
+	Hash    string
+	Message string
+	Author  *User
+	Date    time.Time
+}
+
+// Comment is a general or inline comment of a pull request. IDs are filled
+// in when empty.
+type Comment struct {
+	ID        int
+	ParentID  int // Set for replies
+	Author    *User
+	Body      string
+	Path      string // Set for inline comments
+	Line      int    // Line in the new version of Path
+	OldLine   int    // Line in the old version of Path
+	Deleted   bool
+	CreatedOn time.Time
+}
+
+// Task is a task of a pull request. IDs are filled in when empty.
+type Task struct {
+	ID         int
+	CommentID  int
+	Body       string
+	Creator    *User
+	Resolved   bool
+	ResolvedBy *User
+	CreatedOn  time.Time
+	ResolvedOn *time.Time
+}
+
+// FileChange is a file changed by a pull request
+type FileChange struct {
+	Path         string
+	OldPath      string // Set for renamed files
+	Status       string // added, removed, modified, renamed
+	LinesAdded   int
+	LinesRemoved int
+}
+
+// DeployKey is an SSH key with read access to a repository
+type DeployKey struct {
+	ID      int
+	Key     string
+	Label   string
+	Comment string
+	AddedOn time.Time
+}
+
+// environmentRanks orders the environment types like Bitbucket does
+var environmentRanks = map[string]int{"Test": 0, "Staging": 1, "Production": 2}
+
+// The functions below render the models in the JSON shape of the Bitbucket API
+
+func userJSON(u *User) map[string]interface{} {
+	if u == nil {
+		return nil
+	}
+	return map[string]interface{}{
+		"type":         "user",
+		"uuid":         u.UUID,
+		"username":     u.Username,
+		"nickname":     u.Username,
+		"display_name": u.DisplayName,
+	}
+}
+
+func projectJSON(p *Project) map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "project",
+		"key":         p.Key,
+		"name":        p.Name,
+		"description": p.Description,
+		"uuid":        p.UUID,
+	}
+}
+
+func (s *Server) repositoryJSON(r *Repository) map[string]interface{} {
+	fullName := s.Workspace + "/" + r.Slug
+	data := map[string]interface{}{
+		"type":        "repository",
+		"slug":        r.Slug,
+		"name":        r.Name,
+		"full_name":   fullName,
+		"description": r.Description,
+		"language":    r.Language,
+		"is_private":  r.IsPrivate,
+		"scm":         "git",
+		"mainbranch":  map[string]interface{}{"type": "branch", "name": r.MainBranch},
+		"created_on":  r.CreatedOn,
+		"updated_on":  r.UpdatedOn,
+		"links":       map[string]interface{}{"html": map[string]interface{}{"href": s.WebURL() + "/" + fullName}},
+	}
+	if r.ProjectKey != "" {
+		project := map[string]interface{}{"type": "project", "key": r.ProjectKey}
+		if p := s.project(r.ProjectKey); p != nil {
+			project["name"] = p.Name
+		}
+		data["project"] = project
+	}
+	return data
+}
+
+func stateJSON(state, result string) map[string]interface{} {
+	data := map[string]interface{}{"name": state}
+	if result != "" {
+		data["result"] = map[string]interface{}{"name": result}
+	}
+	return data
+}
+
+func (s *Server) pipelineJSON(repoSlug string, p *Pipeline) map[string]interface{} {
+	target := map[string]interface{}{"type": "pipeline_commit_target"}
+	if p.Branch != "" {
+		target = map[string]interface{}{
+			"type":     "pipeline_ref_target",
+			"ref_type": "branch",
+			"ref_name": p.Branch,
+		}
+	}
+	if p.PullRequest > 0 {
+		target = map[string]interface{}{
+			"type":        "pipeline_pullrequest_target",
+			"source":      p.Branch,
+			"pullrequest": map[string]interface{}{"type": "pullrequest", "id": p.PullRequest},
+		}
+	}
+	if p.Commit != "" {
+		target["commit"] = map[string]interface{}{"type": "commit", "hash": p.Commit}
+	}
+	if p.Custom != "" {
+		target["selector"] = map[string]interface{}{"type": "custom", "pattern": p.Custom}
+	}
+
+	buildSeconds := 0
+	for _, step := range p.Steps {
+		buildSeconds += step.DurationSec
+	}
+
+	data := map[string]interface{}{
+		"type":               "pipeline",
+		"uuid":               p.UUID,
+		"build_number":       p.BuildNumber,
+		"build_seconds_used": buildSeconds,
+		"state":              stateJSON(p.State, p.Result),
+		"created_on":         p.CreatedOn,
+		"target":             target,
+		"trigger": map[string]interface{}{
+			"name": p.Trigger,
+			"type": "pipeline_trigger_" + strings.ToLower(p.Trigger),
+		},
+		"creator":    userJSON(p.Creator),
+		"repository": map[string]interface{}{"type": "repository", "full_name": s.Workspace + "/" + repoSlug},
+	}
+	if p.CompletedOn != nil {
+		data["completed_on"] = p.CompletedOn
+	}
+	return data
+}
+
+func stepJSON(step *Step) map[string]interface{} {
+	return map[string]interface{}{
+		"type":                "pipeline_step",
+		"uuid":                step.UUID,
+		"name":                step.Name,
+		"state":               stateJSON(step.State, step.Result),
+		"duration_in_seconds": step.DurationSec,
+	}
+}
+
+// variableJSON leaves out the value of secured variables, like Bitbucket does
+func variableJSON(v *Variable) map[string]interface{} {
+	data := map[string]interface{}{
+		"type":    "pipeline_variable",
+		"uuid":    v.UUID,
+		"key":     v.Key,
+		"secured": v.Secured,
+	}
+	if !v.Secured {
+		data["value"] = v.Value
+	}
+	return data
+}
+
+func environmentJSON(env *Environment) map[string]interface{} {
+	return map[string]interface{}{
+		"type": "deployment_environment",
+		"uuid": env.UUID,
+		"name": env.Name,
+		"environment_type": map[string]interface{}{
+			"type": "deployment_environment_type",
+			"name": env.Type,
+			"rank": environmentRanks[env.Type],
+		},
+	}
+}
+
+func (s *Server) pullRequestJSON(repoSlug string, pr *PullRequest) map[string]interface{} {
+	reviewers := make([]map[string]interface{}, 0, len(pr.Reviewers))
+	for _, reviewer := range pr.Reviewers {
+		reviewers = append(reviewers, userJSON(reviewer))
+	}
+	sourceRepository := pr.SourceRepository
+	if sourceRepository == "" {
+		sourceRepository = s.Workspace + "/" + repoSlug
+	}
+
+	return map[string]interface{}{
+		"type":        "pullrequest",
+		"id":          pr.ID,
+		"title":       pr.Title,
+		"description": pr.Description,
+		"state":       pr.State,
+		"draft":       pr.Draft,
+		"source": map[string]interface{}{
+			"branch":     map[string]interface{}{"name": pr.SourceBranch},
+			"commit":     map[string]interface{}{"type": "commit", "hash": pr.SourceCommit},
+			"repository": map[string]interface{}{"type": "repository", "full_name": sourceRepository},
+		},
+		"destination": map[string]interface{}{
+			"branch":     map[string]interface{}{"name": pr.DestinationBranch},
+			"repository": map[string]interface{}{"type": "repository", "full_name": s.Workspace + "/" + repoSlug},
+		},
+		"author":        userJSON(pr.Author),
+		"reviewers":     reviewers,
+		"comment_count": pr.commentCount(),
+		"task_count":    pr.openTaskCount(),
+		"created_on":    pr.CreatedOn,
+		"updated_on":    pr.UpdatedOn,
+		"links": map[string]interface{}{
+			"html": map[string]interface{}{
+				"href": fmt.Sprintf("%s/%s/%s/pull-requests/%d", s.WebURL(), s.Workspace, repoSlug, pr.ID),
+			},
+		},
+	}
+}
+
+// hasParticipant returns true if the user is a reviewer or participant
+func (pr *PullRequest) hasParticipant(uuid string) bool {
+	for _, user := range append(append([]*User(nil), pr.Reviewers...), pr.Participants...) {
+		if user.UUID == uuid {
+			return true
+		}
+	}
+	return false
+}
+
+// participantsJSON renders the reviewers and participants with their review
+// state. Like Bitbucket, only a single pull request includes its participants,
+// unless a list asks for them with the fields parameter.
+func participantsJSON(pr *PullRequest) []map[string]interface{} {
+	participants := make([]map[string]interface{}, 0, len(pr.Reviewers)+len(pr.Participants))
+	add := func(user *User, role string) {
+		var state interface{}
+		if reviewState := pr.ReviewStates[user.UUID]; reviewState != "" {
+			state = reviewState
+		}
+		participants = append(participants, map[string]interface{}{
+			"type":     "participant",
+			"user":     userJSON(user),
+			"role":     role,
+			"approved": state == "approved",
+			"state":    state,
+		})
+	}
+	for _, reviewer := range pr.Reviewers {
+		add(reviewer, "REVIEWER")
+	}
+	for _, participant := range pr.Participants {
+		add(participant, "PARTICIPANT")
+	}
+	return participants
+}
+
+// commentCount returns the number of comments that are not deleted
+func (pr *PullRequest) commentCount() int {
+	if len(pr.Comments) == 0 {
+		return pr.CommentCount
+	}
+	count := 0
+	for _, comment := range pr.Comments {
+		if !comment.Deleted {
+			count++
+		}
+	}
+	return count
+}
+
+// openTaskCount returns the number of unresolved tasks
+func (pr *PullRequest) openTaskCount() int {
+	if len(pr.Tasks) == 0 {
+		return pr.TaskCount
+	}
+	count := 0
+	for _, task := range pr.Tasks {
+		if !task.Resolved {
+			count++
+		}
+	}
+	return count
+}
+
+func (s *Server) commentJSON(repoSlug string, pr *PullRequest, c *Comment) map[string]interface{} {
+	data := map[string]interface{}{
+		"type":       "pullrequest_comment",
+		"id":         c.ID,
+		"content":    map[string]interface{}{"type": "rendered", "raw": c.Body, "markup": "markdown"},
+		"user":       userJSON(c.Author),
+		"deleted":    c.Deleted,
+		"created_on": c.CreatedOn,
+		"updated_on": c.CreatedOn,
+		"links": map[string]interface{}{
+			"html": map[string]interface{}{
+				"href": fmt.Sprintf("%s/%s/%s/pull-requests/%d#comment-%d", s.WebURL(), s.Workspace, repoSlug, pr.ID, c.ID),
+			},
+		},
+	}
+	if c.ParentID != 0 {
+		data["parent"] = map[string]interface{}{"id": c.ParentID}
+	}
+	if c.Path != "" {
+		inline := map[string]interface{}{"path": c.Path, "from": nil, "to": nil}
+		if c.Line != 0 {
+			inline["to"] = c.Line
+		}
+		if c.OldLine != 0 {
+			inline["from"] = c.OldLine
+		}
+		data["inline"] = inline
+	}
+	return data
+}
+
+func taskJSON(t *Task) map[string]interface{} {
+	state := "UNRESOLVED"
+	var resolvedBy interface{}
+	if t.Resolved {
+		state = "RESOLVED"
+		resolvedBy = userJSON(t.ResolvedBy)
+	}
+	data := map[string]interface{}{
+		"type":        "task",
+		"id":          t.ID,
+		"state":       state,
+		"content":     map[string]interface{}{"type": "rendered", "raw": t.Body, "markup": "markdown"},
+		"creator":     userJSON(t.Creator),
+		"created_on":  t.CreatedOn,
+		"updated_on":  t.CreatedOn,
+		"resolved_on": t.ResolvedOn,
+		"resolved_by": resolvedBy,
+	}
+	if t.CommentID != 0 {
+		data["comment"] = map[string]interface{}{"id": t.CommentID}
+	}
+	return data
+}
+
+func commitJSON(c *Commit) map[string]interface{} {
+	author := map[string]interface{}{"type": "author"}
+	if c.Author != nil {
+		author["raw"] = fmt.Sprintf("%s <%s@example.com>", c.Author.DisplayName, c.Author.Username)
+		author["user"] = userJSON(c.Author)
+	}
+	return map[string]interface{}{
+		"type":    "commit",
+		"hash":    c.Hash,
+		"message": c.Message,
+		"date":    c.Date,
+		"author":  author,
+	}
+}
+
+func diffStatJSON(f *FileChange) map[string]interface{} {
+	data := map[string]interface{}{
+		"type":          "diffstat",
+		"status":        f.Status,
+		"lines_added":   f.LinesAdded,
+		"lines_removed": f.LinesRemoved,
+		"old":           nil,
+		"new":           nil,
+	}
+	oldPath := f.Path
+	if f.OldPath != "" {
+		oldPath = f.OldPath
+	}
+	if f.Status != "added" {
+		data["old"] = map[string]interface{}{"type": "commit_file", "path": oldPath}
+	}
+	if f.Status != "removed" {
+		data["new"] = map[string]interface{}{"type": "commit_file", "path": f.Path}
+	}
+	return data
+}
+
+// commitStatusJSON renders a pipeline as the build status Bitbucket Pipelines
+// reports for the commit it ran on
+func (s *Server) commitStatusJSON(repoSlug string, p *Pipeline) map[string]interface{} {
+	state := "INPROGRESS"
+	switch p.Result {
+	case "SUCCESSFUL":
+		state = "SUCCESSFUL"
+	case "FAILED", "ERROR":
+		state = "FAILED"
+	case "STOPPED":
+		state = "STOPPED"
+	}
+
+	updatedOn := p.CreatedOn
+	if p.CompletedOn != nil {
+		updatedOn = *p.CompletedOn
+	}
+
+	return map[string]interface{}{
+		"type":       "build",
+		"key":        p.UUID,
+		"name":       fmt.Sprintf("Pipeline #%d for %s", p.BuildNumber, p.Branch),
+		"state":      state,
+		"url":        fmt.Sprintf("%s/%s/%s/pipelines/results/%d", s.WebURL(), s.Workspace, repoSlug, p.BuildNumber),
+		"updated_on": updatedOn,
+	}
+}
+
+func deployKeyJSON(key *DeployKey) map[string]interface{} {
+	return map[string]interface{}{
+		"type":     "deploy_key",
+		"id":       key.ID,
+		"key":      key.Key,
+		"label":    key.Label,
+		"comment":  key.Comment,
+		"added_on": key.AddedOn,
+	}
+}
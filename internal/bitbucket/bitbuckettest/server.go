@@ -0,0 +1,591 @@
+// Package bitbuckettest provides an in-memory stand-in for the Bitbucket Cloud
+// API, for tests and for trying out the CLI without touching a real workspace.
+//
+// The server implements the subset of the API used by the bitbucket package:
+// repositories, projects, pipelines and their steps and logs, repository,
+// deployment and workspace variables, deployment environments, pull requests
+// with their commits, diff, diffstat, comments, tasks and the build statuses of
+// their pipelines, updating, reviewing, declining and merging pull requests,
+// default reviewers, workspace members, deploy keys and the pipelines SSH key
+// pair. Point the CLI at it with --api-url or EISCLI_BITBUCKET_API_URL set to
+// APIURL.
+package bitbuckettest
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"maps"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Server is a fake Bitbucket API for a single workspace. Seed it with the Add
+// methods and inspect the state after a test with the accessor methods, all of
+// which are safe to call while the server is handling requests.
+type Server struct {
+	*httptest.Server
+
+	// Workspace is the only workspace the server knows; requests for other
+	// workspaces get a 404
+	Workspace string
+
+	mu                 sync.Mutex
+	user               User
+	members            []*User
+	projects           []*Project
+	repos              []*repository
+	workspaceVariables []*Variable
+	nextUUID           int
+	requests           []string
+}
+
+// repository holds a repository and everything stored below it
+type repository struct {
+	Repository
+
+	pipelines        []*Pipeline
+	variables        []*Variable
+	environments     []*Environment
+	pullRequests     []*PullRequest
+	defaultReviewers []*User
+	deployKeys       []*DeployKey
+	sshPublicKey     string
+	permissions      map[string]string
+}
+
+// New starts a fake Bitbucket API for the workspace. Close it when done.
+func New(workspace string) *Server {
+	s := NewUnstarted(workspace)
+	s.Start()
+	return s
+}
+
+// NewUnstarted returns a fake Bitbucket API that is not yet started, so that
+// its Listener can be replaced, e.g. to serve on a fixed address
+func NewUnstarted(workspace string) *Server {
+	s := &Server{
+		Workspace: workspace,
+		user: User{
+			UUID:        "{00000000-0000-4000-8000-000000000001}",
+			Username:    "tester",
+			DisplayName: "Test User",
+		},
+		nextUUID: 1,
+	}
+	s.Server = httptest.NewUnstartedServer(s.routes())
+	return s
+}
+
+// APIURL returns the base URL of the API, to be used as bitbucket.api_url
+func (s *Server) APIURL() string {
+	return s.URL + "/2.0"
+}
+
+// WebURL returns the base URL of the web links in API responses, to be used
+// as bitbucket.web_url. The server does not serve any web pages.
+func (s *Server) WebURL() string {
+	return s.URL
+}
+
+// Requests returns the requests handled so far as "METHOD /path?query"
+func (s *Server) Requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]string(nil), s.requests...)
+}
+
+// SetCurrentUser sets the authenticated user, who authors created pull
+// requests and triggered pipelines
+func (s *Server) SetCurrentUser(user User) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.user = user
+}
+
+// AddMember adds a user to the members of the workspace. The current user is
+// always a member.
+func (s *Server) AddMember(user User) User {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.members = append(s.members, &user)
+	return user
+}
+
+// AddProject adds a project to the workspace
+func (s *Server) AddProject(project Project) Project {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if project.UUID == "" {
+		project.UUID = s.newUUID()
+	}
+	if project.Name == "" {
+		project.Name = project.Key
+	}
+	s.projects = append(s.projects, &project)
+	return project
+}
+
+// AddRepository adds a repository to the workspace
+func (s *Server) AddRepository(repo Repository) Repository {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.addRepository(repo).Repository
+}
+
+// AddPipeline adds a pipeline run to a repository. UUIDs, the build number,
+// the creator and the creation time are filled in when empty.
+func (s *Server) AddPipeline(repoSlug string, pipeline Pipeline) Pipeline {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return copyPipeline(s.addPipeline(s.ensureRepo(repoSlug), pipeline))
+}
+
+// AddVariable adds a repository variable
+func (s *Server) AddVariable(repoSlug string, variable Variable) Variable {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	repo := s.ensureRepo(repoSlug)
+	repo.variables = append(repo.variables, s.newVariable(variable))
+	return *repo.variables[len(repo.variables)-1]
+}
+
+// AddEnvironment adds a deployment environment to a repository
+func (s *Server) AddEnvironment(repoSlug string, env Environment) Environment {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return copyEnvironment(s.addEnvironment(s.ensureRepo(repoSlug), env))
+}
+
+// AddDeploymentVariable adds a variable to a deployment environment, which is
+// created as a Test environment if it does not exist
+func (s *Server) AddDeploymentVariable(repoSlug, envName string, variable Variable) Variable {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	repo := s.ensureRepo(repoSlug)
+	env := findEnvironment(repo, envName)
+	if env == nil {
+		env = s.addEnvironment(repo, Environment{Name: envName, Type: "Test"})
+	}
+	env.Variables = append(env.Variables, s.newVariable(variable))
+	return *env.Variables[len(env.Variables)-1]
+}
+
+// AddWorkspaceVariable adds a workspace variable
+func (s *Server) AddWorkspaceVariable(variable Variable) Variable {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.workspaceVariables = append(s.workspaceVariables, s.newVariable(variable))
+	return *s.workspaceVariables[len(s.workspaceVariables)-1]
+}
+
+// AddPullRequest adds a pull request to a repository. The ID, state, author
+// and timestamps are filled in when empty.
+func (s *Server) AddPullRequest(repoSlug string, pr PullRequest) PullRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return *s.addPullRequest(s.ensureRepo(repoSlug), pr)
+}
+
+// SetDefaultReviewers sets the default reviewers of a repository
+func (s *Server) SetDefaultReviewers(repoSlug string, users ...User) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	repo := s.ensureRepo(repoSlug)
+	repo.defaultReviewers = nil
+	for _, user := range users {
+		repo.defaultReviewers = append(repo.defaultReviewers, &user)
+	}
+}
+
+// AddDeployKey adds a deploy key to a repository
+func (s *Server) AddDeployKey(repoSlug string, key DeployKey) DeployKey {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return *s.addDeployKey(s.ensureRepo(repoSlug), key)
+}
+
+// Repository returns a repository and whether it exists
+func (s *Server) Repository(slug string) (Repository, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if repo := s.repo(slug); repo != nil {
+		return repo.Repository, true
+	}
+	return Repository{}, false
+}
+
+// Pipelines returns the pipelines of a repository, oldest first
+func (s *Server) Pipelines(repoSlug string) []Pipeline {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var pipelines []Pipeline
+	if repo := s.repo(repoSlug); repo != nil {
+		for _, pipeline := range repo.pipelines {
+			pipelines = append(pipelines, copyPipeline(pipeline))
+		}
+	}
+	return pipelines
+}
+
+// Variables returns the repository variables of a repository
+func (s *Server) Variables(repoSlug string) []Variable {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if repo := s.repo(repoSlug); repo != nil {
+		return copyVariables(repo.variables)
+	}
+	return nil
+}
+
+// Environments returns the deployment environments of a repository
+func (s *Server) Environments(repoSlug string) []Environment {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var envs []Environment
+	if repo := s.repo(repoSlug); repo != nil {
+		for _, env := range repo.environments {
+			envs = append(envs, copyEnvironment(env))
+		}
+	}
+	return envs
+}
+
+// DeploymentVariables returns the variables of a deployment environment
+func (s *Server) DeploymentVariables(repoSlug, envName string) []Variable {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if repo := s.repo(repoSlug); repo != nil {
+		if env := findEnvironment(repo, envName); env != nil {
+			return copyVariables(env.Variables)
+		}
+	}
+	return nil
+}
+
+// WorkspaceVariables returns the workspace variables
+func (s *Server) WorkspaceVariables() []Variable {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return copyVariables(s.workspaceVariables)
+}
+
+// PullRequests returns the pull requests of a repository, oldest first
+func (s *Server) PullRequests(repoSlug string) []PullRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var prs []PullRequest
+	if repo := s.repo(repoSlug); repo != nil {
+		for _, pr := range repo.pullRequests {
+			copied := *pr
+			// Review states change with requests
+			copied.ReviewStates = maps.Clone(pr.ReviewStates)
+			copied.Participants = slices.Clone(pr.Participants)
+			copied.Comments = slices.Clone(pr.Comments)
+			copied.Tasks = make([]*Task, 0, len(pr.Tasks))
+			for _, task := range pr.Tasks {
+				task := *task
+				copied.Tasks = append(copied.Tasks, &task)
+			}
+			prs = append(prs, copied)
+		}
+	}
+	return prs
+}
+
+// DeployKeys returns the deploy keys of a repository
+func (s *Server) DeployKeys(repoSlug string) []DeployKey {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var keys []DeployKey
+	if repo := s.repo(repoSlug); repo != nil {
+		for _, key := range repo.deployKeys {
+			keys = append(keys, *key)
+		}
+	}
+	return keys
+}
+
+// The helpers below expect s.mu to be held
+
+// newUUID returns a unique UUID in the braced form Bitbucket uses
+func (s *Server) newUUID() string {
+	id := s.nextUUID
+	s.nextUUID++
+	return fmt.Sprintf("{00000000-0000-4000-8000-%012d}", id+1000)
+}
+
+func (s *Server) project(key string) *Project {
+	for _, project := range s.projects {
+		if strings.EqualFold(project.Key, key) {
+			return project
+		}
+	}
+	return nil
+}
+
+func (s *Server) repo(slug string) *repository {
+	for _, repo := range s.repos {
+		if repo.Slug == slug {
+			return repo
+		}
+	}
+	return nil
+}
+
+// ensureRepo returns the repository, adding it if it does not exist yet, so
+// that tests can seed data without adding every repository first
+func (s *Server) ensureRepo(slug string) *repository {
+	if repo := s.repo(slug); repo != nil {
+		return repo
+	}
+	return s.addRepository(Repository{Slug: slug})
+}
+
+func (s *Server) addRepository(repo Repository) *repository {
+	if repo.Name == "" {
+		repo.Name = repo.Slug
+	}
+	if repo.MainBranch == "" {
+		repo.MainBranch = "main"
+	}
+	if repo.CreatedOn.IsZero() {
+		repo.CreatedOn = time.Now().UTC()
+	}
+	if repo.UpdatedOn.IsZero() {
+		repo.UpdatedOn = repo.CreatedOn
+	}
+
+	stored := &repository{Repository: repo, permissions: map[string]string{}}
+	s.repos = append(s.repos, stored)
+	return stored
+}
+
+func (s *Server) addPipeline(repo *repository, pipeline Pipeline) *Pipeline {
+	if pipeline.UUID == "" {
+		pipeline.UUID = s.newUUID()
+	}
+	if pipeline.BuildNumber == 0 {
+		pipeline.BuildNumber = 1
+		for _, existing := range repo.pipelines {
+			pipeline.BuildNumber = max(pipeline.BuildNumber, existing.BuildNumber+1)
+		}
+	}
+	if pipeline.State == "" {
+		pipeline.State = "PENDING"
+		if pipeline.Result != "" {
+			pipeline.State = "COMPLETED"
+		}
+	}
+	if pipeline.Trigger == "" {
+		pipeline.Trigger = "PUSH"
+	}
+	if pipeline.Creator == nil {
+		creator := s.user
+		pipeline.Creator = &creator
+	}
+	if pipeline.CreatedOn.IsZero() {
+		pipeline.CreatedOn = time.Now().UTC()
+	}
+
+	steps := make([]*Step, 0, len(pipeline.Steps))
+	for _, step := range pipeline.Steps {
+		step := *step
+		if step.UUID == "" {
+			step.UUID = s.newUUID()
+		}
+		steps = append(steps, &step)
+	}
+	pipeline.Steps = steps
+
+	repo.pipelines = append(repo.pipelines, &pipeline)
+	return &pipeline
+}
+
+func (s *Server) newVariable(variable Variable) *Variable {
+	if variable.UUID == "" {
+		variable.UUID = s.newUUID()
+	}
+	return &variable
+}
+
+func (s *Server) addEnvironment(repo *repository, env Environment) *Environment {
+	if env.UUID == "" {
+		env.UUID = s.newUUID()
+	}
+	if env.Type == "" {
+		env.Type = "Test"
+	}
+
+	variables := make([]*Variable, 0, len(env.Variables))
+	for _, variable := range env.Variables {
+		variables = append(variables, s.newVariable(*variable))
+	}
+	env.Variables = variables
+
+	repo.environments = append(repo.environments, &env)
+	return &env
+}
+
+func findEnvironment(repo *repository, nameOrUUID string) *Environment {
+	for _, env := range repo.environments {
+		if env.UUID == nameOrUUID || strings.EqualFold(env.Name, nameOrUUID) {
+			return env
+		}
+	}
+	return nil
+}
+
+func (s *Server) addPullRequest(repo *repository, pr PullRequest) *PullRequest {
+	if pr.ID == 0 {
+		pr.ID = 1
+		for _, existing := range repo.pullRequests {
+			pr.ID = max(pr.ID, existing.ID+1)
+		}
+	}
+	if pr.State == "" {
+		pr.State = "OPEN"
+	}
+	if pr.DestinationBranch == "" {
+		pr.DestinationBranch = repo.MainBranch
+	}
+	if pr.Author == nil {
+		author := s.user
+		pr.Author = &author
+	}
+	if pr.CreatedOn.IsZero() {
+		pr.CreatedOn = time.Now().UTC()
+	}
+	if pr.UpdatedOn.IsZero() {
+		pr.UpdatedOn = pr.CreatedOn
+	}
+	if pr.SourceCommit == "" {
+		sum := sha1.Sum([]byte(fmt.Sprintf("%s/%s#%d", repo.Slug, pr.SourceBranch, pr.ID)))
+		pr.SourceCommit = hex.EncodeToString(sum[:])
+	}
+	if len(pr.Commits) == 0 {
+		pr.Commits = []*Commit{{Hash: pr.SourceCommit, Message: pr.Title, Author: pr.Author, Date: pr.CreatedOn}}
+	}
+	for _, comment := range pr.Comments {
+		s.fillComment(&pr, comment)
+	}
+	for _, task := range pr.Tasks {
+		s.fillTask(&pr, task)
+	}
+
+	repo.pullRequests = append(repo.pullRequests, &pr)
+	return &pr
+}
+
+// fillComment fills in the ID, author and creation time of a comment
+func (s *Server) fillComment(pr *PullRequest, comment *Comment) {
+	if comment.ID == 0 {
+		comment.ID = 1
+		for _, existing := range pr.Comments {
+			if existing != comment {
+				comment.ID = max(comment.ID, existing.ID+1)
+			}
+		}
+	}
+	if comment.Author == nil {
+		author := s.user
+		comment.Author = &author
+	}
+	if comment.CreatedOn.IsZero() {
+		comment.CreatedOn = time.Now().UTC()
+	}
+}
+
+// fillTask fills in the ID, creator and creation time of a task
+func (s *Server) fillTask(pr *PullRequest, task *Task) {
+	if task.ID == 0 {
+		task.ID = 1
+		for _, existing := range pr.Tasks {
+			if existing != task {
+				task.ID = max(task.ID, existing.ID+1)
+			}
+		}
+	}
+	if task.Creator == nil {
+		creator := s.user
+		task.Creator = &creator
+	}
+	if task.CreatedOn.IsZero() {
+		task.CreatedOn = time.Now().UTC()
+	}
+}
+
+func (s *Server) addDeployKey(repo *repository, key DeployKey) *DeployKey {
+	if key.ID == 0 {
+		key.ID = 1
+		for _, existing := range repo.deployKeys {
+			key.ID = max(key.ID, existing.ID+1)
+		}
+	}
+	if key.Comment == "" {
+		// Bitbucket takes the comment from the key itself: "ssh-ed25519 AAAA... comment"
+		if fields := strings.Fields(key.Key); len(fields) > 2 {
+			key.Comment = strings.Join(fields[2:], " ")
+		}
+	}
+	if key.AddedOn.IsZero() {
+		key.AddedOn = time.Now().UTC()
+	}
+
+	repo.deployKeys = append(repo.deployKeys, &key)
+	return &key
+}
+
+// copyPipeline returns a copy of the pipeline that does not share its steps
+func copyPipeline(pipeline *Pipeline) Pipeline {
+	copied := *pipeline
+	copied.Steps = make([]*Step, 0, len(pipeline.Steps))
+	for _, step := range pipeline.Steps {
+		step := *step
+		copied.Steps = append(copied.Steps, &step)
+	}
+	return copied
+}
+
+// copyEnvironment returns a copy of the environment that does not share its variables
+func copyEnvironment(env *Environment) Environment {
+	copied := *env
+	copied.Variables = make([]*Variable, 0, len(env.Variables))
+	for _, variable := range env.Variables {
+		variable := *variable
+		copied.Variables = append(copied.Variables, &variable)
+	}
+	return copied
+}
+
+func copyVariables(variables []*Variable) []Variable {
+	copied := make([]Variable, 0, len(variables))
+	for _, variable := range variables {
+		copied = append(copied, *variable)
+	}
+	return copied
+}
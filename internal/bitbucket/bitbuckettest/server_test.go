@@ -0,0 +1,119 @@
+package bitbuckettest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func get(t *testing.T, s *Server, url string, v interface{}) int {
+	t.Helper()
+
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.SetBasicAuth("tester", "secret")
+
+	resp, err := s.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if v != nil {
+		if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+			t.Fatalf("GET %s: invalid JSON: %v", url, err)
+		}
+	}
+	return resp.StatusCode
+}
+
+func TestPagination(t *testing.T) {
+	s := New("workspace")
+	defer s.Close()
+
+	for i := 1; i <= 5; i++ {
+		s.AddRepository(Repository{Slug: fmt.Sprintf("repo%d", i)})
+	}
+
+	var slugs []string
+	url := s.APIURL() + "/repositories/workspace?pagelen=2"
+	for pages := 0; url != ""; pages++ {
+		if pages > 3 {
+			t.Fatal("pagination does not end")
+		}
+
+		var page struct {
+			Values []struct {
+				Slug string `json:"slug"`
+			} `json:"values"`
+			Next string `json:"next"`
+		}
+		if status := get(t, s, url, &page); status != http.StatusOK {
+			t.Fatalf("GET %s: status %d", url, status)
+		}
+		for _, repo := range page.Values {
+			slugs = append(slugs, repo.Slug)
+		}
+		url = page.Next
+	}
+
+	if got := strings.Join(slugs, ","); got != "repo1,repo2,repo3,repo4,repo5" {
+		t.Errorf("slugs = %s", got)
+	}
+}
+
+func TestRequestsNeedAuthAndKnownWorkspace(t *testing.T) {
+	s := New("workspace")
+	defer s.Close()
+
+	resp, err := s.Client().Get(s.APIURL() + "/user")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("unauthenticated request: status %d, want 401", resp.StatusCode)
+	}
+
+	var apiErr struct {
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if status := get(t, s, s.APIURL()+"/repositories/other/repo", &apiErr); status != http.StatusNotFound {
+		t.Errorf("other workspace: status %d, want 404", status)
+	}
+	if apiErr.Error.Message == "" {
+		t.Error("error response has no message")
+	}
+
+	if got := s.Requests(); len(got) != 2 || got[1] != "GET /2.0/repositories/other/repo" {
+		t.Errorf("Requests() = %q", got)
+	}
+}
+
+func TestSecuredVariablesHideValues(t *testing.T) {
+	s := New("workspace")
+	defer s.Close()
+
+	s.AddVariable("repo", Variable{Key: "TOKEN", Value: "secret", Secured: true})
+
+	var page struct {
+		Values []map[string]interface{} `json:"values"`
+	}
+	get(t, s, s.APIURL()+"/repositories/workspace/repo/pipelines_config/variables/", &page)
+
+	if len(page.Values) != 1 {
+		t.Fatalf("got %d variables, want 1", len(page.Values))
+	}
+	if _, ok := page.Values[0]["value"]; ok {
+		t.Errorf("secured variable has a value: %v", page.Values[0])
+	}
+	if got := s.Variables("repo"); len(got) != 1 || got[0].Value != "secret" {
+		t.Errorf("Variables() = %+v, want the stored value", got)
+	}
+}
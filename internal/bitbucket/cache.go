@@ -0,0 +1,180 @@
+package bitbucket
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"bitbucket.org/cover42/eiscli/internal/config"
+)
+
+// responseCache keeps the pages of slow, read-only listing endpoints on disk.
+// Entries younger than the TTL are used without a request; older ones are
+// revalidated with their ETag, so an unchanged listing costs a 304 per page.
+//
+// Entries are grouped per listing (e.g. all pages of the repositories of a
+// workspace) so that a mutating request can drop the whole listing at once.
+type responseCache struct {
+	dir       string
+	ttl       time.Duration
+	workspace string
+
+	// refresh skips reading entries (--no-cache) but still stores fresh ones
+	refresh bool
+
+	now func() time.Time
+}
+
+// cacheEntry is one cached response page
+type cacheEntry struct {
+	URL      string          `json:"url"`
+	ETag     string          `json:"etag,omitempty"`
+	StoredAt time.Time       `json:"stored_at"`
+	Body     json.RawMessage `json:"body"`
+}
+
+// Cached listings, named after their cache subdirectory
+const (
+	cacheGroupRepositories = "repositories"
+	cacheGroupProjects     = "projects"
+)
+
+// EnableCache caches the workspace repository and project listings in dir.
+// With refresh set, cached entries are ignored but replaced by the fresh
+// responses.
+func (c *RestClient) EnableCache(dir string, ttl time.Duration, refresh bool) {
+	c.cache = &responseCache{
+		dir:       dir,
+		ttl:       ttl,
+		workspace: c.workspace,
+		refresh:   refresh,
+		now:       time.Now,
+	}
+}
+
+// enableCache turns on the response cache as configured by cache.ttl and --no-cache
+func enableCache(c *RestClient, cfg config.CacheConfig) {
+	if cfg.TTL <= 0 {
+		return
+	}
+
+	dir, err := config.GetCacheDir()
+	if err != nil {
+		// Without a home directory there is nowhere to cache, which is fine
+		return
+	}
+	c.EnableCache(dir, cfg.TTL, cfg.Disabled)
+}
+
+// ClearCache removes all cached API responses
+func ClearCache() error {
+	dir, err := config.GetCacheDir()
+	if err != nil {
+		return err
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("failed to clear cache: %w", err)
+	}
+	return nil
+}
+
+// group returns the listing a GET request path belongs to, or an empty string
+// if its responses are not cached
+func (rc *responseCache) group(path string) string {
+	switch {
+	case strings.HasPrefix(path, fmt.Sprintf("/repositories/%s?", rc.workspace)):
+		return cacheGroupRepositories
+	case strings.HasPrefix(path, fmt.Sprintf("/workspaces/%s/projects?", rc.workspace)):
+		return cacheGroupProjects
+	}
+	return ""
+}
+
+// affectedGroups returns the listings a successful mutating request changes:
+// creating, updating or deleting a repository or a project
+func (rc *responseCache) affectedGroups(path string) []string {
+	path, _, _ = strings.Cut(path, "?")
+	path = strings.TrimSuffix(path, "/")
+
+	if rest, found := strings.CutPrefix(path, fmt.Sprintf("/repositories/%s/", rc.workspace)); found && !strings.Contains(rest, "/") {
+		return []string{cacheGroupRepositories}
+	}
+	if strings.HasPrefix(path, fmt.Sprintf("/workspaces/%s/projects", rc.workspace)) {
+		return []string{cacheGroupProjects}
+	}
+	return nil
+}
+
+// entryPath returns the file of the cached response of url in a listing
+func (rc *responseCache) entryPath(group, url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return filepath.Join(rc.dir, rc.workspace, group, hex.EncodeToString(sum[:])+".json")
+}
+
+// load returns the cached response of url, or nil if there is none.
+// Unreadable entries are treated as missing.
+func (rc *responseCache) load(group, url string) *cacheEntry {
+	if rc.refresh {
+		return nil
+	}
+
+	data, err := os.ReadFile(rc.entryPath(group, url))
+	if err != nil {
+		return nil
+	}
+
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil || entry.URL != url {
+		return nil
+	}
+	return &entry
+}
+
+// fresh reports whether an entry can be used without revalidating it
+func (rc *responseCache) fresh(entry *cacheEntry) bool {
+	return rc.now().Sub(entry.StoredAt) < rc.ttl
+}
+
+// store saves a response. Failing to write the cache never fails a command,
+// so errors are ignored.
+func (rc *responseCache) store(group, url string, header http.Header, body []byte) {
+	entry := cacheEntry{
+		URL:      url,
+		ETag:     header.Get("ETag"),
+		StoredAt: rc.now(),
+		Body:     body,
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+
+	path := rc.entryPath(group, url)
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return
+	}
+
+	// Write atomically, so concurrent commands never read a partial entry
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".entry-*")
+	if err != nil {
+		return
+	}
+	_, writeErr := tmp.Write(data)
+	closeErr := tmp.Close()
+	if writeErr != nil || closeErr != nil || os.Rename(tmp.Name(), path) != nil {
+		_ = os.Remove(tmp.Name())
+	}
+}
+
+// invalidate drops the listings a successful mutating request has changed
+func (rc *responseCache) invalidate(path string) {
+	for _, group := range rc.affectedGroups(path) {
+		_ = os.RemoveAll(filepath.Join(rc.dir, rc.workspace, group))
+	}
+}
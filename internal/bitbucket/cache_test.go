@@ -0,0 +1,137 @@
+package bitbucket
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"bitbucket.org/cover42/eiscli/internal/bitbucket/bitbuckettest"
+)
+
+// newCachedTestClient returns a client of a fake Bitbucket with 15
+// repositories, caching in a temporary directory with the given clock
+func newCachedTestClient(t *testing.T, now *time.Time, refresh bool) (*RestClient, *bitbuckettest.Server) {
+	t.Helper()
+
+	server := bitbuckettest.New("workspace")
+	t.Cleanup(server.Close)
+	for i := 1; i <= 15; i++ {
+		server.AddRepository(bitbuckettest.Repository{Slug: fmt.Sprintf("service%02d", i)})
+	}
+
+	client := NewRestClient("user", "password", "workspace")
+	client.SetBaseURL(server.APIURL())
+	client.EnableCache(t.TempDir(), 10*time.Minute, refresh)
+	client.cache.now = func() time.Time { return *now }
+	return client, server
+}
+
+// listRepositories lists the repositories in pages of 10 and returns their
+// slugs and the requests it sent
+func listRepositories(t *testing.T, client *RestClient, server *bitbuckettest.Server) ([]string, []string) {
+	t.Helper()
+
+	before := len(server.Requests())
+	var slugs []string
+	err := paginate(context.Background(), client, "/repositories/workspace?pagelen=10", func(data *apiRepository) bool {
+		slugs = append(slugs, data.Slug)
+		return true
+	})
+	if err != nil {
+		t.Fatalf("listing repositories failed: %v", err)
+	}
+	return slugs, server.Requests()[before:]
+}
+
+func TestResponseCache(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	client, server := newCachedTestClient(t, &now, false)
+
+	want, requests := listRepositories(t, client, server)
+	if len(want) != 15 || len(requests) != 2 {
+		t.Fatalf("first listing returned %d repositories with %d requests, want 15 with 2", len(want), len(requests))
+	}
+
+	// Fresh entries are used without asking Bitbucket
+	now = now.Add(5 * time.Minute)
+	slugs, requests := listRepositories(t, client, server)
+	if !reflect.DeepEqual(slugs, want) || len(requests) != 0 {
+		t.Errorf("cached listing returned %v with requests %v", slugs, requests)
+	}
+
+	// Expired entries are revalidated: unchanged pages come back as 304
+	now = now.Add(10 * time.Minute)
+	slugs, requests = listRepositories(t, client, server)
+	if !reflect.DeepEqual(slugs, want) || len(requests) != 2 {
+		t.Errorf("revalidated listing returned %v with requests %v", slugs, requests)
+	}
+	slugs, requests = listRepositories(t, client, server)
+	if !reflect.DeepEqual(slugs, want) || len(requests) != 0 {
+		t.Errorf("revalidation did not renew the entries: %v with requests %v", slugs, requests)
+	}
+
+	// Creating a repository drops the listing
+	if _, err := client.CreateRepository(context.Background(), "service16", "", true); err != nil {
+		t.Fatalf("CreateRepository() error = %v", err)
+	}
+	slugs, requests = listRepositories(t, client, server)
+	if len(slugs) != 16 || len(requests) != 2 {
+		t.Errorf("listing after creating a repository returned %d repositories with requests %v", len(slugs), requests)
+	}
+
+	// Other changes keep it
+	if err := client.CreateRepositoryVariable(context.Background(), "service16", "KEY", "value", false); err != nil {
+		t.Fatalf("CreateRepositoryVariable() error = %v", err)
+	}
+	if _, requests = listRepositories(t, client, server); len(requests) != 0 {
+		t.Errorf("creating a variable dropped the listing: requests %v", requests)
+	}
+}
+
+func TestResponseCacheRefresh(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	client, server := newCachedTestClient(t, &now, true)
+
+	listRepositories(t, client, server)
+	if _, requests := listRepositories(t, client, server); len(requests) != 2 {
+		t.Errorf("--no-cache listing sent requests %v, want 2", requests)
+	}
+
+	// The fresh responses are still stored for later commands
+	client.cache.refresh = false
+	if _, requests := listRepositories(t, client, server); len(requests) != 0 {
+		t.Errorf("listing after --no-cache sent requests %v, want none", requests)
+	}
+}
+
+func TestResponseCacheGroups(t *testing.T) {
+	cache := &responseCache{workspace: "ws"}
+
+	groups := map[string]string{
+		"/repositories/ws?pagelen=100":                           cacheGroupRepositories,
+		"/workspaces/ws/projects?pagelen=100":                    cacheGroupProjects,
+		"/repositories/ws/repo/pipelines?pagelen=10":             "",
+		"/repositories/other?pagelen=100":                        "",
+		"/repositories/ws/repo/pipelines_config/variables/?page": "",
+	}
+	for path, want := range groups {
+		if got := cache.group(path); got != want {
+			t.Errorf("group(%q) = %q, want %q", path, got, want)
+		}
+	}
+
+	affected := map[string][]string{
+		"/repositories/ws/repo":                             {cacheGroupRepositories},
+		"/repositories/ws/repo/":                            {cacheGroupRepositories},
+		"/workspaces/ws/projects/":                          {cacheGroupProjects},
+		"/repositories/ws/repo/pipelines_config/variables/": nil,
+		"/repositories/ws/repo/permissions-config/groups/x": nil,
+	}
+	for path, want := range affected {
+		if got := cache.affectedGroups(path); !reflect.DeepEqual(got, want) {
+			t.Errorf("affectedGroups(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
@@ -1,7 +1,10 @@
 package bitbucket
 
 import (
+	"context"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"bitbucket.org/cover42/eiscli/internal/config"
@@ -38,6 +41,13 @@ func NewClient(cfg *config.Config) (*Client, error) {
 
 		// Create REST client with OAuth
 		restClient := NewRestClientWithOAuth(cfg.Bitbucket.Workspace, tokenStore, oauthClient)
+		restClient.SetMaxRetries(cfg.Bitbucket.MaxRetries)
+		restClient.SetBaseURL(cfg.Bitbucket.APIURL)
+		restClient.SetWebURL(cfg.Bitbucket.WebURL)
+		if err := enableDebug(restClient, cfg.Debug); err != nil {
+			return nil, err
+		}
+		enableCache(restClient, cfg.Cache)
 
 		// Note: go-bitbucket library doesn't support OAuth yet, so we'll use nil
 		// All API calls go through RestClient anyway
@@ -54,6 +64,13 @@ func NewClient(cfg *config.Config) (*Client, error) {
 	// Use Basic Auth (legacy)
 	client := bitbucket.NewBasicAuth(cfg.Bitbucket.Username, cfg.Bitbucket.AppPassword)
 	restClient := NewRestClient(cfg.Bitbucket.Username, cfg.Bitbucket.AppPassword, cfg.Bitbucket.Workspace)
+	restClient.SetMaxRetries(cfg.Bitbucket.MaxRetries)
+	restClient.SetBaseURL(cfg.Bitbucket.APIURL)
+	restClient.SetWebURL(cfg.Bitbucket.WebURL)
+	if err := enableDebug(restClient, cfg.Debug); err != nil {
+		return nil, err
+	}
+	enableCache(restClient, cfg.Cache)
 
 	return &Client{
 		client:     client,
@@ -75,77 +92,132 @@ func (c *Client) GetTokenStore() *TokenStore {
 
 // Pipeline represents a Bitbucket pipeline with relevant information
 type Pipeline struct {
-	UUID          string
-	BuildNumber   int
-	State         PipelineState
-	CreatedOn     time.Time
-	CompletedOn   *time.Time
-	DurationSec   int
-	Target        PipelineTarget
-	Trigger       PipelineTrigger
-	Creator       string
-	BuildSecsUsed int
-	Repository    string // full_name like "cover42/authservicev2"
-	WebURL        string // Bitbucket web URL
-	Steps         []*PipelineStep
+	UUID          string          `json:"uuid" yaml:"uuid"`
+	BuildNumber   int             `json:"build_number" yaml:"build_number"`
+	State         PipelineState   `json:"state" yaml:"state"`
+	CreatedOn     time.Time       `json:"created_on" yaml:"created_on"`
+	CompletedOn   *time.Time      `json:"completed_on,omitempty" yaml:"completed_on,omitempty"`
+	DurationSec   int             `json:"duration_seconds" yaml:"duration_seconds"`
+	Target        PipelineTarget  `json:"target" yaml:"target"`
+	Trigger       PipelineTrigger `json:"trigger" yaml:"trigger"`
+	Creator       string          `json:"creator" yaml:"creator"`
+	BuildSecsUsed int             `json:"build_seconds_used" yaml:"build_seconds_used"`
+	Repository    string          `json:"repository" yaml:"repository"` // full_name like "cover42/authservicev2"
+	WebURL        string          `json:"web_url" yaml:"web_url"`       // Bitbucket web URL
+	Steps         []*PipelineStep `json:"steps,omitempty" yaml:"steps,omitempty"`
 }
 
 // PipelineState represents the state of a pipeline
 type PipelineState struct {
-	Name   string // PENDING, IN_PROGRESS, SUCCESSFUL, FAILED, STOPPED, ERROR, PAUSED
-	Result *PipelineResult
+	Name   string          `json:"name" yaml:"name"` // PENDING, IN_PROGRESS, SUCCESSFUL, FAILED, STOPPED, ERROR, PAUSED
+	Result *PipelineResult `json:"result,omitempty" yaml:"result,omitempty"`
 }
 
 // PipelineResult represents the result of a pipeline
 type PipelineResult struct {
-	Name string // SUCCESSFUL, FAILED, ERROR, STOPPED
+	Name string `json:"name" yaml:"name"` // SUCCESSFUL, FAILED, ERROR, STOPPED
 }
 
-// PipelineTarget represents what the pipeline built
+// PipelineTarget represents what the pipeline built. Pull request pipelines
+// have no ref; they have the source and destination branch and the pull
+// request instead.
 type PipelineTarget struct {
-	RefType  string // branch, tag, etc
-	RefName  string
-	Commit   *Commit
-	Selector *PipelineSelector
-}
-
-// Commit represents a git commit
+	Type        string               `json:"type,omitempty" yaml:"type,omitempty"` // pipeline_ref_target, pipeline_commit_target, pipeline_pullrequest_target
+	RefType     string               `json:"ref_type" yaml:"ref_type"`             // branch, tag, etc
+	RefName     string               `json:"ref_name" yaml:"ref_name"`
+	Source      string               `json:"source,omitempty" yaml:"source,omitempty"` // Source branch of a pull request
+	Destination string               `json:"destination,omitempty" yaml:"destination,omitempty"`
+	PullRequest *PipelinePullRequest `json:"pullrequest,omitempty" yaml:"pullrequest,omitempty"`
+	Commit      *Commit              `json:"commit,omitempty" yaml:"commit,omitempty"`
+	Selector    *PipelineSelector    `json:"selector,omitempty" yaml:"selector,omitempty"`
+}
+
+// PipelinePullRequest is the pull request a pipeline ran for
+type PipelinePullRequest struct {
+	ID    int    `json:"id" yaml:"id"`
+	Title string `json:"title,omitempty" yaml:"title,omitempty"`
+}
+
+// Ref returns a readable name of what the pipeline built: the branch or tag,
+// the source branch and ID of a pull request, or the short commit hash
+func (t *PipelineTarget) Ref() string {
+	switch {
+	case t.PullRequest != nil && t.Source != "":
+		return fmt.Sprintf("%s (PR #%d)", t.Source, t.PullRequest.ID)
+	case t.PullRequest != nil:
+		return fmt.Sprintf("PR #%d", t.PullRequest.ID)
+	case t.RefName != "":
+		return t.RefName
+	case t.Commit != nil && len(t.Commit.Hash) > 7:
+		return t.Commit.Hash[:7]
+	case t.Commit != nil:
+		return t.Commit.Hash
+	default:
+		return t.RefType
+	}
+}
+
+// Commit represents a git commit. Author and date are only set when the API
+// returns them, e.g. for the commits of a pull request.
 type Commit struct {
-	Hash    string
-	Message string
+	Hash    string     `json:"hash" yaml:"hash"`
+	Message string     `json:"message" yaml:"message"`
+	Author  string     `json:"author,omitempty" yaml:"author,omitempty"`
+	Date    *time.Time `json:"date,omitempty" yaml:"date,omitempty"`
 }
 
 // PipelineSelector represents the pipeline selector
 type PipelineSelector struct {
-	Type    string // branches, tags, custom, default
-	Pattern string
+	Type    string `json:"type" yaml:"type"` // branches, tags, custom, default
+	Pattern string `json:"pattern" yaml:"pattern"`
 }
 
 // PipelineTrigger represents what triggered the pipeline
 type PipelineTrigger struct {
-	Type string // push, manual, schedule
+	Type string `json:"type" yaml:"type"` // push, manual, schedule
 }
 
 // PipelineStep represents a step in the pipeline
 type PipelineStep struct {
-	UUID        string
-	Name        string
-	State       string // COMPLETED, FAILED, etc
-	Result      string // SUCCESSFUL, FAILED, etc
-	DurationSec int
-	LogSnippet  string // Last few lines of log
+	UUID        string                 `json:"uuid" yaml:"uuid"`
+	Name        string                 `json:"name" yaml:"name"`
+	State       string                 `json:"state" yaml:"state"`   // COMPLETED, FAILED, etc
+	Result      string                 `json:"result" yaml:"result"` // SUCCESSFUL, FAILED, etc
+	DurationSec int                    `json:"duration_seconds" yaml:"duration_seconds"`
+	LogSnippet  string                 `json:"log_snippet,omitempty" yaml:"log_snippet,omitempty"` // Last few lines of log
+	Services    []*PipelineStepService `json:"services,omitempty" yaml:"services,omitempty"`       // Service containers (docker, databases, ...)
+}
+
+// PipelineStepService represents a service container that ran alongside a step
+type PipelineStepService struct {
+	UUID string `json:"uuid" yaml:"uuid"`
+	Name string `json:"name" yaml:"name"`
 }
 
 // PipelinesOptions holds options for listing pipelines
 type PipelinesOptions struct {
-	RepoSlug string
-	Limit    int
-	Sort     string
-	Status   string
+	RepoSlug    string
+	Limit       int
+	Sort        string    // Sort order (default: -created_on)
+	Status      string    // Pipeline status, e.g. FAILED or IN_PROGRESS
+	Branch      string    // Only pipelines that ran for this branch
+	Trigger     string    // Trigger type, e.g. PUSH, MANUAL or SCHEDULE
+	CreatorUUID string    // Only pipelines started by this user
+	Since       time.Time // Only pipelines created after this time
+}
+
+// TriggerPipelineOptions holds options for triggering a pipeline
+type TriggerPipelineOptions struct {
+	RepoSlug  string
+	Branch    string            // Branch to build
+	Tag       string            // Tag to build (used when Branch is empty)
+	Commit    string            // Commit hash to build (optional when Branch is set)
+	Custom    string            // Name of a custom pipeline from bitbucket-pipelines.yml
+	Variables map[string]string // Variables for custom pipelines
 }
 
 // ListPipelines retrieves the last N pipelines for a repository
-func (c *Client) ListPipelines(opts *PipelinesOptions) ([]*Pipeline, error) {
+func (c *Client) ListPipelines(ctx context.Context, opts *PipelinesOptions) ([]*Pipeline, error) {
 	if opts.RepoSlug == "" {
 		return nil, fmt.Errorf("repository slug is required")
 	}
@@ -155,12 +227,15 @@ func (c *Client) ListPipelines(opts *PipelinesOptions) ([]*Pipeline, error) {
 		limit = 10 // Default limit
 	}
 
+	listOpts := *opts
+	listOpts.Limit = limit
+
 	// Use the REST client for better reliability
-	return c.restClient.ListPipelines(opts.RepoSlug, limit)
+	return c.restClient.ListPipelines(ctx, &listOpts)
 }
 
 // ListPipelinesWithSteps retrieves pipelines with their steps and log snippets
-func (c *Client) ListPipelinesWithSteps(opts *PipelinesOptions, logLines int) ([]*Pipeline, error) {
+func (c *Client) ListPipelinesWithSteps(ctx context.Context, opts *PipelinesOptions, logLines int) ([]*Pipeline, error) {
 	if opts.RepoSlug == "" {
 		return nil, fmt.Errorf("repository slug is required")
 	}
@@ -174,195 +249,242 @@ func (c *Client) ListPipelinesWithSteps(opts *PipelinesOptions, logLines int) ([
 		logLines = 25 // Default log lines
 	}
 
+	listOpts := *opts
+	listOpts.Limit = limit
+
 	// Use the REST client for better reliability
-	return c.restClient.ListPipelinesWithSteps(opts.RepoSlug, limit, logLines)
+	return c.restClient.ListPipelinesWithSteps(ctx, &listOpts, logLines)
 }
 
-// parsePipeline converts the API response to our Pipeline struct
-func parsePipeline(data map[string]interface{}) (*Pipeline, error) {
-	pipeline := &Pipeline{}
+// TriggerPipeline starts a new pipeline run
+func (c *Client) TriggerPipeline(ctx context.Context, opts *TriggerPipelineOptions) (*Pipeline, error) {
+	if opts.RepoSlug == "" {
+		return nil, fmt.Errorf("repository slug is required")
+	}
+	if opts.Branch == "" && opts.Tag == "" && opts.Commit == "" {
+		return nil, fmt.Errorf("branch, tag or commit is required")
+	}
+	if len(opts.Variables) > 0 && opts.Custom == "" {
+		return nil, fmt.Errorf("variables can only be passed to custom pipelines")
+	}
 
-	// UUID
-	if uuid, ok := data["uuid"].(string); ok {
-		pipeline.UUID = uuid
+	pipeline, err := c.restClient.TriggerPipeline(ctx, opts)
+	if err != nil {
+		return nil, err
 	}
 
-	// Build number
-	if buildNum, ok := data["build_number"].(float64); ok {
-		pipeline.BuildNumber = int(buildNum)
+	// The trigger response does not always include the repository
+	if pipeline.WebURL == "" && pipeline.BuildNumber > 0 {
+		pipeline.WebURL = BuildPipelineResultURL(c.restClient.webBaseURL(), c.workspace, opts.RepoSlug, pipeline.BuildNumber)
 	}
 
-	// Build seconds used
-	if buildSecs, ok := data["build_seconds_used"].(float64); ok {
-		pipeline.BuildSecsUsed = int(buildSecs)
+	return pipeline, nil
+}
+
+// GetPipeline retrieves a specific pipeline by UUID
+func (c *Client) GetPipeline(ctx context.Context, repoSlug, pipelineUUID string) (*Pipeline, error) {
+	if repoSlug == "" || pipelineUUID == "" {
+		return nil, fmt.Errorf("repository slug and pipeline UUID are required")
 	}
 
-	// State
-	if stateData, ok := data["state"].(map[string]interface{}); ok {
-		if name, ok := stateData["name"].(string); ok {
-			pipeline.State.Name = name
-		}
-		if resultData, ok := stateData["result"].(map[string]interface{}); ok {
-			result := &PipelineResult{}
-			if name, ok := resultData["name"].(string); ok {
-				result.Name = name
-			}
-			pipeline.State.Result = result
-		}
+	return c.restClient.GetPipeline(ctx, repoSlug, pipelineUUID)
+}
+
+// GetPipelineByBuildNumber retrieves a pipeline by its build number
+func (c *Client) GetPipelineByBuildNumber(ctx context.Context, repoSlug string, buildNumber int) (*Pipeline, error) {
+	if repoSlug == "" {
+		return nil, fmt.Errorf("repository slug is required")
+	}
+	if buildNumber <= 0 {
+		return nil, fmt.Errorf("invalid build number: %d", buildNumber)
 	}
 
-	// Created on
-	if createdStr, ok := data["created_on"].(string); ok {
-		if t, err := time.Parse(time.RFC3339, createdStr); err == nil {
-			pipeline.CreatedOn = t
-		}
+	// The pipelines endpoint accepts a build number in place of the UUID
+	pipeline, err := c.restClient.GetPipeline(ctx, repoSlug, strconv.Itoa(buildNumber))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pipeline #%d: %w", buildNumber, err)
 	}
 
-	// Completed on
-	if completedStr, ok := data["completed_on"].(string); ok {
-		if t, err := time.Parse(time.RFC3339, completedStr); err == nil {
-			pipeline.CompletedOn = &t
-			// Calculate duration
-			pipeline.DurationSec = int(t.Sub(pipeline.CreatedOn).Seconds())
-		}
+	return pipeline, nil
+}
+
+// GetLatestPipelineForBranch retrieves the most recent pipeline that ran for a branch
+func (c *Client) GetLatestPipelineForBranch(ctx context.Context, repoSlug, branch string) (*Pipeline, error) {
+	if repoSlug == "" || branch == "" {
+		return nil, fmt.Errorf("repository slug and branch are required")
 	}
 
-	// Target
-	if targetData, ok := data["target"].(map[string]interface{}); ok {
-		target := PipelineTarget{}
+	pipelines, err := c.restClient.ListPipelines(ctx, &PipelinesOptions{
+		RepoSlug: repoSlug,
+		Branch:   branch,
+		Limit:    1,
+	})
+	if err != nil {
+		return nil, err
+	}
 
-		if refType, ok := targetData["ref_type"].(string); ok {
-			target.RefType = refType
-		}
-		if refName, ok := targetData["ref_name"].(string); ok {
-			target.RefName = refName
-		}
+	if len(pipelines) == 0 {
+		return nil, fmt.Errorf("no pipelines found for branch %s", branch)
+	}
 
-		// Commit
-		if commitData, ok := targetData["commit"].(map[string]interface{}); ok {
-			commit := &Commit{}
-			if hash, ok := commitData["hash"].(string); ok {
-				commit.Hash = hash
-			}
-			if message, ok := commitData["message"].(string); ok {
-				commit.Message = message
-			}
-			target.Commit = commit
-		}
+	return pipelines[0], nil
+}
 
-		// Selector
-		if selectorData, ok := targetData["selector"].(map[string]interface{}); ok {
-			selector := &PipelineSelector{}
-			if sType, ok := selectorData["type"].(string); ok {
-				selector.Type = sType
-			}
-			if pattern, ok := selectorData["pattern"].(string); ok {
-				selector.Pattern = pattern
-			}
-			target.Selector = selector
-		}
+// StopPipeline stops a running pipeline identified by its build number
+func (c *Client) StopPipeline(ctx context.Context, repoSlug string, buildNumber int) (*Pipeline, error) {
+	pipeline, err := c.GetPipelineByBuildNumber(ctx, repoSlug, buildNumber)
+	if err != nil {
+		return nil, err
+	}
 
-		pipeline.Target = target
+	if pipeline.State.Name == "COMPLETED" {
+		return nil, fmt.Errorf("pipeline #%d has already completed", buildNumber)
 	}
 
-	// Trigger
-	if triggerData, ok := data["trigger"].(map[string]interface{}); ok {
-		trigger := PipelineTrigger{}
-		if tType, ok := triggerData["type"].(string); ok {
-			trigger.Type = tType
-		}
-		pipeline.Trigger = trigger
+	if err := c.restClient.StopPipeline(ctx, repoSlug, pipeline.UUID); err != nil {
+		return nil, err
 	}
 
-	// Creator
-	if creatorData, ok := data["creator"].(map[string]interface{}); ok {
-		if displayName, ok := creatorData["display_name"].(string); ok {
-			pipeline.Creator = displayName
-		} else if username, ok := creatorData["username"].(string); ok {
-			pipeline.Creator = username
-		}
+	return pipeline, nil
+}
+
+// RerunPipeline reruns the pipeline identified by its build number by
+// starting a new pipeline for the same target (branch or tag, commit and
+// custom selector). The Bitbucket API cannot rerun single steps of a pipeline.
+// Pull request pipelines are refused, as triggering their commit would start
+// a branch or commit pipeline instead.
+func (c *Client) RerunPipeline(ctx context.Context, repoSlug string, buildNumber int) (*Pipeline, error) {
+	pipeline, err := c.GetPipelineByBuildNumber(ctx, repoSlug, buildNumber)
+	if err != nil {
+		return nil, err
 	}
 
-	// Repository
-	if repoData, ok := data["repository"].(map[string]interface{}); ok {
-		if fullName, ok := repoData["full_name"].(string); ok {
-			pipeline.Repository = fullName
-			// Construct web URL: https://bitbucket.org/{workspace}/{repo_slug}/pipelines/results/{build_number}
-			pipeline.WebURL = fmt.Sprintf("https://bitbucket.org/%s/pipelines/results/%d",
-				fullName, pipeline.BuildNumber)
+	opts := &TriggerPipelineOptions{
+		RepoSlug: repoSlug,
+	}
+	target := pipeline.Target
+	switch {
+	case target.PullRequest != nil:
+		return nil, fmt.Errorf("pipeline #%d ran for pull request #%d, rerun it from the pull request in Bitbucket", buildNumber, target.PullRequest.ID)
+	case target.RefType == "branch" || target.RefType == "named_branch":
+		opts.Branch = target.RefName
+	case target.RefType == "tag" || target.RefType == "annotated_tag":
+		opts.Tag = target.RefName
+	case target.RefType == "" && target.Commit != nil && target.Type != "pipeline_pullrequest_target":
+		// A pipeline of a commit only
+	default:
+		kind := target.RefType
+		if kind == "" {
+			kind = target.Type
 		}
+		return nil, fmt.Errorf("pipeline #%d has an unsupported target (%s), only branch, tag and commit pipelines can be rerun", buildNumber, kind)
+	}
+	if target.Commit != nil {
+		opts.Commit = target.Commit.Hash
+	}
+	if target.Selector != nil && target.Selector.Type == "custom" {
+		opts.Custom = target.Selector.Pattern
 	}
 
-	return pipeline, nil
+	return c.TriggerPipeline(ctx, opts)
 }
 
-// GetPipeline retrieves a specific pipeline by UUID
-func (c *Client) GetPipeline(repoSlug, pipelineUUID string) (*Pipeline, error) {
+// GetPipelineSteps retrieves the steps of a pipeline
+func (c *Client) GetPipelineSteps(ctx context.Context, repoSlug, pipelineUUID string) ([]*PipelineStep, error) {
 	if repoSlug == "" || pipelineUUID == "" {
 		return nil, fmt.Errorf("repository slug and pipeline UUID are required")
 	}
 
-	opts := &bitbucket.PipelinesOptions{
-		Owner:    c.workspace,
-		RepoSlug: repoSlug,
-		IDOrUuid: pipelineUUID,
-	}
+	return c.restClient.GetPipelineSteps(ctx, repoSlug, pipelineUUID)
+}
 
-	res, err := c.client.Repositories.Pipelines.Get(opts)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get pipeline: %w", err)
+// GetStepLogFrom retrieves new log output of a step starting at the given byte offset
+func (c *Client) GetStepLogFrom(ctx context.Context, repoSlug, pipelineUUID, stepUUID string, offset int64) ([]byte, error) {
+	if repoSlug == "" || pipelineUUID == "" || stepUUID == "" {
+		return nil, fmt.Errorf("repository slug, pipeline UUID and step UUID are required")
 	}
 
-	if pipelineData, ok := res.(map[string]interface{}); ok {
-		return parsePipeline(pipelineData)
+	return c.restClient.GetStepLogFrom(ctx, repoSlug, pipelineUUID, stepUUID, offset)
+}
+
+// GetFullStepLog retrieves the complete log of a step's build container, or of one of
+// its service containers when logUUID is a service UUID
+func (c *Client) GetFullStepLog(ctx context.Context, repoSlug, pipelineUUID, stepUUID, logUUID string) ([]byte, error) {
+	if repoSlug == "" || pipelineUUID == "" || stepUUID == "" {
+		return nil, fmt.Errorf("repository slug, pipeline UUID and step UUID are required")
+	}
+	if logUUID == "" {
+		logUUID = stepUUID
 	}
 
-	return nil, fmt.Errorf("unexpected response format")
+	return c.restClient.GetFullStepLog(ctx, repoSlug, pipelineUUID, stepUUID, logUUID)
 }
 
 // Repository represents a Bitbucket repository
 type Repository struct {
-	Slug        string
-	Name        string
-	Description string
-	FullName    string
+	Slug        string    `json:"slug" yaml:"slug"`
+	Name        string    `json:"name" yaml:"name"`
+	Description string    `json:"description" yaml:"description"`
+	FullName    string    `json:"full_name" yaml:"full_name"`
+	MainBranch  string    `json:"main_branch,omitempty" yaml:"main_branch,omitempty"`
+	Language    string    `json:"language,omitempty" yaml:"language,omitempty"`
+	IsPrivate   bool      `json:"is_private" yaml:"is_private"`
+	ProjectKey  string    `json:"project_key,omitempty" yaml:"project_key,omitempty"`
+	ProjectName string    `json:"project_name,omitempty" yaml:"project_name,omitempty"`
+	CreatedOn   time.Time `json:"created_on" yaml:"created_on"`
+	UpdatedOn   time.Time `json:"updated_on" yaml:"updated_on"`
+	Size        int64     `json:"size" yaml:"size"`
 }
 
 // Project represents a Bitbucket project
 type Project struct {
-	Key         string
-	Name        string
-	Description string
-	UUID        string
+	Key         string `json:"key" yaml:"key"`
+	Name        string `json:"name" yaml:"name"`
+	Description string `json:"description" yaml:"description"`
+	UUID        string `json:"uuid" yaml:"uuid"`
 }
 
 // Variable represents a Bitbucket pipeline or deployment variable
 type Variable struct {
-	Key     string
-	Value   string
-	Secured bool
+	UUID    string `json:"uuid,omitempty" yaml:"uuid,omitempty"`
+	Key     string `json:"key" yaml:"key"`
+	Value   string `json:"value" yaml:"value"` // Always empty for secured variables
+	Secured bool   `json:"secured" yaml:"secured"`
 }
 
 // Environment represents a Bitbucket deployment environment
 type Environment struct {
-	UUID string
-	Name string
-	Type string
+	UUID string `json:"uuid" yaml:"uuid"`
+	Name string `json:"name" yaml:"name"`
+	Type string `json:"type" yaml:"type"`
 }
 
 // PullRequest represents a Bitbucket pull request
 type PullRequest struct {
-	ID                int
-	Title             string
-	Description       string
-	State             string // OPEN, MERGED, DECLINED, SUPERSEDED
-	SourceBranch      string
-	DestinationBranch string
-	Author            string
-	Reviewers         []string // List of reviewer UUIDs/usernames
-	CreatedOn         time.Time
-	UpdatedOn         time.Time
-	WebURL            string
+	ID                int       `json:"id" yaml:"id"`
+	Title             string    `json:"title" yaml:"title"`
+	Description       string    `json:"description" yaml:"description"`
+	State             string    `json:"state" yaml:"state"` // OPEN, MERGED, DECLINED, SUPERSEDED
+	Draft             bool      `json:"draft,omitempty" yaml:"draft,omitempty"`
+	SourceBranch      string    `json:"source_branch" yaml:"source_branch"`
+	DestinationBranch string    `json:"destination_branch" yaml:"destination_branch"`
+	Author            string    `json:"author" yaml:"author"`
+	AuthorUUID        string    `json:"author_uuid,omitempty" yaml:"author_uuid,omitempty"`
+	Reviewers         []string  `json:"reviewers" yaml:"reviewers"` // List of reviewer UUIDs/usernames
+	SourceCommit      string    `json:"source_commit,omitempty" yaml:"source_commit,omitempty"`
+	CommentCount      int       `json:"comment_count" yaml:"comment_count"`
+	TaskCount         int       `json:"task_count" yaml:"task_count"` // Open tasks
+	CreatedOn         time.Time `json:"created_on" yaml:"created_on"`
+	UpdatedOn         time.Time `json:"updated_on" yaml:"updated_on"`
+	WebURL            string    `json:"web_url" yaml:"web_url"`
+
+	// Full names (workspace/slug) of the repositories; they differ for pull requests from forks
+	SourceRepository      string `json:"source_repository,omitempty" yaml:"source_repository,omitempty"`
+	DestinationRepository string `json:"destination_repository,omitempty" yaml:"destination_repository,omitempty"`
+
+	// Participants are only set for a single pull request, see GetPullRequest,
+	// and for ListOpenPullRequests
+	Participants []*PullRequestParticipant `json:"participants,omitempty" yaml:"participants,omitempty"`
 }
 
 // PullRequestOptions holds options for listing pull requests
@@ -373,26 +495,88 @@ type PullRequestOptions struct {
 	AuthorEmail string // Filter by PR author email (used when Author is "@me")
 }
 
+// CreatePullRequestOptions holds the fields of a new pull request
+type CreatePullRequestOptions struct {
+	SourceBranch      string
+	DestinationBranch string
+	Title             string
+	Description       string
+	Draft             bool
+	Reviewers         []string // UUIDs of reviewers in addition to the default reviewers
+}
+
 // CreatePullRequest creates a new pull request
-func (c *Client) CreatePullRequest(repoSlug, sourceBranch, destBranch, title, description string) (*PullRequest, error) {
+func (c *Client) CreatePullRequest(ctx context.Context, repoSlug string, opts *CreatePullRequestOptions) (*PullRequest, error) {
 	if repoSlug == "" {
 		return nil, fmt.Errorf("repository slug is required")
 	}
-	if sourceBranch == "" {
+	if opts == nil || opts.SourceBranch == "" {
 		return nil, fmt.Errorf("source branch is required")
 	}
-	if destBranch == "" {
+	if opts.DestinationBranch == "" {
 		return nil, fmt.Errorf("destination branch is required")
 	}
-	if title == "" {
+	if opts.Title == "" {
 		return nil, fmt.Errorf("title is required")
 	}
 
-	return c.restClient.CreatePullRequest(repoSlug, sourceBranch, destBranch, title, description)
+	return c.restClient.CreatePullRequest(ctx, repoSlug, opts)
+}
+
+// ListWorkspaceMembers retrieves the members of the workspace
+func (c *Client) ListWorkspaceMembers(ctx context.Context) ([]*UserInfo, error) {
+	return c.restClient.ListWorkspaceMembers(ctx)
+}
+
+// FindWorkspaceMembers finds the workspace members with the given UUIDs,
+// usernames or display names. The members are listed once for all names.
+func (c *Client) FindWorkspaceMembers(ctx context.Context, names []string) ([]*UserInfo, error) {
+	members, err := c.restClient.ListWorkspaceMembers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	found := make([]*UserInfo, 0, len(names))
+	for _, name := range names {
+		member, err := findMember(members, name)
+		if err != nil {
+			return nil, fmt.Errorf("workspace %s: %w", c.workspace, err)
+		}
+		found = append(found, member)
+	}
+	return found, nil
+}
+
+// findMember finds the member with the given UUID, username or display name.
+// Names are matched case-insensitively and must be unambiguous.
+func findMember(members []*UserInfo, name string) (*UserInfo, error) {
+	name = strings.TrimPrefix(strings.TrimSpace(name), "@")
+	if name == "" {
+		return nil, fmt.Errorf("user name is required")
+	}
+
+	var matches []*UserInfo
+	for _, member := range members {
+		if member.UUID == name || strings.EqualFold(member.Username, name) {
+			return member, nil
+		}
+		if strings.EqualFold(member.DisplayName, name) {
+			matches = append(matches, member)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("no member matches '%s'", name)
+	case 1:
+		return matches[0], nil
+	default:
+		return nil, fmt.Errorf("'%s' matches %d members, use the username", name, len(matches))
+	}
 }
 
 // ListPullRequests retrieves pull requests for a repository
-func (c *Client) ListPullRequests(repoSlug string, opts *PullRequestOptions) ([]*PullRequest, error) {
+func (c *Client) ListPullRequests(ctx context.Context, repoSlug string, opts *PullRequestOptions) ([]*PullRequest, error) {
 	if repoSlug == "" {
 		return nil, fmt.Errorf("repository slug is required")
 	}
@@ -413,7 +597,7 @@ func (c *Client) ListPullRequests(repoSlug string, opts *PullRequestOptions) ([]
 			author = opts.Author
 			// Resolve "@me" to current user's UUID/username and git email
 			if author == "@me" {
-				userInfo, err := c.restClient.GetCurrentUser()
+				userInfo, err := c.restClient.GetCurrentUser(ctx)
 				if err != nil {
 					return nil, fmt.Errorf("failed to get current user: %w", err)
 				}
@@ -441,169 +625,151 @@ func (c *Client) ListPullRequests(repoSlug string, opts *PullRequestOptions) ([]
 		}
 	}
 
-	return c.restClient.ListPullRequests(repoSlug, state, limit, author, authorEmail)
+	return c.restClient.ListPullRequests(ctx, repoSlug, state, limit, author, authorEmail)
+}
+
+// GetCurrentUser retrieves the authenticated user
+func (c *Client) GetCurrentUser(ctx context.Context) (*UserInfo, error) {
+	return c.restClient.GetCurrentUser(ctx)
+}
+
+// GitCredentials returns the username and password for git over HTTPS
+func (c *Client) GitCredentials() (string, string, error) {
+	return c.restClient.GitCredentials()
 }
 
 // GetDefaultBranch retrieves the default branch for a repository
-func (c *Client) GetDefaultBranch(repoSlug string) (string, error) {
+func (c *Client) GetDefaultBranch(ctx context.Context, repoSlug string) (string, error) {
 	if repoSlug == "" {
 		return "", fmt.Errorf("repository slug is required")
 	}
 
-	return c.restClient.GetRepositoryDefaultBranch(repoSlug)
+	return c.restClient.GetRepositoryDefaultBranch(ctx, repoSlug)
+}
+
+// GetRepository retrieves metadata for a single repository
+func (c *Client) GetRepository(ctx context.Context, repoSlug string) (*Repository, error) {
+	if repoSlug == "" {
+		return nil, fmt.Errorf("repository slug is required")
+	}
+
+	return c.restClient.GetRepository(ctx, repoSlug)
 }
 
 // ListRepositories retrieves all repositories in the workspace
-func (c *Client) ListRepositories() ([]*Repository, error) {
+func (c *Client) ListRepositories(ctx context.Context) ([]*Repository, error) {
 	// Use the REST client for better reliability
-	return c.restClient.ListRepositories()
+	return c.restClient.ListRepositories(ctx)
 }
 
 // ListProjects retrieves all projects in the workspace
-func (c *Client) ListProjects() ([]*Project, error) {
-	rawProjects, err := c.restClient.ListProjects()
+func (c *Client) ListProjects(ctx context.Context) ([]*Project, error) {
+	projects, err := c.restClient.ListProjects(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list projects: %w", err)
 	}
 
-	projects := make([]*Project, 0, len(rawProjects))
-	for _, projectData := range rawProjects {
-		project := &Project{}
-
-		if key, ok := projectData["key"].(string); ok {
-			project.Key = key
-		}
-		if name, ok := projectData["name"].(string); ok {
-			project.Name = name
-		}
-		if desc, ok := projectData["description"].(string); ok {
-			project.Description = desc
-		}
-		if uuid, ok := projectData["uuid"].(string); ok {
-			project.UUID = uuid
-		}
-
-		projects = append(projects, project)
-	}
-
 	return projects, nil
 }
 
 // GetRepositoryVariables retrieves repository-level pipeline variables
-func (c *Client) GetRepositoryVariables(repoSlug string) ([]*Variable, error) {
+func (c *Client) GetRepositoryVariables(ctx context.Context, repoSlug string) ([]*Variable, error) {
 	if repoSlug == "" {
 		return nil, fmt.Errorf("repository slug is required")
 	}
 
-	rawVars, err := c.restClient.ListRepositoryVariables(repoSlug)
+	variables, err := c.restClient.ListRepositoryVariables(ctx, repoSlug)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get repository variables: %w", err)
 	}
 
-	variables := make([]*Variable, 0, len(rawVars))
-	for _, varData := range rawVars {
-		variable := &Variable{}
-
-		if key, ok := varData["key"].(string); ok {
-			variable.Key = key
-		}
-		if value, ok := varData["value"].(string); ok {
-			variable.Value = value
-		}
-		if secured, ok := varData["secured"].(bool); ok {
-			variable.Secured = secured
-		}
-
-		variables = append(variables, variable)
-	}
-
 	return variables, nil
 }
 
 // CreateRepositoryVariable creates a new repository-level pipeline variable
-func (c *Client) CreateRepositoryVariable(repoSlug, key, value string, secured bool) error {
+func (c *Client) CreateRepositoryVariable(ctx context.Context, repoSlug, key, value string, secured bool) error {
 	if repoSlug == "" || key == "" {
 		return fmt.Errorf("repository slug and key are required")
 	}
 
-	return c.restClient.CreateRepositoryVariable(repoSlug, key, value, secured)
+	return c.restClient.CreateRepositoryVariable(ctx, repoSlug, key, value, secured)
+}
+
+// UpdateRepositoryVariable updates an existing repository-level pipeline variable
+func (c *Client) UpdateRepositoryVariable(ctx context.Context, repoSlug, uuid, key, value string, secured bool) error {
+	if repoSlug == "" || uuid == "" || key == "" {
+		return fmt.Errorf("repository slug, variable UUID, and key are required")
+	}
+
+	return c.restClient.UpdateRepositoryVariable(ctx, repoSlug, uuid, key, value, secured)
+}
+
+// DeleteRepositoryVariable deletes a repository-level pipeline variable
+func (c *Client) DeleteRepositoryVariable(ctx context.Context, repoSlug, uuid string) error {
+	if repoSlug == "" || uuid == "" {
+		return fmt.Errorf("repository slug and variable UUID are required")
+	}
+
+	return c.restClient.DeleteRepositoryVariable(ctx, repoSlug, uuid)
 }
 
 // GetDeploymentEnvironments retrieves all deployment environments for a repository
-func (c *Client) GetDeploymentEnvironments(repoSlug string) ([]*Environment, error) {
+func (c *Client) GetDeploymentEnvironments(ctx context.Context, repoSlug string) ([]*Environment, error) {
 	if repoSlug == "" {
 		return nil, fmt.Errorf("repository slug is required")
 	}
 
-	rawEnvs, err := c.restClient.ListDeploymentEnvironments(repoSlug)
+	environments, err := c.restClient.ListDeploymentEnvironments(ctx, repoSlug)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get deployment environments: %w", err)
 	}
 
-	environments := make([]*Environment, 0, len(rawEnvs))
-	for _, envData := range rawEnvs {
-		environment := &Environment{}
-
-		if uuid, ok := envData["uuid"].(string); ok {
-			environment.UUID = uuid
-		}
-		if name, ok := envData["name"].(string); ok {
-			environment.Name = name
-		}
-		if envType, ok := envData["environment_type"].(map[string]interface{}); ok {
-			if typeName, ok := envType["name"].(string); ok {
-				environment.Type = typeName
-			}
-		}
-
-		environments = append(environments, environment)
-	}
-
 	return environments, nil
 }
 
 // GetDeploymentVariablesForEnv retrieves deployment variables for a specific environment
-func (c *Client) GetDeploymentVariablesForEnv(repoSlug, envUUID string) ([]*Variable, error) {
+func (c *Client) GetDeploymentVariablesForEnv(ctx context.Context, repoSlug, envUUID string) ([]*Variable, error) {
 	if repoSlug == "" || envUUID == "" {
 		return nil, fmt.Errorf("repository slug and environment UUID are required")
 	}
 
-	rawVars, err := c.restClient.ListDeploymentVariables(repoSlug, envUUID)
+	variables, err := c.restClient.ListDeploymentVariables(ctx, repoSlug, envUUID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get deployment variables: %w", err)
 	}
 
-	variables := make([]*Variable, 0, len(rawVars))
-	for _, varData := range rawVars {
-		variable := &Variable{}
-
-		if key, ok := varData["key"].(string); ok {
-			variable.Key = key
-		}
-		if value, ok := varData["value"].(string); ok {
-			variable.Value = value
-		}
-		if secured, ok := varData["secured"].(bool); ok {
-			variable.Secured = secured
-		}
-
-		variables = append(variables, variable)
-	}
-
 	return variables, nil
 }
 
 // CreateDeploymentVariable creates a new deployment variable for a specific environment
-func (c *Client) CreateDeploymentVariable(repoSlug, envUUID, key, value string, secured bool) error {
+func (c *Client) CreateDeploymentVariable(ctx context.Context, repoSlug, envUUID, key, value string, secured bool) error {
 	if repoSlug == "" || envUUID == "" || key == "" {
 		return fmt.Errorf("repository slug, environment UUID, and key are required")
 	}
 
-	return c.restClient.CreateDeploymentVariable(repoSlug, envUUID, key, value, secured)
+	return c.restClient.CreateDeploymentVariable(ctx, repoSlug, envUUID, key, value, secured)
+}
+
+// UpdateDeploymentVariable updates an existing deployment variable of a specific environment
+func (c *Client) UpdateDeploymentVariable(ctx context.Context, repoSlug, envUUID, uuid, key, value string, secured bool) error {
+	if repoSlug == "" || envUUID == "" || uuid == "" || key == "" {
+		return fmt.Errorf("repository slug, environment UUID, variable UUID, and key are required")
+	}
+
+	return c.restClient.UpdateDeploymentVariable(ctx, repoSlug, envUUID, uuid, key, value, secured)
+}
+
+// DeleteDeploymentVariable deletes a deployment variable of a specific environment
+func (c *Client) DeleteDeploymentVariable(ctx context.Context, repoSlug, envUUID, uuid string) error {
+	if repoSlug == "" || envUUID == "" || uuid == "" {
+		return fmt.Errorf("repository slug, environment UUID, and variable UUID are required")
+	}
+
+	return c.restClient.DeleteDeploymentVariable(ctx, repoSlug, envUUID, uuid)
 }
 
 // CreateDeploymentEnvironment creates a new deployment environment for a repository
-func (c *Client) CreateDeploymentEnvironment(repoSlug, envName, envType string) (*Environment, error) {
+func (c *Client) CreateDeploymentEnvironment(ctx context.Context, repoSlug, envName, envType string) (*Environment, error) {
 	if repoSlug == "" {
 		return nil, fmt.Errorf("repository slug is required")
 	}
@@ -625,68 +791,24 @@ func (c *Client) CreateDeploymentEnvironment(repoSlug, envName, envType string)
 	rank := GetEnvironmentRank(envType)
 
 	// Create the environment via REST API
-	envData, err := c.restClient.CreateDeploymentEnvironment(repoSlug, envName, envType, rank)
-	if err != nil {
-		return nil, err
-	}
-
-	// Parse the response into Environment struct
-	environment := &Environment{}
-
-	if uuid, ok := envData["uuid"].(string); ok {
-		environment.UUID = uuid
-	}
-	if name, ok := envData["name"].(string); ok {
-		environment.Name = name
-	}
-	if envTypeData, ok := envData["environment_type"].(map[string]interface{}); ok {
-		if typeName, ok := envTypeData["name"].(string); ok {
-			environment.Type = typeName
-		}
-	}
-
-	return environment, nil
+	return c.restClient.CreateDeploymentEnvironment(ctx, repoSlug, envName, envType, rank)
 }
 
 // GetWorkspaceVariables retrieves workspace-level pipeline variables
-func (c *Client) GetWorkspaceVariables() ([]*Variable, error) {
-	rawVars, err := c.restClient.ListWorkspaceVariables()
+func (c *Client) GetWorkspaceVariables(ctx context.Context) ([]*Variable, error) {
+	variables, err := c.restClient.ListWorkspaceVariables(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get workspace variables: %w", err)
 	}
 
-	variables := make([]*Variable, 0, len(rawVars))
-	for _, varData := range rawVars {
-		variable := &Variable{}
-
-		if key, ok := varData["key"].(string); ok {
-			variable.Key = key
-		}
-		if value, ok := varData["value"].(string); ok {
-			variable.Value = value
-		}
-		if secured, ok := varData["secured"].(bool); ok {
-			variable.Secured = secured
-		}
-
-		// Store UUID if available for updates
-		if uuid, ok := varData["uuid"].(string); ok {
-			// Store UUID in a new field if needed, or we can fetch it later
-			// For now, we'll need to search by key when updating
-			_ = uuid
-		}
-
-		variables = append(variables, variable)
-	}
-
 	return variables, nil
 }
 
 // CreateOrUpdateWorkspaceVariable creates or updates a workspace-level pipeline variable
 // Returns true if updated, false if created
-func (c *Client) CreateOrUpdateWorkspaceVariable(key, value string, secured bool) (bool, string, error) {
+func (c *Client) CreateOrUpdateWorkspaceVariable(ctx context.Context, key, value string, secured bool) (bool, string, error) {
 	// Get all workspace variables
-	rawVars, err := c.restClient.ListWorkspaceVariables()
+	variables, err := c.restClient.ListWorkspaceVariables(ctx)
 	if err != nil {
 		return false, "", fmt.Errorf("failed to list workspace variables: %w", err)
 	}
@@ -694,65 +816,70 @@ func (c *Client) CreateOrUpdateWorkspaceVariable(key, value string, secured bool
 	// Check if variable already exists
 	var existingUUID string
 	var existingValue string
-	for _, varData := range rawVars {
-		if varKey, ok := varData["key"].(string); ok && varKey == key {
-			if uuid, ok := varData["uuid"].(string); ok {
-				existingUUID = uuid
-			}
-			if val, ok := varData["value"].(string); ok {
-				existingValue = val
-			}
+	for _, variable := range variables {
+		if variable.Key == key {
+			existingUUID = variable.UUID
+			existingValue = variable.Value
 			break
 		}
 	}
 
 	// Update if exists, create if not
 	if existingUUID != "" {
-		_, err := c.restClient.UpdateWorkspaceVariable(existingUUID, key, value, secured)
+		_, err := c.restClient.UpdateWorkspaceVariable(ctx, existingUUID, key, value, secured)
 		if err != nil {
 			return false, "", fmt.Errorf("failed to update workspace variable: %w", err)
 		}
 		return true, existingValue, nil
 	}
 
-	_, err = c.restClient.CreateWorkspaceVariable(key, value, secured)
+	_, err = c.restClient.CreateWorkspaceVariable(ctx, key, value, secured)
 	if err != nil {
 		return false, "", fmt.Errorf("failed to create workspace variable: %w", err)
 	}
 	return false, "", nil
 }
 
-// CreateRepository creates a new repository in Bitbucket
-func (c *Client) CreateRepository(repoSlug, projectKey string, isPrivate bool) (*Repository, error) {
-	if repoSlug == "" {
-		return nil, fmt.Errorf("repository slug is required")
+// UpdateWorkspaceVariable updates an existing workspace-level pipeline variable
+func (c *Client) UpdateWorkspaceVariable(ctx context.Context, uuid, key, value string, secured bool) error {
+	if uuid == "" || key == "" {
+		return fmt.Errorf("variable UUID and key are required")
 	}
 
-	data, err := c.restClient.CreateRepository(repoSlug, projectKey, isPrivate)
-	if err != nil {
-		return nil, err
-	}
+	_, err := c.restClient.UpdateWorkspaceVariable(ctx, uuid, key, value, secured)
+	return err
+}
 
-	// Parse the response into Repository struct
-	repo := &Repository{}
-	if slug, ok := data["slug"].(string); ok {
-		repo.Slug = slug
-	}
-	if name, ok := data["name"].(string); ok {
-		repo.Name = name
+// CreateWorkspaceVariable creates a new workspace-level pipeline variable
+func (c *Client) CreateWorkspaceVariable(ctx context.Context, key, value string, secured bool) error {
+	if key == "" {
+		return fmt.Errorf("key is required")
 	}
-	if desc, ok := data["description"].(string); ok {
-		repo.Description = desc
+
+	_, err := c.restClient.CreateWorkspaceVariable(ctx, key, value, secured)
+	return err
+}
+
+// DeleteWorkspaceVariable deletes a workspace-level pipeline variable
+func (c *Client) DeleteWorkspaceVariable(ctx context.Context, uuid string) error {
+	if uuid == "" {
+		return fmt.Errorf("variable UUID is required")
 	}
-	if fullName, ok := data["full_name"].(string); ok {
-		repo.FullName = fullName
+
+	return c.restClient.DeleteWorkspaceVariable(ctx, uuid)
+}
+
+// CreateRepository creates a new repository in Bitbucket
+func (c *Client) CreateRepository(ctx context.Context, repoSlug, projectKey string, isPrivate bool) (*Repository, error) {
+	if repoSlug == "" {
+		return nil, fmt.Errorf("repository slug is required")
 	}
 
-	return repo, nil
+	return c.restClient.CreateRepository(ctx, repoSlug, projectKey, isPrivate)
 }
 
 // SetRepositoryPermissions sets permissions for a group on a repository
-func (c *Client) SetRepositoryPermissions(repoSlug, groupSlug, permission string) error {
+func (c *Client) SetRepositoryPermissions(ctx context.Context, repoSlug, groupSlug, permission string) error {
 	if repoSlug == "" || groupSlug == "" {
 		return fmt.Errorf("repository slug and group slug are required")
 	}
@@ -760,11 +887,11 @@ func (c *Client) SetRepositoryPermissions(repoSlug, groupSlug, permission string
 		return fmt.Errorf("permission is required")
 	}
 
-	return c.restClient.SetRepositoryPermissions(repoSlug, groupSlug, permission)
+	return c.restClient.SetRepositoryPermissions(ctx, repoSlug, groupSlug, permission)
 }
 
 // SetRepositoryDefaultBranch sets the default branch for a repository
-func (c *Client) SetRepositoryDefaultBranch(repoSlug, branchName string) error {
+func (c *Client) SetRepositoryDefaultBranch(ctx context.Context, repoSlug, branchName string) error {
 	if repoSlug == "" {
 		return fmt.Errorf("repository slug is required")
 	}
@@ -772,50 +899,36 @@ func (c *Client) SetRepositoryDefaultBranch(repoSlug, branchName string) error {
 		return fmt.Errorf("branch name is required")
 	}
 
-	return c.restClient.SetRepositoryDefaultBranch(repoSlug, branchName)
+	return c.restClient.SetRepositoryDefaultBranch(ctx, repoSlug, branchName)
 }
 
 // SSHKeyPair represents a pipeline SSH key pair
 type SSHKeyPair struct {
-	PublicKey string
+	PublicKey string `json:"public_key" yaml:"public_key"`
 }
 
 // DeployKey represents a repository deploy key
 type DeployKey struct {
-	ID       int
-	Key      string
-	Label    string
-	Comment  string
-	AddedOn  time.Time
-	LastUsed *time.Time
+	ID       int        `json:"id" yaml:"id"`
+	Key      string     `json:"key" yaml:"key"`
+	Label    string     `json:"label" yaml:"label"`
+	Comment  string     `json:"comment" yaml:"comment"`
+	AddedOn  time.Time  `json:"added_on" yaml:"added_on"`
+	LastUsed *time.Time `json:"last_used,omitempty" yaml:"last_used,omitempty"`
 }
 
 // GetPipelineSSHKeyPair retrieves the SSH key pair for a repository's pipelines
 // Returns nil if no key pair exists
-func (c *Client) GetPipelineSSHKeyPair(repoSlug string) (*SSHKeyPair, error) {
+func (c *Client) GetPipelineSSHKeyPair(ctx context.Context, repoSlug string) (*SSHKeyPair, error) {
 	if repoSlug == "" {
 		return nil, fmt.Errorf("repository slug is required")
 	}
 
-	data, err := c.restClient.GetPipelineSSHKeyPair(repoSlug)
-	if err != nil {
-		return nil, err
-	}
-
-	if data == nil {
-		return nil, nil
-	}
-
-	keyPair := &SSHKeyPair{}
-	if publicKey, ok := data["public_key"].(string); ok {
-		keyPair.PublicKey = publicKey
-	}
-
-	return keyPair, nil
+	return c.restClient.GetPipelineSSHKeyPair(ctx, repoSlug)
 }
 
 // CreatePipelineSSHKeyPair creates or updates the SSH key pair for a repository's pipelines
-func (c *Client) CreatePipelineSSHKeyPair(repoSlug, privateKey, publicKey string) (*SSHKeyPair, error) {
+func (c *Client) CreatePipelineSSHKeyPair(ctx context.Context, repoSlug, privateKey, publicKey string) (*SSHKeyPair, error) {
 	if repoSlug == "" {
 		return nil, fmt.Errorf("repository slug is required")
 	}
@@ -823,65 +936,20 @@ func (c *Client) CreatePipelineSSHKeyPair(repoSlug, privateKey, publicKey string
 		return nil, fmt.Errorf("private key and public key are required")
 	}
 
-	data, err := c.restClient.CreatePipelineSSHKeyPair(repoSlug, privateKey, publicKey)
-	if err != nil {
-		return nil, err
-	}
-
-	keyPair := &SSHKeyPair{}
-	if pk, ok := data["public_key"].(string); ok {
-		keyPair.PublicKey = pk
-	}
-
-	return keyPair, nil
+	return c.restClient.CreatePipelineSSHKeyPair(ctx, repoSlug, privateKey, publicKey)
 }
 
 // ListDeployKeys retrieves all deploy keys for a repository
-func (c *Client) ListDeployKeys(repoSlug string) ([]*DeployKey, error) {
+func (c *Client) ListDeployKeys(ctx context.Context, repoSlug string) ([]*DeployKey, error) {
 	if repoSlug == "" {
 		return nil, fmt.Errorf("repository slug is required")
 	}
 
-	rawKeys, err := c.restClient.ListDeployKeys(repoSlug)
-	if err != nil {
-		return nil, err
-	}
-
-	deployKeys := make([]*DeployKey, 0, len(rawKeys))
-	for _, keyData := range rawKeys {
-		deployKey := &DeployKey{}
-
-		if id, ok := keyData["id"].(float64); ok {
-			deployKey.ID = int(id)
-		}
-		if key, ok := keyData["key"].(string); ok {
-			deployKey.Key = key
-		}
-		if label, ok := keyData["label"].(string); ok {
-			deployKey.Label = label
-		}
-		if comment, ok := keyData["comment"].(string); ok {
-			deployKey.Comment = comment
-		}
-		if addedOnStr, ok := keyData["added_on"].(string); ok {
-			if t, err := time.Parse(time.RFC3339, addedOnStr); err == nil {
-				deployKey.AddedOn = t
-			}
-		}
-		if lastUsedStr, ok := keyData["last_used"].(string); ok {
-			if t, err := time.Parse(time.RFC3339, lastUsedStr); err == nil {
-				deployKey.LastUsed = &t
-			}
-		}
-
-		deployKeys = append(deployKeys, deployKey)
-	}
-
-	return deployKeys, nil
+	return c.restClient.ListDeployKeys(ctx, repoSlug)
 }
 
 // AddDeployKey adds a new deploy key to a repository
-func (c *Client) AddDeployKey(repoSlug, key, label string) (*DeployKey, error) {
+func (c *Client) AddDeployKey(ctx context.Context, repoSlug, key, label string) (*DeployKey, error) {
 	if repoSlug == "" {
 		return nil, fmt.Errorf("repository slug is required")
 	}
@@ -892,32 +960,13 @@ func (c *Client) AddDeployKey(repoSlug, key, label string) (*DeployKey, error) {
 		return nil, fmt.Errorf("label is required")
 	}
 
-	data, err := c.restClient.CreateDeployKey(repoSlug, key, label)
-	if err != nil {
-		return nil, err
-	}
-
-	deployKey := &DeployKey{}
-	if id, ok := data["id"].(float64); ok {
-		deployKey.ID = int(id)
-	}
-	if k, ok := data["key"].(string); ok {
-		deployKey.Key = k
-	}
-	if l, ok := data["label"].(string); ok {
-		deployKey.Label = l
-	}
-	if comment, ok := data["comment"].(string); ok {
-		deployKey.Comment = comment
-	}
-
-	return deployKey, nil
+	return c.restClient.CreateDeployKey(ctx, repoSlug, key, label)
 }
 
 // FindDeployKeyByLabel searches for a deploy key with a specific label in a repository
 // Returns nil if no key with that label is found
-func (c *Client) FindDeployKeyByLabel(repoSlug, label string) (*DeployKey, error) {
-	deployKeys, err := c.ListDeployKeys(repoSlug)
+func (c *Client) FindDeployKeyByLabel(ctx context.Context, repoSlug, label string) (*DeployKey, error) {
+	deployKeys, err := c.ListDeployKeys(ctx, repoSlug)
 	if err != nil {
 		return nil, err
 	}
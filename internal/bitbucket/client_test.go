@@ -0,0 +1,164 @@
+package bitbucket
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"bitbucket.org/cover42/eiscli/internal/bitbucket/bitbuckettest"
+)
+
+func TestPipelineTargetRef(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		want string
+	}{
+		{
+			name: "branch",
+			json: `{"type": "pipeline_ref_target", "ref_type": "branch", "ref_name": "main", "commit": {"hash": "e151c0ffee"}}`,
+			want: "main",
+		},
+		{
+			name: "tag",
+			json: `{"type": "pipeline_ref_target", "ref_type": "tag", "ref_name": "v1.2.0"}`,
+			want: "v1.2.0",
+		},
+		{
+			name: "pull request",
+			json: `{"type": "pipeline_pullrequest_target", "source": "feature/EIS-1", "destination": "main", "pullrequest": {"type": "pullrequest", "id": 42, "title": "EIS-1 Retries"}, "commit": {"hash": "e151c0ffee"}}`,
+			want: "feature/EIS-1 (PR #42)",
+		},
+		{
+			name: "pull request without source",
+			json: `{"type": "pipeline_pullrequest_target", "pullrequest": {"id": 42}}`,
+			want: "PR #42",
+		},
+		{
+			name: "commit",
+			json: `{"type": "pipeline_commit_target", "commit": {"hash": "e151c0ffee0ddba11"}}`,
+			want: "e151c0f",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var target apiPipelineTarget
+			if err := json.Unmarshal([]byte(tt.json), &target); err != nil {
+				t.Fatal(err)
+			}
+			if got := target.toPipelineTarget(); got.Ref() != tt.want {
+				t.Errorf("Ref() = %q, want %q", got.Ref(), tt.want)
+			}
+		})
+	}
+}
+
+func TestPipelineTargetCommitAuthor(t *testing.T) {
+	data := `{"target": {"type": "pipeline_ref_target", "ref_type": "branch", "ref_name": "main", "commit": {"type": "commit", "hash": "e151c0ffee", "message": "Add retries\n", "author": {"raw": "Jane Doe <jane@example.com>"}}}}`
+
+	var pipeline apiPipeline
+	if err := json.Unmarshal([]byte(data), &pipeline); err != nil {
+		t.Fatalf("decoding a pipeline with a commit author object: %v", err)
+	}
+	var schedule apiPipelineSchedule
+	if err := json.Unmarshal([]byte(data), &schedule); err != nil {
+		t.Fatalf("decoding a schedule with a commit author object: %v", err)
+	}
+
+	commit := pipeline.toPipeline("https://bitbucket.org").Target.Commit
+	if commit == nil || commit.Hash != "e151c0ffee" || commit.Message != "Add retries" || commit.Author != "Jane Doe" {
+		t.Errorf("target commit = %+v, want e151c0ffee by Jane Doe", commit)
+	}
+}
+
+func TestRerunPipeline(t *testing.T) {
+	server := bitbuckettest.New("workspace")
+	defer server.Close()
+	branch := server.AddPipeline("repo", bitbuckettest.Pipeline{Branch: "main", Commit: "e151c0ffee", Result: "FAILED"})
+	pullRequest := server.AddPipeline("repo", bitbuckettest.Pipeline{Branch: "feature/x", Commit: "ba5eba11", PullRequest: 7, Result: "FAILED"})
+
+	restClient := NewRestClient("user", "password", "workspace")
+	restClient.SetBaseURL(server.APIURL())
+	client := &Client{restClient: restClient, workspace: "workspace"}
+
+	rerun, err := client.RerunPipeline(context.Background(), "repo", branch.BuildNumber)
+	if err != nil {
+		t.Fatalf("RerunPipeline() error = %v", err)
+	}
+	if rerun.BuildNumber <= pullRequest.BuildNumber || rerun.Target.RefName != "main" {
+		t.Errorf("RerunPipeline() = #%d of %q, want a new pipeline of main", rerun.BuildNumber, rerun.Target.RefName)
+	}
+
+	pipelines := len(server.Pipelines("repo"))
+	_, err = client.RerunPipeline(context.Background(), "repo", pullRequest.BuildNumber)
+	if err == nil || !strings.Contains(err.Error(), "pull request #7") {
+		t.Errorf("RerunPipeline() of a pull request pipeline error = %v, want it refused", err)
+	}
+	if got := len(server.Pipelines("repo")); got != pipelines {
+		t.Errorf("RerunPipeline() of a pull request pipeline started a pipeline")
+	}
+}
+
+func TestClientsKeepTheirWebURL(t *testing.T) {
+	server := bitbuckettest.New("workspace")
+	defer server.Close()
+	pipeline := server.AddPipeline("repo", bitbuckettest.Pipeline{Branch: "main", Result: "SUCCESSFUL"})
+
+	newClient := func(webURL string) *Client {
+		restClient := NewRestClient("user", "password", "workspace")
+		restClient.SetBaseURL(server.APIURL())
+		restClient.SetWebURL(webURL)
+		return &Client{restClient: restClient, workspace: "workspace"}
+	}
+	local := newClient("http://127.0.0.1:7990/")
+	cloud := newClient("")
+
+	for client, want := range map[*Client]string{
+		local: "http://127.0.0.1:7990/workspace/repo/pipelines/results/1",
+		cloud: "https://bitbucket.org/workspace/repo/pipelines/results/1",
+	} {
+		got, err := client.GetPipelineByBuildNumber(context.Background(), "repo", pipeline.BuildNumber)
+		if err != nil {
+			t.Fatalf("GetPipelineByBuildNumber() error = %v", err)
+		}
+		if got.WebURL != want {
+			t.Errorf("pipeline WebURL = %s, want %s", got.WebURL, want)
+		}
+	}
+}
+
+func TestFindMember(t *testing.T) {
+	members := []*UserInfo{
+		{UUID: "{jane}", Username: "jane", DisplayName: "Jane Doe"},
+		{UUID: "{john}", Username: "john", DisplayName: "John Doe"},
+		{UUID: "{jd}", Username: "jdoe", DisplayName: "John Doe"},
+	}
+
+	tests := []struct {
+		name    string
+		want    string
+		wantErr string
+	}{
+		{"{john}", "{john}", ""},
+		{"@Jane", "{jane}", ""},
+		{"jane doe", "{jane}", ""},
+		{"John Doe", "", "matches 2 members"},
+		{"nobody", "", "no member matches"},
+		{" ", "", "user name is required"},
+	}
+
+	for _, tt := range tests {
+		member, err := findMember(members, tt.name)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("findMember(%q) error = %v, want %q", tt.name, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || member.UUID != tt.want {
+			t.Errorf("findMember(%q) = %+v, %v, want %s", tt.name, member, err, tt.want)
+		}
+	}
+}
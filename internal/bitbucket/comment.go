@@ -0,0 +1,167 @@
+package bitbucket
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Task states of a pull request
+const (
+	TaskStateResolved   = "RESOLVED"
+	TaskStateUnresolved = "UNRESOLVED"
+)
+
+// PullRequestComment is a general or inline comment of a pull request
+type PullRequestComment struct {
+	ID        int       `json:"id" yaml:"id"`
+	ParentID  int       `json:"parent_id,omitempty" yaml:"parent_id,omitempty"` // Set for replies
+	Author    string    `json:"author" yaml:"author"`
+	Body      string    `json:"body" yaml:"body"`
+	Path      string    `json:"path,omitempty" yaml:"path,omitempty"`         // Set for inline comments
+	Line      int       `json:"line,omitempty" yaml:"line,omitempty"`         // Line in the new version of the file
+	OldLine   int       `json:"old_line,omitempty" yaml:"old_line,omitempty"` // Line in the old version, for removed lines
+	Deleted   bool      `json:"deleted,omitempty" yaml:"deleted,omitempty"`
+	CreatedOn time.Time `json:"created_on" yaml:"created_on"`
+	UpdatedOn time.Time `json:"updated_on" yaml:"updated_on"`
+	WebURL    string    `json:"web_url,omitempty" yaml:"web_url,omitempty"`
+}
+
+// IsInline returns true if the comment is on a file of the diff
+func (c *PullRequestComment) IsInline() bool {
+	return c.Path != ""
+}
+
+// PullRequestTask is a task of a pull request, usually attached to a comment
+type PullRequestTask struct {
+	ID         int        `json:"id" yaml:"id"`
+	State      string     `json:"state" yaml:"state"` // RESOLVED or UNRESOLVED
+	Body       string     `json:"body" yaml:"body"`
+	Creator    string     `json:"creator" yaml:"creator"`
+	CommentID  int        `json:"comment_id,omitempty" yaml:"comment_id,omitempty"`
+	CreatedOn  time.Time  `json:"created_on" yaml:"created_on"`
+	ResolvedOn *time.Time `json:"resolved_on,omitempty" yaml:"resolved_on,omitempty"`
+	ResolvedBy string     `json:"resolved_by,omitempty" yaml:"resolved_by,omitempty"`
+}
+
+// Resolved returns true if the task is resolved
+func (t *PullRequestTask) Resolved() bool {
+	return t.State == TaskStateResolved
+}
+
+// CommentOptions holds the options for adding a comment to a pull request
+type CommentOptions struct {
+	Body     string
+	Path     string // File of an inline comment
+	Line     int    // Line in the new version of Path; 0 comments on the whole file
+	ParentID int    // Comment to reply to
+}
+
+// ListPullRequestComments fetches the comments of a pull request, oldest first
+func (c *RestClient) ListPullRequestComments(ctx context.Context, repoSlug string, id int) ([]*PullRequestComment, error) {
+	path := fmt.Sprintf("/repositories/%s/%s/pullrequests/%d/comments?pagelen=100", c.workspace, repoSlug, id)
+
+	comments := make([]*PullRequestComment, 0)
+	err := paginate(ctx, c, path, func(data *apiComment) bool {
+		comments = append(comments, data.toComment())
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get comments: %w", err)
+	}
+
+	return comments, nil
+}
+
+// CreatePullRequestComment adds a comment to a pull request
+func (c *RestClient) CreatePullRequestComment(ctx context.Context, repoSlug string, id int, opts *CommentOptions) (*PullRequestComment, error) {
+	path := fmt.Sprintf("/repositories/%s/%s/pullrequests/%d/comments", c.workspace, repoSlug, id)
+
+	requestBody := map[string]interface{}{
+		"content": map[string]interface{}{"raw": opts.Body},
+	}
+	if opts.Path != "" {
+		inline := map[string]interface{}{"path": opts.Path}
+		if opts.Line > 0 {
+			inline["to"] = opts.Line
+		}
+		requestBody["inline"] = inline
+	}
+	if opts.ParentID > 0 {
+		requestBody["parent"] = map[string]interface{}{"id": opts.ParentID}
+	}
+
+	var data apiComment
+	if err := c.doRequestWithBody(ctx, "POST", path, requestBody, &data); err != nil {
+		return nil, fmt.Errorf("failed to add comment: %w", err)
+	}
+
+	return data.toComment(), nil
+}
+
+// ListPullRequestTasks fetches the tasks of a pull request
+func (c *RestClient) ListPullRequestTasks(ctx context.Context, repoSlug string, id int) ([]*PullRequestTask, error) {
+	path := fmt.Sprintf("/repositories/%s/%s/pullrequests/%d/tasks?pagelen=100", c.workspace, repoSlug, id)
+
+	tasks := make([]*PullRequestTask, 0)
+	err := paginate(ctx, c, path, func(data *apiTask) bool {
+		tasks = append(tasks, data.toTask())
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tasks: %w", err)
+	}
+
+	return tasks, nil
+}
+
+// UpdatePullRequestTaskState resolves or reopens a task of a pull request
+func (c *RestClient) UpdatePullRequestTaskState(ctx context.Context, repoSlug string, id, taskID int, state string) (*PullRequestTask, error) {
+	path := fmt.Sprintf("/repositories/%s/%s/pullrequests/%d/tasks/%d", c.workspace, repoSlug, id, taskID)
+
+	var data apiTask
+	if err := c.doRequestWithBody(ctx, "PUT", path, map[string]interface{}{"state": state}, &data); err != nil {
+		return nil, fmt.Errorf("failed to update task %d: %w", taskID, err)
+	}
+
+	return data.toTask(), nil
+}
+
+// ListPullRequestComments retrieves the comments of a pull request, oldest first
+func (c *Client) ListPullRequestComments(ctx context.Context, repoSlug string, id int) ([]*PullRequestComment, error) {
+	return c.restClient.ListPullRequestComments(ctx, repoSlug, id)
+}
+
+// CreatePullRequestComment adds a general comment, an inline comment or a
+// reply to a pull request
+func (c *Client) CreatePullRequestComment(ctx context.Context, repoSlug string, id int, opts *CommentOptions) (*PullRequestComment, error) {
+	if opts == nil || opts.Body == "" {
+		return nil, fmt.Errorf("comment body is required")
+	}
+	if opts.Line < 0 {
+		return nil, fmt.Errorf("invalid line %d", opts.Line)
+	}
+	if opts.Line > 0 && opts.Path == "" {
+		return nil, fmt.Errorf("a file is required to comment on a line")
+	}
+	if opts.ParentID > 0 && opts.Path != "" {
+		return nil, fmt.Errorf("a reply cannot be placed on a file, it belongs to the thread of its parent")
+	}
+
+	return c.restClient.CreatePullRequestComment(ctx, repoSlug, id, opts)
+}
+
+// ListPullRequestTasks retrieves the tasks of a pull request
+func (c *Client) ListPullRequestTasks(ctx context.Context, repoSlug string, id int) ([]*PullRequestTask, error) {
+	return c.restClient.ListPullRequestTasks(ctx, repoSlug, id)
+}
+
+// ResolvePullRequestTask marks a task of a pull request as resolved
+func (c *Client) ResolvePullRequestTask(ctx context.Context, repoSlug string, id, taskID int) (*PullRequestTask, error) {
+	return c.restClient.UpdatePullRequestTaskState(ctx, repoSlug, id, taskID, TaskStateResolved)
+}
+
+// ReopenPullRequestTask marks a resolved task of a pull request as unresolved
+func (c *Client) ReopenPullRequestTask(ctx context.Context, repoSlug string, id, taskID int) (*PullRequestTask, error) {
+	return c.restClient.UpdatePullRequestTaskState(ctx, repoSlug, id, taskID, TaskStateUnresolved)
+}
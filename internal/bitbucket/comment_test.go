@@ -0,0 +1,86 @@
+package bitbucket
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"bitbucket.org/cover42/eiscli/internal/bitbucket/bitbuckettest"
+)
+
+func TestPullRequestCommentsAndTasks(t *testing.T) {
+	server := bitbuckettest.New("workspace")
+	defer server.Close()
+	reviewer := &bitbuckettest.User{UUID: "{reviewer}", Username: "reviewer", DisplayName: "Re Viewer"}
+	server.AddPullRequest("repo", bitbuckettest.PullRequest{
+		Title:        "Change",
+		SourceBranch: "feature/x",
+		Comments: []*bitbuckettest.Comment{
+			{Body: "General remark", Author: reviewer},
+			{Body: "Retry here?", Path: "main.go", Line: 12, Author: reviewer},
+		},
+		Tasks: []*bitbuckettest.Task{{CommentID: 2, Body: "Add a retry", Creator: reviewer}},
+	})
+
+	restClient := NewRestClient("user", "password", "workspace")
+	restClient.SetBaseURL(server.APIURL())
+	client := &Client{restClient: restClient, workspace: "workspace"}
+	ctx := context.Background()
+
+	comments, err := client.ListPullRequestComments(ctx, "repo", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(comments) != 2 || comments[0].IsInline() || comments[0].Author != "Re Viewer" {
+		t.Fatalf("ListPullRequestComments() = %+v", comments)
+	}
+	if inline := comments[1]; !inline.IsInline() || inline.Path != "main.go" || inline.Line != 12 || inline.WebURL == "" {
+		t.Errorf("inline comment = %+v", inline)
+	}
+
+	reply, err := client.CreatePullRequestComment(ctx, "repo", 1, &CommentOptions{Body: "Done", ParentID: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reply.ID != 3 || reply.ParentID != 2 || reply.Path != "main.go" || reply.Line != 12 {
+		t.Errorf("reply = %+v, want a reply on main.go:12", reply)
+	}
+
+	for _, opts := range []*CommentOptions{
+		{},
+		{Body: "x", Line: 3},
+		{Body: "x", Path: "main.go", ParentID: 2},
+	} {
+		if _, err := client.CreatePullRequestComment(ctx, "repo", 1, opts); err == nil {
+			t.Errorf("CreatePullRequestComment(%+v) succeeded", opts)
+		}
+	}
+	var apiErr *APIError
+	if _, err := client.CreatePullRequestComment(ctx, "repo", 1, &CommentOptions{Body: "x", ParentID: 99}); !errors.As(err, &apiErr) || apiErr.StatusCode != 400 {
+		t.Errorf("reply to a missing comment error = %v, want status 400", err)
+	}
+
+	tasks, err := client.ListPullRequestTasks(ctx, "repo", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tasks) != 1 || tasks[0].Resolved() || tasks[0].CommentID != 2 || tasks[0].Creator != "Re Viewer" {
+		t.Fatalf("ListPullRequestTasks() = %+v", tasks)
+	}
+
+	task, err := client.ResolvePullRequestTask(ctx, "repo", 1, tasks[0].ID)
+	if err != nil || !task.Resolved() || task.ResolvedOn == nil {
+		t.Errorf("ResolvePullRequestTask() = %+v, %v", task, err)
+	}
+	if pr := server.PullRequests("repo")[0]; !pr.Tasks[0].Resolved {
+		t.Error("task not resolved on the server")
+	}
+
+	task, err = client.ReopenPullRequestTask(ctx, "repo", 1, tasks[0].ID)
+	if err != nil || task.Resolved() {
+		t.Errorf("ReopenPullRequestTask() = %+v, %v", task, err)
+	}
+	if _, err := client.ResolvePullRequestTask(ctx, "repo", 1, 42); !errors.Is(err, ErrNotFound) {
+		t.Errorf("ResolvePullRequestTask() of a missing task error = %v, want ErrNotFound", err)
+	}
+}
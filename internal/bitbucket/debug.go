@@ -0,0 +1,325 @@
+package bitbucket
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"bitbucket.org/cover42/eiscli/internal/config"
+)
+
+// redacted replaces secrets in debug logs and dumps
+const redacted = "[REDACTED]"
+
+// sensitiveKeys are JSON keys and query parameters whose values are never
+// logged or dumped. Variable values are only redacted when the variable is
+// secured, see redactJSON.
+var sensitiveKeys = map[string]bool{
+	"access_token":  true,
+	"app_password":  true,
+	"client_secret": true,
+	"password":      true,
+	"private_key":   true,
+	"refresh_token": true,
+	"secret":        true,
+	"token":         true,
+}
+
+// sensitiveQueryParams are query parameters that are redacted in addition to
+// sensitiveKeys, e.g. the signature of the presigned S3 URLs that step logs
+// redirect to
+var sensitiveQueryParams = map[string]bool{
+	"signature":            true,
+	"x-amz-credential":     true,
+	"x-amz-security-token": true,
+	"x-amz-signature":      true,
+}
+
+// sensitiveHeaders are request and response headers whose values are redacted
+var sensitiveHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
+// dumpSeq numbers the dumped requests of this process across clients
+var dumpSeq atomic.Int64
+
+// dumpPrefix keeps dumps of separate runs into the same directory apart
+var dumpPrefix = time.Now().Format("20060102-150405")
+
+// debugTransport logs every request with its status, latency and response
+// size, and optionally writes the full, redacted request/response pair to a
+// dump directory for attaching to bug reports
+type debugTransport struct {
+	next    http.RoundTripper
+	log     io.Writer // nil disables logging
+	dumpDir string    // empty disables dumps
+
+	mu sync.Mutex // serializes log lines of concurrent requests
+}
+
+// EnableDebug traces all requests of the client. Each request is logged to w
+// (if not nil); with a dumpDir the full request/response pairs are written
+// there as well. Secrets are redacted in both.
+func (c *RestClient) EnableDebug(w io.Writer, dumpDir string) error {
+	if dumpDir != "" {
+		if err := os.MkdirAll(dumpDir, 0o700); err != nil {
+			return fmt.Errorf("failed to create debug dump directory: %w", err)
+		}
+	}
+
+	next := c.client.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	c.client.Transport = &debugTransport{next: next, log: w, dumpDir: dumpDir}
+	return nil
+}
+
+// enableDebug turns on the tracing configured by --debug and --debug-dump
+func enableDebug(c *RestClient, cfg config.DebugConfig) error {
+	if !cfg.Enabled && cfg.DumpDir == "" {
+		return nil
+	}
+
+	var w io.Writer
+	if cfg.Enabled {
+		w = os.Stderr
+	}
+	return c.EnableDebug(w, cfg.DumpDir)
+}
+
+// debugf writes a line to the debug log if the client is traced, e.g. about
+// responses that never reach the transport
+func (c *RestClient) debugf(format string, args ...interface{}) {
+	if t, ok := c.client.Transport.(*debugTransport); ok {
+		t.logf(format, args...)
+	}
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *debugTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil && req.GetBody != nil {
+		// Read a copy so that the request can still be sent (and retried)
+		if body, err := req.GetBody(); err == nil {
+			reqBody, _ = io.ReadAll(body)
+			body.Close()
+		}
+	}
+
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	latency := time.Since(start)
+
+	if err != nil {
+		t.logf("%s %s -> error: %v (%s)", req.Method, redactURL(req.URL), err, formatLatency(latency))
+		t.dump(req, reqBody, nil, nil, err)
+		return nil, err
+	}
+
+	// Buffer the body to measure and dump it, then hand an identical one on
+	respBody, readErr := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	line := fmt.Sprintf("%s %s -> %d (%s, %s)", req.Method, redactURL(req.URL), resp.StatusCode,
+		formatLatency(latency), formatSize(len(respBody)))
+	if requestID := resp.Header.Get("X-Request-Id"); requestID != "" {
+		line += " request-id=" + requestID
+	}
+	if readErr != nil {
+		line += fmt.Sprintf(" read error: %v", readErr)
+	}
+	t.logf("%s", line)
+	t.dump(req, reqBody, resp, respBody, readErr)
+
+	if readErr != nil {
+		return nil, readErr
+	}
+	return resp, nil
+}
+
+// logf writes one line to the debug log
+func (t *debugTransport) logf(format string, args ...interface{}) {
+	if t.log == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	fmt.Fprintf(t.log, "[debug] "+format+"\n", args...)
+}
+
+// dump writes the redacted request/response pair to the dump directory
+func (t *debugTransport) dump(req *http.Request, reqBody []byte, resp *http.Response, respBody []byte, respErr error) {
+	if t.dumpDir == "" {
+		return
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s\n", req.Method, redactURL(req.URL))
+	writeHeaders(&b, req.Header)
+	writeBody(&b, req.Header.Get("Content-Type"), reqBody)
+
+	b.WriteString("\n---\n\n")
+	if resp != nil {
+		fmt.Fprintf(&b, "%s\n", resp.Status)
+		writeHeaders(&b, resp.Header)
+		writeBody(&b, resp.Header.Get("Content-Type"), respBody)
+	}
+	if respErr != nil {
+		fmt.Fprintf(&b, "error: %v\n", respErr)
+	}
+
+	name := fmt.Sprintf("%s-%04d-%s-%s.http", dumpPrefix, dumpSeq.Add(1), req.Method, dumpName(req.URL.Path))
+	if err := os.WriteFile(filepath.Join(t.dumpDir, name), []byte(b.String()), 0o600); err != nil {
+		t.logf("failed to write debug dump: %v", err)
+	}
+}
+
+// nonNameChars are replaced in dump file names
+var nonNameChars = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+
+// dumpName turns a request path into a readable file name component
+func dumpName(path string) string {
+	path = strings.TrimPrefix(path, "/2.0")
+	name := strings.Trim(nonNameChars.ReplaceAllString(path, "_"), "_")
+	if len(name) > 80 {
+		name = name[:80]
+	}
+	return name
+}
+
+func writeHeaders(b *strings.Builder, header http.Header) {
+	header = redactHeaders(header)
+
+	keys := make([]string, 0, len(header))
+	for key := range header {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		for _, value := range header[key] {
+			fmt.Fprintf(b, "%s: %s\n", key, value)
+		}
+	}
+}
+
+func writeBody(b *strings.Builder, contentType string, body []byte) {
+	if len(body) == 0 {
+		return
+	}
+	b.WriteString("\n")
+	b.Write(redactBody(contentType, body))
+	b.WriteString("\n")
+}
+
+// redactHeaders returns a copy of header with credentials replaced. The auth
+// scheme is kept so that the dump still shows which authentication was used.
+// Redirect targets are redacted like request URLs.
+func redactHeaders(header http.Header) http.Header {
+	header = header.Clone()
+	if location := header.Get("Location"); location != "" {
+		if u, err := url.Parse(location); err == nil {
+			header.Set("Location", redactURL(u))
+		}
+	}
+	for _, name := range sensitiveHeaders {
+		values := header.Values(name)
+		for i, value := range values {
+			if scheme, _, found := strings.Cut(value, " "); found && name != "Cookie" && name != "Set-Cookie" {
+				values[i] = scheme + " " + redacted
+			} else {
+				values[i] = redacted
+			}
+		}
+	}
+	return header
+}
+
+// redactURL returns the URL with credentials in the user info or query removed
+func redactURL(u *url.URL) string {
+	redactedURL := *u
+	if redactedURL.User != nil {
+		redactedURL.User = url.User(redacted)
+	}
+
+	query := redactedURL.Query()
+	changed := false
+	for key := range query {
+		if sensitiveKeys[strings.ToLower(key)] || sensitiveQueryParams[strings.ToLower(key)] {
+			query.Set(key, redacted)
+			changed = true
+		}
+	}
+	if changed {
+		redactedURL.RawQuery = query.Encode()
+	}
+	return redactedURL.String()
+}
+
+// redactBody redacts secrets in a JSON body and indents it for reading.
+// Other bodies, e.g. step logs, are returned unchanged.
+func redactBody(contentType string, body []byte) []byte {
+	var data interface{}
+	if err := json.Unmarshal(body, &data); err != nil {
+		if strings.Contains(contentType, "json") {
+			return []byte(redacted + " (unparseable JSON body)")
+		}
+		return body
+	}
+
+	indented, err := json.MarshalIndent(redactJSON(data), "", "  ")
+	if err != nil {
+		return []byte(redacted)
+	}
+	return indented
+}
+
+// redactJSON replaces the values of sensitive keys and of secured variables
+func redactJSON(data interface{}) interface{} {
+	switch value := data.(type) {
+	case map[string]interface{}:
+		secured, _ := value["secured"].(bool)
+		for key, item := range value {
+			if sensitiveKeys[strings.ToLower(key)] || (secured && key == "value") {
+				value[key] = redacted
+				continue
+			}
+			value[key] = redactJSON(item)
+		}
+		return value
+	case []interface{}:
+		for i, item := range value {
+			value[i] = redactJSON(item)
+		}
+		return value
+	default:
+		return data
+	}
+}
+
+// formatLatency rounds a request duration for the log
+func formatLatency(d time.Duration) string {
+	if d < time.Second {
+		return d.Round(time.Millisecond).String()
+	}
+	return d.Round(10 * time.Millisecond).String()
+}
+
+// formatSize formats a response size for the log
+func formatSize(n int) string {
+	if n < 1024 {
+		return fmt.Sprintf("%d B", n)
+	}
+	return fmt.Sprintf("%.1f KB", float64(n)/1024)
+}
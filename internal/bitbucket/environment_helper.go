@@ -2,6 +2,8 @@ package bitbucket
 
 import (
 	"bufio"
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"strings"
@@ -67,6 +69,7 @@ func PromptCreateEnvironment(envName, inferredType string) (bool, string, error)
 // EnsureEnvironmentExists checks if an environment exists and creates it if necessary
 // Returns the environment (either existing or newly created) or an error
 func EnsureEnvironmentExists(
+	ctx context.Context,
 	client *Client,
 	repoSlug string,
 	envName string,
@@ -74,7 +77,7 @@ func EnsureEnvironmentExists(
 	envTypeOverride string,
 ) (*Environment, error) {
 	// First, try to fetch all environments
-	environments, err := client.GetDeploymentEnvironments(repoSlug)
+	environments, err := client.GetDeploymentEnvironments(ctx, repoSlug)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch deployment environments: %w", err)
 	}
@@ -135,11 +138,17 @@ func EnsureEnvironmentExists(
 
 	fmt.Printf("\nCreating deployment environment '%s' (type: %s)...\n", envName, envType)
 
-	newEnv, err := client.CreateDeploymentEnvironment(repoSlug, envName, envType)
+	newEnv, err := client.CreateDeploymentEnvironment(ctx, repoSlug, envName, envType)
 	if err != nil {
 		// Check if it's a permission error
-		if strings.Contains(err.Error(), "403") || strings.Contains(err.Error(), "Forbidden") {
+		if errors.Is(err, ErrForbidden) {
 			fmt.Printf("%s Failed to create environment\n\n", redColor("✗"))
+
+			// A token without the needed scope is fixed by logging in again, not by an admin
+			var apiErr *APIError
+			if errors.As(err, &apiErr) && len(apiErr.MissingScopes) > 0 {
+				return nil, fmt.Errorf("permission denied: %w", err)
+			}
 			return nil, fmt.Errorf("permission denied: You don't have permission to create deployment environments.\n"+
 				"Please ask your Bitbucket workspace admin to:\n"+
 				"  1. Grant you 'Deployments: Write' permission, or\n"+
@@ -147,10 +156,10 @@ func EnsureEnvironmentExists(
 		}
 
 		// Check if it already exists (race condition)
-		if strings.Contains(err.Error(), "already exists") || strings.Contains(err.Error(), "409") {
+		if errors.Is(err, ErrConflict) {
 			fmt.Printf("Environment already exists (created by another process). Continuing...\n")
 			// Refetch environments to get the newly created one
-			environments, refetchErr := client.GetDeploymentEnvironments(repoSlug)
+			environments, refetchErr := client.GetDeploymentEnvironments(ctx, repoSlug)
 			if refetchErr != nil {
 				return nil, fmt.Errorf("environment exists but failed to refetch: %w", refetchErr)
 			}
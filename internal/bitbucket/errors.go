@@ -0,0 +1,225 @@
+package bitbucket
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Sentinel errors for common API failures. An *APIError matches the sentinel
+// of its status code with errors.Is, e.g. errors.Is(err, ErrNotFound).
+var (
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrForbidden    = errors.New("forbidden")
+	ErrNotFound     = errors.New("not found")
+	ErrConflict     = errors.New("conflict")
+)
+
+// APIError is a Bitbucket API request that failed with a non-2xx status
+type APIError struct {
+	StatusCode int
+	Method     string
+	Path       string
+
+	// Message and Detail are the error description returned by Bitbucket.
+	// Message falls back to the raw response body if it is not an API error.
+	Message string
+	Detail  string
+
+	// Fields holds validation errors per request field
+	Fields map[string][]string
+
+	// RequestID identifies the request in Bitbucket's logs, for support requests
+	RequestID string
+
+	// MissingScopes are the OAuth scopes the credentials lack, if the request
+	// was denied because of them
+	MissingScopes []string
+
+	// Scopes Bitbucket reports for a scope error
+	requiredScopes []string
+	grantedScopes  []string
+}
+
+// Error keeps the "API request failed with status N" prefix of the former
+// string errors, so existing messages and log searches still match
+func (e *APIError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "API request failed with status %d", e.StatusCode)
+	if e.Message != "" {
+		fmt.Fprintf(&b, ": %s", e.Message)
+	}
+	if e.Detail != "" {
+		fmt.Fprintf(&b, " (%s)", e.Detail)
+	}
+
+	fields := make([]string, 0, len(e.Fields))
+	for field := range e.Fields {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	for _, field := range fields {
+		fmt.Fprintf(&b, "; %s: %s", field, strings.Join(e.Fields[field], ", "))
+	}
+
+	if len(e.MissingScopes) > 0 {
+		fmt.Fprintf(&b, "; missing OAuth scope %s, run 'eiscli auth login' to grant it",
+			strings.Join(e.MissingScopes, ", "))
+	}
+	if e.RequestID != "" {
+		fmt.Fprintf(&b, " [request ID %s]", e.RequestID)
+	}
+	return b.String()
+}
+
+// Is matches the sentinel error of the status code
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized
+	case ErrForbidden:
+		return e.StatusCode == http.StatusForbidden
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrConflict:
+		return e.StatusCode == http.StatusConflict
+	}
+	return false
+}
+
+// apiErrorResponse is the body of a failed API request
+type apiErrorResponse struct {
+	Error struct {
+		ID      string                     `json:"id"`
+		Message string                     `json:"message"`
+		Detail  json.RawMessage            `json:"detail"`
+		Fields  map[string]json.RawMessage `json:"fields"`
+	} `json:"error"`
+}
+
+// apiScopeDetail is the detail of a scope error, e.g.
+// {"required": ["pipeline:variable"], "granted": ["account", "pipeline"]}
+type apiScopeDetail struct {
+	Required []string `json:"required"`
+	Granted  []string `json:"granted"`
+}
+
+// newAPIError builds the error of a failed request from its response
+func newAPIError(method, path string, resp *http.Response, body []byte) *APIError {
+	apiErr := &APIError{
+		StatusCode: resp.StatusCode,
+		Method:     method,
+		Path:       path,
+		RequestID:  resp.Header.Get("X-Request-Id"),
+	}
+
+	var data apiErrorResponse
+	if err := json.Unmarshal(body, &data); err != nil || data.Error.Message == "" {
+		apiErr.Message = strings.TrimSpace(string(body))
+		return apiErr
+	}
+
+	apiErr.Message = data.Error.Message
+	if apiErr.RequestID == "" {
+		apiErr.RequestID = data.Error.ID
+	}
+
+	// The detail is either a description or, for scope errors, the scopes
+	var detail string
+	var scopes apiScopeDetail
+	if json.Unmarshal(data.Error.Detail, &detail) == nil {
+		apiErr.Detail = detail
+	} else if json.Unmarshal(data.Error.Detail, &scopes) == nil {
+		apiErr.requiredScopes = scopes.Required
+		apiErr.grantedScopes = scopes.Granted
+	}
+
+	// Field errors are a message or a list of messages per field
+	for field, raw := range data.Error.Fields {
+		var messages []string
+		var message string
+		if json.Unmarshal(raw, &messages) != nil {
+			if json.Unmarshal(raw, &message) != nil {
+				continue
+			}
+			messages = []string{message}
+		}
+		if apiErr.Fields == nil {
+			apiErr.Fields = make(map[string][]string)
+		}
+		apiErr.Fields[field] = messages
+	}
+
+	return apiErr
+}
+
+// isScopeError reports whether the request was denied because the credentials
+// lack a privilege scope, rather than because of repository permissions
+func (e *APIError) isScopeError() bool {
+	if e.StatusCode != http.StatusForbidden {
+		return false
+	}
+	message := strings.ToLower(e.Message)
+	return len(e.requiredScopes) > 0 || strings.Contains(message, "scope") || strings.Contains(message, "privilege")
+}
+
+// missingScopes returns the scopes from requiredScopes that a scope error is
+// about and that are not among the granted scopes. When Bitbucket does not name
+// the scopes, the scope is derived from the request.
+func (e *APIError) missingScopes(granted []string) []string {
+	if !e.isScopeError() {
+		return nil
+	}
+	if len(e.grantedScopes) > 0 {
+		granted = e.grantedScopes
+	}
+
+	required := e.requiredScopes
+	if len(required) == 0 {
+		if scope := scopeForRequest(e.Method, e.Path); scope != "" {
+			required = []string{scope}
+		}
+	}
+
+	var missing []string
+	for _, scope := range required {
+		if slices.Contains(requiredScopes, scope) && !slices.Contains(granted, scope) {
+			missing = append(missing, scope)
+		}
+	}
+	return missing
+}
+
+// scopeForRequest returns the scope from requiredScopes that an API request
+// needs, or an empty string if it needs none of them
+func scopeForRequest(method, path string) string {
+	write := method != http.MethodGet && method != http.MethodHead
+
+	switch {
+	case strings.Contains(path, "/pipelines_config/variables"),
+		strings.Contains(path, "/pipelines-config/variables"),
+		strings.Contains(path, "/deployments_config/"):
+		return "pipeline:variable"
+	case strings.Contains(path, "/pipelines"):
+		if write {
+			return "pipeline:write"
+		}
+		return "pipeline"
+	case strings.Contains(path, "/pullrequests"):
+		if write {
+			return "pullrequest:write"
+		}
+		return "pullrequest"
+	case strings.Contains(path, "/hooks"):
+		return "webhook"
+	case path == "/user" || strings.HasPrefix(path, "/user?"):
+		return "account"
+	case strings.HasPrefix(path, "/repositories/") && !write:
+		return "repository"
+	}
+	return ""
+}
@@ -0,0 +1,168 @@
+package bitbucket
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewAPIError(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		header  http.Header
+		body    string
+		want    *APIError
+		wantMsg string
+	}{
+		{
+			name:   "bitbucket error",
+			status: http.StatusBadRequest,
+			header: http.Header{"X-Request-Id": {"abc123"}},
+			body:   `{"type": "error", "error": {"message": "Bad request", "fields": {"key": ["This field is required."], "value": "Too long"}}}`,
+			want: &APIError{
+				StatusCode: 400, Method: "POST", Path: "/vars",
+				Message:   "Bad request",
+				Fields:    map[string][]string{"key": {"This field is required."}, "value": {"Too long"}},
+				RequestID: "abc123",
+			},
+			wantMsg: "API request failed with status 400: Bad request; key: This field is required.; value: Too long [request ID abc123]",
+		},
+		{
+			name:   "detail and request ID in body",
+			status: http.StatusConflict,
+			body:   `{"error": {"message": "Conflict", "detail": "Environment exists", "id": "def456"}}`,
+			want: &APIError{
+				StatusCode: 409, Method: "POST", Path: "/vars",
+				Message: "Conflict", Detail: "Environment exists", RequestID: "def456",
+			},
+			wantMsg: "API request failed with status 409: Conflict (Environment exists) [request ID def456]",
+		},
+		{
+			name:    "not an API error",
+			status:  http.StatusBadGateway,
+			body:    "<html>Bad Gateway</html>\n",
+			want:    &APIError{StatusCode: 502, Method: "POST", Path: "/vars", Message: "<html>Bad Gateway</html>"},
+			wantMsg: "API request failed with status 502: <html>Bad Gateway</html>",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{StatusCode: tt.status, Header: tt.header}
+			if resp.Header == nil {
+				resp.Header = http.Header{}
+			}
+
+			got := newAPIError("POST", "/vars", resp, []byte(tt.body))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("newAPIError() = %+v, want %+v", got, tt.want)
+			}
+			if got.Error() != tt.wantMsg {
+				t.Errorf("Error() = %q, want %q", got.Error(), tt.wantMsg)
+			}
+		})
+	}
+}
+
+func TestAPIErrorIs(t *testing.T) {
+	sentinels := []error{ErrUnauthorized, ErrForbidden, ErrNotFound, ErrConflict}
+	statuses := map[int]error{401: ErrUnauthorized, 403: ErrForbidden, 404: ErrNotFound, 409: ErrConflict, 500: nil}
+
+	for status, want := range statuses {
+		err := fmt.Errorf("failed to get repository: %w", &APIError{StatusCode: status})
+		for _, sentinel := range sentinels {
+			if got := errors.Is(err, sentinel); got != (sentinel == want) {
+				t.Errorf("status %d: errors.Is(err, %v) = %v", status, sentinel, got)
+			}
+		}
+	}
+}
+
+func TestMissingScopes(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     *APIError
+		granted []string
+		want    []string
+	}{
+		{
+			name: "scopes named by bitbucket",
+			err: &APIError{
+				StatusCode: 403, Message: "Your credentials lack one or more required privilege scopes.",
+				requiredScopes: []string{"pipeline:variable"}, grantedScopes: []string{"account", "pipeline"},
+			},
+			want: []string{"pipeline:variable"},
+		},
+		{
+			name:    "scope derived from request",
+			err:     &APIError{StatusCode: 403, Method: "POST", Path: "/repositories/ws/repo/pipelines/", Message: "Your credentials lack one or more required privilege scopes."},
+			granted: []string{"pipeline"},
+			want:    []string{"pipeline:write"},
+		},
+		{
+			name:    "derived scope already granted",
+			err:     &APIError{StatusCode: 403, Method: "GET", Path: "/repositories/ws/repo/pullrequests", Message: "Missing privilege scope"},
+			granted: []string{"pullrequest"},
+			want:    nil,
+		},
+		{
+			name: "repository permission error",
+			err:  &APIError{StatusCode: 403, Method: "POST", Path: "/repositories/ws/repo/environments/", Message: "You do not have admin access"},
+			want: nil,
+		},
+		{
+			name: "not forbidden",
+			err:  &APIError{StatusCode: 404, Message: "scope not found", requiredScopes: []string{"pipeline"}},
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.err.missingScopes(tt.granted); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("missingScopes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRestClientReportsMissingScope(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(`{"type": "error", "error": {"message": "Your credentials lack one or more required privilege scopes.",
+			"detail": {"required": ["pipeline:variable"], "granted": ["account", "repository", "pipeline"]}}}`))
+	}))
+	defer server.Close()
+
+	client := &RestClient{
+		workspace: "workspace",
+		client:    server.Client(),
+		baseURL:   server.URL + "/2.0",
+		useOAuth:  true,
+		tokenStore: &TokenStore{
+			AccessToken: "token",
+			ExpiresAt:   time.Now().Add(time.Hour),
+		},
+	}
+
+	err := client.CreateRepositoryVariable(context.Background(), "repo", "KEY", "value", false)
+	if !errors.Is(err, ErrForbidden) {
+		t.Fatalf("CreateRepositoryVariable() error = %v, want ErrForbidden", err)
+	}
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || !reflect.DeepEqual(apiErr.MissingScopes, []string{"pipeline:variable"}) {
+		t.Fatalf("MissingScopes = %v, want [pipeline:variable]", apiErr)
+	}
+	if !strings.Contains(err.Error(), "missing OAuth scope pipeline:variable") {
+		t.Errorf("error does not name the missing scope: %v", err)
+	}
+}
@@ -0,0 +1,60 @@
+package bitbucket
+
+import (
+	"context"
+	"strings"
+)
+
+// page is one page of a paginated Bitbucket API response
+type page[T any] struct {
+	Values []T    `json:"values"`
+	Next   string `json:"next"`
+}
+
+// paginate requests path and follows the next links of the responses, calling
+// yield for every value in order. It stops early, without error, when yield
+// returns false.
+func paginate[T any](ctx context.Context, c *RestClient, path string, yield func(T) bool) error {
+	for path != "" {
+		var current page[T]
+		if err := c.doRequest(ctx, "GET", path, &current); err != nil {
+			return err
+		}
+
+		for _, value := range current.Values {
+			if !yield(value) {
+				return nil
+			}
+		}
+
+		path = nextPagePath(c.baseURL, current.Next)
+	}
+
+	return nil
+}
+
+// listAll requests path and returns the values of all pages
+func listAll[T any](ctx context.Context, c *RestClient, path string) ([]T, error) {
+	values := make([]T, 0)
+	err := paginate(ctx, c, path, func(value T) bool {
+		values = append(values, value)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return values, nil
+}
+
+// nextPagePath turns the absolute next link of a page into a path relative to
+// the API base URL. It returns an empty path when there is no next page.
+func nextPagePath(baseURL, next string) string {
+	if path, found := strings.CutPrefix(next, baseURL); found && baseURL != "" {
+		return path
+	}
+	if _, path, found := strings.Cut(next, "/2.0"); found {
+		return path
+	}
+	return ""
+}
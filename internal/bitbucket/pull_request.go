@@ -0,0 +1,423 @@
+package bitbucket
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// PullRequestParticipant is a reviewer or other participant of a pull request
+type PullRequestParticipant struct {
+	UUID     string `json:"uuid" yaml:"uuid"`
+	Name     string `json:"name" yaml:"name"`
+	Role     string `json:"role" yaml:"role"` // REVIEWER or PARTICIPANT
+	Approved bool   `json:"approved" yaml:"approved"`
+	State    string `json:"state,omitempty" yaml:"state,omitempty"` // approved, changes_requested or empty
+}
+
+// RequestedChanges returns true if the participant requested changes
+func (p *PullRequestParticipant) RequestedChanges() bool {
+	return p.State == "changes_requested"
+}
+
+// DiffStat is the change of one file in a pull request
+type DiffStat struct {
+	Path         string `json:"path" yaml:"path"`
+	OldPath      string `json:"old_path,omitempty" yaml:"old_path,omitempty"` // Set for renamed files
+	Status       string `json:"status" yaml:"status"`                         // added, removed, modified, renamed
+	LinesAdded   int    `json:"lines_added" yaml:"lines_added"`
+	LinesRemoved int    `json:"lines_removed" yaml:"lines_removed"`
+}
+
+// Summary returns the first line of the commit message
+func (c *Commit) Summary() string {
+	summary, _, _ := strings.Cut(c.Message, "\n")
+	return summary
+}
+
+// BuildStatus is the status of a build of a commit, e.g. a pipeline run
+type BuildStatus struct {
+	Key         string    `json:"key" yaml:"key"`
+	Name        string    `json:"name" yaml:"name"`
+	State       string    `json:"state" yaml:"state"` // SUCCESSFUL, FAILED, INPROGRESS, STOPPED
+	Description string    `json:"description,omitempty" yaml:"description,omitempty"`
+	URL         string    `json:"url,omitempty" yaml:"url,omitempty"`
+	UpdatedOn   time.Time `json:"updated_on" yaml:"updated_on"`
+}
+
+// Merge strategies of a pull request
+const (
+	MergeStrategyMergeCommit = "merge_commit"
+	MergeStrategySquash      = "squash"
+	MergeStrategyFastForward = "fast_forward"
+)
+
+// MergeStrategies lists the supported merge strategies
+var MergeStrategies = []string{MergeStrategyMergeCommit, MergeStrategySquash, MergeStrategyFastForward}
+
+// MergeOptions holds the options for merging a pull request
+type MergeOptions struct {
+	Strategy          string // One of MergeStrategies; empty uses the repository default
+	Message           string // Commit message; empty uses the Bitbucket default
+	CloseSourceBranch bool
+}
+
+// UpdatePullRequestOptions holds the fields of a pull request to change.
+// Empty fields are left as they are.
+type UpdatePullRequestOptions struct {
+	Title       string
+	Description string
+	Reviewers   []string // UUIDs of all reviewers; nil keeps the reviewers
+}
+
+// IsFromFork returns true if the source branch is in another repository than
+// the destination branch
+func (pr *PullRequest) IsFromFork() bool {
+	return pr.SourceRepository != "" && pr.DestinationRepository != "" &&
+		!strings.EqualFold(pr.SourceRepository, pr.DestinationRepository)
+}
+
+// GetPullRequest fetches a pull request with its participants
+func (c *RestClient) GetPullRequest(ctx context.Context, repoSlug string, id int) (*PullRequest, error) {
+	path := fmt.Sprintf("/repositories/%s/%s/pullrequests/%d", c.workspace, repoSlug, id)
+
+	var data apiPullRequest
+	if err := c.doRequest(ctx, "GET", path, &data); err != nil {
+		return nil, fmt.Errorf("failed to get pull request #%d: %w", id, err)
+	}
+
+	return data.toPullRequest(c.webBaseURL(), c.workspace, repoSlug), nil
+}
+
+// FindOpenPullRequest returns the open pull request from a source branch, or
+// nil if there is none
+func (c *RestClient) FindOpenPullRequest(ctx context.Context, repoSlug, sourceBranch string) (*PullRequest, error) {
+	query := url.Values{}
+	query.Set("state", "OPEN")
+	query.Set("q", fmt.Sprintf("source.branch.name=%q", sourceBranch))
+	query.Set("pagelen", "50")
+	path := fmt.Sprintf("/repositories/%s/%s/pullrequests?%s", c.workspace, repoSlug, query.Encode())
+
+	var found *apiPullRequest
+	err := paginate(ctx, c, path, func(data *apiPullRequest) bool {
+		// Checked again in case the query is not supported
+		if data.Source.Branch.Name == sourceBranch {
+			found = data
+			return false
+		}
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find pull request: %w", err)
+	}
+	if found == nil {
+		return nil, nil
+	}
+
+	return found.toPullRequest(c.webBaseURL(), c.workspace, repoSlug), nil
+}
+
+// ListOpenPullRequests fetches all open pull requests of a repository with
+// their reviewers and participants, which Bitbucket only lists when asked
+func (c *RestClient) ListOpenPullRequests(ctx context.Context, repoSlug string) ([]*PullRequest, error) {
+	query := url.Values{}
+	query.Set("state", "OPEN")
+	query.Set("fields", "+values.reviewers,+values.participants")
+	query.Set("pagelen", "50")
+	path := fmt.Sprintf("/repositories/%s/%s/pullrequests?%s", c.workspace, repoSlug, query.Encode())
+
+	pullRequests := make([]*PullRequest, 0)
+	err := paginate(ctx, c, path, func(data *apiPullRequest) bool {
+		pullRequests = append(pullRequests, data.toPullRequest(c.webBaseURL(), c.workspace, repoSlug))
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pull requests: %w", err)
+	}
+
+	return pullRequests, nil
+}
+
+// UpdatePullRequest changes the title, description or reviewers of a pull request
+func (c *RestClient) UpdatePullRequest(ctx context.Context, repoSlug string, id int, opts *UpdatePullRequestOptions) (*PullRequest, error) {
+	path := fmt.Sprintf("/repositories/%s/%s/pullrequests/%d", c.workspace, repoSlug, id)
+
+	requestBody := map[string]interface{}{}
+	if opts.Title != "" {
+		requestBody["title"] = opts.Title
+	}
+	if opts.Description != "" {
+		requestBody["description"] = opts.Description
+	}
+	if opts.Reviewers != nil {
+		reviewers := make([]map[string]interface{}, 0, len(opts.Reviewers))
+		for _, uuid := range opts.Reviewers {
+			reviewers = append(reviewers, map[string]interface{}{"uuid": uuid})
+		}
+		requestBody["reviewers"] = reviewers
+	}
+
+	var data apiPullRequest
+	if err := c.doRequestWithBody(ctx, "PUT", path, requestBody, &data); err != nil {
+		return nil, fmt.Errorf("failed to update pull request #%d: %w", id, err)
+	}
+
+	return data.toPullRequest(c.webBaseURL(), c.workspace, repoSlug), nil
+}
+
+// ListPullRequestDiffStat fetches the changed files of a pull request
+func (c *RestClient) ListPullRequestDiffStat(ctx context.Context, repoSlug string, id int) ([]*DiffStat, error) {
+	path := fmt.Sprintf("/repositories/%s/%s/pullrequests/%d/diffstat?pagelen=100", c.workspace, repoSlug, id)
+
+	stats := make([]*DiffStat, 0)
+	err := paginate(ctx, c, path, func(data *apiDiffStat) bool {
+		stats = append(stats, data.toDiffStat())
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get diffstat: %w", err)
+	}
+
+	return stats, nil
+}
+
+// GetPullRequestDiff fetches the unified diff of a pull request. Bitbucket
+// redirects to the diff of the source and destination commits, which is followed.
+func (c *RestClient) GetPullRequestDiff(ctx context.Context, repoSlug string, id int) (string, error) {
+	// Refresh token if needed (OAuth only)
+	if c.useOAuth {
+		if err := c.ensureValidToken(); err != nil {
+			return "", err
+		}
+	}
+
+	path := fmt.Sprintf("/repositories/%s/%s/pullrequests/%d/diff", c.workspace, repoSlug, id)
+
+	resp, body, err := c.send(ctx, c.client, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+path, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+
+		c.setAuthHeader(req)
+		req.Header.Set("Accept", "text/plain")
+		return req, nil
+	})
+	if err != nil {
+		if resp != nil {
+			return "", fmt.Errorf("failed to read response body: %w", err)
+		}
+		return "", fmt.Errorf("failed to execute request: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to get diff of pull request #%d: %w", id, newAPIError("GET", path, resp, body))
+	}
+
+	return string(body), nil
+}
+
+// ListPullRequestCommits fetches the commits of a pull request, newest first
+func (c *RestClient) ListPullRequestCommits(ctx context.Context, repoSlug string, id int) ([]*Commit, error) {
+	path := fmt.Sprintf("/repositories/%s/%s/pullrequests/%d/commits?pagelen=100", c.workspace, repoSlug, id)
+
+	commits := make([]*Commit, 0)
+	err := paginate(ctx, c, path, func(data *apiCommit) bool {
+		commits = append(commits, data.toCommit())
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get commits: %w", err)
+	}
+
+	return commits, nil
+}
+
+// ListCommitStatuses fetches the build statuses reported for a commit, such
+// as the pipelines that ran on it
+func (c *RestClient) ListCommitStatuses(ctx context.Context, repoSlug, commit string) ([]*BuildStatus, error) {
+	path := fmt.Sprintf("/repositories/%s/%s/commit/%s/statuses?pagelen=100", c.workspace, repoSlug, commit)
+
+	statuses := make([]*BuildStatus, 0)
+	err := paginate(ctx, c, path, func(data *apiCommitStatus) bool {
+		statuses = append(statuses, data.toBuildStatus())
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get build statuses: %w", err)
+	}
+
+	return statuses, nil
+}
+
+// ApprovePullRequest approves a pull request as the current user
+func (c *RestClient) ApprovePullRequest(ctx context.Context, repoSlug string, id int) error {
+	path := fmt.Sprintf("/repositories/%s/%s/pullrequests/%d/approve", c.workspace, repoSlug, id)
+
+	if err := c.doRequestWithBody(ctx, "POST", path, map[string]interface{}{}, nil); err != nil {
+		return fmt.Errorf("failed to approve pull request #%d: %w", id, err)
+	}
+
+	return nil
+}
+
+// UnapprovePullRequest withdraws the approval of the current user
+func (c *RestClient) UnapprovePullRequest(ctx context.Context, repoSlug string, id int) error {
+	path := fmt.Sprintf("/repositories/%s/%s/pullrequests/%d/approve", c.workspace, repoSlug, id)
+
+	if err := c.doRequest(ctx, "DELETE", path, nil); err != nil {
+		return fmt.Errorf("failed to unapprove pull request #%d: %w", id, err)
+	}
+
+	return nil
+}
+
+// RequestChanges requests changes on a pull request as the current user
+func (c *RestClient) RequestChanges(ctx context.Context, repoSlug string, id int) error {
+	path := fmt.Sprintf("/repositories/%s/%s/pullrequests/%d/request-changes", c.workspace, repoSlug, id)
+
+	if err := c.doRequestWithBody(ctx, "POST", path, map[string]interface{}{}, nil); err != nil {
+		return fmt.Errorf("failed to request changes on pull request #%d: %w", id, err)
+	}
+
+	return nil
+}
+
+// DeclinePullRequest declines a pull request
+func (c *RestClient) DeclinePullRequest(ctx context.Context, repoSlug string, id int) (*PullRequest, error) {
+	path := fmt.Sprintf("/repositories/%s/%s/pullrequests/%d/decline", c.workspace, repoSlug, id)
+
+	var data apiPullRequest
+	if err := c.doRequestWithBody(ctx, "POST", path, map[string]interface{}{}, &data); err != nil {
+		return nil, fmt.Errorf("failed to decline pull request #%d: %w", id, err)
+	}
+
+	return data.toPullRequest(c.webBaseURL(), c.workspace, repoSlug), nil
+}
+
+// MergePullRequest merges a pull request
+func (c *RestClient) MergePullRequest(ctx context.Context, repoSlug string, id int, opts *MergeOptions) (*PullRequest, error) {
+	path := fmt.Sprintf("/repositories/%s/%s/pullrequests/%d/merge", c.workspace, repoSlug, id)
+
+	requestBody := map[string]interface{}{
+		"type":                "pullrequest",
+		"close_source_branch": opts.CloseSourceBranch,
+	}
+	if opts.Strategy != "" {
+		requestBody["merge_strategy"] = opts.Strategy
+	}
+	if opts.Message != "" {
+		requestBody["message"] = opts.Message
+	}
+
+	var data apiPullRequest
+	if err := c.doRequestWithBody(ctx, "POST", path, requestBody, &data); err != nil {
+		return nil, fmt.Errorf("failed to merge pull request #%d: %w", id, err)
+	}
+
+	return data.toPullRequest(c.webBaseURL(), c.workspace, repoSlug), nil
+}
+
+// GetPullRequest retrieves a pull request with its participants
+func (c *Client) GetPullRequest(ctx context.Context, repoSlug string, id int) (*PullRequest, error) {
+	if repoSlug == "" {
+		return nil, fmt.Errorf("repository slug is required")
+	}
+	if id <= 0 {
+		return nil, fmt.Errorf("invalid pull request ID %d", id)
+	}
+
+	return c.restClient.GetPullRequest(ctx, repoSlug, id)
+}
+
+// FindOpenPullRequest returns the open pull request from a source branch.
+// Returns nil if there is none.
+func (c *Client) FindOpenPullRequest(ctx context.Context, repoSlug, sourceBranch string) (*PullRequest, error) {
+	if repoSlug == "" || sourceBranch == "" {
+		return nil, fmt.Errorf("repository slug and source branch are required")
+	}
+
+	return c.restClient.FindOpenPullRequest(ctx, repoSlug, sourceBranch)
+}
+
+// ListOpenPullRequests retrieves all open pull requests of a repository with
+// their participants
+func (c *Client) ListOpenPullRequests(ctx context.Context, repoSlug string) ([]*PullRequest, error) {
+	if repoSlug == "" {
+		return nil, fmt.Errorf("repository slug is required")
+	}
+
+	return c.restClient.ListOpenPullRequests(ctx, repoSlug)
+}
+
+// UpdatePullRequest changes the title, description or reviewers of a pull request
+func (c *Client) UpdatePullRequest(ctx context.Context, repoSlug string, id int, opts *UpdatePullRequestOptions) (*PullRequest, error) {
+	if id <= 0 {
+		return nil, fmt.Errorf("invalid pull request ID %d", id)
+	}
+	if opts == nil || (opts.Title == "" && opts.Description == "" && opts.Reviewers == nil) {
+		return nil, fmt.Errorf("nothing to update")
+	}
+
+	return c.restClient.UpdatePullRequest(ctx, repoSlug, id, opts)
+}
+
+// ListPullRequestDiffStat retrieves the changed files of a pull request
+func (c *Client) ListPullRequestDiffStat(ctx context.Context, repoSlug string, id int) ([]*DiffStat, error) {
+	return c.restClient.ListPullRequestDiffStat(ctx, repoSlug, id)
+}
+
+// GetPullRequestDiff retrieves the unified diff of a pull request
+func (c *Client) GetPullRequestDiff(ctx context.Context, repoSlug string, id int) (string, error) {
+	return c.restClient.GetPullRequestDiff(ctx, repoSlug, id)
+}
+
+// ListPullRequestCommits retrieves the commits of a pull request, newest first
+func (c *Client) ListPullRequestCommits(ctx context.Context, repoSlug string, id int) ([]*Commit, error) {
+	return c.restClient.ListPullRequestCommits(ctx, repoSlug, id)
+}
+
+// ListCommitStatuses retrieves the build statuses of a commit
+func (c *Client) ListCommitStatuses(ctx context.Context, repoSlug, commit string) ([]*BuildStatus, error) {
+	if commit == "" {
+		return nil, fmt.Errorf("commit hash is required")
+	}
+
+	return c.restClient.ListCommitStatuses(ctx, repoSlug, commit)
+}
+
+// ApprovePullRequest approves a pull request
+func (c *Client) ApprovePullRequest(ctx context.Context, repoSlug string, id int) error {
+	return c.restClient.ApprovePullRequest(ctx, repoSlug, id)
+}
+
+// UnapprovePullRequest withdraws the approval of a pull request
+func (c *Client) UnapprovePullRequest(ctx context.Context, repoSlug string, id int) error {
+	return c.restClient.UnapprovePullRequest(ctx, repoSlug, id)
+}
+
+// RequestChanges requests changes on a pull request
+func (c *Client) RequestChanges(ctx context.Context, repoSlug string, id int) error {
+	return c.restClient.RequestChanges(ctx, repoSlug, id)
+}
+
+// DeclinePullRequest declines a pull request
+func (c *Client) DeclinePullRequest(ctx context.Context, repoSlug string, id int) (*PullRequest, error) {
+	return c.restClient.DeclinePullRequest(ctx, repoSlug, id)
+}
+
+// MergePullRequest merges a pull request
+func (c *Client) MergePullRequest(ctx context.Context, repoSlug string, id int, opts *MergeOptions) (*PullRequest, error) {
+	if opts == nil {
+		opts = &MergeOptions{}
+	}
+	if opts.Strategy != "" && !slices.Contains(MergeStrategies, opts.Strategy) {
+		return nil, fmt.Errorf("invalid merge strategy '%s', must be one of: %s", opts.Strategy, strings.Join(MergeStrategies, ", "))
+	}
+
+	return c.restClient.MergePullRequest(ctx, repoSlug, id, opts)
+}
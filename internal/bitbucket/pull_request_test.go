@@ -0,0 +1,218 @@
+package bitbucket
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"bitbucket.org/cover42/eiscli/internal/bitbucket/bitbuckettest"
+)
+
+func TestDiffStatConversion(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		want *DiffStat
+	}{
+		{
+			name: "modified",
+			json: `{"status": "modified", "lines_added": 3, "lines_removed": 1, "old": {"path": "a.go"}, "new": {"path": "a.go"}}`,
+			want: &DiffStat{Path: "a.go", Status: "modified", LinesAdded: 3, LinesRemoved: 1},
+		},
+		{
+			name: "renamed",
+			json: `{"status": "renamed", "old": {"path": "old.go"}, "new": {"path": "new.go"}}`,
+			want: &DiffStat{Path: "new.go", OldPath: "old.go", Status: "renamed"},
+		},
+		{
+			name: "removed",
+			json: `{"status": "removed", "lines_removed": 7, "old": {"path": "gone.go"}, "new": null}`,
+			want: &DiffStat{Path: "gone.go", Status: "removed", LinesRemoved: 7},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var data apiDiffStat
+			if err := json.Unmarshal([]byte(tt.json), &data); err != nil {
+				t.Fatal(err)
+			}
+			if got := data.toDiffStat(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("toDiffStat() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCommitConversion(t *testing.T) {
+	var data apiCommit
+	if err := json.Unmarshal([]byte(`{"hash": "abc", "message": "Fix retries\n\nDetails\n", "author": {"raw": "Jane Doe <jane@example.com>"}}`), &data); err != nil {
+		t.Fatal(err)
+	}
+
+	commit := data.toCommit()
+	if commit.Author != "Jane Doe" {
+		t.Errorf("Author = %q, want the name of the raw git author", commit.Author)
+	}
+	if commit.Summary() != "Fix retries" || commit.Date != nil {
+		t.Errorf("commit = %+v", commit)
+	}
+}
+
+func TestFindOpenPullRequest(t *testing.T) {
+	server := bitbuckettest.New("workspace")
+	defer server.Close()
+	server.AddPullRequest("repo", bitbuckettest.PullRequest{Title: "Other", SourceBranch: "feature/other"})
+	server.AddPullRequest("repo", bitbuckettest.PullRequest{Title: "Merged", SourceBranch: "feature/x", State: "MERGED"})
+	server.AddPullRequest("repo", bitbuckettest.PullRequest{Title: "Open", SourceBranch: "feature/x"})
+
+	client := NewRestClient("user", "password", "workspace")
+	client.SetBaseURL(server.APIURL())
+
+	pr, err := client.FindOpenPullRequest(context.Background(), "repo", "feature/x")
+	if err != nil {
+		t.Fatalf("FindOpenPullRequest() error = %v", err)
+	}
+	if pr == nil || pr.Title != "Open" || pr.SourceCommit == "" {
+		t.Errorf("FindOpenPullRequest() = %+v, want the open pull request", pr)
+	}
+
+	pr, err = client.FindOpenPullRequest(context.Background(), "repo", "feature/none")
+	if err != nil || pr != nil {
+		t.Errorf("FindOpenPullRequest() = %+v, %v, want nil", pr, err)
+	}
+}
+
+func TestListPullRequestsPaginates(t *testing.T) {
+	server := bitbuckettest.New("workspace")
+	defer server.Close()
+	jane := &bitbuckettest.User{UUID: "{jane}", Username: "jane"}
+	john := &bitbuckettest.User{UUID: "{john}", Username: "john"}
+	for i := 0; i < 120; i++ {
+		author := john
+		if i%4 == 0 {
+			author = jane
+		}
+		server.AddPullRequest("repo", bitbuckettest.PullRequest{Title: fmt.Sprintf("PR %d", i), SourceBranch: fmt.Sprintf("feature/%d", i), Author: author})
+	}
+
+	client := NewRestClient("user", "password", "workspace")
+	client.SetBaseURL(server.APIURL())
+
+	prs, err := client.ListPullRequests(context.Background(), "repo", "OPEN", 100, "", "")
+	if err != nil {
+		t.Fatalf("ListPullRequests() error = %v", err)
+	}
+	if len(prs) != 100 {
+		t.Errorf("ListPullRequests() with limit 100 returned %d pull requests, want 100", len(prs))
+	}
+
+	// Only every fourth pull request is Jane's, so they span several pages
+	prs, err = client.ListPullRequests(context.Background(), "repo", "OPEN", 25, "{jane}", "")
+	if err != nil {
+		t.Fatalf("ListPullRequests() by author error = %v", err)
+	}
+	if len(prs) != 25 {
+		t.Errorf("ListPullRequests() by author returned %d pull requests, want 25", len(prs))
+	}
+	for _, pr := range prs {
+		if pr.Author != "jane" {
+			t.Errorf("ListPullRequests() by author returned %q by %q", pr.Title, pr.Author)
+		}
+	}
+}
+
+func TestReviewAndMergePullRequest(t *testing.T) {
+	server := bitbuckettest.New("workspace")
+	defer server.Close()
+	author := &bitbuckettest.User{UUID: "{author}", Username: "author", DisplayName: "Au Thor"}
+	server.AddPullRequest("repo", bitbuckettest.PullRequest{Title: "Change", SourceBranch: "feature/x", Author: author})
+	server.AddPullRequest("repo", bitbuckettest.PullRequest{Title: "Own change", SourceBranch: "feature/y"})
+
+	client := NewRestClient("user", "password", "workspace")
+	client.SetBaseURL(server.APIURL())
+	ctx := context.Background()
+
+	if err := client.ApprovePullRequest(ctx, "repo", 1); err != nil {
+		t.Fatalf("ApprovePullRequest() error = %v", err)
+	}
+	pr, err := client.GetPullRequest(ctx, "repo", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pr.Participants) != 1 || !pr.Participants[0].Approved || pr.Participants[0].Role != "PARTICIPANT" {
+		t.Errorf("participants after approving = %+v", pr.Participants)
+	}
+
+	if err := client.RequestChanges(ctx, "repo", 1); err != nil {
+		t.Fatalf("RequestChanges() error = %v", err)
+	}
+	if err := client.UnapprovePullRequest(ctx, "repo", 1); err != nil {
+		t.Fatalf("UnapprovePullRequest() error = %v", err)
+	}
+	if states := server.PullRequests("repo")[0].ReviewStates; len(states) != 0 {
+		t.Errorf("review states after unapproving = %v", states)
+	}
+
+	// Bitbucket refuses approvals of your own pull requests
+	if err := client.ApprovePullRequest(ctx, "repo", 2); err == nil {
+		t.Error("ApprovePullRequest() of an own pull request succeeded")
+	}
+
+	merged, err := client.MergePullRequest(ctx, "repo", 1, &MergeOptions{Strategy: MergeStrategySquash, Message: "Squashed", CloseSourceBranch: true})
+	if err != nil {
+		t.Fatalf("MergePullRequest() error = %v", err)
+	}
+	if merged.State != "MERGED" {
+		t.Errorf("state after merging = %s", merged.State)
+	}
+	if got := server.PullRequests("repo")[0]; got.MergeStrategy != "squash" || got.MergeMessage != "Squashed" || !got.CloseSourceBranch {
+		t.Errorf("merge options sent = %+v", got)
+	}
+
+	declined, err := client.DeclinePullRequest(ctx, "repo", 2)
+	if err != nil || declined.State != "DECLINED" {
+		t.Errorf("DeclinePullRequest() = %+v, %v", declined, err)
+	}
+	if _, err := client.DeclinePullRequest(ctx, "repo", 2); err == nil {
+		t.Error("declining a declined pull request succeeded")
+	}
+}
+
+func TestPullRequestFromFork(t *testing.T) {
+	server := bitbuckettest.New("workspace")
+	defer server.Close()
+	server.AddPullRequest("repo", bitbuckettest.PullRequest{Title: "Own", SourceBranch: "feature/x", Diff: "diff --git a/a.go b/a.go\n"})
+	server.AddPullRequest("repo", bitbuckettest.PullRequest{Title: "Fork", SourceBranch: "feature/x", SourceRepository: "alice/repo"})
+
+	client := NewRestClient("user", "password", "workspace")
+	client.SetBaseURL(server.APIURL())
+	ctx := context.Background()
+
+	own, err := client.GetPullRequest(ctx, "repo", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if own.IsFromFork() || own.SourceRepository != "workspace/repo" {
+		t.Errorf("pull request from the repository = %+v", own)
+	}
+
+	fork, err := client.GetPullRequest(ctx, "repo", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !fork.IsFromFork() || fork.SourceRepository != "alice/repo" || fork.DestinationRepository != "workspace/repo" {
+		t.Errorf("pull request from a fork = %+v", fork)
+	}
+
+	diff, err := client.GetPullRequestDiff(ctx, "repo", 1)
+	if err != nil || diff != "diff --git a/a.go b/a.go\n" {
+		t.Errorf("GetPullRequestDiff() = %q, %v", diff, err)
+	}
+	if _, err := client.GetPullRequestDiff(ctx, "repo", 3); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetPullRequestDiff() of a missing pull request error = %v, want ErrNotFound", err)
+	}
+}
@@ -1,17 +1,27 @@
 package bitbucket
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
+
+	"bitbucket.org/cover42/eiscli/internal/config"
 )
 
 // RestClient is a simple REST API client for Bitbucket
 type RestClient struct {
 	baseURL     string
+	webURL      string // Base of web UI links, see webBaseURL
 	username    string
 	password    string
 	workspace   string
@@ -19,12 +29,51 @@ type RestClient struct {
 	useOAuth    bool
 	tokenStore  *TokenStore
 	oauthClient *OAuthClient
+	tokenMu     sync.Mutex // guards tokenStore, requests may run concurrently
+
+	// Retry policy for rate-limited and transiently failing requests
+	maxRetries     int
+	retryBaseDelay time.Duration
+
+	// cache keeps workspace listings on disk, nil disables it
+	cache *responseCache
+}
+
+// SetMaxRetries sets how many times a rate-limited or transiently failing request
+// is retried. Zero disables retries.
+func (c *RestClient) SetMaxRetries(n int) {
+	c.maxRetries = max(n, 0)
+}
+
+// SetWebURL changes the base of the web UI links of pipelines and pull
+// requests, e.g. to point them at a local stand-in server. An empty URL keeps
+// the current one.
+func (c *RestClient) SetWebURL(webURL string) {
+	if webURL != "" {
+		c.webURL = strings.TrimRight(webURL, "/")
+	}
+}
+
+// webBaseURL returns the base of the web UI links
+func (c *RestClient) webBaseURL() string {
+	if c.webURL == "" {
+		return config.DefaultWebURL
+	}
+	return c.webURL
+}
+
+// SetBaseURL points the client at another Bitbucket API, e.g. a local stand-in
+// server. An empty URL keeps the current one.
+func (c *RestClient) SetBaseURL(baseURL string) {
+	if baseURL != "" {
+		c.baseURL = strings.TrimRight(baseURL, "/")
+	}
 }
 
 // NewRestClient creates a new REST API client with Basic Auth
 func NewRestClient(username, password, workspace string) *RestClient {
 	return &RestClient{
-		baseURL:   "https://api.bitbucket.org/2.0",
+		baseURL:   config.DefaultAPIURL,
 		username:  username,
 		password:  password,
 		workspace: workspace,
@@ -32,13 +81,14 @@ func NewRestClient(username, password, workspace string) *RestClient {
 		client: &http.Client{
 			Timeout: 30 * time.Second,
 		},
+		maxRetries: config.DefaultMaxRetries,
 	}
 }
 
 // NewRestClientWithOAuth creates a new REST API client with OAuth
 func NewRestClientWithOAuth(workspace string, tokenStore *TokenStore, oauthClient *OAuthClient) *RestClient {
 	return &RestClient{
-		baseURL:     "https://api.bitbucket.org/2.0",
+		baseURL:     config.DefaultAPIURL,
 		workspace:   workspace,
 		useOAuth:    true,
 		tokenStore:  tokenStore,
@@ -46,157 +96,276 @@ func NewRestClientWithOAuth(workspace string, tokenStore *TokenStore, oauthClien
 		client: &http.Client{
 			Timeout: 30 * time.Second,
 		},
+		maxRetries: config.DefaultMaxRetries,
 	}
 }
 
-// doRequest performs an HTTP request with authentication
-func (c *RestClient) doRequest(method, path string) (map[string]interface{}, error) {
+// doRequest performs an HTTP request with authentication and decodes the JSON
+// response into result. A nil result discards the response body.
+func (c *RestClient) doRequest(ctx context.Context, method, path string, result interface{}) error {
+	return c.doJSONRequest(ctx, method, path, nil, result)
+}
+
+// doRequestWithBody performs an HTTP request with a JSON body and decodes the
+// JSON response into result. A nil result discards the response body.
+func (c *RestClient) doRequestWithBody(ctx context.Context, method, path string, body, result interface{}) error {
+	jsonBody, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request body: %w", err)
+	}
+
+	return c.doJSONRequest(ctx, method, path, jsonBody, result)
+}
+
+// doJSONRequest sends an authenticated request with an optional JSON body and
+// decodes the JSON response into result. Transient failures are retried, see send.
+func (c *RestClient) doJSONRequest(ctx context.Context, method, path string, jsonBody []byte, result interface{}) error {
 	// Refresh token if needed (OAuth only)
 	if c.useOAuth {
 		if err := c.ensureValidToken(); err != nil {
-			return nil, err
+			return err
 		}
 	}
 
 	url := c.baseURL + path
 
-	req, err := http.NewRequest(method, url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+	// Listings in the response cache are served from disk while fresh
+	var cacheGroup string
+	var cached *cacheEntry
+	if c.cache != nil && method == http.MethodGet {
+		cacheGroup = c.cache.group(path)
+		if cacheGroup != "" {
+			cached = c.cache.load(cacheGroup, url)
+		}
+		if cached != nil && c.cache.fresh(cached) {
+			c.debugf("%s %s -> cached (%s old)", method, url, c.cache.now().Sub(cached.StoredAt).Round(time.Second))
+			return decodeResult(cached.Body, result)
+		}
 	}
 
-	// Set authentication header
-	if c.useOAuth {
-		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.tokenStore.AccessToken))
-	} else {
-		req.SetBasicAuth(c.username, c.password)
-	}
-	req.Header.Set("Accept", "application/json")
+	resp, respBody, err := c.send(ctx, c.client, func() (*http.Request, error) {
+		var bodyReader io.Reader
+		if jsonBody != nil {
+			bodyReader = bytes.NewReader(jsonBody)
+		}
 
-	resp, err := c.client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to execute request: %w", err)
-	}
-	defer resp.Body.Close()
+		req, err := http.NewRequestWithContext(ctx, method, url, bodyReader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
 
-	body, err := io.ReadAll(resp.Body)
+		c.setAuthHeader(req)
+		req.Header.Set("Accept", "application/json")
+		if jsonBody != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		if cached != nil && cached.ETag != "" {
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
+		return req, nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
+		if resp != nil {
+			return fmt.Errorf("failed to read response body: %w", err)
+		}
+		return fmt.Errorf("failed to execute request: %w", err)
 	}
 
-	// Handle 401 Unauthorized (token might be invalid)
-	if resp.StatusCode == 401 && c.useOAuth {
-		return nil, fmt.Errorf("authentication failed: token may be invalid (try running 'eiscli auth login' again)")
+	// An unchanged listing is served from the cache again for another TTL
+	if resp.StatusCode == http.StatusNotModified && cached != nil {
+		header := resp.Header.Clone()
+		if header.Get("ETag") == "" {
+			header.Set("ETag", cached.ETag)
+		}
+		c.cache.store(cacheGroup, url, header, cached.Body)
+		return decodeResult(cached.Body, result)
 	}
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
-	}
+		apiErr := newAPIError(method, path, resp, respBody)
 
-	var result map[string]interface{}
-	if err := json.Unmarshal(body, &result); err != nil {
-		return nil, fmt.Errorf("failed to parse JSON response: %w", err)
-	}
+		// Handle 401 Unauthorized (token might be invalid)
+		if apiErr.StatusCode == http.StatusUnauthorized && c.useOAuth {
+			return fmt.Errorf("authentication failed: token may be invalid (try running 'eiscli auth login' again): %w", apiErr)
+		}
 
-	return result, nil
-}
+		// Name the OAuth scopes a login would have to grant
+		if c.useOAuth {
+			apiErr.MissingScopes = apiErr.missingScopes(c.grantedScopes())
+		}
 
-// doRequestWithBody performs an HTTP request with a JSON body
-func (c *RestClient) doRequestWithBody(method, path string, body interface{}) (map[string]interface{}, error) {
-	// Refresh token if needed (OAuth only)
-	if c.useOAuth {
-		if err := c.ensureValidToken(); err != nil {
-			return nil, err
+		return apiErr
+	}
+
+	if c.cache != nil {
+		if cacheGroup != "" {
+			c.cache.store(cacheGroup, url, resp.Header, respBody)
+		} else if method != http.MethodGet {
+			c.cache.invalidate(path)
 		}
 	}
 
-	url := c.baseURL + path
+	return decodeResult(respBody, result)
+}
 
-	jsonBody, err := json.Marshal(body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request body: %w", err)
+// decodeResult decodes a JSON response body into result. A nil result or an
+// empty body, e.g. of a 204 No Content response, decodes to nothing.
+func decodeResult(body []byte, result interface{}) error {
+	if result == nil || len(body) == 0 {
+		return nil
 	}
 
-	req, err := http.NewRequest(method, url, strings.NewReader(string(jsonBody)))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+	if err := json.Unmarshal(body, result); err != nil {
+		return fmt.Errorf("failed to parse JSON response: %w", err)
 	}
 
-	// Set authentication header
+	return nil
+}
+
+// setAuthHeader adds Basic Auth or the OAuth bearer token to a request
+func (c *RestClient) setAuthHeader(req *http.Request) {
 	if c.useOAuth {
-		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.tokenStore.AccessToken))
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.accessToken()))
 	} else {
 		req.SetBasicAuth(c.username, c.password)
 	}
-	req.Header.Set("Accept", "application/json")
-	req.Header.Set("Content-Type", "application/json")
+}
 
-	resp, err := c.client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to execute request: %w", err)
-	}
-	defer resp.Body.Close()
+// maxPipelinesPageLen is the largest page size the pipelines endpoint accepts
+const maxPipelinesPageLen = 100
 
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
+// ListPipelines fetches pipelines for a repository, following pagination until
+// opts.Limit pipelines have been collected. Filters are passed as query parameters;
+// opts.Since is applied client-side and stops pagination early.
+func (c *RestClient) ListPipelines(ctx context.Context, opts *PipelinesOptions) ([]*Pipeline, error) {
+	pageLen := opts.Limit
+	if pageLen <= 0 || pageLen > maxPipelinesPageLen {
+		pageLen = maxPipelinesPageLen
 	}
 
-	// Handle 401 Unauthorized (token might be invalid)
-	if resp.StatusCode == 401 && c.useOAuth {
-		return nil, fmt.Errorf("authentication failed: token may be invalid (try running 'eiscli auth login' again)")
+	sortOrder := opts.Sort
+	if sortOrder == "" {
+		sortOrder = "-created_on"
 	}
 
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(respBody))
+	query := url.Values{}
+	query.Set("pagelen", strconv.Itoa(pageLen))
+	query.Set("sort", sortOrder)
+	if opts.Branch != "" {
+		query.Set("target.branch", opts.Branch)
 	}
-
-	var result map[string]interface{}
-	if err := json.Unmarshal(respBody, &result); err != nil {
-		return nil, fmt.Errorf("failed to parse JSON response: %w", err)
+	if opts.Status != "" {
+		query.Set("status", opts.Status)
+	}
+	if opts.Trigger != "" {
+		query.Set("trigger_type", opts.Trigger)
+	}
+	if opts.CreatorUUID != "" {
+		query.Set("creator.uuid", opts.CreatorUUID)
 	}
 
-	return result, nil
-}
+	path := fmt.Sprintf("/repositories/%s/%s/pipelines?%s", c.workspace, opts.RepoSlug, query.Encode())
+
+	// Since only works as a cut-off when pipelines are sorted newest first
+	stopAtSince := !opts.Since.IsZero() && sortOrder == "-created_on"
+
+	pipelines := make([]*Pipeline, 0)
 
-// ListPipelines fetches pipelines for a repository
-func (c *RestClient) ListPipelines(repoSlug string, limit int) ([]*Pipeline, error) {
-	path := fmt.Sprintf("/repositories/%s/%s/pipelines?pagelen=%d&sort=-created_on",
-		c.workspace, repoSlug, limit)
+	err := paginate(ctx, c, path, func(data *apiPipeline) bool {
+		pipeline := data.toPipeline(c.webBaseURL())
 
-	data, err := c.doRequest("GET", path)
+		if !opts.Since.IsZero() && pipeline.CreatedOn.Before(opts.Since) {
+			// Older pipelines only follow when sorted newest first
+			return !stopAtSince
+		}
+
+		pipelines = append(pipelines, pipeline)
+		return opts.Limit <= 0 || len(pipelines) < opts.Limit
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	pipelines := make([]*Pipeline, 0)
+	return pipelines, nil
+}
 
-	if values, ok := data["values"].([]interface{}); ok {
-		for _, v := range values {
-			if pipelineData, ok := v.(map[string]interface{}); ok {
-				pipeline, err := parsePipeline(pipelineData)
-				if err != nil {
-					fmt.Printf("Warning: failed to parse pipeline: %v\n", err)
-					continue
-				}
-				pipelines = append(pipelines, pipeline)
-			}
+// TriggerPipeline starts a new pipeline run for a branch, commit and/or custom pipeline
+func (c *RestClient) TriggerPipeline(ctx context.Context, opts *TriggerPipelineOptions) (*Pipeline, error) {
+	path := fmt.Sprintf("/repositories/%s/%s/pipelines/", c.workspace, opts.RepoSlug)
+
+	target := map[string]interface{}{}
+	if opts.Branch != "" {
+		target["type"] = "pipeline_ref_target"
+		target["ref_type"] = "branch"
+		target["ref_name"] = opts.Branch
+	} else if opts.Tag != "" {
+		target["type"] = "pipeline_ref_target"
+		target["ref_type"] = "tag"
+		target["ref_name"] = opts.Tag
+	} else {
+		target["type"] = "pipeline_commit_target"
+	}
+
+	if opts.Commit != "" {
+		target["commit"] = map[string]interface{}{
+			"type": "commit",
+			"hash": opts.Commit,
 		}
 	}
 
-	return pipelines, nil
+	if opts.Custom != "" {
+		target["selector"] = map[string]interface{}{
+			"type":    "custom",
+			"pattern": opts.Custom,
+		}
+	}
+
+	requestBody := map[string]interface{}{
+		"target": target,
+	}
+
+	if len(opts.Variables) > 0 {
+		// Sort keys so the request body is deterministic
+		keys := make([]string, 0, len(opts.Variables))
+		for key := range opts.Variables {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		variables := make([]map[string]interface{}, 0, len(keys))
+		for _, key := range keys {
+			variables = append(variables, map[string]interface{}{
+				"key":   key,
+				"value": opts.Variables[key],
+			})
+		}
+		requestBody["variables"] = variables
+	}
+
+	var data apiPipeline
+	if err := c.doRequestWithBody(ctx, "POST", path, requestBody, &data); err != nil {
+		return nil, fmt.Errorf("failed to trigger pipeline: %w", err)
+	}
+
+	return data.toPipeline(c.webBaseURL()), nil
 }
 
 // ListPipelinesWithSteps fetches pipelines with their steps and log snippets
-func (c *RestClient) ListPipelinesWithSteps(repoSlug string, limit int, logLines int) ([]*Pipeline, error) {
-	pipelines, err := c.ListPipelines(repoSlug, limit)
+func (c *RestClient) ListPipelinesWithSteps(ctx context.Context, opts *PipelinesOptions, logLines int) ([]*Pipeline, error) {
+	repoSlug := opts.RepoSlug
+	pipelines, err := c.ListPipelines(ctx, opts)
 	if err != nil {
 		return nil, err
 	}
 
 	// Fetch steps for each pipeline
 	for _, pipeline := range pipelines {
-		steps, err := c.GetPipelineSteps(repoSlug, pipeline.UUID)
+		// Step errors are ignored below, so stop explicitly when canceled
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		steps, err := c.GetPipelineSteps(ctx, repoSlug, pipeline.UUID)
 		if err != nil {
 			// Don't fail the whole request if steps fail
 			continue
@@ -206,7 +375,7 @@ func (c *RestClient) ListPipelinesWithSteps(repoSlug string, limit int, logLines
 		for _, step := range steps {
 			// Fetch logs for all completed steps
 			if step.State == "COMPLETED" {
-				logSnippet, err := c.GetStepLog(repoSlug, pipeline.UUID, step.UUID, logLines)
+				logSnippet, err := c.GetStepLog(ctx, repoSlug, pipeline.UUID, step.UUID, logLines)
 				if err == nil && logSnippet != "" {
 					step.LogSnippet = logSnippet
 				}
@@ -220,184 +389,88 @@ func (c *RestClient) ListPipelinesWithSteps(repoSlug string, limit int, logLines
 }
 
 // ListRepositories fetches all repositories in the workspace
-func (c *RestClient) ListRepositories() ([]*Repository, error) {
+func (c *RestClient) ListRepositories(ctx context.Context) ([]*Repository, error) {
 	path := fmt.Sprintf("/repositories/%s?pagelen=100", c.workspace)
 
 	repositories := make([]*Repository, 0)
-
-	for path != "" {
-		data, err := c.doRequest("GET", path)
-		if err != nil {
-			return nil, err
-		}
-
-		if values, ok := data["values"].([]interface{}); ok {
-			for _, v := range values {
-				if repoData, ok := v.(map[string]interface{}); ok {
-					repo := &Repository{}
-
-					if slug, ok := repoData["slug"].(string); ok {
-						repo.Slug = slug
-					}
-					if name, ok := repoData["name"].(string); ok {
-						repo.Name = name
-					}
-					if desc, ok := repoData["description"].(string); ok {
-						repo.Description = desc
-					}
-					if fullName, ok := repoData["full_name"].(string); ok {
-						repo.FullName = fullName
-					}
-
-					repositories = append(repositories, repo)
-				}
-			}
-		}
-
-		// Check for next page
-		if next, ok := data["next"].(string); ok && next != "" {
-			if strings.Contains(next, "/2.0") {
-				path = strings.SplitN(next, "/2.0", 2)[1]
-			} else {
-				path = ""
-			}
-		} else {
-			path = ""
-		}
+	err := paginate(ctx, c, path, func(data *apiRepository) bool {
+		repositories = append(repositories, data.toRepository())
+		return true
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return repositories, nil
 }
 
 // ListProjects fetches all projects in the workspace
-func (c *RestClient) ListProjects() ([]map[string]interface{}, error) {
+func (c *RestClient) ListProjects(ctx context.Context) ([]*Project, error) {
 	path := fmt.Sprintf("/workspaces/%s/projects?pagelen=100", c.workspace)
 
-	projects := make([]map[string]interface{}, 0)
-
-	for path != "" {
-		data, err := c.doRequest("GET", path)
-		if err != nil {
-			return nil, err
-		}
-
-		if values, ok := data["values"].([]interface{}); ok {
-			for _, v := range values {
-				if projectData, ok := v.(map[string]interface{}); ok {
-					projects = append(projects, projectData)
-				}
-			}
-		}
-
-		// Check for next page
-		if next, ok := data["next"].(string); ok && next != "" {
-			if strings.Contains(next, "/2.0") {
-				path = strings.SplitN(next, "/2.0", 2)[1]
-			} else {
-				path = ""
-			}
-		} else {
-			path = ""
-		}
-	}
-
-	return projects, nil
+	return listAll[*Project](ctx, c, path)
 }
 
 // GetPipelineSteps fetches steps for a specific pipeline
-func (c *RestClient) GetPipelineSteps(repoSlug, pipelineUUID string) ([]*PipelineStep, error) {
+func (c *RestClient) GetPipelineSteps(ctx context.Context, repoSlug, pipelineUUID string) ([]*PipelineStep, error) {
 	path := fmt.Sprintf("/repositories/%s/%s/pipelines/%s/steps/?pagelen=100",
 		c.workspace, repoSlug, pipelineUUID)
 
 	steps := make([]*PipelineStep, 0)
+	err := paginate(ctx, c, path, func(data *apiPipelineStep) bool {
+		steps = append(steps, data.toPipelineStep())
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
 
-	for path != "" {
-		data, err := c.doRequest("GET", path)
-		if err != nil {
-			return nil, err
-		}
+	return steps, nil
+}
 
-		if values, ok := data["values"].([]interface{}); ok {
-			for _, v := range values {
-				if stepData, ok := v.(map[string]interface{}); ok {
-					step := &PipelineStep{}
-
-					if uuid, ok := stepData["uuid"].(string); ok {
-						step.UUID = uuid
-					}
-					if name, ok := stepData["name"].(string); ok {
-						step.Name = name
-					}
-					if stateData, ok := stepData["state"].(map[string]interface{}); ok {
-						if stateName, ok := stateData["name"].(string); ok {
-							step.State = stateName
-						}
-						if resultData, ok := stateData["result"].(map[string]interface{}); ok {
-							if resultName, ok := resultData["name"].(string); ok {
-								step.Result = resultName
-							}
-						}
-					}
-					if durationMs, ok := stepData["duration_in_seconds"].(float64); ok {
-						step.DurationSec = int(durationMs)
-					}
-
-					steps = append(steps, step)
-				}
-			}
-		}
+// GetPipeline fetches a single pipeline by UUID or build number
+func (c *RestClient) GetPipeline(ctx context.Context, repoSlug, pipelineID string) (*Pipeline, error) {
+	path := fmt.Sprintf("/repositories/%s/%s/pipelines/%s", c.workspace, repoSlug, pipelineID)
 
-		// Check for next page
-		if next, ok := data["next"].(string); ok && next != "" {
-			if strings.Contains(next, "/2.0") {
-				path = strings.SplitN(next, "/2.0", 2)[1]
-			} else {
-				path = ""
-			}
-		} else {
-			path = ""
-		}
+	var data apiPipeline
+	if err := c.doRequest(ctx, "GET", path, &data); err != nil {
+		return nil, fmt.Errorf("failed to get pipeline: %w", err)
 	}
 
-	return steps, nil
+	return data.toPipeline(c.webBaseURL()), nil
 }
 
-// GetStepLog fetches the last N lines of a step's log
-func (c *RestClient) GetStepLog(repoSlug, pipelineUUID, stepUUID string, lines int) (string, error) {
+// StopPipeline stops a running pipeline
+func (c *RestClient) StopPipeline(ctx context.Context, repoSlug, pipelineUUID string) error {
+	path := fmt.Sprintf("/repositories/%s/%s/pipelines/%s/stopPipeline", c.workspace, repoSlug, pipelineUUID)
+
+	if err := c.doRequestWithBody(ctx, "POST", path, map[string]interface{}{}, nil); err != nil {
+		return fmt.Errorf("failed to stop pipeline: %w", err)
+	}
+
+	return nil
+}
+
+// fetchStepLog requests a log of a step with the given Range header (empty for the whole log).
+// logUUID is the step UUID for the main build container or a service container UUID.
+// It returns the HTTP status code and the response body.
+func (c *RestClient) fetchStepLog(ctx context.Context, repoSlug, pipelineUUID, stepUUID, logUUID, rangeHeader string) (int, []byte, error) {
 	// Refresh token if needed (OAuth only)
 	if c.useOAuth {
 		if err := c.ensureValidToken(); err != nil {
-			return "", err
+			return 0, nil, err
 		}
 	}
 
-	// Use the /logs/{log_uuid} endpoint where log_uuid is the step UUID for main container
 	path := fmt.Sprintf("/repositories/%s/%s/pipelines/%s/steps/%s/logs/%s",
-		c.workspace, repoSlug, pipelineUUID, stepUUID, stepUUID)
+		c.workspace, repoSlug, pipelineUUID, stepUUID, logUUID)
 
 	url := c.baseURL + path
 
-	// Estimate bytes needed: ~100 bytes per line
-	bytesToFetch := lines * 100
-	rangeHeader := fmt.Sprintf("bytes=-%d", bytesToFetch)
-
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
-	}
-
-	// Set authentication header
-	if c.useOAuth {
-		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.tokenStore.AccessToken))
-	} else {
-		req.SetBasicAuth(c.username, c.password)
-	}
-	// Don't set Accept header - server returns application/octet-stream
-	req.Header.Set("Range", rangeHeader)
-
 	// Create a client that follows redirects (307 to long-term storage)
 	client := &http.Client{
-		Timeout: 30 * time.Second,
+		Timeout:   30 * time.Second,
+		Transport: c.client.Transport,
 		CheckRedirect: func(req *http.Request, via []*http.Request) error {
 			// Allow up to 10 redirects
 			if len(via) >= 10 {
@@ -408,21 +481,67 @@ func (c *RestClient) GetStepLog(repoSlug, pipelineUUID, stepUUID string, lines i
 		},
 	}
 
-	resp, err := client.Do(req)
+	resp, body, err := c.send(ctx, client, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+
+		c.setAuthHeader(req)
+		// Don't set Accept header - server returns application/octet-stream
+		if rangeHeader != "" {
+			req.Header.Set("Range", rangeHeader)
+		}
+		return req, nil
+	})
 	if err != nil {
-		return "", fmt.Errorf("failed to execute request: %w", err)
+		if resp != nil {
+			return resp.StatusCode, nil, fmt.Errorf("failed to read response body: %w", err)
+		}
+		return 0, nil, fmt.Errorf("failed to execute request: %w", err)
 	}
-	defer resp.Body.Close()
 
-	// Accept 200 (full content) or 206 (partial content from Range)
-	if resp.StatusCode != 200 && resp.StatusCode != 206 {
-		// Return empty string if log is not available
-		return "", nil
+	return resp.StatusCode, body, nil
+}
+
+// GetStepLogFrom fetches the part of a step's log starting at the given byte offset.
+// It returns no data (and no error) while the log is not available yet or has no new bytes.
+func (c *RestClient) GetStepLogFrom(ctx context.Context, repoSlug, pipelineUUID, stepUUID string, offset int64) ([]byte, error) {
+	status, body, err := c.fetchStepLog(ctx, repoSlug, pipelineUUID, stepUUID, stepUUID, fmt.Sprintf("bytes=%d-", offset))
+	if err != nil {
+		return nil, err
 	}
 
-	body, err := io.ReadAll(resp.Body)
+	switch status {
+	case http.StatusPartialContent:
+		return body, nil
+	case http.StatusOK:
+		// Range was ignored, the full log was returned
+		if int64(len(body)) <= offset {
+			return nil, nil
+		}
+		return body[offset:], nil
+	case http.StatusNotFound, http.StatusRequestedRangeNotSatisfiable:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("failed to fetch step log: status %d: %s", status, string(body))
+	}
+}
+
+// GetStepLog fetches the last N lines of a step's log
+func (c *RestClient) GetStepLog(ctx context.Context, repoSlug, pipelineUUID, stepUUID string, lines int) (string, error) {
+	// Estimate bytes needed: ~100 bytes per line
+	bytesToFetch := lines * 100
+	rangeHeader := fmt.Sprintf("bytes=-%d", bytesToFetch)
+
+	status, body, err := c.fetchStepLog(ctx, repoSlug, pipelineUUID, stepUUID, stepUUID, rangeHeader)
 	if err != nil {
-		return "", fmt.Errorf("failed to read response body: %w", err)
+		return "", err
+	}
+
+	// Accept 200 (full content) or 206 (partial content from Range)
+	if status != http.StatusOK && status != http.StatusPartialContent {
+		return "", fmt.Errorf("failed to fetch step log: status %d: %s", status, string(body))
 	}
 
 	// Get last N lines
@@ -446,45 +565,156 @@ func (c *RestClient) GetStepLog(repoSlug, pipelineUUID, stepUUID string, lines i
 	return strings.Join(lastLines, "\n"), nil
 }
 
-// ListRepositoryVariables fetches repository-level pipeline variables with pagination
-func (c *RestClient) ListRepositoryVariables(repoSlug string) ([]map[string]interface{}, error) {
-	path := fmt.Sprintf("/repositories/%s/%s/pipelines_config/variables/?pagelen=100",
-		c.workspace, repoSlug)
+// GetFullStepLog fetches the complete log of a step's build container or service container.
+// logUUID is the step UUID for the build container or the service container UUID.
+func (c *RestClient) GetFullStepLog(ctx context.Context, repoSlug, pipelineUUID, stepUUID, logUUID string) ([]byte, error) {
+	status, body, err := c.fetchStepLog(ctx, repoSlug, pipelineUUID, stepUUID, logUUID, "")
+	if err != nil {
+		return nil, err
+	}
 
-	variables := make([]map[string]interface{}, 0)
+	if status != http.StatusOK && status != http.StatusPartialContent {
+		return nil, fmt.Errorf("failed to fetch log: status %d: %s", status, string(body))
+	}
 
-	for path != "" {
-		data, err := c.doRequest("GET", path)
-		if err != nil {
-			return nil, err
-		}
+	return body, nil
+}
 
-		if values, ok := data["values"].([]interface{}); ok {
-			for _, v := range values {
-				if varData, ok := v.(map[string]interface{}); ok {
-					variables = append(variables, varData)
-				}
-			}
-		}
+// GetStepTestReport fetches the test report summary of a pipeline step.
+// Returns nil if the step has no test report (404 response)
+func (c *RestClient) GetStepTestReport(ctx context.Context, repoSlug, pipelineUUID, stepUUID string) (*TestReportSummary, error) {
+	path := fmt.Sprintf("/repositories/%s/%s/pipelines/%s/steps/%s/test_reports",
+		c.workspace, repoSlug, pipelineUUID, stepUUID)
 
-		// Check for next page
-		if next, ok := data["next"].(string); ok && next != "" {
-			// Extract path from full URL
-			if strings.Contains(next, "/2.0") {
-				path = strings.SplitN(next, "/2.0", 2)[1]
-			} else {
-				path = ""
-			}
-		} else {
-			path = ""
+	var data apiTestReport
+	if err := c.doRequest(ctx, "GET", path, &data); err != nil {
+		// Check if it's a 404 (step has no test report)
+		if errors.Is(err, ErrNotFound) {
+			return nil, nil
 		}
+		return nil, fmt.Errorf("failed to get test report: %w", err)
+	}
+
+	return data.toSummary(), nil
+}
+
+// ListStepTestCases fetches the test cases of a pipeline step's test report with pagination
+func (c *RestClient) ListStepTestCases(ctx context.Context, repoSlug, pipelineUUID, stepUUID string) ([]*TestCase, error) {
+	path := fmt.Sprintf("/repositories/%s/%s/pipelines/%s/steps/%s/test_reports/test_cases?pagelen=100",
+		c.workspace, repoSlug, pipelineUUID, stepUUID)
+
+	testCases, err := listAll[*TestCase](ctx, c, path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list test cases: %w", err)
+	}
+
+	for _, testCase := range testCases {
+		testCase.Status = strings.ToUpper(testCase.Status)
+	}
+
+	return testCases, nil
+}
+
+// GetTestCaseReasons fetches the failure reasons (messages and stack traces) of a test case
+// and returns their messages joined by newlines
+func (c *RestClient) GetTestCaseReasons(ctx context.Context, repoSlug, pipelineUUID, stepUUID, testCaseUUID string) (string, error) {
+	path := fmt.Sprintf("/repositories/%s/%s/pipelines/%s/steps/%s/test_reports/test_cases/%s/test_case_reasons",
+		c.workspace, repoSlug, pipelineUUID, stepUUID, testCaseUUID)
+
+	var data apiTestCaseReasons
+	if err := c.doRequest(ctx, "GET", path, &data); err != nil {
+		return "", fmt.Errorf("failed to get test case reasons: %w", err)
+	}
+
+	return data.messages(), nil
+}
+
+// ListPipelineSchedules fetches the pipeline schedules of a repository with pagination
+func (c *RestClient) ListPipelineSchedules(ctx context.Context, repoSlug string) ([]*PipelineSchedule, error) {
+	path := fmt.Sprintf("/repositories/%s/%s/pipelines_config/schedules/?pagelen=100",
+		c.workspace, repoSlug)
+
+	schedules := make([]*PipelineSchedule, 0)
+	err := paginate(ctx, c, path, func(data *apiPipelineSchedule) bool {
+		schedules = append(schedules, data.toPipelineSchedule())
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pipeline schedules: %w", err)
+	}
+
+	return schedules, nil
+}
+
+// CreatePipelineSchedule creates a schedule running a custom pipeline on a branch.
+// cronPattern must be a 7-field Quartz expression.
+func (c *RestClient) CreatePipelineSchedule(ctx context.Context, repoSlug, branch, selector, cronPattern string) (*PipelineSchedule, error) {
+	path := fmt.Sprintf("/repositories/%s/%s/pipelines_config/schedules/",
+		c.workspace, repoSlug)
+
+	requestBody := map[string]interface{}{
+		"type":    "pipeline_schedule",
+		"enabled": true,
+		"target": map[string]interface{}{
+			"type":     "pipeline_ref_target",
+			"ref_type": "branch",
+			"ref_name": branch,
+			"selector": map[string]interface{}{
+				"type":    "custom",
+				"pattern": selector,
+			},
+		},
+		"cron_pattern": cronPattern,
 	}
 
-	return variables, nil
+	var data apiPipelineSchedule
+	if err := c.doRequestWithBody(ctx, "POST", path, requestBody, &data); err != nil {
+		return nil, fmt.Errorf("failed to create pipeline schedule: %w", err)
+	}
+
+	return data.toPipelineSchedule(), nil
+}
+
+// UpdatePipelineSchedule enables or disables a pipeline schedule
+func (c *RestClient) UpdatePipelineSchedule(ctx context.Context, repoSlug, scheduleUUID string, enabled bool) (*PipelineSchedule, error) {
+	path := fmt.Sprintf("/repositories/%s/%s/pipelines_config/schedules/%s",
+		c.workspace, repoSlug, scheduleUUID)
+
+	requestBody := map[string]interface{}{
+		"type":    "pipeline_schedule",
+		"enabled": enabled,
+	}
+
+	var data apiPipelineSchedule
+	if err := c.doRequestWithBody(ctx, "PUT", path, requestBody, &data); err != nil {
+		return nil, fmt.Errorf("failed to update pipeline schedule: %w", err)
+	}
+
+	return data.toPipelineSchedule(), nil
+}
+
+// DeletePipelineSchedule deletes a pipeline schedule
+func (c *RestClient) DeletePipelineSchedule(ctx context.Context, repoSlug, scheduleUUID string) error {
+	path := fmt.Sprintf("/repositories/%s/%s/pipelines_config/schedules/%s",
+		c.workspace, repoSlug, scheduleUUID)
+
+	if err := c.doRequest(ctx, "DELETE", path, nil); err != nil {
+		return fmt.Errorf("failed to delete pipeline schedule: %w", err)
+	}
+
+	return nil
+}
+
+// ListRepositoryVariables fetches repository-level pipeline variables with pagination
+func (c *RestClient) ListRepositoryVariables(ctx context.Context, repoSlug string) ([]*Variable, error) {
+	path := fmt.Sprintf("/repositories/%s/%s/pipelines_config/variables/?pagelen=100",
+		c.workspace, repoSlug)
+
+	return listAll[*Variable](ctx, c, path)
 }
 
 // CreateRepositoryVariable creates a new repository-level pipeline variable
-func (c *RestClient) CreateRepositoryVariable(repoSlug, key, value string, secured bool) error {
+func (c *RestClient) CreateRepositoryVariable(ctx context.Context, repoSlug, key, value string, secured bool) error {
 	path := fmt.Sprintf("/repositories/%s/%s/pipelines_config/variables/",
 		c.workspace, repoSlug)
 
@@ -494,91 +724,90 @@ func (c *RestClient) CreateRepositoryVariable(repoSlug, key, value string, secur
 		"secured": secured,
 	}
 
-	_, err := c.doRequestWithBody("POST", path, requestBody)
-	if err != nil {
+	if err := c.doRequestWithBody(ctx, "POST", path, requestBody, nil); err != nil {
 		return fmt.Errorf("failed to create repository variable: %w", err)
 	}
 
 	return nil
 }
 
-// ListDeploymentEnvironments fetches all deployment environments for a repository
-func (c *RestClient) ListDeploymentEnvironments(repoSlug string) ([]map[string]interface{}, error) {
-	path := fmt.Sprintf("/repositories/%s/%s/environments/?pagelen=100",
-		c.workspace, repoSlug)
+// UpdateRepositoryVariable updates an existing repository-level pipeline variable
+func (c *RestClient) UpdateRepositoryVariable(ctx context.Context, repoSlug, uuid, key, value string, secured bool) error {
+	path := fmt.Sprintf("/repositories/%s/%s/pipelines_config/variables/%s",
+		c.workspace, repoSlug, uuid)
+
+	requestBody := map[string]interface{}{
+		"key":     key,
+		"value":   value,
+		"secured": secured,
+	}
 
-	environments := make([]map[string]interface{}, 0)
+	if err := c.doRequestWithBody(ctx, "PUT", path, requestBody, nil); err != nil {
+		return fmt.Errorf("failed to update repository variable: %w", err)
+	}
 
-	for path != "" {
-		data, err := c.doRequest("GET", path)
-		if err != nil {
-			return nil, err
-		}
+	return nil
+}
 
-		if values, ok := data["values"].([]interface{}); ok {
-			for _, v := range values {
-				if envData, ok := v.(map[string]interface{}); ok {
-					environments = append(environments, envData)
-				}
-			}
-		}
+// DeleteRepositoryVariable deletes a repository-level pipeline variable
+func (c *RestClient) DeleteRepositoryVariable(ctx context.Context, repoSlug, uuid string) error {
+	path := fmt.Sprintf("/repositories/%s/%s/pipelines_config/variables/%s",
+		c.workspace, repoSlug, uuid)
 
-		// Check for next page
-		if next, ok := data["next"].(string); ok && next != "" {
-			if strings.Contains(next, "/2.0") {
-				path = strings.SplitN(next, "/2.0", 2)[1]
-			} else {
-				path = ""
-			}
-		} else {
-			path = ""
-		}
+	if err := c.doRequest(ctx, "DELETE", path, nil); err != nil {
+		return fmt.Errorf("failed to delete repository variable: %w", err)
+	}
+
+	return nil
+}
+
+// ListDeploymentEnvironments fetches all deployment environments for a repository
+func (c *RestClient) ListDeploymentEnvironments(ctx context.Context, repoSlug string) ([]*Environment, error) {
+	path := fmt.Sprintf("/repositories/%s/%s/environments/?pagelen=100",
+		c.workspace, repoSlug)
+
+	environments := make([]*Environment, 0)
+	err := paginate(ctx, c, path, func(data *apiEnvironment) bool {
+		environments = append(environments, data.toEnvironment())
+		return true
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return environments, nil
 }
 
 // ListDeploymentVariables fetches deployment variables for a specific environment with pagination
-func (c *RestClient) ListDeploymentVariables(repoSlug, environmentUUID string) ([]map[string]interface{}, error) {
+func (c *RestClient) ListDeploymentVariables(ctx context.Context, repoSlug, environmentUUID string) ([]*Variable, error) {
 	path := fmt.Sprintf("/repositories/%s/%s/deployments_config/environments/%s/variables?pagelen=100",
 		c.workspace, repoSlug, environmentUUID)
 
-	variables := make([]map[string]interface{}, 0)
+	return listAll[*Variable](ctx, c, path)
+}
 
-	for path != "" {
-		data, err := c.doRequest("GET", path)
-		if err != nil {
-			return nil, err
-		}
+// CreateDeploymentVariable creates a new deployment variable for a specific environment
+func (c *RestClient) CreateDeploymentVariable(ctx context.Context, repoSlug, environmentUUID, key, value string, secured bool) error {
+	path := fmt.Sprintf("/repositories/%s/%s/deployments_config/environments/%s/variables",
+		c.workspace, repoSlug, environmentUUID)
 
-		if values, ok := data["values"].([]interface{}); ok {
-			for _, v := range values {
-				if varData, ok := v.(map[string]interface{}); ok {
-					variables = append(variables, varData)
-				}
-			}
-		}
+	requestBody := map[string]interface{}{
+		"key":     key,
+		"value":   value,
+		"secured": secured,
+	}
 
-		// Check for next page
-		if next, ok := data["next"].(string); ok && next != "" {
-			// Extract path from full URL
-			if strings.Contains(next, "/2.0") {
-				path = strings.SplitN(next, "/2.0", 2)[1]
-			} else {
-				path = ""
-			}
-		} else {
-			path = ""
-		}
+	if err := c.doRequestWithBody(ctx, "POST", path, requestBody, nil); err != nil {
+		return fmt.Errorf("failed to create deployment variable: %w", err)
 	}
 
-	return variables, nil
+	return nil
 }
 
-// CreateDeploymentVariable creates a new deployment variable for a specific environment
-func (c *RestClient) CreateDeploymentVariable(repoSlug, environmentUUID, key, value string, secured bool) error {
-	path := fmt.Sprintf("/repositories/%s/%s/deployments_config/environments/%s/variables",
-		c.workspace, repoSlug, environmentUUID)
+// UpdateDeploymentVariable updates an existing deployment variable of a specific environment
+func (c *RestClient) UpdateDeploymentVariable(ctx context.Context, repoSlug, environmentUUID, uuid, key, value string, secured bool) error {
+	path := fmt.Sprintf("/repositories/%s/%s/deployments_config/environments/%s/variables/%s",
+		c.workspace, repoSlug, environmentUUID, uuid)
 
 	requestBody := map[string]interface{}{
 		"key":     key,
@@ -586,16 +815,27 @@ func (c *RestClient) CreateDeploymentVariable(repoSlug, environmentUUID, key, va
 		"secured": secured,
 	}
 
-	_, err := c.doRequestWithBody("POST", path, requestBody)
-	if err != nil {
-		return fmt.Errorf("failed to create deployment variable: %w", err)
+	if err := c.doRequestWithBody(ctx, "PUT", path, requestBody, nil); err != nil {
+		return fmt.Errorf("failed to update deployment variable: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteDeploymentVariable deletes a deployment variable of a specific environment
+func (c *RestClient) DeleteDeploymentVariable(ctx context.Context, repoSlug, environmentUUID, uuid string) error {
+	path := fmt.Sprintf("/repositories/%s/%s/deployments_config/environments/%s/variables/%s",
+		c.workspace, repoSlug, environmentUUID, uuid)
+
+	if err := c.doRequest(ctx, "DELETE", path, nil); err != nil {
+		return fmt.Errorf("failed to delete deployment variable: %w", err)
 	}
 
 	return nil
 }
 
 // CreateDeploymentEnvironment creates a new deployment environment for a repository
-func (c *RestClient) CreateDeploymentEnvironment(repoSlug, envName, envType string, rank int) (map[string]interface{}, error) {
+func (c *RestClient) CreateDeploymentEnvironment(ctx context.Context, repoSlug, envName, envType string, rank int) (*Environment, error) {
 	path := fmt.Sprintf("/repositories/%s/%s/environments/",
 		c.workspace, repoSlug)
 
@@ -607,63 +847,35 @@ func (c *RestClient) CreateDeploymentEnvironment(repoSlug, envName, envType stri
 		},
 	}
 
-	data, err := c.doRequestWithBody("POST", path, requestBody)
-	if err != nil {
+	var data apiEnvironment
+	if err := c.doRequestWithBody(ctx, "POST", path, requestBody, &data); err != nil {
 		return nil, fmt.Errorf("failed to create deployment environment: %w", err)
 	}
 
-	return data, nil
+	return data.toEnvironment(), nil
 }
 
 // ListWorkspaceVariables fetches workspace-level pipeline variables
-func (c *RestClient) ListWorkspaceVariables() ([]map[string]interface{}, error) {
+func (c *RestClient) ListWorkspaceVariables(ctx context.Context) ([]*Variable, error) {
 	path := fmt.Sprintf("/workspaces/%s/pipelines-config/variables?pagelen=100", c.workspace)
 
-	variables := make([]map[string]interface{}, 0)
-
-	for path != "" {
-		data, err := c.doRequest("GET", path)
-		if err != nil {
-			return nil, err
-		}
-
-		if values, ok := data["values"].([]interface{}); ok {
-			for _, v := range values {
-				if varData, ok := v.(map[string]interface{}); ok {
-					variables = append(variables, varData)
-				}
-			}
-		}
-
-		// Check for next page
-		if next, ok := data["next"].(string); ok && next != "" {
-			if strings.Contains(next, "/2.0") {
-				path = strings.SplitN(next, "/2.0", 2)[1]
-			} else {
-				path = ""
-			}
-		} else {
-			path = ""
-		}
-	}
-
-	return variables, nil
+	return listAll[*Variable](ctx, c, path)
 }
 
 // GetWorkspaceVariable fetches a specific workspace variable by UUID
-func (c *RestClient) GetWorkspaceVariable(uuid string) (map[string]interface{}, error) {
+func (c *RestClient) GetWorkspaceVariable(ctx context.Context, uuid string) (*Variable, error) {
 	path := fmt.Sprintf("/workspaces/%s/pipelines-config/variables/%s", c.workspace, uuid)
 
-	data, err := c.doRequest("GET", path)
-	if err != nil {
+	variable := &Variable{}
+	if err := c.doRequest(ctx, "GET", path, variable); err != nil {
 		return nil, err
 	}
 
-	return data, nil
+	return variable, nil
 }
 
 // CreateWorkspaceVariable creates a new workspace-level pipeline variable
-func (c *RestClient) CreateWorkspaceVariable(key, value string, secured bool) (map[string]interface{}, error) {
+func (c *RestClient) CreateWorkspaceVariable(ctx context.Context, key, value string, secured bool) (*Variable, error) {
 	path := fmt.Sprintf("/workspaces/%s/pipelines-config/variables", c.workspace)
 
 	requestBody := map[string]interface{}{
@@ -672,16 +884,16 @@ func (c *RestClient) CreateWorkspaceVariable(key, value string, secured bool) (m
 		"secured": secured,
 	}
 
-	data, err := c.doRequestWithBody("POST", path, requestBody)
-	if err != nil {
+	variable := &Variable{}
+	if err := c.doRequestWithBody(ctx, "POST", path, requestBody, variable); err != nil {
 		return nil, fmt.Errorf("failed to create workspace variable: %w", err)
 	}
 
-	return data, nil
+	return variable, nil
 }
 
 // UpdateWorkspaceVariable updates an existing workspace-level pipeline variable
-func (c *RestClient) UpdateWorkspaceVariable(uuid, key, value string, secured bool) (map[string]interface{}, error) {
+func (c *RestClient) UpdateWorkspaceVariable(ctx context.Context, uuid, key, value string, secured bool) (*Variable, error) {
 	path := fmt.Sprintf("/workspaces/%s/pipelines-config/variables/%s", c.workspace, uuid)
 
 	requestBody := map[string]interface{}{
@@ -690,310 +902,212 @@ func (c *RestClient) UpdateWorkspaceVariable(uuid, key, value string, secured bo
 		"secured": secured,
 	}
 
-	data, err := c.doRequestWithBody("PUT", path, requestBody)
-	if err != nil {
+	variable := &Variable{}
+	if err := c.doRequestWithBody(ctx, "PUT", path, requestBody, variable); err != nil {
 		return nil, fmt.Errorf("failed to update workspace variable: %w", err)
 	}
 
-	return data, nil
+	return variable, nil
 }
 
-// GetDefaultReviewers fetches the default reviewers configured for a repository
-func (c *RestClient) GetDefaultReviewers(repoSlug string) ([]map[string]interface{}, error) {
-	path := fmt.Sprintf("/repositories/%s/%s/default-reviewers?pagelen=100", c.workspace, repoSlug)
+// DeleteWorkspaceVariable deletes a workspace-level pipeline variable
+func (c *RestClient) DeleteWorkspaceVariable(ctx context.Context, uuid string) error {
+	path := fmt.Sprintf("/workspaces/%s/pipelines-config/variables/%s", c.workspace, uuid)
 
-	reviewers := make([]map[string]interface{}, 0)
+	if err := c.doRequest(ctx, "DELETE", path, nil); err != nil {
+		return fmt.Errorf("failed to delete workspace variable: %w", err)
+	}
 
-	for path != "" {
-		data, err := c.doRequest("GET", path)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get default reviewers: %w", err)
-		}
+	return nil
+}
 
-		if values, ok := data["values"].([]interface{}); ok {
-			for _, v := range values {
-				if userData, ok := v.(map[string]interface{}); ok {
-					reviewers = append(reviewers, userData)
-				}
-			}
-		}
+// GetDefaultReviewers fetches the default reviewers configured for a repository
+func (c *RestClient) GetDefaultReviewers(ctx context.Context, repoSlug string) ([]*UserInfo, error) {
+	path := fmt.Sprintf("/repositories/%s/%s/default-reviewers?pagelen=100", c.workspace, repoSlug)
 
-		// Check for next page
-		if next, ok := data["next"].(string); ok && next != "" {
-			if strings.Contains(next, "/2.0") {
-				path = strings.SplitN(next, "/2.0", 2)[1]
-			} else {
-				path = ""
-			}
-		} else {
-			path = ""
-		}
+	reviewers := make([]*UserInfo, 0)
+	err := paginate(ctx, c, path, func(data *apiUser) bool {
+		reviewers = append(reviewers, data.toUserInfo())
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get default reviewers: %w", err)
 	}
 
 	return reviewers, nil
 }
 
 // CreatePullRequest creates a new pull request
-func (c *RestClient) CreatePullRequest(repoSlug, sourceBranch, destBranch, title, description string) (*PullRequest, error) {
+func (c *RestClient) CreatePullRequest(ctx context.Context, repoSlug string, opts *CreatePullRequestOptions) (*PullRequest, error) {
 	path := fmt.Sprintf("/repositories/%s/%s/pullrequests", c.workspace, repoSlug)
 
 	requestBody := map[string]interface{}{
-		"title":       title,
-		"description": description,
+		"title":       opts.Title,
+		"description": opts.Description,
 		"source": map[string]interface{}{
 			"branch": map[string]interface{}{
-				"name": sourceBranch,
+				"name": opts.SourceBranch,
 			},
 		},
 		"destination": map[string]interface{}{
 			"branch": map[string]interface{}{
-				"name": destBranch,
+				"name": opts.DestinationBranch,
 			},
 		},
 	}
+	if opts.Draft {
+		requestBody["draft"] = true
+	}
+
+	// Fetch default reviewers and include them together with the requested
+	// ones, excluding the current user
+	reviewerUUIDs := make([]string, 0, len(opts.Reviewers))
+	if defaultReviewers, err := c.GetDefaultReviewers(ctx, repoSlug); err == nil {
+		for _, reviewer := range defaultReviewers {
+			reviewerUUIDs = append(reviewerUUIDs, reviewer.UUID)
+		}
+	}
+	reviewerUUIDs = append(reviewerUUIDs, opts.Reviewers...)
 
-	// Fetch default reviewers and include them, excluding the current user
-	defaultReviewers, err := c.GetDefaultReviewers(repoSlug)
-	if err == nil && len(defaultReviewers) > 0 {
+	if len(reviewerUUIDs) > 0 {
 		// Get current user to exclude from reviewers (author can't be a reviewer)
 		var currentUserUUID string
-		if currentUser, err := c.GetCurrentUser(); err == nil {
+		if currentUser, err := c.GetCurrentUser(ctx); err == nil {
 			currentUserUUID = currentUser.UUID
 		}
 
-		reviewers := make([]map[string]interface{}, 0, len(defaultReviewers))
-		for _, reviewer := range defaultReviewers {
-			if uuid, ok := reviewer["uuid"].(string); ok && uuid != "" {
-				if uuid == currentUserUUID {
-					continue
-				}
-				reviewers = append(reviewers, map[string]interface{}{
-					"uuid": uuid,
-				})
+		reviewers := make([]map[string]interface{}, 0, len(reviewerUUIDs))
+		seen := make(map[string]bool, len(reviewerUUIDs))
+		for _, uuid := range reviewerUUIDs {
+			if uuid == "" || uuid == currentUserUUID || seen[uuid] {
+				continue
 			}
+			seen[uuid] = true
+			reviewers = append(reviewers, map[string]interface{}{
+				"uuid": uuid,
+			})
 		}
 		if len(reviewers) > 0 {
 			requestBody["reviewers"] = reviewers
 		}
 	}
 
-	data, err := c.doRequestWithBody("POST", path, requestBody)
-	if err != nil {
+	var data apiPullRequest
+	if err := c.doRequestWithBody(ctx, "POST", path, requestBody, &data); err != nil {
 		return nil, fmt.Errorf("failed to create pull request: %w", err)
 	}
 
-	return parsePullRequest(data, c.workspace, repoSlug)
+	return data.toPullRequest(c.webBaseURL(), c.workspace, repoSlug), nil
 }
 
-// ListPullRequests fetches pull requests for a repository
-func (c *RestClient) ListPullRequests(repoSlug string, state string, limit int, author string, authorEmail string) ([]*PullRequest, error) {
-	path := fmt.Sprintf("/repositories/%s/%s/pullrequests?pagelen=%d", c.workspace, repoSlug, limit)
-	if state != "" {
-		path += fmt.Sprintf("&state=%s", state)
-	}
+// ListWorkspaceMembers fetches the users that are members of the workspace
+func (c *RestClient) ListWorkspaceMembers(ctx context.Context) ([]*UserInfo, error) {
+	path := fmt.Sprintf("/workspaces/%s/members?pagelen=100", c.workspace)
 
-	data, err := c.doRequest("GET", path)
+	members := make([]*UserInfo, 0)
+	err := paginate(ctx, c, path, func(data *apiWorkspaceMembership) bool {
+		if data.User != nil {
+			members = append(members, data.User.toUserInfo())
+		}
+		return true
+	})
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to get workspace members: %w", err)
 	}
 
-	pullRequests := make([]*PullRequest, 0)
+	return members, nil
+}
 
-	if values, ok := data["values"].([]interface{}); ok {
-		for _, v := range values {
-			if prData, ok := v.(map[string]interface{}); ok {
-				// Filter by author if specified (check raw data before parsing)
-				if author != "" || authorEmail != "" {
-					found := false
-					if authorData, ok := prData["author"].(map[string]interface{}); ok {
-						// Check UUID or username if author is provided
-						if author != "" {
-							if uuid, ok := authorData["uuid"].(string); ok && uuid == author {
-								found = true
-							} else if username, ok := authorData["username"].(string); ok && username == author {
-								found = true
-							}
-						}
-						// Check email if provided (matches if UUID/username OR email matches)
-						if !found && authorEmail != "" {
-							if email, ok := authorData["email"].(string); ok && email == authorEmail {
-								found = true
-							}
-						}
-					}
-					if !found {
-						continue
-					}
-				}
+// maxPullRequestsPageLen is the largest page size the pull requests endpoint accepts
+const maxPullRequestsPageLen = 50
 
-				pr, err := parsePullRequest(prData, c.workspace, repoSlug)
-				if err != nil {
-					fmt.Printf("Warning: failed to parse pull request: %v\n", err)
-					continue
-				}
+// ListPullRequests fetches pull requests for a repository, following
+// pagination until limit pull requests (of the author, if given) are collected
+func (c *RestClient) ListPullRequests(ctx context.Context, repoSlug string, state string, limit int, author string, authorEmail string) ([]*PullRequest, error) {
+	filterByAuthor := author != "" || authorEmail != ""
 
-				pullRequests = append(pullRequests, pr)
-			}
+	// Most pull requests are filtered out when listing those of an author
+	pageLen := limit
+	if filterByAuthor || pageLen <= 0 || pageLen > maxPullRequestsPageLen {
+		pageLen = maxPullRequestsPageLen
+	}
+
+	query := url.Values{}
+	query.Set("pagelen", strconv.Itoa(pageLen))
+	if state != "" {
+		query.Set("state", state)
+	}
+	path := fmt.Sprintf("/repositories/%s/%s/pullrequests?%s", c.workspace, repoSlug, query.Encode())
+
+	pullRequests := make([]*PullRequest, 0)
+	err := paginate(ctx, c, path, func(data *apiPullRequest) bool {
+		// Filter by author if specified (matches if UUID/username OR email matches)
+		if filterByAuthor && !data.authoredBy(author, authorEmail) {
+			return true
 		}
+
+		pullRequests = append(pullRequests, data.toPullRequest(c.webBaseURL(), c.workspace, repoSlug))
+		return limit <= 0 || len(pullRequests) < limit
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return pullRequests, nil
 }
 
-// GetRepositoryDefaultBranch fetches the default branch for a repository
-func (c *RestClient) GetRepositoryDefaultBranch(repoSlug string) (string, error) {
+// GetRepository fetches a single repository
+func (c *RestClient) GetRepository(ctx context.Context, repoSlug string) (*Repository, error) {
 	path := fmt.Sprintf("/repositories/%s/%s", c.workspace, repoSlug)
 
-	data, err := c.doRequest("GET", path)
+	var data apiRepository
+	if err := c.doRequest(ctx, "GET", path, &data); err != nil {
+		return nil, fmt.Errorf("failed to get repository: %w", err)
+	}
+
+	return data.toRepository(), nil
+}
+
+// GetRepositoryDefaultBranch fetches the default branch for a repository
+func (c *RestClient) GetRepositoryDefaultBranch(ctx context.Context, repoSlug string) (string, error) {
+	repo, err := c.GetRepository(ctx, repoSlug)
 	if err != nil {
-		return "", fmt.Errorf("failed to get repository: %w", err)
+		return "", err
 	}
 
-	// Extract mainbranch.name from response
-	if mainbranch, ok := data["mainbranch"].(map[string]interface{}); ok {
-		if name, ok := mainbranch["name"].(string); ok {
-			return name, nil
-		}
+	if repo.MainBranch != "" {
+		return repo.MainBranch, nil
 	}
 
 	// Fallback to common defaults if mainbranch is not available
 	return "main", nil
 }
 
-// UserInfo represents the current authenticated user
+// UserInfo represents a Bitbucket user, e.g. the current authenticated user
 type UserInfo struct {
-	UUID     string
-	Username string
+	UUID        string `json:"uuid" yaml:"uuid"`
+	Username    string `json:"username" yaml:"username"`
+	DisplayName string `json:"display_name,omitempty" yaml:"display_name,omitempty"`
 }
 
 // GetCurrentUser fetches the current authenticated user information
-func (c *RestClient) GetCurrentUser() (*UserInfo, error) {
+func (c *RestClient) GetCurrentUser(ctx context.Context) (*UserInfo, error) {
 	path := "/user"
 
-	data, err := c.doRequest("GET", path)
-	if err != nil {
+	var data apiUser
+	if err := c.doRequest(ctx, "GET", path, &data); err != nil {
 		return nil, fmt.Errorf("failed to get current user: %w", err)
 	}
 
-	user := &UserInfo{}
-
-	// Extract UUID
-	if uuid, ok := data["uuid"].(string); ok {
-		user.UUID = uuid
-	}
-
-	// Extract username
-	if username, ok := data["username"].(string); ok {
-		user.Username = username
-	}
-
-	if user.UUID == "" && user.Username == "" {
+	if data.UUID == "" && data.Username == "" {
 		return nil, fmt.Errorf("failed to extract user information from API response")
 	}
 
-	return user, nil
-}
-
-// parsePullRequest converts the API response to our PullRequest struct
-func parsePullRequest(data map[string]interface{}, workspace, repoSlug string) (*PullRequest, error) {
-	pr := &PullRequest{}
-
-	// ID
-	if id, ok := data["id"].(float64); ok {
-		pr.ID = int(id)
-	}
-
-	// Title
-	if title, ok := data["title"].(string); ok {
-		pr.Title = title
-	}
-
-	// Description
-	if desc, ok := data["description"].(string); ok {
-		pr.Description = desc
-	}
-
-	// State
-	if state, ok := data["state"].(string); ok {
-		pr.State = state
-	}
-
-	// Source branch
-	if source, ok := data["source"].(map[string]interface{}); ok {
-		if branch, ok := source["branch"].(map[string]interface{}); ok {
-			if name, ok := branch["name"].(string); ok {
-				pr.SourceBranch = name
-			}
-		}
-	}
-
-	// Destination branch
-	if dest, ok := data["destination"].(map[string]interface{}); ok {
-		if branch, ok := dest["branch"].(map[string]interface{}); ok {
-			if name, ok := branch["name"].(string); ok {
-				pr.DestinationBranch = name
-			}
-		}
-	}
-
-	// Author
-	if author, ok := data["author"].(map[string]interface{}); ok {
-		if displayName, ok := author["display_name"].(string); ok {
-			pr.Author = displayName
-		} else if username, ok := author["username"].(string); ok {
-			pr.Author = username
-		}
-	}
-
-	// Reviewers
-	pr.Reviewers = make([]string, 0)
-	if reviewers, ok := data["reviewers"].([]interface{}); ok {
-		for _, reviewerData := range reviewers {
-			if reviewer, ok := reviewerData.(map[string]interface{}); ok {
-				// Prefer UUID for matching, fallback to username
-				if uuid, ok := reviewer["uuid"].(string); ok && uuid != "" {
-					pr.Reviewers = append(pr.Reviewers, uuid)
-				} else if username, ok := reviewer["username"].(string); ok && username != "" {
-					pr.Reviewers = append(pr.Reviewers, username)
-				}
-			}
-		}
-	}
-
-	// Created on
-	if createdStr, ok := data["created_on"].(string); ok {
-		if t, err := time.Parse(time.RFC3339, createdStr); err == nil {
-			pr.CreatedOn = t
-		}
-	}
-
-	// Updated on
-	if updatedStr, ok := data["updated_on"].(string); ok {
-		if t, err := time.Parse(time.RFC3339, updatedStr); err == nil {
-			pr.UpdatedOn = t
-		}
-	}
-
-	// Web URL - construct from links or build manually
-	if links, ok := data["links"].(map[string]interface{}); ok {
-		if html, ok := links["html"].(map[string]interface{}); ok {
-			if href, ok := html["href"].(string); ok {
-				pr.WebURL = href
-			}
-		}
-	}
-
-	// If no web URL from links, construct it manually
-	if pr.WebURL == "" && pr.ID > 0 {
-		pr.WebURL = fmt.Sprintf("https://bitbucket.org/%s/%s/pull-requests/%d",
-			workspace, repoSlug, pr.ID)
-	}
-
-	return pr, nil
+	return data.toUserInfo(), nil
 }
 
 // CreateRepository creates a new repository in Bitbucket
-func (c *RestClient) CreateRepository(repoSlug, projectKey string, isPrivate bool) (map[string]interface{}, error) {
+func (c *RestClient) CreateRepository(ctx context.Context, repoSlug, projectKey string, isPrivate bool) (*Repository, error) {
 	path := fmt.Sprintf("/repositories/%s/%s", c.workspace, repoSlug)
 
 	requestBody := map[string]interface{}{
@@ -1007,16 +1121,16 @@ func (c *RestClient) CreateRepository(repoSlug, projectKey string, isPrivate boo
 		}
 	}
 
-	data, err := c.doRequestWithBody("POST", path, requestBody)
-	if err != nil {
+	var data apiRepository
+	if err := c.doRequestWithBody(ctx, "POST", path, requestBody, &data); err != nil {
 		return nil, fmt.Errorf("failed to create repository: %w", err)
 	}
 
-	return data, nil
+	return data.toRepository(), nil
 }
 
 // SetRepositoryPermissions sets permissions for a group on a repository
-func (c *RestClient) SetRepositoryPermissions(repoSlug, groupSlug, permission string) error {
+func (c *RestClient) SetRepositoryPermissions(ctx context.Context, repoSlug, groupSlug, permission string) error {
 	path := fmt.Sprintf("/repositories/%s/%s/permissions-config/groups/%s",
 		c.workspace, repoSlug, groupSlug)
 
@@ -1024,8 +1138,7 @@ func (c *RestClient) SetRepositoryPermissions(repoSlug, groupSlug, permission st
 		"permission": permission,
 	}
 
-	_, err := c.doRequestWithBody("PUT", path, requestBody)
-	if err != nil {
+	if err := c.doRequestWithBody(ctx, "PUT", path, requestBody, nil); err != nil {
 		return fmt.Errorf("failed to set repository permissions: %w", err)
 	}
 
@@ -1033,7 +1146,7 @@ func (c *RestClient) SetRepositoryPermissions(repoSlug, groupSlug, permission st
 }
 
 // SetRepositoryDefaultBranch sets the default branch for a repository
-func (c *RestClient) SetRepositoryDefaultBranch(repoSlug, branchName string) error {
+func (c *RestClient) SetRepositoryDefaultBranch(ctx context.Context, repoSlug, branchName string) error {
 	path := fmt.Sprintf("/repositories/%s/%s", c.workspace, repoSlug)
 
 	requestBody := map[string]interface{}{
@@ -1042,8 +1155,7 @@ func (c *RestClient) SetRepositoryDefaultBranch(repoSlug, branchName string) err
 		},
 	}
 
-	_, err := c.doRequestWithBody("PUT", path, requestBody)
-	if err != nil {
+	if err := c.doRequestWithBody(ctx, "PUT", path, requestBody, nil); err != nil {
 		return fmt.Errorf("failed to set default branch: %w", err)
 	}
 
@@ -1052,24 +1164,24 @@ func (c *RestClient) SetRepositoryDefaultBranch(repoSlug, branchName string) err
 
 // GetPipelineSSHKeyPair retrieves the SSH key pair for a repository's pipelines
 // Returns nil if no key pair exists (404 response)
-func (c *RestClient) GetPipelineSSHKeyPair(repoSlug string) (map[string]interface{}, error) {
+func (c *RestClient) GetPipelineSSHKeyPair(ctx context.Context, repoSlug string) (*SSHKeyPair, error) {
 	path := fmt.Sprintf("/repositories/%s/%s/pipelines_config/ssh/key_pair",
 		c.workspace, repoSlug)
 
-	data, err := c.doRequest("GET", path)
-	if err != nil {
+	keyPair := &SSHKeyPair{}
+	if err := c.doRequest(ctx, "GET", path, keyPair); err != nil {
 		// Check if it's a 404 (key pair doesn't exist)
-		if strings.Contains(err.Error(), "404") {
+		if errors.Is(err, ErrNotFound) {
 			return nil, nil
 		}
 		return nil, fmt.Errorf("failed to get SSH key pair: %w", err)
 	}
 
-	return data, nil
+	return keyPair, nil
 }
 
 // CreatePipelineSSHKeyPair creates or updates the SSH key pair for a repository's pipelines
-func (c *RestClient) CreatePipelineSSHKeyPair(repoSlug, privateKey, publicKey string) (map[string]interface{}, error) {
+func (c *RestClient) CreatePipelineSSHKeyPair(ctx context.Context, repoSlug, privateKey, publicKey string) (*SSHKeyPair, error) {
 	path := fmt.Sprintf("/repositories/%s/%s/pipelines_config/ssh/key_pair",
 		c.workspace, repoSlug)
 
@@ -1078,52 +1190,29 @@ func (c *RestClient) CreatePipelineSSHKeyPair(repoSlug, privateKey, publicKey st
 		"public_key":  publicKey,
 	}
 
-	data, err := c.doRequestWithBody("PUT", path, requestBody)
-	if err != nil {
+	keyPair := &SSHKeyPair{}
+	if err := c.doRequestWithBody(ctx, "PUT", path, requestBody, keyPair); err != nil {
 		return nil, fmt.Errorf("failed to create SSH key pair: %w", err)
 	}
 
-	return data, nil
+	return keyPair, nil
 }
 
 // ListDeployKeys retrieves all deploy keys for a repository
-func (c *RestClient) ListDeployKeys(repoSlug string) ([]map[string]interface{}, error) {
+func (c *RestClient) ListDeployKeys(ctx context.Context, repoSlug string) ([]*DeployKey, error) {
 	path := fmt.Sprintf("/repositories/%s/%s/deploy-keys?pagelen=100",
 		c.workspace, repoSlug)
 
-	deployKeys := make([]map[string]interface{}, 0)
-
-	for path != "" {
-		data, err := c.doRequest("GET", path)
-		if err != nil {
-			return nil, fmt.Errorf("failed to list deploy keys: %w", err)
-		}
-
-		if values, ok := data["values"].([]interface{}); ok {
-			for _, v := range values {
-				if keyData, ok := v.(map[string]interface{}); ok {
-					deployKeys = append(deployKeys, keyData)
-				}
-			}
-		}
-
-		// Check for next page
-		if next, ok := data["next"].(string); ok && next != "" {
-			if strings.Contains(next, "/2.0") {
-				path = strings.SplitN(next, "/2.0", 2)[1]
-			} else {
-				path = ""
-			}
-		} else {
-			path = ""
-		}
+	deployKeys, err := listAll[*DeployKey](ctx, c, path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deploy keys: %w", err)
 	}
 
 	return deployKeys, nil
 }
 
 // CreateDeployKey adds a new deploy key to a repository
-func (c *RestClient) CreateDeployKey(repoSlug, key, label string) (map[string]interface{}, error) {
+func (c *RestClient) CreateDeployKey(ctx context.Context, repoSlug, key, label string) (*DeployKey, error) {
 	path := fmt.Sprintf("/repositories/%s/%s/deploy-keys",
 		c.workspace, repoSlug)
 
@@ -1132,16 +1221,49 @@ func (c *RestClient) CreateDeployKey(repoSlug, key, label string) (map[string]in
 		"label": label,
 	}
 
-	data, err := c.doRequestWithBody("POST", path, requestBody)
-	if err != nil {
+	deployKey := &DeployKey{}
+	if err := c.doRequestWithBody(ctx, "POST", path, requestBody, deployKey); err != nil {
 		return nil, fmt.Errorf("failed to create deploy key: %w", err)
 	}
 
-	return data, nil
+	return deployKey, nil
+}
+
+// grantedScopes returns the OAuth scopes granted to the current token
+func (c *RestClient) grantedScopes() []string {
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
+	if c.tokenStore == nil {
+		return nil
+	}
+	return strings.Fields(c.tokenStore.Scopes)
+}
+
+// accessToken returns the current OAuth access token
+func (c *RestClient) accessToken() string {
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
+	return c.tokenStore.AccessToken
+}
+
+// GitCredentials returns the credentials for git over HTTPS: the app password
+// with Basic Auth, or the access token as x-token-auth with OAuth
+func (c *RestClient) GitCredentials() (string, string, error) {
+	if !c.useOAuth {
+		return c.username, c.password, nil
+	}
+
+	if err := c.ensureValidToken(); err != nil {
+		return "", "", err
+	}
+	return "x-token-auth", c.accessToken(), nil
 }
 
 // ensureValidToken ensures the OAuth token is valid, refreshing if necessary
 func (c *RestClient) ensureValidToken() error {
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
+
 	if c.tokenStore.NeedsRefresh() {
 		newTokenStore, err := c.oauthClient.RefreshAccessToken(c.tokenStore.RefreshToken)
 		if err != nil {
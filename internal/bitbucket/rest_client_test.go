@@ -1,11 +1,15 @@
 package bitbucket
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 )
 
 // mockPaginatedResponse creates a paginated response with optional next page URL
@@ -22,239 +26,91 @@ func mockPaginatedResponse(values []map[string]interface{}, nextURL string) map[
 	return response
 }
 
-// TestListRepositoryVariablesPagination tests that ListRepositoryVariables handles pagination correctly
-func TestListRepositoryVariablesPagination(t *testing.T) {
-	tests := []struct {
-		name          string
-		pages         [][]map[string]interface{}
-		expectedCount int
-		expectedKeys  []string
-	}{
-		{
-			name: "Single page - no pagination needed",
-			pages: [][]map[string]interface{}{
-				{
-					{"key": "VAR1", "value": "value1", "secured": false},
-					{"key": "VAR2", "value": "value2", "secured": false},
-				},
-			},
-			expectedCount: 2,
-			expectedKeys:  []string{"VAR1", "VAR2"},
-		},
-		{
-			name: "Two pages - pagination needed",
-			pages: [][]map[string]interface{}{
-				{
-					{"key": "VAR1", "value": "value1", "secured": false},
-					{"key": "VAR2", "value": "value2", "secured": false},
-				},
-				{
-					{"key": "VAR3", "value": "value3", "secured": false},
-					{"key": "VAR4", "value": "value4", "secured": false},
-				},
-			},
-			expectedCount: 4,
-			expectedKeys:  []string{"VAR1", "VAR2", "VAR3", "VAR4"},
-		},
-		{
-			name: "Three pages - multiple pagination",
-			pages: [][]map[string]interface{}{
-				{
-					{"key": "VAR1", "value": "value1", "secured": false},
-				},
-				{
-					{"key": "VAR2", "value": "value2", "secured": false},
-				},
-				{
-					{"key": "VAR3", "value": "value3", "secured": false},
-				},
-			},
-			expectedCount: 3,
-			expectedKeys:  []string{"VAR1", "VAR2", "VAR3"},
-		},
-		{
-			name:          "Empty response",
-			pages:         [][]map[string]interface{}{{}},
-			expectedCount: 0,
-			expectedKeys:  []string{},
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			pageIndex := 0
-
-			var server *httptest.Server
-			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-				if pageIndex >= len(tt.pages) {
-					t.Fatalf("Unexpected request for page %d", pageIndex)
-					return
-				}
-
-				var nextURL string
-				if pageIndex < len(tt.pages)-1 {
-					nextURL = server.URL + "/2.0/repositories/workspace/repo/pipelines_config/variables/?pagelen=100&page=2"
-				}
+// newPaginatedServer serves the given pages at path in order, linking each page to the next one
+func newPaginatedServer(t *testing.T, path string, pages [][]map[string]interface{}, requests *int) *httptest.Server {
+	t.Helper()
 
-				response := mockPaginatedResponse(tt.pages[pageIndex], nextURL)
-				pageIndex++
-
-				w.Header().Set("Content-Type", "application/json")
-				json.NewEncoder(w).Encode(response)
-			}))
-			defer server.Close()
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != path {
+			t.Errorf("Expected request to %s, got %s", path, r.URL.Path)
+		}
 
-			client := &RestClient{
-				workspace: "workspace",
-				client:    server.Client(),
-				baseURL:   server.URL + "/2.0",
-			}
+		pageIndex := 0
+		if page := r.URL.Query().Get("page"); page != "" {
+			fmt.Sscanf(page, "%d", &pageIndex)
+			pageIndex--
+		}
+		if pageIndex >= len(pages) {
+			t.Errorf("Unexpected request for page %d", pageIndex+1)
+			http.NotFound(w, r)
+			return
+		}
+		*requests++
 
-			variables, err := client.ListRepositoryVariables("repo")
-			if err != nil {
-				t.Fatalf("ListRepositoryVariables returned error: %v", err)
-			}
+		var nextURL string
+		if pageIndex < len(pages)-1 {
+			query := r.URL.Query()
+			query.Set("page", fmt.Sprintf("%d", pageIndex+2))
+			nextURL = server.URL + r.URL.Path + "?" + query.Encode()
+		}
 
-			if len(variables) != tt.expectedCount {
-				t.Errorf("Expected %d variables, got %d", tt.expectedCount, len(variables))
-			}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(mockPaginatedResponse(pages[pageIndex], nextURL))
+	}))
 
-			// Verify all expected keys are present
-			for _, expectedKey := range tt.expectedKeys {
-				found := false
-				for _, v := range variables {
-					if v["key"] == expectedKey {
-						found = true
-						break
-					}
-				}
-				if !found {
-					t.Errorf("Expected key %s not found in results", expectedKey)
-				}
-			}
-		})
-	}
+	return server
 }
 
-// TestListDeploymentVariablesPagination tests that ListDeploymentVariables handles pagination correctly
-func TestListDeploymentVariablesPagination(t *testing.T) {
+// TestPaginate tests that the generic paginator follows next links and stops early when asked to
+func TestPaginate(t *testing.T) {
 	tests := []struct {
-		name          string
-		pages         [][]map[string]interface{}
-		expectedCount int
+		name             string
+		pages            [][]map[string]interface{}
+		stopAfter        int // Stop after this many values (0 = never)
+		expectedKeys     []string
+		expectedRequests int
 	}{
 		{
-			name: "Single page",
+			name: "Single page - no pagination needed",
 			pages: [][]map[string]interface{}{
-				{
-					{"key": "ENV_VAR1", "value": "value1", "secured": false},
-					{"key": "ENV_VAR2", "value": "value2", "secured": true},
-				},
+				{{"key": "VAR1"}, {"key": "VAR2"}},
 			},
-			expectedCount: 2,
+			expectedKeys:     []string{"VAR1", "VAR2"},
+			expectedRequests: 1,
 		},
 		{
-			name: "Multiple pages",
+			name: "Three pages - multiple pagination",
 			pages: [][]map[string]interface{}{
-				{{"key": "VAR1", "value": "v1", "secured": false}},
-				{{"key": "VAR2", "value": "v2", "secured": false}},
-				{{"key": "VAR3", "value": "v3", "secured": false}},
+				{{"key": "VAR1"}, {"key": "VAR2"}},
+				{{"key": "VAR3"}},
+				{{"key": "VAR4"}},
 			},
-			expectedCount: 3,
+			expectedKeys:     []string{"VAR1", "VAR2", "VAR3", "VAR4"},
+			expectedRequests: 3,
 		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			pageIndex := 0
-			envUUID := "{test-env-uuid}"
-
-			var server *httptest.Server
-			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-				// Verify the URL contains the environment UUID
-				if !strings.Contains(r.URL.Path, envUUID) {
-					t.Errorf("Expected URL to contain environment UUID %s, got %s", envUUID, r.URL.Path)
-				}
-
-				var nextURL string
-				if pageIndex < len(tt.pages)-1 {
-					nextURL = server.URL + "/2.0/repositories/workspace/repo/deployments_config/environments/" + envUUID + "/variables?pagelen=100&page=2"
-				}
-
-				response := mockPaginatedResponse(tt.pages[pageIndex], nextURL)
-				pageIndex++
-
-				w.Header().Set("Content-Type", "application/json")
-				json.NewEncoder(w).Encode(response)
-			}))
-			defer server.Close()
-
-			client := &RestClient{
-				workspace: "workspace",
-				client:    server.Client(),
-				baseURL:   server.URL + "/2.0",
-			}
-
-			variables, err := client.ListDeploymentVariables("repo", envUUID)
-			if err != nil {
-				t.Fatalf("ListDeploymentVariables returned error: %v", err)
-			}
-
-			if len(variables) != tt.expectedCount {
-				t.Errorf("Expected %d variables, got %d", tt.expectedCount, len(variables))
-			}
-		})
-	}
-}
-
-// TestListRepositoriesPagination tests that ListRepositories handles pagination correctly
-func TestListRepositoriesPagination(t *testing.T) {
-	tests := []struct {
-		name          string
-		pages         [][]map[string]interface{}
-		expectedCount int
-		expectedSlugs []string
-	}{
 		{
-			name: "Single page",
-			pages: [][]map[string]interface{}{
-				{
-					{"slug": "repo1", "name": "Repository 1", "full_name": "workspace/repo1"},
-					{"slug": "repo2", "name": "Repository 2", "full_name": "workspace/repo2"},
-				},
-			},
-			expectedCount: 2,
-			expectedSlugs: []string{"repo1", "repo2"},
+			name:             "Empty response",
+			pages:            [][]map[string]interface{}{{}},
+			expectedKeys:     []string{},
+			expectedRequests: 1,
 		},
 		{
-			name: "Multiple pages",
+			name: "Stops early without fetching further pages",
 			pages: [][]map[string]interface{}{
-				{{"slug": "repo1", "name": "Repo 1", "full_name": "workspace/repo1"}},
-				{{"slug": "repo2", "name": "Repo 2", "full_name": "workspace/repo2"}},
-				{{"slug": "repo3", "name": "Repo 3", "full_name": "workspace/repo3"}},
+				{{"key": "VAR1"}, {"key": "VAR2"}},
+				{{"key": "VAR3"}},
 			},
-			expectedCount: 3,
-			expectedSlugs: []string{"repo1", "repo2", "repo3"},
+			stopAfter:        2,
+			expectedKeys:     []string{"VAR1", "VAR2"},
+			expectedRequests: 1,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			pageIndex := 0
-
-			var server *httptest.Server
-			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-				var nextURL string
-				if pageIndex < len(tt.pages)-1 {
-					nextURL = server.URL + "/2.0/repositories/workspace?pagelen=100&page=2"
-				}
-
-				response := mockPaginatedResponse(tt.pages[pageIndex], nextURL)
-				pageIndex++
-
-				w.Header().Set("Content-Type", "application/json")
-				json.NewEncoder(w).Encode(response)
-			}))
+			requests := 0
+			server := newPaginatedServer(t, "/2.0/items", tt.pages, &requests)
 			defer server.Close()
 
 			client := &RestClient{
@@ -263,343 +119,202 @@ func TestListRepositoriesPagination(t *testing.T) {
 				baseURL:   server.URL + "/2.0",
 			}
 
-			repos, err := client.ListRepositories()
+			keys := make([]string, 0)
+			err := paginate(context.Background(), client, "/items?pagelen=2", func(v *Variable) bool {
+				keys = append(keys, v.Key)
+				return tt.stopAfter == 0 || len(keys) < tt.stopAfter
+			})
 			if err != nil {
-				t.Fatalf("ListRepositories returned error: %v", err)
+				t.Fatalf("paginate returned error: %v", err)
 			}
 
-			if len(repos) != tt.expectedCount {
-				t.Errorf("Expected %d repositories, got %d", tt.expectedCount, len(repos))
+			if strings.Join(keys, ",") != strings.Join(tt.expectedKeys, ",") {
+				t.Errorf("Expected keys %v, got %v", tt.expectedKeys, keys)
 			}
-
-			for _, expectedSlug := range tt.expectedSlugs {
-				found := false
-				for _, repo := range repos {
-					if repo.Slug == expectedSlug {
-						found = true
-						break
-					}
-				}
-				if !found {
-					t.Errorf("Expected slug %s not found in results", expectedSlug)
-				}
+			if requests != tt.expectedRequests {
+				t.Errorf("Expected %d requests, got %d", tt.expectedRequests, requests)
 			}
 		})
 	}
 }
 
-// TestListWorkspaceVariablesPagination tests that ListWorkspaceVariables handles pagination correctly
-func TestListWorkspaceVariablesPagination(t *testing.T) {
-	tests := []struct {
-		name          string
-		pages         [][]map[string]interface{}
-		expectedCount int
-	}{
-		{
-			name: "Single page",
-			pages: [][]map[string]interface{}{
-				{
-					{"key": "WS_VAR1", "value": "value1", "secured": false},
-				},
-			},
-			expectedCount: 1,
-		},
-		{
-			name: "Multiple pages",
-			pages: [][]map[string]interface{}{
-				{{"key": "WS_VAR1", "value": "v1", "secured": false}},
-				{{"key": "WS_VAR2", "value": "v2", "secured": false}},
-			},
-			expectedCount: 2,
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			pageIndex := 0
-
-			var server *httptest.Server
-			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-				var nextURL string
-				if pageIndex < len(tt.pages)-1 {
-					nextURL = server.URL + "/2.0/workspaces/workspace/pipelines-config/variables?pagelen=100&page=2"
-				}
-
-				response := mockPaginatedResponse(tt.pages[pageIndex], nextURL)
-				pageIndex++
-
-				w.Header().Set("Content-Type", "application/json")
-				json.NewEncoder(w).Encode(response)
-			}))
-			defer server.Close()
-
-			client := &RestClient{
-				workspace: "workspace",
-				client:    server.Client(),
-				baseURL:   server.URL + "/2.0",
-			}
+// TestPaginateError tests that an error on a later page is returned
+func TestPaginateError(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("page") == "2" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		json.NewEncoder(w).Encode(mockPaginatedResponse([]map[string]interface{}{{"key": "VAR1"}}, server.URL+"/2.0/items?page=2"))
+	}))
+	defer server.Close()
 
-			variables, err := client.ListWorkspaceVariables()
-			if err != nil {
-				t.Fatalf("ListWorkspaceVariables returned error: %v", err)
-			}
+	client := &RestClient{
+		workspace: "workspace",
+		client:    server.Client(),
+		baseURL:   server.URL + "/2.0",
+	}
 
-			if len(variables) != tt.expectedCount {
-				t.Errorf("Expected %d variables, got %d", tt.expectedCount, len(variables))
-			}
-		})
+	if _, err := listAll[*Variable](context.Background(), client, "/items"); err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("Expected 403 error, got %v", err)
 	}
 }
 
-// TestListDeploymentEnvironmentsPagination tests that ListDeploymentEnvironments handles pagination correctly
-func TestListDeploymentEnvironmentsPagination(t *testing.T) {
+// TestListEndpointsDecodeTypedModels tests that every paginated endpoint collects all pages
+// and decodes the API objects into the typed models
+func TestListEndpointsDecodeTypedModels(t *testing.T) {
 	tests := []struct {
-		name          string
-		pages         [][]map[string]interface{}
-		expectedCount int
+		name         string
+		expectedPath string
+		pages        [][]map[string]interface{}
+		list         func(c *RestClient) ([]string, error)
+		expected     []string
 	}{
 		{
-			name: "Single page",
+			name:         "Repository variables",
+			expectedPath: "/2.0/repositories/workspace/repo/pipelines_config/variables/",
 			pages: [][]map[string]interface{}{
-				{
-					{"name": "Test", "uuid": "{env-1}", "slug": "test"},
-					{"name": "Production", "uuid": "{env-2}", "slug": "production"},
-				},
+				{{"uuid": "{v1}", "key": "VAR1", "value": "value1", "secured": false}},
+				{{"uuid": "{v2}", "key": "VAR2", "secured": true}},
 			},
-			expectedCount: 2,
-		},
-		{
-			name: "Multiple pages",
-			pages: [][]map[string]interface{}{
-				{{"name": "Test", "uuid": "{env-1}", "slug": "test"}},
-				{{"name": "Staging", "uuid": "{env-2}", "slug": "staging"}},
-				{{"name": "Production", "uuid": "{env-3}", "slug": "production"}},
+			list: func(c *RestClient) ([]string, error) {
+				vars, err := c.ListRepositoryVariables(context.Background(), "repo")
+				return collect(vars, func(v *Variable) string { return fmt.Sprintf("%s %s=%s %t", v.UUID, v.Key, v.Value, v.Secured) }), err
 			},
-			expectedCount: 3,
+			expected: []string{"{v1} VAR1=value1 false", "{v2} VAR2= true"},
 		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			pageIndex := 0
-
-			var server *httptest.Server
-			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-				var nextURL string
-				if pageIndex < len(tt.pages)-1 {
-					nextURL = server.URL + "/2.0/repositories/workspace/repo/environments/?pagelen=100&page=2"
-				}
-
-				response := mockPaginatedResponse(tt.pages[pageIndex], nextURL)
-				pageIndex++
-
-				w.Header().Set("Content-Type", "application/json")
-				json.NewEncoder(w).Encode(response)
-			}))
-			defer server.Close()
-
-			client := &RestClient{
-				workspace: "workspace",
-				client:    server.Client(),
-				baseURL:   server.URL + "/2.0",
-			}
-
-			envs, err := client.ListDeploymentEnvironments("repo")
-			if err != nil {
-				t.Fatalf("ListDeploymentEnvironments returned error: %v", err)
-			}
-
-			if len(envs) != tt.expectedCount {
-				t.Errorf("Expected %d environments, got %d", tt.expectedCount, len(envs))
-			}
-		})
-	}
-}
-
-// TestListDeployKeysPagination tests that ListDeployKeys handles pagination correctly
-func TestListDeployKeysPagination(t *testing.T) {
-	tests := []struct {
-		name          string
-		pages         [][]map[string]interface{}
-		expectedCount int
-	}{
 		{
-			name: "Single page",
+			name:         "Deployment variables",
+			expectedPath: "/2.0/repositories/workspace/repo/deployments_config/environments/{env}/variables",
 			pages: [][]map[string]interface{}{
-				{
-					{"id": 1, "label": "key1", "key": "ssh-ed25519 AAAA..."},
-					{"id": 2, "label": "key2", "key": "ssh-ed25519 BBBB..."},
-				},
+				{{"key": "ENV_VAR1", "value": "value1"}},
+				{{"key": "ENV_VAR2", "secured": true}},
 			},
-			expectedCount: 2,
-		},
-		{
-			name: "Multiple pages",
-			pages: [][]map[string]interface{}{
-				{{"id": 1, "label": "key1", "key": "ssh-ed25519 AAAA..."}},
-				{{"id": 2, "label": "key2", "key": "ssh-ed25519 BBBB..."}},
+			list: func(c *RestClient) ([]string, error) {
+				vars, err := c.ListDeploymentVariables(context.Background(), "repo", "{env}")
+				return collect(vars, func(v *Variable) string { return fmt.Sprintf("%s=%s %t", v.Key, v.Value, v.Secured) }), err
 			},
-			expectedCount: 2,
+			expected: []string{"ENV_VAR1=value1 false", "ENV_VAR2= true"},
 		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			pageIndex := 0
-
-			var server *httptest.Server
-			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-				var nextURL string
-				if pageIndex < len(tt.pages)-1 {
-					nextURL = server.URL + "/2.0/repositories/workspace/repo/deploy-keys?pagelen=100&page=2"
-				}
-
-				response := mockPaginatedResponse(tt.pages[pageIndex], nextURL)
-				pageIndex++
-
-				w.Header().Set("Content-Type", "application/json")
-				json.NewEncoder(w).Encode(response)
-			}))
-			defer server.Close()
-
-			client := &RestClient{
-				workspace: "workspace",
-				client:    server.Client(),
-				baseURL:   server.URL + "/2.0",
-			}
-
-			keys, err := client.ListDeployKeys("repo")
-			if err != nil {
-				t.Fatalf("ListDeployKeys returned error: %v", err)
-			}
-
-			if len(keys) != tt.expectedCount {
-				t.Errorf("Expected %d keys, got %d", tt.expectedCount, len(keys))
-			}
-		})
-	}
-}
-
-// TestGetPipelineStepsPagination tests that GetPipelineSteps handles pagination correctly
-func TestGetPipelineStepsPagination(t *testing.T) {
-	tests := []struct {
-		name          string
-		pages         [][]map[string]interface{}
-		expectedCount int
-	}{
 		{
-			name: "Single page",
+			name:         "Workspace variables",
+			expectedPath: "/2.0/workspaces/workspace/pipelines-config/variables",
 			pages: [][]map[string]interface{}{
-				{
-					{"uuid": "{step-1}", "name": "Build", "state": map[string]interface{}{"name": "COMPLETED"}},
-					{"uuid": "{step-2}", "name": "Test", "state": map[string]interface{}{"name": "COMPLETED"}},
-				},
+				{{"uuid": "{w1}", "key": "WS_VAR1", "value": "v1"}},
+				{{"uuid": "{w2}", "key": "WS_VAR2", "value": "v2"}},
 			},
-			expectedCount: 2,
+			list: func(c *RestClient) ([]string, error) {
+				vars, err := c.ListWorkspaceVariables(context.Background())
+				return collect(vars, func(v *Variable) string { return v.UUID + " " + v.Key }), err
+			},
+			expected: []string{"{w1} WS_VAR1", "{w2} WS_VAR2"},
 		},
 		{
-			name: "Multiple pages",
+			name:         "Repositories",
+			expectedPath: "/2.0/repositories/workspace",
 			pages: [][]map[string]interface{}{
-				{{"uuid": "{step-1}", "name": "Build", "state": map[string]interface{}{"name": "COMPLETED"}}},
-				{{"uuid": "{step-2}", "name": "Test", "state": map[string]interface{}{"name": "COMPLETED"}}},
-				{{"uuid": "{step-3}", "name": "Deploy", "state": map[string]interface{}{"name": "COMPLETED"}}},
+				{{"slug": "repo1", "full_name": "workspace/repo1", "mainbranch": map[string]interface{}{"name": "master"}, "project": map[string]interface{}{"key": "PROJ"}}},
+				{{"slug": "repo2", "full_name": "workspace/repo2", "is_private": true, "created_on": "2024-01-15T10:30:00.123456+00:00"}},
+			},
+			list: func(c *RestClient) ([]string, error) {
+				repos, err := c.ListRepositories(context.Background())
+				return collect(repos, func(r *Repository) string {
+					return fmt.Sprintf("%s %s %s %t %d", r.FullName, r.MainBranch, r.ProjectKey, r.IsPrivate, r.CreatedOn.Year())
+				}), err
 			},
-			expectedCount: 3,
+			expected: []string{"workspace/repo1 master PROJ false 1", "workspace/repo2   true 2024"},
 		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			pageIndex := 0
-			pipelineUUID := "{pipeline-uuid}"
-
-			var server *httptest.Server
-			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-				var nextURL string
-				if pageIndex < len(tt.pages)-1 {
-					nextURL = server.URL + "/2.0/repositories/workspace/repo/pipelines/" + pipelineUUID + "/steps/?pagelen=100&page=2"
-				}
-
-				response := mockPaginatedResponse(tt.pages[pageIndex], nextURL)
-				pageIndex++
-
-				w.Header().Set("Content-Type", "application/json")
-				json.NewEncoder(w).Encode(response)
-			}))
-			defer server.Close()
-
-			client := &RestClient{
-				workspace: "workspace",
-				client:    server.Client(),
-				baseURL:   server.URL + "/2.0",
-			}
-
-			steps, err := client.GetPipelineSteps("repo", pipelineUUID)
-			if err != nil {
-				t.Fatalf("GetPipelineSteps returned error: %v", err)
-			}
-
-			if len(steps) != tt.expectedCount {
-				t.Errorf("Expected %d steps, got %d", tt.expectedCount, len(steps))
-			}
-		})
-	}
-}
-
-// TestGetDefaultReviewersPagination tests that GetDefaultReviewers handles pagination correctly
-func TestGetDefaultReviewersPagination(t *testing.T) {
-	tests := []struct {
-		name          string
-		pages         [][]map[string]interface{}
-		expectedCount int
-		expectedUUIDs []string
-	}{
 		{
-			name: "Single page - two reviewers",
+			name:         "Projects",
+			expectedPath: "/2.0/workspaces/workspace/projects",
 			pages: [][]map[string]interface{}{
-				{
-					{"uuid": "{user-1}", "display_name": "Alice", "username": "alice"},
-					{"uuid": "{user-2}", "display_name": "Bob", "username": "bob"},
-				},
+				{{"key": "PROJ1", "name": "Project 1", "uuid": "{p1}"}},
+				{{"key": "PROJ2", "name": "Project 2", "uuid": "{p2}"}},
 			},
-			expectedCount: 2,
-			expectedUUIDs: []string{"{user-1}", "{user-2}"},
+			list: func(c *RestClient) ([]string, error) {
+				projects, err := c.ListProjects(context.Background())
+				return collect(projects, func(p *Project) string { return p.Key + " " + p.Name + " " + p.UUID }), err
+			},
+			expected: []string{"PROJ1 Project 1 {p1}", "PROJ2 Project 2 {p2}"},
+		},
+		{
+			name:         "Deployment environments",
+			expectedPath: "/2.0/repositories/workspace/repo/environments/",
+			pages: [][]map[string]interface{}{
+				{{"uuid": "{env-1}", "name": "Test", "environment_type": map[string]interface{}{"name": "Test", "rank": 0}}},
+				{{"uuid": "{env-2}", "name": "Production", "environment_type": map[string]interface{}{"name": "Production", "rank": 2}}},
+			},
+			list: func(c *RestClient) ([]string, error) {
+				envs, err := c.ListDeploymentEnvironments(context.Background(), "repo")
+				return collect(envs, func(e *Environment) string { return e.UUID + " " + e.Name + " " + e.Type }), err
+			},
+			expected: []string{"{env-1} Test Test", "{env-2} Production Production"},
+		},
+		{
+			name:         "Deploy keys",
+			expectedPath: "/2.0/repositories/workspace/repo/deploy-keys",
+			pages: [][]map[string]interface{}{
+				{{"id": 1, "key": "ssh-rsa AAA", "label": "pipelines", "added_on": "2024-01-15T10:30:00Z"}},
+				{{"id": 2, "key": "ssh-ed25519 BBB", "label": "deploy", "last_used": "2024-02-01T08:00:00Z"}},
+			},
+			list: func(c *RestClient) ([]string, error) {
+				keys, err := c.ListDeployKeys(context.Background(), "repo")
+				return collect(keys, func(k *DeployKey) string {
+					return fmt.Sprintf("%d %s %s %t", k.ID, k.Label, k.AddedOn.Format("2006-01-02"), k.LastUsed != nil)
+				}), err
+			},
+			expected: []string{"1 pipelines 2024-01-15 false", "2 deploy 0001-01-01 true"},
+		},
+		{
+			name:         "Pipeline steps",
+			expectedPath: "/2.0/repositories/workspace/repo/pipelines/{pipeline}/steps/",
+			pages: [][]map[string]interface{}{
+				{{"uuid": "{s1}", "name": "Build", "duration_in_seconds": 42, "state": map[string]interface{}{"name": "COMPLETED", "result": map[string]interface{}{"name": "SUCCESSFUL"}}}},
+				{{"uuid": "{s2}", "name": "Deploy", "state": map[string]interface{}{"name": "PENDING"}, "services": []map[string]interface{}{{"name": "docker", "uuid": "{svc}"}, {"name": "no-uuid"}}}},
+			},
+			list: func(c *RestClient) ([]string, error) {
+				steps, err := c.GetPipelineSteps(context.Background(), "repo", "{pipeline}")
+				return collect(steps, func(s *PipelineStep) string {
+					return fmt.Sprintf("%s %s %s/%s %ds %d services", s.UUID, s.Name, s.State, s.Result, s.DurationSec, len(s.Services))
+				}), err
+			},
+			expected: []string{"{s1} Build COMPLETED/SUCCESSFUL 42s 0 services", "{s2} Deploy PENDING/ 0s 1 services"},
 		},
 		{
-			name: "Multiple pages",
+			name:         "Default reviewers",
+			expectedPath: "/2.0/repositories/workspace/repo/default-reviewers",
 			pages: [][]map[string]interface{}{
-				{{"uuid": "{user-1}", "display_name": "Alice"}},
+				{{"uuid": "{user-1}", "display_name": "Alice", "username": "alice"}},
 				{{"uuid": "{user-2}", "display_name": "Bob"}},
-				{{"uuid": "{user-3}", "display_name": "Charlie"}},
 			},
-			expectedCount: 3,
-			expectedUUIDs: []string{"{user-1}", "{user-2}", "{user-3}"},
+			list: func(c *RestClient) ([]string, error) {
+				reviewers, err := c.GetDefaultReviewers(context.Background(), "repo")
+				return collect(reviewers, func(u *UserInfo) string { return u.UUID + " " + u.DisplayName }), err
+			},
+			expected: []string{"{user-1} Alice", "{user-2} Bob"},
 		},
 		{
-			name:          "Empty - no default reviewers",
-			pages:         [][]map[string]interface{}{{}},
-			expectedCount: 0,
-			expectedUUIDs: []string{},
+			name:         "Pipeline schedules",
+			expectedPath: "/2.0/repositories/workspace/repo/pipelines_config/schedules/",
+			pages: [][]map[string]interface{}{
+				{{"uuid": "{sch-1}", "enabled": true, "cron_pattern": "0 0 2 * * ? *", "target": map[string]interface{}{"ref_name": "master", "selector": map[string]interface{}{"pattern": "nightly"}}}},
+				{{"uuid": "{sch-2}", "enabled": false, "cron_pattern": "0 30 6 ? * MON-FRI *", "target": map[string]interface{}{"ref_name": "develop"}}},
+			},
+			list: func(c *RestClient) ([]string, error) {
+				schedules, err := c.ListPipelineSchedules(context.Background(), "repo")
+				return collect(schedules, func(s *PipelineSchedule) string {
+					return fmt.Sprintf("%s %t %s %s", s.UUID, s.Enabled, s.Branch, s.Selector)
+				}), err
+			},
+			expected: []string{"{sch-1} true master nightly", "{sch-2} false develop "},
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			pageIndex := 0
-
-			var server *httptest.Server
-			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-				var nextURL string
-				if pageIndex < len(tt.pages)-1 {
-					nextURL = server.URL + "/2.0/repositories/workspace/repo/default-reviewers?pagelen=100&page=2"
-				}
-
-				response := mockPaginatedResponse(tt.pages[pageIndex], nextURL)
-				pageIndex++
-
-				w.Header().Set("Content-Type", "application/json")
-				json.NewEncoder(w).Encode(response)
-			}))
+			requests := 0
+			server := newPaginatedServer(t, tt.expectedPath, tt.pages, &requests)
 			defer server.Close()
 
 			client := &RestClient{
@@ -608,36 +323,36 @@ func TestGetDefaultReviewersPagination(t *testing.T) {
 				baseURL:   server.URL + "/2.0",
 			}
 
-			reviewers, err := client.GetDefaultReviewers("repo")
+			got, err := tt.list(client)
 			if err != nil {
-				t.Fatalf("GetDefaultReviewers returned error: %v", err)
+				t.Fatalf("list returned error: %v", err)
 			}
 
-			if len(reviewers) != tt.expectedCount {
-				t.Errorf("Expected %d reviewers, got %d", tt.expectedCount, len(reviewers))
+			if strings.Join(got, "|") != strings.Join(tt.expected, "|") {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
 			}
-
-			for _, expectedUUID := range tt.expectedUUIDs {
-				found := false
-				for _, r := range reviewers {
-					if r["uuid"] == expectedUUID {
-						found = true
-						break
-					}
-				}
-				if !found {
-					t.Errorf("Expected UUID %s not found in results", expectedUUID)
-				}
+			if requests != len(tt.pages) {
+				t.Errorf("Expected %d requests, got %d", len(tt.pages), requests)
 			}
 		})
 	}
 }
 
+// collect maps values to strings for comparison
+func collect[T any](values []T, format func(T) string) []string {
+	result := make([]string, 0, len(values))
+	for _, v := range values {
+		result = append(result, format(v))
+	}
+	return result
+}
+
 // TestCreatePullRequestWithDefaultReviewers tests that CreatePullRequest includes default reviewers
 func TestCreatePullRequestWithDefaultReviewers(t *testing.T) {
 	tests := []struct {
 		name              string
 		defaultReviewers  []map[string]interface{}
+		extraReviewers    []string // Reviewers requested in addition to the default ones
 		currentUserUUID   string
 		expectedReviewers []string // UUIDs expected in the POST body
 	}{
@@ -673,6 +388,15 @@ func TestCreatePullRequestWithDefaultReviewers(t *testing.T) {
 			currentUserUUID:   "{user-1}",
 			expectedReviewers: []string{"{user-2}"},
 		},
+		{
+			name: "Adds requested reviewers once, excludes current user",
+			defaultReviewers: []map[string]interface{}{
+				{"uuid": "{user-2}", "display_name": "Bob"},
+			},
+			extraReviewers:    []string{"{user-2}", "{user-3}", "{user-1}"},
+			currentUserUUID:   "{user-1}",
+			expectedReviewers: []string{"{user-2}", "{user-3}"},
+		},
 	}
 
 	for _, tt := range tests {
@@ -727,7 +451,13 @@ func TestCreatePullRequestWithDefaultReviewers(t *testing.T) {
 				baseURL:   server.URL + "/2.0",
 			}
 
-			pr, err := client.CreatePullRequest("repo", "feature", "master", "Test PR", "description")
+			pr, err := client.CreatePullRequest(context.Background(), "repo", &CreatePullRequestOptions{
+				SourceBranch:      "feature",
+				DestinationBranch: "master",
+				Title:             "Test PR",
+				Description:       "description",
+				Reviewers:         tt.extraReviewers,
+			})
 			if err != nil {
 				t.Fatalf("CreatePullRequest returned error: %v", err)
 			}
@@ -811,7 +541,12 @@ func TestCreatePullRequestDefaultReviewersFetchFails(t *testing.T) {
 		baseURL:   server.URL + "/2.0",
 	}
 
-	pr, err := client.CreatePullRequest("repo", "feature", "master", "Test PR", "description")
+	pr, err := client.CreatePullRequest(context.Background(), "repo", &CreatePullRequestOptions{
+		SourceBranch:      "feature",
+		DestinationBranch: "master",
+		Title:             "Test PR",
+		Description:       "description",
+	})
 	if err != nil {
 		t.Fatalf("CreatePullRequest should succeed even if default reviewers fetch fails, got: %v", err)
 	}
@@ -821,43 +556,385 @@ func TestCreatePullRequestDefaultReviewersFetchFails(t *testing.T) {
 	}
 }
 
-// TestListProjectsPagination tests that ListProjects handles pagination correctly
-func TestListProjectsPagination(t *testing.T) {
+// TestTriggerPipelineRequestBody tests that TriggerPipeline builds the expected target
+func TestTriggerPipelineRequestBody(t *testing.T) {
+	tests := []struct {
+		name              string
+		opts              *TriggerPipelineOptions
+		expectedType      string
+		expectedRefName   string
+		expectedCommit    string
+		expectedSelector  string
+		expectedVariables []string
+	}{
+		{
+			name:            "Branch",
+			opts:            &TriggerPipelineOptions{RepoSlug: "repo", Branch: "master"},
+			expectedType:    "pipeline_ref_target",
+			expectedRefName: "master",
+		},
+		{
+			name:           "Commit only",
+			opts:           &TriggerPipelineOptions{RepoSlug: "repo", Commit: "abc123"},
+			expectedType:   "pipeline_commit_target",
+			expectedCommit: "abc123",
+		},
+		{
+			name: "Custom pipeline with variables",
+			opts: &TriggerPipelineOptions{
+				RepoSlug:  "repo",
+				Branch:    "feature",
+				Custom:    "deploy",
+				Variables: map[string]string{"VERSION": "1.0", "DRY_RUN": "true"},
+			},
+			expectedType:      "pipeline_ref_target",
+			expectedRefName:   "feature",
+			expectedSelector:  "deploy",
+			expectedVariables: []string{"DRY_RUN", "VERSION"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var capturedBody map[string]interface{}
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != "POST" || r.URL.Path != "/2.0/repositories/workspace/repo/pipelines/" {
+					http.NotFound(w, r)
+					return
+				}
+				json.NewDecoder(r.Body).Decode(&capturedBody)
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusCreated)
+				json.NewEncoder(w).Encode(map[string]interface{}{
+					"uuid":         "{pipeline-1}",
+					"build_number": float64(42),
+					"state":        map[string]interface{}{"name": "PENDING"},
+				})
+			}))
+			defer server.Close()
+
+			client := &RestClient{
+				workspace: "workspace",
+				client:    server.Client(),
+				baseURL:   server.URL + "/2.0",
+			}
+
+			pipeline, err := client.TriggerPipeline(context.Background(), tt.opts)
+			if err != nil {
+				t.Fatalf("TriggerPipeline returned error: %v", err)
+			}
+			if pipeline.BuildNumber != 42 {
+				t.Errorf("Expected build number 42, got %d", pipeline.BuildNumber)
+			}
+
+			target, ok := capturedBody["target"].(map[string]interface{})
+			if !ok {
+				t.Fatal("Expected target in request body")
+			}
+			if target["type"] != tt.expectedType {
+				t.Errorf("Expected target type %s, got %v", tt.expectedType, target["type"])
+			}
+			if tt.expectedRefName != "" && target["ref_name"] != tt.expectedRefName {
+				t.Errorf("Expected ref_name %s, got %v", tt.expectedRefName, target["ref_name"])
+			}
+			if tt.expectedCommit != "" {
+				commit, _ := target["commit"].(map[string]interface{})
+				if commit["hash"] != tt.expectedCommit {
+					t.Errorf("Expected commit %s, got %v", tt.expectedCommit, commit["hash"])
+				}
+			}
+			if tt.expectedSelector != "" {
+				selector, _ := target["selector"].(map[string]interface{})
+				if selector["type"] != "custom" || selector["pattern"] != tt.expectedSelector {
+					t.Errorf("Expected custom selector %s, got %v", tt.expectedSelector, selector)
+				}
+			}
+
+			variables, _ := capturedBody["variables"].([]interface{})
+			if len(variables) != len(tt.expectedVariables) {
+				t.Fatalf("Expected %d variables, got %d", len(tt.expectedVariables), len(variables))
+			}
+			for i, key := range tt.expectedVariables {
+				variable, _ := variables[i].(map[string]interface{})
+				if variable["key"] != key {
+					t.Errorf("Expected variable %d to be %s, got %v", i, key, variable["key"])
+				}
+			}
+		})
+	}
+}
+
+// TestGetStepLogFrom tests incremental log fetching with Range offsets
+func TestGetStepLogFrom(t *testing.T) {
+	fullLog := "line 1\nline 2\nline 3\n"
+
+	tests := []struct {
+		name          string
+		offset        int64
+		handler       func(w http.ResponseWriter, r *http.Request)
+		expectedData  string
+		expectedRange string
+		expectError   bool
+	}{
+		{
+			name:   "Partial content from offset",
+			offset: 7,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusPartialContent)
+				w.Write([]byte(fullLog[7:]))
+			},
+			expectedData:  "line 2\nline 3\n",
+			expectedRange: "bytes=7-",
+		},
+		{
+			name:   "Range ignored - full log returned",
+			offset: 14,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(fullLog))
+			},
+			expectedData:  "line 3\n",
+			expectedRange: "bytes=14-",
+		},
+		{
+			name:   "No new data",
+			offset: int64(len(fullLog)),
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+			},
+			expectedData:  "",
+			expectedRange: "bytes=21-",
+		},
+		{
+			name:   "Log not available yet",
+			offset: 0,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				http.NotFound(w, r)
+			},
+			expectedData:  "",
+			expectedRange: "bytes=0-",
+		},
+		{
+			name:   "Server error is reported",
+			offset: 0,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusInternalServerError)
+			},
+			expectedRange: "bytes=0-",
+			expectError:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var capturedRange string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				capturedRange = r.Header.Get("Range")
+				tt.handler(w, r)
+			}))
+			defer server.Close()
+
+			client := &RestClient{
+				workspace: "workspace",
+				client:    server.Client(),
+				baseURL:   server.URL + "/2.0",
+			}
+
+			data, err := client.GetStepLogFrom(context.Background(), "repo", "{pipeline}", "{step}", tt.offset)
+			if tt.expectError {
+				if err == nil {
+					t.Fatal("Expected an error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetStepLogFrom returned error: %v", err)
+			}
+			if string(data) != tt.expectedData {
+				t.Errorf("Expected data %q, got %q", tt.expectedData, string(data))
+			}
+			if capturedRange != tt.expectedRange {
+				t.Errorf("Expected Range header %q, got %q", tt.expectedRange, capturedRange)
+			}
+		})
+	}
+}
+
+// TestStopPipeline tests stopping a pipeline with an empty 204 response
+func TestStopPipeline(t *testing.T) {
+	var capturedMethod, capturedPath string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		capturedMethod = r.Method
+		capturedPath = r.URL.Path
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	client := &RestClient{
+		workspace: "workspace",
+		client:    server.Client(),
+		baseURL:   server.URL + "/2.0",
+	}
+
+	if err := client.StopPipeline(context.Background(), "repo", "{pipeline-uuid}"); err != nil {
+		t.Fatalf("StopPipeline returned error: %v", err)
+	}
+
+	if capturedMethod != "POST" {
+		t.Errorf("Expected POST request, got %s", capturedMethod)
+	}
+	expectedPath := "/2.0/repositories/workspace/repo/pipelines/{pipeline-uuid}/stopPipeline"
+	if capturedPath != expectedPath {
+		t.Errorf("Expected path %s, got %s", expectedPath, capturedPath)
+	}
+}
+
+// TestGetFullStepLog tests downloading complete build and service container logs
+func TestGetFullStepLog(t *testing.T) {
+	tests := []struct {
+		name         string
+		logUUID      string
+		statusCode   int
+		body         string
+		expectedPath string
+		expectError  bool
+	}{
+		{
+			name:         "Build container log",
+			logUUID:      "{step}",
+			statusCode:   http.StatusOK,
+			body:         "+ make test\nok\n",
+			expectedPath: "/2.0/repositories/workspace/repo/pipelines/{pipeline}/steps/{step}/logs/{step}",
+		},
+		{
+			name:         "Service container log",
+			logUUID:      "{docker}",
+			statusCode:   http.StatusOK,
+			body:         "docker daemon started\n",
+			expectedPath: "/2.0/repositories/workspace/repo/pipelines/{pipeline}/steps/{step}/logs/{docker}",
+		},
+		{
+			name:         "Missing log is reported",
+			logUUID:      "{step}",
+			statusCode:   http.StatusNotFound,
+			body:         `{"error": {"message": "Not found"}}`,
+			expectedPath: "/2.0/repositories/workspace/repo/pipelines/{pipeline}/steps/{step}/logs/{step}",
+			expectError:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var capturedPath, capturedRange string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				capturedPath = r.URL.Path
+				capturedRange = r.Header.Get("Range")
+				w.WriteHeader(tt.statusCode)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			client := &RestClient{
+				workspace: "workspace",
+				client:    server.Client(),
+				baseURL:   server.URL + "/2.0",
+			}
+
+			data, err := client.GetFullStepLog(context.Background(), "repo", "{pipeline}", "{step}", tt.logUUID)
+			if capturedPath != tt.expectedPath {
+				t.Errorf("Expected path %s, got %s", tt.expectedPath, capturedPath)
+			}
+			if capturedRange != "" {
+				t.Errorf("Expected no Range header, got %q", capturedRange)
+			}
+			if tt.expectError {
+				if err == nil {
+					t.Fatal("Expected an error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetFullStepLog returned error: %v", err)
+			}
+			if string(data) != tt.body {
+				t.Errorf("Expected log %q, got %q", tt.body, string(data))
+			}
+		})
+	}
+}
+
+// TestListPipelinesFiltersAndPagination tests query parameters, limits across pages and the since cut-off
+func TestListPipelinesFiltersAndPagination(t *testing.T) {
+	pipelinePage := func(start, count int, createdOn string) []map[string]interface{} {
+		values := make([]map[string]interface{}, 0, count)
+		for i := 0; i < count; i++ {
+			values = append(values, map[string]interface{}{
+				"uuid":         fmt.Sprintf("{pipeline-%d}", start-i),
+				"build_number": start - i,
+				"created_on":   createdOn,
+				"state":        map[string]interface{}{"name": "COMPLETED"},
+			})
+		}
+		return values
+	}
+
 	tests := []struct {
 		name          string
+		opts          PipelinesOptions
 		pages         [][]map[string]interface{}
 		expectedCount int
+		expectedPages int
+		expectedQuery map[string]string
 	}{
 		{
-			name: "Single page",
-			pages: [][]map[string]interface{}{
-				{
-					{"key": "PROJ1", "name": "Project 1"},
-					{"key": "PROJ2", "name": "Project 2"},
-				},
-			},
+			name:          "Filters are sent as query parameters",
+			opts:          PipelinesOptions{RepoSlug: "repo", Limit: 5, Branch: "master", Status: "FAILED", Trigger: "SCHEDULE", CreatorUUID: "{user}"},
+			pages:         [][]map[string]interface{}{pipelinePage(10, 2, "2024-01-10T10:00:00Z")},
 			expectedCount: 2,
+			expectedPages: 1,
+			expectedQuery: map[string]string{
+				"pagelen":       "5",
+				"sort":          "-created_on",
+				"target.branch": "master",
+				"status":        "FAILED",
+				"trigger_type":  "SCHEDULE",
+				"creator.uuid":  "{user}",
+			},
+		},
+		{
+			name:          "Limit above page size follows next",
+			opts:          PipelinesOptions{RepoSlug: "repo", Limit: 150},
+			pages:         [][]map[string]interface{}{pipelinePage(300, 100, "2024-01-10T10:00:00Z"), pipelinePage(200, 100, "2024-01-09T10:00:00Z")},
+			expectedCount: 150,
+			expectedPages: 2,
+			expectedQuery: map[string]string{"pagelen": "100"},
 		},
 		{
-			name: "Multiple pages",
+			name: "Since stops pagination at older pipelines",
+			opts: PipelinesOptions{RepoSlug: "repo", Limit: 100, Since: time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC)},
 			pages: [][]map[string]interface{}{
-				{{"key": "PROJ1", "name": "Project 1"}},
-				{{"key": "PROJ2", "name": "Project 2"}},
-				{{"key": "PROJ3", "name": "Project 3"}},
+				append(pipelinePage(10, 2, "2024-01-10T10:00:00Z"), pipelinePage(8, 1, "2024-01-01T10:00:00Z")...),
+				pipelinePage(7, 2, "2023-12-31T10:00:00Z"),
 			},
-			expectedCount: 3,
+			expectedCount: 2,
+			expectedPages: 1,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			pageIndex := 0
+			var firstQuery map[string][]string
 
 			var server *httptest.Server
 			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if pageIndex == 0 {
+					firstQuery = r.URL.Query()
+				}
+
 				var nextURL string
 				if pageIndex < len(tt.pages)-1 {
-					nextURL = server.URL + "/2.0/workspaces/workspace/projects?pagelen=100&page=2"
+					nextURL = server.URL + "/2.0/repositories/workspace/repo/pipelines?page=2"
 				}
 
 				response := mockPaginatedResponse(tt.pages[pageIndex], nextURL)
@@ -874,14 +951,158 @@ func TestListProjectsPagination(t *testing.T) {
 				baseURL:   server.URL + "/2.0",
 			}
 
-			projects, err := client.ListProjects()
+			pipelines, err := client.ListPipelines(context.Background(), &tt.opts)
 			if err != nil {
-				t.Fatalf("ListProjects returned error: %v", err)
+				t.Fatalf("ListPipelines returned error: %v", err)
 			}
 
-			if len(projects) != tt.expectedCount {
-				t.Errorf("Expected %d projects, got %d", tt.expectedCount, len(projects))
+			if len(pipelines) != tt.expectedCount {
+				t.Errorf("Expected %d pipelines, got %d", tt.expectedCount, len(pipelines))
+			}
+			if pageIndex != tt.expectedPages {
+				t.Errorf("Expected %d page requests, got %d", tt.expectedPages, pageIndex)
+			}
+			for key, expected := range tt.expectedQuery {
+				if got := firstQuery[key]; len(got) != 1 || got[0] != expected {
+					t.Errorf("Expected query parameter %s=%s, got %v", key, expected, got)
+				}
 			}
 		})
 	}
 }
+
+// TestGetPipelineTestReports tests collecting step test reports and failing test cases
+func TestGetPipelineTestReports(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		base := "/2.0/repositories/workspace/repo/pipelines/{pipeline}/steps"
+		var response interface{}
+
+		switch r.URL.Path {
+		case base + "/":
+			response = mockPaginatedResponse([]map[string]interface{}{
+				{"uuid": "{build}", "name": "Build"},
+				{"uuid": "{test}", "name": "Test"},
+				{"uuid": "{lint}", "name": "Lint"},
+				{"uuid": "{e2e}", "name": "E2E"},
+			}, "")
+		case base + "/{build}/test_reports":
+			http.NotFound(w, r)
+			return
+		case base + "/{lint}/test_reports", base + "/{e2e}/test_reports/test_cases":
+			http.Error(w, `{"error": {"message": "unavailable"}}`, http.StatusServiceUnavailable)
+			return
+		case base + "/{e2e}/test_reports":
+			response = map[string]interface{}{
+				"number_of_test_cases":        2,
+				"number_of_failed_test_cases": 2,
+			}
+		case base + "/{test}/test_reports":
+			response = map[string]interface{}{
+				"number_of_test_cases":            3,
+				"number_of_successful_test_cases": 1,
+				"number_of_failed_test_cases":     1,
+				"number_of_skipped_test_cases":    1,
+			}
+		case base + "/{test}/test_reports/test_cases":
+			response = mockPaginatedResponse([]map[string]interface{}{
+				{"uuid": "{case-1}", "name": "TestOK", "status": "SUCCESS"},
+				{"uuid": "{case-2}", "name": "TestBroken", "fully_qualified_name": "pkg.TestBroken", "status": "FAILED"},
+				{"uuid": "{case-3}", "name": "TestSkipped", "status": "SKIPPED"},
+			}, "")
+		case base + "/{test}/test_reports/test_cases/{case-2}/test_case_reasons":
+			response = mockPaginatedResponse([]map[string]interface{}{
+				{"message": "expected 1, got 2"},
+			}, "")
+		default:
+			t.Errorf("Unexpected request: %s", r.URL.Path)
+			http.NotFound(w, r)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	}))
+	defer server.Close()
+
+	client := &Client{
+		workspace: "workspace",
+		restClient: &RestClient{
+			workspace: "workspace",
+			client:    server.Client(),
+			baseURL:   server.URL + "/2.0",
+		},
+	}
+
+	reports, err := client.GetPipelineTestReports(context.Background(), "repo", "{pipeline}")
+	if err != nil {
+		t.Fatalf("GetPipelineTestReports returned error: %v", err)
+	}
+
+	if len(reports) != 3 {
+		t.Fatalf("Expected 3 reports (steps without reports skipped), got %d", len(reports))
+	}
+
+	// Steps whose report or test cases failed keep the error and do not stop the others
+	if lint := reports[1]; lint.StepName != "Lint" || lint.Summary != nil || lint.Error == "" {
+		t.Errorf("Expected the Lint report to fail, got %+v", lint)
+	}
+	if e2e := reports[2]; e2e.StepName != "E2E" || e2e.Summary == nil || e2e.Summary.Failed != 2 || !strings.Contains(e2e.Error, "failing tests") {
+		t.Errorf("Expected the E2E summary with the error of its test cases, got %+v", e2e)
+	}
+
+	report := reports[0]
+	if report.Error != "" {
+		t.Errorf("Expected no error for step Test, got %s", report.Error)
+	}
+	if report.StepName != "Test" {
+		t.Errorf("Expected step Test, got %s", report.StepName)
+	}
+	expectedSummary := TestReportSummary{Total: 3, Passed: 1, Failed: 1, Skipped: 1}
+	if *report.Summary != expectedSummary {
+		t.Errorf("Expected summary %+v, got %+v", expectedSummary, *report.Summary)
+	}
+	if len(report.FailedCases) != 1 {
+		t.Fatalf("Expected 1 failed case, got %d", len(report.FailedCases))
+	}
+	if report.FailedCases[0].FullyQualifiedName != "pkg.TestBroken" {
+		t.Errorf("Expected failing test pkg.TestBroken, got %s", report.FailedCases[0].FullyQualifiedName)
+	}
+	if report.FailedCases[0].FailureMessage != "expected 1, got 2" {
+		t.Errorf("Expected failure message, got %q", report.FailedCases[0].FailureMessage)
+	}
+}
+
+// TestCanceledContextStopsPagination tests that a canceled context stops paginated listings
+func TestCanceledContextStopsPagination(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	requests := 0
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		// Cancel while the first page is being served
+		cancel()
+
+		response := mockPaginatedResponse([]map[string]interface{}{
+			{"slug": "repo1", "name": "Repo 1", "full_name": "workspace/repo1"},
+		}, server.URL+"/2.0/repositories/workspace?pagelen=100&page=2")
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	}))
+	defer server.Close()
+
+	client := &RestClient{
+		workspace: "workspace",
+		client:    server.Client(),
+		baseURL:   server.URL + "/2.0",
+	}
+
+	_, err := client.ListRepositories(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled error, got %v", err)
+	}
+	if requests != 1 {
+		t.Errorf("Expected pagination to stop after 1 request, got %d", requests)
+	}
+}
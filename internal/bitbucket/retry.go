@@ -0,0 +1,167 @@
+package bitbucket
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// defaultRetryBaseDelay is the backoff before the first retry, doubled on every attempt
+	defaultRetryBaseDelay = 500 * time.Millisecond
+	// maxRetryDelay caps the exponential backoff between two attempts
+	maxRetryDelay = 30 * time.Second
+	// maxRetryAfter is the longest server-requested wait we honour; beyond that we give up
+	maxRetryAfter = 2 * time.Minute
+)
+
+// retryableStatus reports whether a response status is worth retrying:
+// rate limiting and transient server errors
+func retryableStatus(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests,
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// idempotentMethod reports whether a request can safely be sent more than once
+func idempotentMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// neverReachedServer reports whether a transport error happened before any byte of
+// the request was sent, i.e. while resolving the host or opening the connection.
+// Only then is it safe to repeat a non-idempotent request.
+func neverReachedServer(err error) bool {
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return true
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		return opErr.Op == "dial"
+	}
+	return false
+}
+
+// retryAfter returns how long the server asked us to wait before the next attempt,
+// based on the Retry-After header (seconds or HTTP date) or, failing that, the
+// X-RateLimit-Reset header (Unix timestamp or seconds). It returns 0 if neither is set.
+func retryAfter(header http.Header, now time.Time) time.Duration {
+	if value := header.Get("Retry-After"); value != "" {
+		if seconds, err := strconv.Atoi(value); err == nil {
+			return time.Duration(max(seconds, 0)) * time.Second
+		}
+		if date, err := http.ParseTime(value); err == nil {
+			return max(date.Sub(now), 0)
+		}
+	}
+
+	if value := header.Get("X-RateLimit-Reset"); value != "" {
+		if reset, err := strconv.ParseInt(value, 10, 64); err == nil {
+			// Small values are a number of seconds, large ones a Unix timestamp
+			if reset < 1_000_000_000 {
+				return time.Duration(max(reset, 0)) * time.Second
+			}
+			return max(time.Unix(reset, 0).Sub(now), 0)
+		}
+	}
+
+	return 0
+}
+
+// backoff returns the jittered exponential delay before retry number attempt (starting at 0)
+func (c *RestClient) backoff(attempt int) time.Duration {
+	base := c.retryBaseDelay
+	if base <= 0 {
+		base = defaultRetryBaseDelay
+	}
+
+	delay := base << attempt
+	if delay <= 0 || delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+
+	// Equal jitter: wait between half and the full delay so concurrent
+	// clients hitting the same rate limit don't retry in lockstep
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// send executes the request built by newRequest with the given HTTP client and returns
+// the response and its fully read body. Rate-limited (429) and transient 5xx responses
+// and network errors are retried up to c.maxRetries times with exponential backoff.
+// Non-idempotent requests are only retried when they never reached the server.
+// newRequest is called for every attempt so the request body can be sent again.
+func (c *RestClient) send(ctx context.Context, client *http.Client, newRequest func() (*http.Request, error)) (*http.Response, []byte, error) {
+	for attempt := 0; ; attempt++ {
+		req, err := newRequest()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			if ctx.Err() != nil || attempt >= c.maxRetries {
+				return nil, nil, err
+			}
+			if !idempotentMethod(req.Method) && !neverReachedServer(err) {
+				return nil, nil, err
+			}
+			if waitErr := sleepContext(ctx, c.backoff(attempt)); waitErr != nil {
+				return nil, nil, err
+			}
+			continue
+		}
+
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return resp, nil, err
+		}
+
+		if !retryableStatus(resp.StatusCode) || attempt >= c.maxRetries || !idempotentMethod(req.Method) {
+			return resp, body, nil
+		}
+
+		delay := c.backoff(attempt)
+		if wait := retryAfter(resp.Header, time.Now()); wait > 0 {
+			if wait > maxRetryAfter {
+				// Waiting that long is worse than failing, report the rate limit instead
+				return resp, body, nil
+			}
+			delay = wait
+		}
+
+		if err := sleepContext(ctx, delay); err != nil {
+			return resp, body, nil
+		}
+	}
+}
+
+// sleepContext waits for d or until ctx is done, whichever comes first
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
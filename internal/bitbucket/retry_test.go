@@ -0,0 +1,178 @@
+package bitbucket
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newRetryTestClient(server *httptest.Server, maxRetries int) *RestClient {
+	return &RestClient{
+		workspace:      "workspace",
+		client:         server.Client(),
+		baseURL:        server.URL + "/2.0",
+		maxRetries:     maxRetries,
+		retryBaseDelay: time.Millisecond,
+	}
+}
+
+func TestRetryOnRateLimit(t *testing.T) {
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hits.Add(1) < 3 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"uuid": "{p1}", "build_number": 7}`))
+	}))
+	defer server.Close()
+
+	client := newRetryTestClient(server, 3)
+	pipeline, err := client.GetPipeline(context.Background(), "repo", "{p1}")
+	if err != nil {
+		t.Fatalf("GetPipeline() error = %v", err)
+	}
+	if pipeline.BuildNumber != 7 {
+		t.Errorf("BuildNumber = %d, want 7", pipeline.BuildNumber)
+	}
+	if got := hits.Load(); got != 3 {
+		t.Errorf("server hit %d times, want 3", got)
+	}
+}
+
+func TestRetryGivesUpAfterMaxRetries(t *testing.T) {
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = w.Write([]byte("unavailable"))
+	}))
+	defer server.Close()
+
+	client := newRetryTestClient(server, 2)
+	_, err := client.GetPipeline(context.Background(), "repo", "{p1}")
+	if err == nil || !strings.Contains(err.Error(), "status 503") {
+		t.Fatalf("GetPipeline() error = %v, want status 503", err)
+	}
+	if got := hits.Load(); got != 3 {
+		t.Errorf("server hit %d times, want 3 (1 attempt + 2 retries)", got)
+	}
+}
+
+func TestRetryDoesNotRepeatPostThatReachedServer(t *testing.T) {
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	client := newRetryTestClient(server, 3)
+	if err := client.StopPipeline(context.Background(), "repo", "{p1}"); err == nil {
+		t.Fatal("StopPipeline() expected error")
+	}
+	if got := hits.Load(); got != 1 {
+		t.Errorf("server hit %d times, want 1", got)
+	}
+}
+
+func TestRetryResendsBody(t *testing.T) {
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if !strings.Contains(string(body), `"enabled":false`) {
+			t.Errorf("attempt %d: body = %q", hits.Load()+1, body)
+		}
+		if hits.Add(1) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write([]byte(`{"uuid": "{s1}", "enabled": false}`))
+	}))
+	defer server.Close()
+
+	client := newRetryTestClient(server, 3)
+	if _, err := client.UpdatePipelineSchedule(context.Background(), "repo", "{s1}", false); err != nil {
+		t.Fatalf("UpdatePipelineSchedule() error = %v", err)
+	}
+	if got := hits.Load(); got != 2 {
+		t.Errorf("server hit %d times, want 2", got)
+	}
+}
+
+func TestRetryStopsWhenContextCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		cancel()
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	client := newRetryTestClient(server, 3)
+	if _, err := client.GetPipeline(ctx, "repo", "{p1}"); err == nil {
+		t.Fatal("GetPipeline() expected error")
+	}
+	if got := hits.Load(); got != 1 {
+		t.Errorf("server hit %d times, want 1", got)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		header http.Header
+		want   time.Duration
+	}{
+		{"none", http.Header{}, 0},
+		{"seconds", http.Header{"Retry-After": {"5"}}, 5 * time.Second},
+		{"http date", http.Header{"Retry-After": {now.Add(10 * time.Second).Format(http.TimeFormat)}}, 10 * time.Second},
+		{"date in the past", http.Header{"Retry-After": {now.Add(-time.Minute).Format(http.TimeFormat)}}, 0},
+		{"rate limit reset timestamp", http.Header{"X-Ratelimit-Reset": {"1735732830"}}, 30 * time.Second},
+		{"rate limit reset seconds", http.Header{"X-Ratelimit-Reset": {"15"}}, 15 * time.Second},
+		{"retry after wins", http.Header{"Retry-After": {"2"}, "X-Ratelimit-Reset": {"15"}}, 2 * time.Second},
+		{"invalid", http.Header{"Retry-After": {"soon"}}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := retryAfter(tt.header, now); got != tt.want {
+				t.Errorf("retryAfter() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNeverReachedServer(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"dial error", &net.OpError{Op: "dial", Err: errors.New("connection refused")}, true},
+		{"dns error", &net.DNSError{Err: "no such host", Name: "api.bitbucket.org"}, true},
+		{"read error", &net.OpError{Op: "read", Err: errors.New("connection reset by peer")}, false},
+		{"other error", errors.New("unexpected EOF"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := neverReachedServer(tt.err); got != tt.want {
+				t.Errorf("neverReachedServer() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
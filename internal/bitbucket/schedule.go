@@ -0,0 +1,315 @@
+package bitbucket
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PipelineSchedule represents a scheduled pipeline
+type PipelineSchedule struct {
+	UUID        string     `json:"uuid" yaml:"uuid"`
+	Enabled     bool       `json:"enabled" yaml:"enabled"`
+	Branch      string     `json:"branch" yaml:"branch"`
+	Selector    string     `json:"selector,omitempty" yaml:"selector,omitempty"` // Custom pipeline name
+	CronPattern string     `json:"cron_pattern" yaml:"cron_pattern"`
+	CreatedOn   *time.Time `json:"created_on,omitempty" yaml:"created_on,omitempty"`
+	UpdatedOn   *time.Time `json:"updated_on,omitempty" yaml:"updated_on,omitempty"`
+}
+
+// CreatePipelineScheduleOptions holds options for creating a pipeline schedule
+type CreatePipelineScheduleOptions struct {
+	RepoSlug string
+	Branch   string // Branch to run the pipeline on
+	Selector string // Custom pipeline from bitbucket-pipelines.yml
+	Cron     string // Standard 5-field or Quartz 6/7-field cron expression (UTC)
+}
+
+// cronField describes the allowed values of a Quartz cron field
+type cronField struct {
+	name     string
+	min, max int
+	names    []string // Optional value names, index 0 maps to min
+	optional bool     // Allows "?"
+	special  bool     // Allows L, W and # modifiers
+}
+
+var quartzFields = []cronField{
+	{name: "seconds", min: 0, max: 59},
+	{name: "minutes", min: 0, max: 59},
+	{name: "hours", min: 0, max: 23},
+	{name: "day of month", min: 1, max: 31, optional: true, special: true},
+	{name: "month", min: 1, max: 12, names: []string{"JAN", "FEB", "MAR", "APR", "MAY", "JUN", "JUL", "AUG", "SEP", "OCT", "NOV", "DEC"}},
+	{name: "day of week", min: 1, max: 7, names: []string{"SUN", "MON", "TUE", "WED", "THU", "FRI", "SAT"}, optional: true, special: true},
+	{name: "year", min: 1970, max: 2099},
+}
+
+// standardWeekdays maps standard cron day-of-week numbers (0 or 7 is Sunday) to Quartz names
+var standardWeekdays = []string{"SUN", "MON", "TUE", "WED", "THU", "FRI", "SAT", "SUN"}
+
+// ParseCronExpression validates a cron expression and returns it in the 7-field
+// Quartz format used by Bitbucket (seconds minutes hours day-of-month month
+// day-of-week year). Standard 5-field expressions (minutes hours day-of-month
+// month day-of-week) are converted; 6 and 7-field Quartz expressions are
+// validated as they are. Schedules run in UTC.
+func ParseCronExpression(expr string) (string, error) {
+	fields := strings.Fields(strings.ToUpper(expr))
+
+	switch len(fields) {
+	case 5:
+		converted, err := convertStandardCron(fields)
+		if err != nil {
+			return "", fmt.Errorf("invalid cron expression %q: %w", expr, err)
+		}
+		fields = converted
+	case 6:
+		fields = append(fields, "*")
+	case 7:
+	default:
+		return "", fmt.Errorf("invalid cron expression %q: expected 5 fields (minute hour day-of-month month day-of-week) or a 6/7-field Quartz expression, got %d fields", expr, len(fields))
+	}
+
+	for i, field := range fields {
+		if err := validateCronField(field, quartzFields[i]); err != nil {
+			return "", fmt.Errorf("invalid cron expression %q: %w", expr, err)
+		}
+	}
+
+	// Quartz requires exactly one of day-of-month and day-of-week to be "?"
+	dayOfMonth, dayOfWeek := fields[3], fields[5]
+	if (dayOfMonth == "?") == (dayOfWeek == "?") {
+		return "", fmt.Errorf("invalid cron expression %q: exactly one of day of month and day of week must be '?'", expr)
+	}
+
+	return strings.Join(fields, " "), nil
+}
+
+// convertStandardCron converts a 5-field cron expression to 7 Quartz fields
+func convertStandardCron(fields []string) ([]string, error) {
+	minutes, hours, dayOfMonth, month, dayOfWeek := fields[0], fields[1], fields[2], fields[3], fields[4]
+
+	if dayOfMonth == "?" || dayOfWeek == "?" {
+		return nil, fmt.Errorf("'?' is not supported in 5-field cron expressions")
+	}
+
+	switch {
+	case dayOfWeek == "*":
+		dayOfWeek = "?"
+	case dayOfMonth == "*":
+		dayOfMonth = "?"
+		converted, err := convertStandardWeekdays(dayOfWeek)
+		if err != nil {
+			return nil, err
+		}
+		dayOfWeek = converted
+	default:
+		return nil, fmt.Errorf("restricting both day of month and day of week is not supported")
+	}
+
+	return []string{"0", minutes, hours, dayOfMonth, month, dayOfWeek, "*"}, nil
+}
+
+// convertStandardWeekdays replaces standard day-of-week numbers (0-7) with Quartz names
+func convertStandardWeekdays(field string) (string, error) {
+	var b strings.Builder
+	number := ""
+
+	flush := func() error {
+		if number == "" {
+			return nil
+		}
+		n, _ := strconv.Atoi(number)
+		if n >= len(standardWeekdays) {
+			return fmt.Errorf("day of week value %d out of range 0-7", n)
+		}
+		b.WriteString(standardWeekdays[n])
+		number = ""
+		return nil
+	}
+
+	afterSlash := false
+	for _, r := range field {
+		switch {
+		case r >= '0' && r <= '9' && !afterSlash:
+			number += string(r)
+		case r >= '0' && r <= '9':
+			// Step values are not weekdays
+			b.WriteRune(r)
+		default:
+			if err := flush(); err != nil {
+				return "", err
+			}
+			afterSlash = r == '/'
+			b.WriteRune(r)
+		}
+	}
+	if err := flush(); err != nil {
+		return "", err
+	}
+
+	return b.String(), nil
+}
+
+// validateCronField checks a single Quartz cron field
+func validateCronField(value string, field cronField) error {
+	if value == "?" {
+		if !field.optional {
+			return fmt.Errorf("'?' is not allowed in %s", field.name)
+		}
+		return nil
+	}
+
+	for _, part := range strings.Split(value, ",") {
+		if err := validateCronPart(part, field); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateCronPart checks one comma-separated element of a cron field
+func validateCronPart(part string, field cronField) error {
+	if part == "" {
+		return fmt.Errorf("empty value in %s", field.name)
+	}
+
+	if field.special {
+		switch {
+		case part == "L":
+			return nil
+		case part == "LW" && field.name == "day of month":
+			return nil
+		case strings.HasSuffix(part, "W") && field.name == "day of month":
+			return validateCronValue(strings.TrimSuffix(part, "W"), field)
+		case strings.HasSuffix(part, "L") && field.name == "day of week":
+			return validateCronValue(strings.TrimSuffix(part, "L"), field)
+		case strings.Contains(part, "#") && field.name == "day of week":
+			day, nth, _ := strings.Cut(part, "#")
+			if n, err := strconv.Atoi(nth); err != nil || n < 1 || n > 5 {
+				return fmt.Errorf("invalid occurrence %q in %s (must be 1-5)", nth, field.name)
+			}
+			return validateCronValue(day, field)
+		}
+	}
+
+	rangePart, step, hasStep := strings.Cut(part, "/")
+	if hasStep {
+		n, err := strconv.Atoi(step)
+		if err != nil || n < 1 {
+			return fmt.Errorf("invalid step %q in %s", step, field.name)
+		}
+	}
+
+	if rangePart == "*" {
+		return nil
+	}
+
+	start, end, isRange := strings.Cut(rangePart, "-")
+	if err := validateCronValue(start, field); err != nil {
+		return err
+	}
+	if isRange {
+		return validateCronValue(end, field)
+	}
+	return nil
+}
+
+// validateCronValue checks a single number or name against the field range
+func validateCronValue(value string, field cronField) error {
+	for _, name := range field.names {
+		if value == name {
+			return nil
+		}
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return fmt.Errorf("invalid value %q in %s", value, field.name)
+	}
+	if n < field.min || n > field.max {
+		return fmt.Errorf("value %d out of range %d-%d in %s", n, field.min, field.max, field.name)
+	}
+	return nil
+}
+
+// ListPipelineSchedules retrieves the pipeline schedules of a repository
+func (c *Client) ListPipelineSchedules(ctx context.Context, repoSlug string) ([]*PipelineSchedule, error) {
+	if repoSlug == "" {
+		return nil, fmt.Errorf("repository slug is required")
+	}
+
+	return c.restClient.ListPipelineSchedules(ctx, repoSlug)
+}
+
+// CreatePipelineSchedule validates the cron expression and creates a pipeline schedule
+func (c *Client) CreatePipelineSchedule(ctx context.Context, opts *CreatePipelineScheduleOptions) (*PipelineSchedule, error) {
+	if opts.RepoSlug == "" {
+		return nil, fmt.Errorf("repository slug is required")
+	}
+	if opts.Branch == "" {
+		return nil, fmt.Errorf("branch is required")
+	}
+	if opts.Selector == "" {
+		return nil, fmt.Errorf("custom pipeline selector is required")
+	}
+
+	cronPattern, err := ParseCronExpression(opts.Cron)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.restClient.CreatePipelineSchedule(ctx, opts.RepoSlug, opts.Branch, opts.Selector, cronPattern)
+}
+
+// SetPipelineScheduleEnabled enables or disables a pipeline schedule
+func (c *Client) SetPipelineScheduleEnabled(ctx context.Context, repoSlug, scheduleUUID string, enabled bool) (*PipelineSchedule, error) {
+	if repoSlug == "" || scheduleUUID == "" {
+		return nil, fmt.Errorf("repository slug and schedule UUID are required")
+	}
+
+	return c.restClient.UpdatePipelineSchedule(ctx, repoSlug, scheduleUUID, enabled)
+}
+
+// DeletePipelineSchedule deletes a pipeline schedule
+func (c *Client) DeletePipelineSchedule(ctx context.Context, repoSlug, scheduleUUID string) error {
+	if repoSlug == "" || scheduleUUID == "" {
+		return fmt.Errorf("repository slug and schedule UUID are required")
+	}
+
+	return c.restClient.DeletePipelineSchedule(ctx, repoSlug, scheduleUUID)
+}
+
+// FindPipelineSchedule finds a schedule by UUID or unique UUID prefix (braces optional)
+func (c *Client) FindPipelineSchedule(ctx context.Context, repoSlug, id string) (*PipelineSchedule, error) {
+	schedules, err := c.ListPipelineSchedules(ctx, repoSlug)
+	if err != nil {
+		return nil, err
+	}
+
+	id = strings.ToLower(strings.Trim(id, "{}"))
+	if id == "" {
+		return nil, fmt.Errorf("schedule ID is required")
+	}
+
+	var matches []*PipelineSchedule
+	for _, schedule := range schedules {
+		uuid := strings.ToLower(strings.Trim(schedule.UUID, "{}"))
+		if uuid == id {
+			return schedule, nil
+		}
+		if strings.HasPrefix(uuid, id) {
+			matches = append(matches, schedule)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("no schedule found with ID %s", id)
+	case 1:
+		return matches[0], nil
+	default:
+		return nil, fmt.Errorf("schedule ID %s is ambiguous (%d matches)", id, len(matches))
+	}
+}
@@ -0,0 +1,58 @@
+package bitbucket
+
+import (
+	"testing"
+)
+
+// TestParseCronExpression tests local cron validation and conversion to Quartz format
+func TestParseCronExpression(t *testing.T) {
+	tests := []struct {
+		name        string
+		expr        string
+		expected    string
+		expectError bool
+	}{
+		{name: "Daily at 02:30", expr: "30 2 * * *", expected: "0 30 2 * * ? *"},
+		{name: "Weekdays with numbers", expr: "0 3 * * 1-5", expected: "0 0 3 ? * MON-FRI *"},
+		{name: "Sunday as 0 and 7", expr: "0 3 * * 0,7", expected: "0 0 3 ? * SUN,SUN *"},
+		{name: "Weekday names", expr: "0 3 * * mon-fri", expected: "0 0 3 ? * MON-FRI *"},
+		{name: "Day of week step", expr: "0 3 * * */2", expected: "0 0 3 ? * */2 *"},
+		{name: "First of month", expr: "0 0 1 * *", expected: "0 0 0 1 * ? *"},
+		{name: "Every 15 minutes", expr: "*/15 * * * *", expected: "0 */15 * * * ? *"},
+		{name: "Month names", expr: "0 0 1 jan,jul *", expected: "0 0 0 1 JAN,JUL ? *"},
+		{name: "Quartz 6 fields", expr: "0 0 12 ? * MON", expected: "0 0 12 ? * MON *"},
+		{name: "Quartz 7 fields", expr: "0 0 12 * * ? 2030", expected: "0 0 12 * * ? 2030"},
+		{name: "Quartz last day of month", expr: "0 0 12 L * ? *", expected: "0 0 12 L * ? *"},
+		{name: "Quartz nth weekday", expr: "0 0 12 ? * 2#1 *", expected: "0 0 12 ? * 2#1 *"},
+		{name: "Too few fields", expr: "0 3 * *", expectError: true},
+		{name: "Too many fields", expr: "0 0 3 * * ? * *", expectError: true},
+		{name: "Minute out of range", expr: "60 3 * * *", expectError: true},
+		{name: "Hour out of range", expr: "0 24 * * *", expectError: true},
+		{name: "Invalid day of week", expr: "0 3 * * 8", expectError: true},
+		{name: "Both day fields restricted", expr: "0 3 1 * 1", expectError: true},
+		{name: "Question mark in 5 fields", expr: "0 3 ? * 1", expectError: true},
+		{name: "Invalid step", expr: "*/0 * * * *", expectError: true},
+		{name: "Garbage value", expr: "0 3 * * foo", expectError: true},
+		{name: "Quartz without question mark", expr: "0 0 12 * * MON *", expectError: true},
+		{name: "Quartz with two question marks", expr: "0 0 12 ? * ? *", expectError: true},
+		{name: "Empty expression", expr: "", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ParseCronExpression(tt.expr)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Expected an error for %q, got %q", tt.expr, result)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseCronExpression(%q) returned error: %v", tt.expr, err)
+			}
+			if result != tt.expected {
+				t.Errorf("ParseCronExpression(%q) = %q, expected %q", tt.expr, result, tt.expected)
+			}
+		})
+	}
+}
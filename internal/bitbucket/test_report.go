@@ -0,0 +1,115 @@
+package bitbucket
+
+import (
+	"context"
+	"fmt"
+)
+
+// maxFailureReasonsPerStep limits how many failing test cases get their failure reason fetched
+const maxFailureReasonsPerStep = 25
+
+// TestReportSummary holds the test counts of a step's test report
+type TestReportSummary struct {
+	Total   int `json:"total" yaml:"total"`
+	Passed  int `json:"passed" yaml:"passed"`
+	Failed  int `json:"failed" yaml:"failed"`
+	Errors  int `json:"errors" yaml:"errors"`
+	Skipped int `json:"skipped" yaml:"skipped"`
+}
+
+// TestCase represents a single test case of a step's test report
+type TestCase struct {
+	UUID               string `json:"uuid" yaml:"uuid"`
+	Name               string `json:"name" yaml:"name"`
+	FullyQualifiedName string `json:"fully_qualified_name,omitempty" yaml:"fully_qualified_name,omitempty"`
+	PackageName        string `json:"package_name,omitempty" yaml:"package_name,omitempty"`
+	Status             string `json:"status" yaml:"status"` // SUCCESS, FAILED, ERROR, SKIPPED
+	Duration           string `json:"duration,omitempty" yaml:"duration,omitempty"`
+	FailureMessage     string `json:"failure_message,omitempty" yaml:"failure_message,omitempty"`
+}
+
+// StepTestReport holds the test report of a pipeline step. Error is set if
+// the report or its failing test cases could not be fetched; Summary is nil
+// if the report itself failed.
+type StepTestReport struct {
+	StepUUID    string             `json:"step_uuid" yaml:"step_uuid"`
+	StepName    string             `json:"step_name" yaml:"step_name"`
+	Summary     *TestReportSummary `json:"summary" yaml:"summary"`
+	FailedCases []*TestCase        `json:"failed_cases,omitempty" yaml:"failed_cases,omitempty"`
+	Error       string             `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// IsFailed returns true if a test case failed or errored
+func (t *TestCase) IsFailed() bool {
+	return t.Status == "FAILED" || t.Status == "ERROR"
+}
+
+// GetPipelineTestReports retrieves the test reports of all steps of a pipeline.
+// Steps without a test report are skipped. Failing test cases include their
+// failure message. A step whose report fails to load is returned with its
+// Error set, so that the reports of the other steps are still shown.
+func (c *Client) GetPipelineTestReports(ctx context.Context, repoSlug, pipelineUUID string) ([]*StepTestReport, error) {
+	if repoSlug == "" || pipelineUUID == "" {
+		return nil, fmt.Errorf("repository slug and pipeline UUID are required")
+	}
+
+	steps, err := c.restClient.GetPipelineSteps(ctx, repoSlug, pipelineUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	reports := make([]*StepTestReport, 0)
+	for _, step := range steps {
+		summary, err := c.restClient.GetStepTestReport(ctx, repoSlug, pipelineUUID, step.UUID)
+		if err != nil {
+			reports = append(reports, &StepTestReport{StepUUID: step.UUID, StepName: step.Name, Error: err.Error()})
+			continue
+		}
+		if summary == nil {
+			continue
+		}
+
+		report := &StepTestReport{
+			StepUUID: step.UUID,
+			StepName: step.Name,
+			Summary:  summary,
+		}
+
+		if report.Summary.Failed > 0 || report.Summary.Errors > 0 {
+			report.FailedCases, err = c.getFailedTestCases(ctx, repoSlug, pipelineUUID, step.UUID)
+			if err != nil {
+				report.Error = fmt.Sprintf("failed to list the failing tests: %v", err)
+			}
+		}
+
+		reports = append(reports, report)
+	}
+
+	return reports, nil
+}
+
+// getFailedTestCases lists the failing test cases of a step with their failure messages
+func (c *Client) getFailedTestCases(ctx context.Context, repoSlug, pipelineUUID, stepUUID string) ([]*TestCase, error) {
+	testCases, err := c.restClient.ListStepTestCases(ctx, repoSlug, pipelineUUID, stepUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	failed := make([]*TestCase, 0)
+	for _, testCase := range testCases {
+		if !testCase.IsFailed() {
+			continue
+		}
+
+		if testCase.UUID != "" && len(failed) < maxFailureReasonsPerStep {
+			message, err := c.restClient.GetTestCaseReasons(ctx, repoSlug, pipelineUUID, stepUUID, testCase.UUID)
+			if err == nil {
+				testCase.FailureMessage = message
+			}
+		}
+
+		failed = append(failed, testCase)
+	}
+
+	return failed, nil
+}
@@ -2,37 +2,45 @@ package bitbucket
 
 import "fmt"
 
+// The functions below build links into the Bitbucket web UI at webURL, which
+// is config.DefaultWebURL unless bitbucket.web_url is configured
+
 // BuildRepositoryURL constructs the main Bitbucket repository URL
-func BuildRepositoryURL(workspace, repoSlug string) string {
-	return fmt.Sprintf("https://bitbucket.org/%s/%s", workspace, repoSlug)
+func BuildRepositoryURL(webURL, workspace, repoSlug string) string {
+	return fmt.Sprintf("%s/%s/%s", webURL, workspace, repoSlug)
 }
 
 // BuildPipelinesURL constructs the Bitbucket pipelines page URL
-func BuildPipelinesURL(workspace, repoSlug string) string {
-	return fmt.Sprintf("https://bitbucket.org/%s/%s/pipelines", workspace, repoSlug)
+func BuildPipelinesURL(webURL, workspace, repoSlug string) string {
+	return fmt.Sprintf("%s/%s/%s/pipelines", webURL, workspace, repoSlug)
+}
+
+// BuildPipelineResultURL constructs the Bitbucket URL for a single pipeline run
+func BuildPipelineResultURL(webURL, workspace, repoSlug string, buildNumber int) string {
+	return fmt.Sprintf("%s/%s/%s/pipelines/results/%d", webURL, workspace, repoSlug, buildNumber)
 }
 
 // BuildPullRequestsURL constructs the Bitbucket pull requests page URL
-func BuildPullRequestsURL(workspace, repoSlug string) string {
-	return fmt.Sprintf("https://bitbucket.org/%s/%s/pull-requests", workspace, repoSlug)
+func BuildPullRequestsURL(webURL, workspace, repoSlug string) string {
+	return fmt.Sprintf("%s/%s/%s/pull-requests", webURL, workspace, repoSlug)
 }
 
 // BuildPullRequestURL constructs the Bitbucket pull request URL for a specific PR
-func BuildPullRequestURL(workspace, repoSlug string, prID int) string {
-	return fmt.Sprintf("https://bitbucket.org/%s/%s/pull-requests/%d", workspace, repoSlug, prID)
+func BuildPullRequestURL(webURL, workspace, repoSlug string, prID int) string {
+	return fmt.Sprintf("%s/%s/%s/pull-requests/%d", webURL, workspace, repoSlug, prID)
 }
 
 // BuildDeploymentVariablesURL constructs the Bitbucket deployment variables settings page URL
-func BuildDeploymentVariablesURL(workspace, repoSlug string) string {
-	return fmt.Sprintf("https://bitbucket.org/%s/%s/admin/pipelines/deployment-settings", workspace, repoSlug)
+func BuildDeploymentVariablesURL(webURL, workspace, repoSlug string) string {
+	return fmt.Sprintf("%s/%s/%s/admin/pipelines/deployment-settings", webURL, workspace, repoSlug)
 }
 
 // BuildRepositoryVariablesURL constructs the Bitbucket repository variables settings page URL
-func BuildRepositoryVariablesURL(workspace, repoSlug string) string {
-	return fmt.Sprintf("https://bitbucket.org/%s/%s/admin/pipelines/repository-variables", workspace, repoSlug)
+func BuildRepositoryVariablesURL(webURL, workspace, repoSlug string) string {
+	return fmt.Sprintf("%s/%s/%s/admin/pipelines/repository-variables", webURL, workspace, repoSlug)
 }
 
 // BuildSettingsURL constructs the Bitbucket repository settings page URL
-func BuildSettingsURL(workspace, repoSlug string) string {
-	return fmt.Sprintf("https://bitbucket.org/%s/%s/admin", workspace, repoSlug)
+func BuildSettingsURL(webURL, workspace, repoSlug string) string {
+	return fmt.Sprintf("%s/%s/%s/admin", webURL, workspace, repoSlug)
 }
@@ -2,9 +2,14 @@ package config
 
 import (
 	"fmt"
+	"net"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
+	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 )
 
@@ -17,11 +22,23 @@ func GetTokenFilePath() (string, error) {
 	return filepath.Join(home, ".eiscli", "tokens.json"), nil
 }
 
+// GetCacheDir returns the directory of the API response cache
+func GetCacheDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(home, ".eiscli", "cache"), nil
+}
+
 // Config holds the application configuration
 type Config struct {
-	Bitbucket  BitbucketConfig  `mapstructure:"bitbucket"`
-	Deployment DeploymentConfig `mapstructure:"deployment"`
-	AWS        AWSConfig        `mapstructure:"aws"`
+	Bitbucket   BitbucketConfig   `mapstructure:"bitbucket"`
+	Deployment  DeploymentConfig  `mapstructure:"deployment"`
+	AWS         AWSConfig         `mapstructure:"aws"`
+	Debug       DebugConfig       `mapstructure:"debug"`
+	Cache       CacheConfig       `mapstructure:"cache"`
+	PullRequest PullRequestConfig `mapstructure:"pull_request"`
 }
 
 // BitbucketConfig holds Bitbucket-specific configuration
@@ -36,8 +53,25 @@ type BitbucketConfig struct {
 	AppPassword string `mapstructure:"app_password"`
 
 	Workspace string `mapstructure:"workspace"`
+
+	// MaxRetries is how often rate-limited (429) and transient 5xx responses are retried
+	MaxRetries int `mapstructure:"max_retries"`
+
+	// APIURL and WebURL point the CLI at a Bitbucket other than bitbucket.org,
+	// e.g. a local stand-in server for testing
+	APIURL string `mapstructure:"api_url"`
+	WebURL string `mapstructure:"web_url"`
 }
 
+// DefaultMaxRetries is used when bitbucket.max_retries is not configured
+const DefaultMaxRetries = 3
+
+// Default Bitbucket Cloud URLs, used when bitbucket.api_url and bitbucket.web_url are not configured
+const (
+	DefaultAPIURL = "https://api.bitbucket.org/2.0"
+	DefaultWebURL = "https://bitbucket.org"
+)
+
 // DeploymentConfig holds deployment-related configuration
 type DeploymentConfig struct {
 	AutoCreateEnvironments bool   `mapstructure:"auto_create_environments"`
@@ -51,6 +85,35 @@ type AWSConfig struct {
 	Region         string `mapstructure:"region"`
 }
 
+// DebugConfig controls tracing of API requests
+type DebugConfig struct {
+	// Enabled logs method, URL, status, latency and size of every request to stderr
+	Enabled bool `mapstructure:"enabled"`
+
+	// DumpDir receives the full, redacted request/response pairs if set
+	DumpDir string `mapstructure:"dump_dir"`
+}
+
+// CacheConfig controls the on-disk cache of workspace listings
+type CacheConfig struct {
+	// TTL is how long cached listings are used without asking Bitbucket; after
+	// that they are revalidated. Zero disables the cache.
+	TTL time.Duration `mapstructure:"ttl"`
+
+	// Disabled fetches fresh listings (--no-cache), which still update the cache
+	Disabled bool `mapstructure:"disabled"`
+}
+
+// PullRequestConfig holds defaults for creating pull requests
+type PullRequestConfig struct {
+	// Template is a Markdown file used as the description of new pull requests
+	// in repositories without a .bitbucket/pull_request_template.md
+	Template string `mapstructure:"template"`
+}
+
+// DefaultCacheTTL is used when cache.ttl is not configured
+const DefaultCacheTTL = 10 * time.Minute
+
 var globalConfig *Config
 
 // Load reads configuration from file and environment variables
@@ -80,6 +143,13 @@ func Load() (*Config, error) {
 	_ = viper.BindEnv("bitbucket.client_id", "EISCLI_BITBUCKET_CLIENT_ID")
 	_ = viper.BindEnv("bitbucket.client_secret", "EISCLI_BITBUCKET_CLIENT_SECRET")
 	_ = viper.BindEnv("bitbucket.use_oauth", "EISCLI_BITBUCKET_USE_OAUTH")
+	_ = viper.BindEnv("bitbucket.max_retries", "EISCLI_BITBUCKET_MAX_RETRIES")
+	_ = viper.BindEnv("bitbucket.api_url", "EISCLI_BITBUCKET_API_URL")
+	_ = viper.BindEnv("bitbucket.web_url", "EISCLI_BITBUCKET_WEB_URL")
+	_ = viper.BindEnv("debug.enabled", "EISCLI_DEBUG")
+	_ = viper.BindEnv("debug.dump_dir", "EISCLI_DEBUG_DUMP")
+	_ = viper.BindEnv("cache.ttl", "EISCLI_CACHE_TTL")
+	_ = viper.BindEnv("cache.disabled", "EISCLI_NO_CACHE")
 
 	// Bind AWS environment variables
 	_ = viper.BindEnv("aws.default_profile", "AWS_PROFILE")
@@ -123,6 +193,26 @@ func Load() (*Config, error) {
 		}
 	}
 
+	// Retry transient API failures unless explicitly configured (0 disables retries)
+	if !viper.IsSet("bitbucket.max_retries") {
+		config.Bitbucket.MaxRetries = DefaultMaxRetries
+	}
+
+	// Cache workspace listings unless explicitly configured (0 disables the cache)
+	if !viper.IsSet("cache.ttl") {
+		config.Cache.TTL = DefaultCacheTTL
+	}
+
+	// Talk to Bitbucket Cloud unless another API is configured
+	config.Bitbucket.APIURL = strings.TrimRight(config.Bitbucket.APIURL, "/")
+	if config.Bitbucket.APIURL == "" {
+		config.Bitbucket.APIURL = DefaultAPIURL
+	}
+	config.Bitbucket.WebURL = strings.TrimRight(config.Bitbucket.WebURL, "/")
+	if config.Bitbucket.WebURL == "" {
+		config.Bitbucket.WebURL = DefaultWebURL
+	}
+
 	// Set defaults for AWS config
 	if config.AWS.Region == "" {
 		config.AWS.Region = "eu-central-1"
@@ -138,6 +228,12 @@ func Load() (*Config, error) {
 	return globalConfig, nil
 }
 
+// BindFlag makes a command line flag override the configuration key, e.g.
+// --api-url for bitbucket.api_url. It must be called before Load.
+func BindFlag(key string, flag *pflag.Flag) error {
+	return viper.BindPFlag(key, flag)
+}
+
 // Build-time OAuth default providers (set by bitbucket package init)
 var (
 	getBuildTimeClientID     func() string
@@ -179,6 +275,21 @@ func createDefaultConfigFile() error {
 bitbucket:
   # Your Bitbucket workspace (organization slug)
   workspace: "cover42"
+  # How often rate-limited or failing API requests are retried (0 disables retries)
+  #max_retries: 3
+  # Bitbucket API and web URLs (only needed for a Bitbucket other than bitbucket.org);
+  # the API URL must use https unless it points at localhost
+  #api_url: "https://api.bitbucket.org/2.0"
+  #web_url: "https://bitbucket.org"
+
+# How long repository and project listings are cached in ~/.eiscli/cache (0 disables the cache)
+#cache:
+#  ttl: 10m
+
+# Description template of new pull requests, for repositories without a
+# .bitbucket/pull_request_template.md
+#pull_request:
+#  template: "~/.eiscli/pull_request_template.md"
 
 # AWS Configuration (optional)
 # Uncomment and modify if you need custom AWS profiles
@@ -202,6 +313,12 @@ bitbucket:
 	return nil
 }
 
+// Reset forgets the loaded configuration, so that the next Load reads the
+// configuration file, environment and flags again
+func Reset() {
+	globalConfig = nil
+}
+
 // Get returns the global configuration
 func Get() *Config {
 	if globalConfig == nil {
@@ -234,6 +351,26 @@ func (c *Config) Validate() error {
 		}
 	}
 
+	if c.Bitbucket.MaxRetries < 0 {
+		return fmt.Errorf("bitbucket max_retries must not be negative")
+	}
+
+	if c.Cache.TTL < 0 {
+		return fmt.Errorf("cache ttl must not be negative")
+	}
+
+	// The credentials are sent to the API, so plain http is only accepted for
+	// a stand-in server on this machine
+	if !isHTTPURL(c.Bitbucket.APIURL) {
+		return fmt.Errorf("bitbucket api_url must be an http(s) URL, got %q", c.Bitbucket.APIURL)
+	}
+	if !isSecureURL(c.Bitbucket.APIURL) {
+		return fmt.Errorf("bitbucket api_url must use https unless it points at localhost, got %q", c.Bitbucket.APIURL)
+	}
+	if !isHTTPURL(c.Bitbucket.WebURL) {
+		return fmt.Errorf("bitbucket web_url must be an http(s) URL, got %q", c.Bitbucket.WebURL)
+	}
+
 	// Validate deployment config if default environment type is set
 	if c.Deployment.DefaultEnvironmentType != "" {
 		validTypes := []string{"Test", "Staging", "Production"}
@@ -251,3 +388,33 @@ func (c *Config) Validate() error {
 
 	return nil
 }
+
+// isHTTPURL reports whether value is empty (i.e. the default is used) or an absolute http(s) URL
+func isHTTPURL(value string) bool {
+	if value == "" {
+		return true
+	}
+	u, err := url.Parse(value)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// isSecureURL reports whether value is empty, an https URL or an http URL of a
+// loopback host
+func isSecureURL(value string) bool {
+	if value == "" {
+		return true
+	}
+	u, err := url.Parse(value)
+	if err != nil {
+		return false
+	}
+	if u.Scheme == "https" {
+		return true
+	}
+	host := u.Hostname()
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,41 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateAPIURL(t *testing.T) {
+	tests := []struct {
+		apiURL  string
+		wantErr string
+	}{
+		{"", ""},
+		{"https://api.bitbucket.org/2.0", ""},
+		{"https://bitbucket.example.com/api/2.0", ""},
+		{"http://127.0.0.1:7990/2.0", ""},
+		{"http://localhost:7990/2.0", ""},
+		{"http://[::1]:7990/2.0", ""},
+		{"http://bitbucket.example.com/2.0", "must use https"},
+		{"http://10.0.0.5:7990/2.0", "must use https"},
+		{"http://localhost.example.com/2.0", "must use https"},
+		{"ftp://api.bitbucket.org/2.0", "must be an http(s) URL"},
+		{"api.bitbucket.org/2.0", "must be an http(s) URL"},
+	}
+
+	for _, tt := range tests {
+		cfg := &Config{Bitbucket: BitbucketConfig{
+			Workspace:   "workspace",
+			Username:    "user",
+			AppPassword: "secret",
+			APIURL:      tt.apiURL,
+		}}
+		err := cfg.Validate()
+		switch {
+		case tt.wantErr == "" && err != nil:
+			t.Errorf("Validate() with api_url %q error = %v, want none", tt.apiURL, err)
+		case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+			t.Errorf("Validate() with api_url %q error = %v, want %q", tt.apiURL, err, tt.wantErr)
+		}
+	}
+}
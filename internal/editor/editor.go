@@ -0,0 +1,54 @@
+package editor
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Command returns the editor set in $VISUAL or $EDITOR, or "" if neither is set
+func Command() string {
+	if editor := strings.TrimSpace(os.Getenv("VISUAL")); editor != "" {
+		return editor
+	}
+	return strings.TrimSpace(os.Getenv("EDITOR"))
+}
+
+// Edit opens content in the editor and returns the saved text. The content is
+// written to a temporary file named name, so that editors can pick the file
+// type from its extension. The editor command may include arguments, e.g.
+// "code --wait".
+func Edit(name, content string) (string, error) {
+	args := strings.Fields(Command())
+	if len(args) == 0 {
+		return "", fmt.Errorf("no editor configured, set $EDITOR")
+	}
+
+	dir, err := os.MkdirTemp("", "eiscli-edit")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		return "", fmt.Errorf("failed to write temporary file: %w", err)
+	}
+
+	cmd := exec.Command(args[0], append(args[1:], path)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("editor %s failed: %w", args[0], err)
+	}
+
+	edited, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read edited file: %w", err)
+	}
+
+	return string(edited), nil
+}
@@ -0,0 +1,39 @@
+package editor
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestEdit(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the test editor is a shell script")
+	}
+
+	// An "editor" that appends a line to the file it is given
+	script := filepath.Join(t.TempDir(), "append")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\necho edited >> \"$1\"\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("VISUAL", "")
+	t.Setenv("EDITOR", script)
+
+	if Command() != script {
+		t.Errorf("Command() = %q, want %q", Command(), script)
+	}
+
+	got, err := Edit("PULL_REQUEST.md", "## Summary\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "## Summary\nedited\n"; got != want {
+		t.Errorf("Edit() = %q, want %q", got, want)
+	}
+
+	t.Setenv("EDITOR", "")
+	if _, err := Edit("PULL_REQUEST.md", ""); err == nil {
+		t.Error("Edit() without an editor succeeded")
+	}
+}
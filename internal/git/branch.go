@@ -0,0 +1,285 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
+)
+
+// GetDefaultBranch returns the default branch of the 'origin' remote, as
+// recorded in refs/remotes/origin/HEAD by git clone
+func GetDefaultBranch() (string, error) {
+	repo, err := openRepository()
+	if err != nil {
+		return "", err
+	}
+
+	ref, err := repo.Reference(plumbing.NewRemoteHEADReferenceName("origin"), false)
+	if err != nil {
+		return "", fmt.Errorf("default branch of 'origin' not known: %w", err)
+	}
+	if ref.Type() != plumbing.SymbolicReference {
+		return "", fmt.Errorf("default branch of 'origin' not known: origin/HEAD is not a symbolic reference")
+	}
+
+	branch, ok := strings.CutPrefix(ref.Target().String(), "refs/remotes/origin/")
+	if !ok || branch == "" {
+		return "", fmt.Errorf("unexpected origin/HEAD target %s", ref.Target())
+	}
+
+	return branch, nil
+}
+
+// GetHeadCommit returns the hash of the commit checked out in the current directory
+func GetHeadCommit() (string, error) {
+	repo, err := openRepository()
+	if err != nil {
+		return "", err
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		return "", fmt.Errorf("failed to get HEAD reference: %w", err)
+	}
+
+	return head.Hash().String(), nil
+}
+
+// CheckoutBranch switches the working tree to a local branch. If there is no
+// local branch with the name, it is created from the branch of 'origin'.
+// Fails without touching the working tree if it has unstaged changes.
+func CheckoutBranch(name string) error {
+	repo, err := openRepository()
+	if err != nil {
+		return err
+	}
+
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return fmt.Errorf("failed to get working tree: %w", err)
+	}
+
+	opts := &git.CheckoutOptions{Branch: plumbing.NewBranchReferenceName(name)}
+	if _, err := repo.Reference(opts.Branch, false); errors.Is(err, plumbing.ErrReferenceNotFound) {
+		remoteRef, err := repo.Reference(plumbing.NewRemoteReferenceName("origin", name), true)
+		if err != nil {
+			return fmt.Errorf("branch '%s' not found locally or on 'origin'", name)
+		}
+		opts.Create = true
+		opts.Hash = remoteRef.Hash()
+	} else if err != nil {
+		return fmt.Errorf("failed to look up branch '%s': %w", name, err)
+	}
+
+	if err := worktree.Checkout(opts); err != nil {
+		if errors.Is(err, git.ErrUnstagedChanges) {
+			return fmt.Errorf("cannot switch to '%s': the working tree has uncommitted changes", name)
+		}
+		return fmt.Errorf("failed to switch to '%s': %w", name, err)
+	}
+
+	return nil
+}
+
+// DeleteBranch deletes a local branch and its configuration, like
+// git branch -D. The current branch cannot be deleted.
+func DeleteBranch(name string) error {
+	repo, err := openRepository()
+	if err != nil {
+		return err
+	}
+
+	refName := plumbing.NewBranchReferenceName(name)
+	if head, err := repo.Head(); err == nil && head.Name() == refName {
+		return fmt.Errorf("cannot delete the current branch '%s'", name)
+	}
+
+	if _, err := repo.Reference(refName, false); err != nil {
+		return fmt.Errorf("branch '%s' not found: %w", name, err)
+	}
+	if err := repo.Storer.RemoveReference(refName); err != nil {
+		return fmt.Errorf("failed to delete branch '%s': %w", name, err)
+	}
+
+	// Branches without upstream have no configuration
+	if err := repo.DeleteBranch(name); err != nil && !errors.Is(err, git.ErrBranchNotFound) {
+		return fmt.Errorf("failed to remove configuration of branch '%s': %w", name, err)
+	}
+
+	return nil
+}
+
+// ErrBranchDiverged is returned if a local branch and its remote branch both
+// have commits the other lacks, so that one cannot be fast-forwarded to the other
+var ErrBranchDiverged = errors.New("local branch has diverged")
+
+// RemoteBranchCheckout describes a branch to fetch and check out
+type RemoteBranchCheckout struct {
+	Remote      string // Remote to fetch from; created with RemoteURL if it does not exist
+	RemoteURL   string
+	Branch      string // Branch on the remote
+	LocalBranch string // Local branch to create or update; it tracks the remote branch
+	Force       bool   // Reset the local branch if it has diverged from the remote branch
+
+	// Credentials for HTTPS remotes; SSH remotes use the SSH agent
+	Username string
+	Password string
+}
+
+// CheckoutRemoteBranch fetches a branch from a remote and checks it out as a
+// local branch. An existing local branch is fast-forwarded; if it has
+// diverged, it is only reset with Force. Fails if the working tree has
+// unstaged changes.
+func CheckoutRemoteBranch(opts *RemoteBranchCheckout) error {
+	repo, err := openRepository()
+	if err != nil {
+		return err
+	}
+
+	remote, err := repo.Remote(opts.Remote)
+	if errors.Is(err, git.ErrRemoteNotFound) && opts.RemoteURL != "" {
+		remote, err = repo.CreateRemote(&config.RemoteConfig{
+			Name:  opts.Remote,
+			URLs:  []string{opts.RemoteURL},
+			Fetch: []config.RefSpec{config.RefSpec(fmt.Sprintf("+refs/heads/*:refs/remotes/%s/*", opts.Remote))},
+		})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get remote '%s': %w", opts.Remote, err)
+	}
+
+	// Fetch only the branch
+	remoteRefName := plumbing.NewRemoteReferenceName(opts.Remote, opts.Branch)
+	refSpec := config.RefSpec(fmt.Sprintf("+%s:%s", plumbing.NewBranchReferenceName(opts.Branch), remoteRefName))
+	err = remote.Fetch(&git.FetchOptions{
+		RefSpecs: []config.RefSpec{refSpec},
+		Auth:     remoteAuth(remote.Config().URLs[0], opts.Username, opts.Password),
+	})
+	if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+		return fmt.Errorf("failed to fetch '%s' from '%s': %w", opts.Branch, opts.Remote, err)
+	}
+
+	remoteRef, err := repo.Reference(remoteRefName, true)
+	if err != nil {
+		return fmt.Errorf("branch '%s' not found on '%s': %w", opts.Branch, opts.Remote, err)
+	}
+
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return fmt.Errorf("failed to get working tree: %w", err)
+	}
+
+	localRefName := plumbing.NewBranchReferenceName(opts.LocalBranch)
+	localRef, err := repo.Reference(localRefName, false)
+	switch {
+	case errors.Is(err, plumbing.ErrReferenceNotFound):
+		err = worktree.Checkout(&git.CheckoutOptions{Branch: localRefName, Hash: remoteRef.Hash(), Create: true})
+	case err != nil:
+		return fmt.Errorf("failed to look up branch '%s': %w", opts.LocalBranch, err)
+	default:
+		err = updateLocalBranch(repo, worktree, localRef, remoteRef.Hash(), opts.Force)
+	}
+	if err != nil {
+		if errors.Is(err, git.ErrUnstagedChanges) {
+			return fmt.Errorf("cannot switch to '%s': the working tree has uncommitted changes", opts.LocalBranch)
+		}
+		return err
+	}
+
+	// Track the remote branch, so that git pull and git push work
+	err = repo.CreateBranch(&config.Branch{Name: opts.LocalBranch, Remote: opts.Remote, Merge: plumbing.NewBranchReferenceName(opts.Branch)})
+	if err != nil && !errors.Is(err, git.ErrBranchExists) {
+		return fmt.Errorf("failed to configure branch '%s': %w", opts.LocalBranch, err)
+	}
+
+	return nil
+}
+
+// updateLocalBranch moves an existing local branch to the fetched commit and
+// checks it out
+func updateLocalBranch(repo *git.Repository, worktree *git.Worktree, localRef *plumbing.Reference, hash plumbing.Hash, force bool) error {
+	if localRef.Hash() != hash && !force {
+		local, err := repo.CommitObject(localRef.Hash())
+		if err != nil {
+			return fmt.Errorf("failed to read branch '%s': %w", localRef.Name().Short(), err)
+		}
+		fetched, err := repo.CommitObject(hash)
+		if err != nil {
+			return fmt.Errorf("failed to read fetched commit: %w", err)
+		}
+		fastForward, err := local.IsAncestor(fetched)
+		if err != nil {
+			return fmt.Errorf("failed to compare branch '%s' with the fetched commit: %w", localRef.Name().Short(), err)
+		}
+		if !fastForward {
+			return fmt.Errorf("%w: '%s' has commits that are not on the remote branch", ErrBranchDiverged, localRef.Name().Short())
+		}
+	}
+
+	head, err := repo.Head()
+	if err == nil && head.Name() == localRef.Name() {
+		// Resetting the current branch moves it and updates the working tree
+		return worktree.Reset(&git.ResetOptions{Commit: hash, Mode: git.MergeReset})
+	}
+
+	if err := repo.Storer.SetReference(plumbing.NewHashReference(localRef.Name(), hash)); err != nil {
+		return fmt.Errorf("failed to update branch '%s': %w", localRef.Name().Short(), err)
+	}
+	return worktree.Checkout(&git.CheckoutOptions{Branch: localRef.Name()})
+}
+
+// remoteAuth returns the credentials for fetching from or pushing to an HTTPS remote
+func remoteAuth(remoteURL, username, password string) transport.AuthMethod {
+	if username == "" || !(strings.HasPrefix(remoteURL, "https://") || strings.HasPrefix(remoteURL, "http://")) {
+		return nil
+	}
+	return &http.BasicAuth{Username: username, Password: password}
+}
+
+// RemoteURLForRepository returns the URL of another repository on the host
+// of the 'origin' remote, using the same protocol, e.g. to fetch from a fork.
+// fullName is the path of the repository like workspace/slug.
+func RemoteURLForRepository(fullName string) (string, error) {
+	repo, err := openRepository()
+	if err != nil {
+		return "", err
+	}
+
+	remote, err := repo.Remote("origin")
+	if err != nil {
+		return "", fmt.Errorf("no 'origin' remote found: %w", err)
+	}
+	if len(remote.Config().URLs) == 0 {
+		return "", fmt.Errorf("no remote URL configured for 'origin'")
+	}
+
+	return replaceRepositoryPath(remote.Config().URLs[0], fullName)
+}
+
+// replaceRepositoryPath replaces the repository path of a git URL
+// Supports:
+// - SSH: git@bitbucket.org:cover42/documentservicev2.git
+// - URLs: https://user@bitbucket.org/cover42/documentservicev2.git, ssh://git@bitbucket.org/...
+func replaceRepositoryPath(remoteURL, fullName string) (string, error) {
+	if !strings.Contains(remoteURL, "://") {
+		host, _, found := strings.Cut(remoteURL, ":")
+		if !found {
+			return "", fmt.Errorf("unsupported URL format")
+		}
+		return fmt.Sprintf("%s:%s.git", host, fullName), nil
+	}
+
+	u, err := url.Parse(remoteURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid URL: %w", err)
+	}
+	u.Path = "/" + fullName + ".git"
+	return u.String(), nil
+}
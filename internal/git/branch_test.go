@@ -0,0 +1,234 @@
+package git
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// commitFile writes a file in the worktree of a repository and commits it
+func commitFile(t *testing.T, repo *git.Repository, name, content string) plumbing.Hash {
+	t.Helper()
+
+	worktree, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(worktree.Filesystem.Root(), name), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := worktree.Add(name); err != nil {
+		t.Fatal(err)
+	}
+	hash, err := worktree.Commit("Change "+name, &git.CommitOptions{
+		Author: &object.Signature{Name: "Test", Email: "test@example.com", When: time.Now()},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return hash
+}
+
+// initRepository creates a repository with one commit on main and a
+// feature branch, checks out the feature branch and changes into it
+func initRepository(t *testing.T) *git.Repository {
+	t.Helper()
+
+	dir := t.TempDir()
+	repo, err := git.PlainInitWithOptions(dir, &git.PlainInitOptions{
+		InitOptions: git.InitOptions{DefaultBranch: plumbing.NewBranchReferenceName("main")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hash := commitFile(t, repo, "README.md", "hello\n")
+
+	// A clone records the default branch of origin
+	if err := repo.Storer.SetReference(plumbing.NewHashReference(plumbing.NewRemoteReferenceName("origin", "main"), hash)); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Storer.SetReference(plumbing.NewSymbolicReference(plumbing.NewRemoteHEADReferenceName("origin"), plumbing.NewRemoteReferenceName("origin", "main"))); err != nil {
+		t.Fatal(err)
+	}
+
+	worktree, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := worktree.Checkout(&git.CheckoutOptions{Branch: plumbing.NewBranchReferenceName("feature/x"), Create: true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.CreateBranch(&config.Branch{Name: "feature/x", Remote: "origin", Merge: plumbing.NewBranchReferenceName("feature/x")}); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Chdir(dir)
+	return repo
+}
+
+func TestGetDefaultBranch(t *testing.T) {
+	initRepository(t)
+
+	branch, err := GetDefaultBranch()
+	if err != nil || branch != "main" {
+		t.Errorf("GetDefaultBranch() = %q, %v, want main", branch, err)
+	}
+}
+
+func TestCheckoutAndDeleteBranch(t *testing.T) {
+	repo := initRepository(t)
+
+	if err := DeleteBranch("feature/x"); err == nil {
+		t.Error("DeleteBranch() of the current branch succeeded")
+	}
+
+	if err := CheckoutBranch("main"); err != nil {
+		t.Fatalf("CheckoutBranch() error = %v", err)
+	}
+	if branch, _ := GetCurrentBranch(); branch != "main" {
+		t.Errorf("current branch = %q, want main", branch)
+	}
+
+	if err := DeleteBranch("feature/x"); err != nil {
+		t.Fatalf("DeleteBranch() error = %v", err)
+	}
+	if _, err := repo.Reference(plumbing.NewBranchReferenceName("feature/x"), false); err == nil {
+		t.Error("branch still exists after DeleteBranch()")
+	}
+	if _, err := repo.Branch("feature/x"); err == nil {
+		t.Error("branch configuration still exists after DeleteBranch()")
+	}
+
+	if err := CheckoutBranch("missing"); err == nil {
+		t.Error("CheckoutBranch() of a missing branch succeeded")
+	}
+}
+
+func TestCheckoutBranchFromOrigin(t *testing.T) {
+	repo := initRepository(t)
+
+	head, err := repo.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Storer.SetReference(plumbing.NewHashReference(plumbing.NewRemoteReferenceName("origin", "develop"), head.Hash())); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := CheckoutBranch("develop"); err != nil {
+		t.Fatalf("CheckoutBranch() error = %v", err)
+	}
+	if branch, _ := GetCurrentBranch(); branch != "develop" {
+		t.Errorf("current branch = %q, want develop", branch)
+	}
+}
+
+func TestCheckoutRemoteBranch(t *testing.T) {
+	// A repository standing in for Bitbucket with a pull request branch
+	upstream, err := git.PlainInitWithOptions(t.TempDir(), &git.PlainInitOptions{
+		InitOptions: git.InitOptions{DefaultBranch: plumbing.NewBranchReferenceName("main")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	commitFile(t, upstream, "README.md", "hello\n")
+	upstreamTree, _ := upstream.Worktree()
+	if err := upstreamTree.Checkout(&git.CheckoutOptions{Branch: plumbing.NewBranchReferenceName("feature/pr"), Create: true}); err != nil {
+		t.Fatal(err)
+	}
+	first := commitFile(t, upstream, "feature.go", "package feature\n")
+
+	dir := t.TempDir()
+	if _, err := git.PlainClone(dir, false, &git.CloneOptions{URL: upstreamTree.Filesystem.Root(), ReferenceName: plumbing.NewBranchReferenceName("main")}); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(dir)
+
+	// go-git does not notice packs fetched by another instance, so the clone
+	// is opened again for every check
+	clone := func() *git.Repository {
+		repo, err := git.PlainOpen(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return repo
+	}
+
+	checkout := &RemoteBranchCheckout{Remote: "origin", Branch: "feature/pr", LocalBranch: "feature/pr"}
+	if err := CheckoutRemoteBranch(checkout); err != nil {
+		t.Fatalf("CheckoutRemoteBranch() error = %v", err)
+	}
+	assertHead(t, clone(), "feature/pr", first)
+	if branch, err := clone().Branch("feature/pr"); err != nil || branch.Remote != "origin" || branch.Merge != plumbing.NewBranchReferenceName("feature/pr") {
+		t.Errorf("branch configuration = %+v, %v, want tracking origin/feature/pr", branch, err)
+	}
+
+	// New commits on the pull request are fast-forwarded
+	second := commitFile(t, upstream, "feature.go", "package feature // v2\n")
+	if err := CheckoutRemoteBranch(checkout); err != nil {
+		t.Fatalf("CheckoutRemoteBranch() after a new commit error = %v", err)
+	}
+	assertHead(t, clone(), "feature/pr", second)
+
+	// Local commits are only dropped with Force
+	commitFile(t, clone(), "local.txt", "local\n")
+	commitFile(t, upstream, "feature.go", "package feature // v3\n")
+	if err := CheckoutRemoteBranch(checkout); !errors.Is(err, ErrBranchDiverged) {
+		t.Fatalf("CheckoutRemoteBranch() of a diverged branch error = %v, want ErrBranchDiverged", err)
+	}
+	checkout.Force = true
+	if err := CheckoutRemoteBranch(checkout); err != nil {
+		t.Fatalf("CheckoutRemoteBranch() with Force error = %v", err)
+	}
+	upstreamHead, _ := upstream.Head()
+	assertHead(t, clone(), "feature/pr", upstreamHead.Hash())
+
+	// A fork gets its own remote
+	fork := &RemoteBranchCheckout{Remote: "alice", RemoteURL: upstreamTree.Filesystem.Root(), Branch: "feature/pr", LocalBranch: "alice-feature/pr"}
+	if err := CheckoutRemoteBranch(fork); err != nil {
+		t.Fatalf("CheckoutRemoteBranch() of a fork error = %v", err)
+	}
+	assertHead(t, clone(), "alice-feature/pr", upstreamHead.Hash())
+	if remote, err := clone().Remote("alice"); err != nil || remote.Config().URLs[0] != upstreamTree.Filesystem.Root() {
+		t.Errorf("fork remote = %v, %v", remote, err)
+	}
+}
+
+// assertHead checks the current branch and commit of a repository
+func assertHead(t *testing.T, repo *git.Repository, branch string, hash plumbing.Hash) {
+	t.Helper()
+
+	head, err := repo.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if head.Name().Short() != branch || head.Hash() != hash {
+		t.Errorf("HEAD = %s at %s, want %s at %s", head.Name().Short(), head.Hash(), branch, hash)
+	}
+}
+
+func TestReplaceRepositoryPath(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"git@bitbucket.org:cover42/policyservice.git", "git@bitbucket.org:alice/policyservice.git"},
+		{"https://user@bitbucket.org/cover42/policyservice.git", "https://user@bitbucket.org/alice/policyservice.git"},
+		{"ssh://git@bitbucket.org/cover42/policyservice", "ssh://git@bitbucket.org/alice/policyservice.git"},
+	}
+
+	for _, tt := range tests {
+		got, err := replaceRepositoryPath(tt.url, "alice/policyservice")
+		if err != nil || got != tt.want {
+			t.Errorf("replaceRepositoryPath(%q) = %q, %v, want %q", tt.url, got, err, tt.want)
+		}
+	}
+}
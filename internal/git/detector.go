@@ -126,6 +126,21 @@ func IsGitRepository() bool {
 	return err == nil
 }
 
+// GetRepositoryRoot returns the top-level directory of the working tree
+func GetRepositoryRoot() (string, error) {
+	repo, err := openRepository()
+	if err != nil {
+		return "", err
+	}
+
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return "", fmt.Errorf("failed to get working tree: %w", err)
+	}
+
+	return worktree.Filesystem.Root(), nil
+}
+
 // GetCurrentBranch detects the current git branch name
 func GetCurrentBranch() (string, error) {
 	repo, err := openRepository()
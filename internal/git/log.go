@@ -0,0 +1,85 @@
+package git
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// GetBranchCommitMessages returns the messages of the commits on the current
+// branch that are not on base, oldest first, like git log --reverse base..HEAD.
+// Merge commits are left out. base is looked up as a branch of 'origin' first,
+// then as a local branch.
+func GetBranchCommitMessages(base string) ([]string, error) {
+	repo, err := openRepository()
+	if err != nil {
+		return nil, err
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get HEAD reference: %w", err)
+	}
+	headCommit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get HEAD commit: %w", err)
+	}
+
+	baseRef, err := repo.Reference(plumbing.NewRemoteReferenceName("origin", base), true)
+	if err != nil {
+		baseRef, err = repo.Reference(plumbing.NewBranchReferenceName(base), true)
+		if err != nil {
+			return nil, fmt.Errorf("branch '%s' not found locally or on 'origin'", base)
+		}
+	}
+	baseCommit, err := repo.CommitObject(baseRef.Hash())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get commit of '%s': %w", base, err)
+	}
+
+	commits, err := commitsNotOn(headCommit, baseCommit)
+	if err != nil {
+		return nil, err
+	}
+
+	// The walk goes from new to old, so reversing it orders commits made in
+	// the same second correctly
+	slices.Reverse(commits)
+	sort.SliceStable(commits, func(i, j int) bool {
+		return commits[i].Committer.When.Before(commits[j].Committer.When)
+	})
+
+	messages := make([]string, 0, len(commits))
+	for _, c := range commits {
+		if c.NumParents() <= 1 {
+			messages = append(messages, c.Message)
+		}
+	}
+	return messages, nil
+}
+
+// commitsNotOn returns the commits reachable from tip that are not reachable
+// from base, like git rev-list base..tip, from new to old
+func commitsNotOn(tip, base *object.Commit) ([]*object.Commit, error) {
+	onBase := make(map[plumbing.Hash]bool)
+	err := object.NewCommitPreorderIter(base, nil, nil).ForEach(func(c *object.Commit) error {
+		onBase[c.Hash] = true
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history of %s: %w", base.Hash, err)
+	}
+
+	var commits []*object.Commit
+	err = object.NewCommitPreorderIter(tip, onBase, nil).ForEach(func(c *object.Commit) error {
+		commits = append(commits, c)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history of %s: %w", tip.Hash, err)
+	}
+	return commits, nil
+}
@@ -0,0 +1,30 @@
+package git
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGetBranchCommitMessages(t *testing.T) {
+	repo := initRepository(t)
+
+	messages, err := GetBranchCommitMessages("main")
+	if err != nil || len(messages) != 0 {
+		t.Errorf("GetBranchCommitMessages() without commits = %q, %v", messages, err)
+	}
+
+	commitFile(t, repo, "a.go", "package a\n")
+	commitFile(t, repo, "b.go", "package b\n")
+
+	messages, err = GetBranchCommitMessages("main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"Change a.go", "Change b.go"}; !reflect.DeepEqual(messages, want) {
+		t.Errorf("GetBranchCommitMessages() = %q, want %q", messages, want)
+	}
+
+	if _, err := GetBranchCommitMessages("missing"); err == nil {
+		t.Error("GetBranchCommitMessages() of a missing base succeeded")
+	}
+}
@@ -0,0 +1,152 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// BranchPushStatus compares a local branch with the branch it is pushed to,
+// as of the last fetch
+type BranchPushStatus struct {
+	Remote       string // Remote the branch is pushed to; its upstream, or 'origin'
+	RemoteBranch string // Branch on the remote
+	OnRemote     bool   // The remote branch exists
+	Ahead        int    // Local commits that are not on the remote branch
+	Behind       int    // Commits on the remote branch that are not in the local branch
+}
+
+// NeedsPush returns true if the remote branch is missing or lacks local commits
+func (s *BranchPushStatus) NeedsPush() bool {
+	return !s.OnRemote || s.Ahead > 0
+}
+
+// Diverged returns true if both branches have commits the other lacks, so
+// that pushing would be rejected
+func (s *BranchPushStatus) Diverged() bool {
+	return s.Ahead > 0 && s.Behind > 0
+}
+
+// GetBranchPushStatus compares a local branch with its upstream branch, or
+// with the branch of the same name on 'origin' if it has no upstream. Only
+// the remote-tracking branch is looked at; nothing is fetched.
+func GetBranchPushStatus(branch string) (*BranchPushStatus, error) {
+	repo, err := openRepository()
+	if err != nil {
+		return nil, err
+	}
+
+	localRef, err := repo.Reference(plumbing.NewBranchReferenceName(branch), true)
+	if err != nil {
+		return nil, fmt.Errorf("branch '%s' not found: %w", branch, err)
+	}
+
+	status := &BranchPushStatus{Remote: "origin", RemoteBranch: branch}
+	if cfg, err := repo.Branch(branch); err == nil && cfg.Remote != "" && cfg.Remote != "." && cfg.Merge != "" {
+		status.Remote = cfg.Remote
+		status.RemoteBranch = cfg.Merge.Short()
+	}
+
+	remoteRef, err := repo.Reference(plumbing.NewRemoteReferenceName(status.Remote, status.RemoteBranch), true)
+	if errors.Is(err, plumbing.ErrReferenceNotFound) {
+		return status, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up '%s/%s': %w", status.Remote, status.RemoteBranch, err)
+	}
+	status.OnRemote = true
+
+	if remoteRef.Hash() == localRef.Hash() {
+		return status, nil
+	}
+
+	local, err := repo.CommitObject(localRef.Hash())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read branch '%s': %w", branch, err)
+	}
+	remote, err := repo.CommitObject(remoteRef.Hash())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read '%s/%s': %w", status.Remote, status.RemoteBranch, err)
+	}
+
+	ahead, err := commitsNotOn(local, remote)
+	if err != nil {
+		return nil, err
+	}
+	behind, err := commitsNotOn(remote, local)
+	if err != nil {
+		return nil, err
+	}
+	status.Ahead, status.Behind = len(ahead), len(behind)
+
+	return status, nil
+}
+
+// BranchPush describes a local branch to push
+type BranchPush struct {
+	Remote       string
+	Branch       string // Local branch
+	RemoteBranch string // Branch on the remote; the local branch name if empty
+
+	// Credentials for HTTPS remotes; SSH remotes use the SSH agent
+	Username string
+	Password string
+}
+
+// PushBranch pushes a local branch, like git push -u. A branch without
+// upstream is set up to track the pushed branch.
+func PushBranch(opts *BranchPush) error {
+	repo, err := openRepository()
+	if err != nil {
+		return err
+	}
+
+	remote, err := repo.Remote(opts.Remote)
+	if err != nil {
+		return fmt.Errorf("failed to get remote '%s': %w", opts.Remote, err)
+	}
+	if len(remote.Config().URLs) == 0 {
+		return fmt.Errorf("no remote URL configured for '%s'", opts.Remote)
+	}
+
+	remoteBranch := opts.RemoteBranch
+	if remoteBranch == "" {
+		remoteBranch = opts.Branch
+	}
+	localRefName := plumbing.NewBranchReferenceName(opts.Branch)
+	refSpec := config.RefSpec(fmt.Sprintf("%s:%s", localRefName, plumbing.NewBranchReferenceName(remoteBranch)))
+
+	err = remote.Push(&git.PushOptions{
+		RemoteName: opts.Remote,
+		RefSpecs:   []config.RefSpec{refSpec},
+		Auth:       remoteAuth(remote.Config().URLs[0], opts.Username, opts.Password),
+	})
+	if errors.Is(err, git.ErrNonFastForwardUpdate) {
+		return fmt.Errorf("%w: '%s/%s' has commits that are not in '%s', pull them first", ErrBranchDiverged, opts.Remote, remoteBranch, opts.Branch)
+	}
+	if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+		return fmt.Errorf("failed to push '%s' to '%s': %w", opts.Branch, opts.Remote, err)
+	}
+
+	// Record the pushed commit as the remote branch, like git push does
+	localRef, err := repo.Reference(localRefName, true)
+	if err != nil {
+		return fmt.Errorf("branch '%s' not found: %w", opts.Branch, err)
+	}
+	remoteRef := plumbing.NewHashReference(plumbing.NewRemoteReferenceName(opts.Remote, remoteBranch), localRef.Hash())
+	if err := repo.Storer.SetReference(remoteRef); err != nil {
+		return fmt.Errorf("failed to update '%s/%s': %w", opts.Remote, remoteBranch, err)
+	}
+
+	if _, err := repo.Branch(opts.Branch); errors.Is(err, git.ErrBranchNotFound) {
+		err = repo.CreateBranch(&config.Branch{Name: opts.Branch, Remote: opts.Remote, Merge: plumbing.NewBranchReferenceName(remoteBranch)})
+		if err != nil {
+			return fmt.Errorf("failed to configure branch '%s': %w", opts.Branch, err)
+		}
+	}
+
+	return nil
+}
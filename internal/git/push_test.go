@@ -0,0 +1,62 @@
+package git
+
+import (
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+func TestPushBranch(t *testing.T) {
+	repo := initRepository(t)
+
+	upstreamDir := t.TempDir()
+	upstream, err := git.PlainInit(upstreamDir, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.CreateRemote(&config.RemoteConfig{Name: "origin", URLs: []string{upstreamDir}}); err != nil {
+		t.Fatal(err)
+	}
+
+	status, err := GetBranchPushStatus("feature/x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.OnRemote || !status.NeedsPush() || status.Remote != "origin" || status.RemoteBranch != "feature/x" {
+		t.Errorf("status of an unpushed branch = %+v", status)
+	}
+
+	if err := PushBranch(&BranchPush{Remote: "origin", Branch: "feature/x"}); err != nil {
+		t.Fatalf("PushBranch() error = %v", err)
+	}
+	head, _ := repo.Head()
+	if ref, err := upstream.Reference(plumbing.NewBranchReferenceName("feature/x"), true); err != nil || ref.Hash() != head.Hash() {
+		t.Errorf("pushed branch = %v, %v, want %s", ref, err, head.Hash())
+	}
+	if status, err := GetBranchPushStatus("feature/x"); err != nil || status.NeedsPush() {
+		t.Errorf("status of a pushed branch = %+v, %v", status, err)
+	}
+
+	commitFile(t, repo, "a.go", "package a\n")
+	if status, err := GetBranchPushStatus("feature/x"); err != nil || status.Ahead != 1 || status.Behind != 0 || !status.NeedsPush() {
+		t.Errorf("status with a new commit = %+v, %v, want 1 ahead", status, err)
+	}
+
+	// Another commit on the remote branch, as if someone else pushed it
+	worktree, _ := repo.Worktree()
+	if err := worktree.Checkout(&git.CheckoutOptions{Branch: plumbing.NewBranchReferenceName("main")}); err != nil {
+		t.Fatal(err)
+	}
+	other := commitFile(t, repo, "b.go", "package b\n")
+	if err := worktree.Checkout(&git.CheckoutOptions{Branch: plumbing.NewBranchReferenceName("feature/x")}); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Storer.SetReference(plumbing.NewHashReference(plumbing.NewRemoteReferenceName("origin", "feature/x"), other)); err != nil {
+		t.Fatal(err)
+	}
+	if status, err := GetBranchPushStatus("feature/x"); err != nil || !status.Diverged() {
+		t.Errorf("status of a diverged branch = %+v, %v", status, err)
+	}
+}